@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/audit"
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// prism-replay 重放审计日志中的请求
+// 读取Prism写出的审计JSONL文件，将每条记录中的 ChatRequest 重新发送到网关，
+// 并对比本次响应与记录中的响应内容是否一致
+//
+// 用法：
+//
+//	prism-replay -file logs/audit.jsonl -target http://localhost:8080 -key sk-xxx
+//
+// 注意：消息内容已脱敏的记录（redacted=true）无法重放，会被跳过
+func main() {
+	file := flag.String("file", "./logs/audit.jsonl", "审计日志文件路径")
+	target := flag.String("target", "http://localhost:8080", "Prism网关地址")
+	apiKey := flag.String("key", "", "调用网关使用的API密钥（可选）")
+	modelOverride := flag.String("model", "", "覆盖请求中的模型名称（可选）")
+	limit := flag.Int("limit", 0, "最多重放的记录数（0表示不限制）")
+	dryRun := flag.Bool("dry-run", false, "只打印将要重放的请求，不实际发送")
+	flag.Parse()
+
+	f, err := os.Open(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "打开审计日志失败: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	endpoint := strings.TrimRight(*target, "/") + "/v1/chat/completions"
+
+	var replayed, skipped, failed, mismatched int
+	err = audit.ReadRecords(f, func(rec *audit.Record) error {
+		if *limit > 0 && replayed >= *limit {
+			return nil
+		}
+		if rec.Redacted || rec.Request == nil {
+			skipped++
+			return nil
+		}
+
+		req := *rec.Request
+		if *modelOverride != "" {
+			req.Model = *modelOverride
+		}
+
+		if *dryRun {
+			fmt.Printf("[dry-run] %s model=%s stream=%v messages=%d\n", rec.RequestID, req.Model, req.Stream, len(req.Messages))
+			replayed++
+			return nil
+		}
+
+		content, status, elapsed, err := replay(client, endpoint, *apiKey, &req)
+		replayed++
+		if err != nil {
+			failed++
+			fmt.Printf("✗ %s status=%d error=%v\n", rec.RequestID, status, err)
+			return nil
+		}
+
+		same := rec.Response != nil && rec.Response.GetContent() == content
+		if !same {
+			mismatched++
+		}
+		fmt.Printf("✓ %s status=%d latency=%s (recorded %dms) same_content=%v\n",
+			rec.RequestID, status, elapsed.Round(time.Millisecond), rec.LatencyMs, same)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取审计日志失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n重放: %d, 跳过(已脱敏): %d, 失败: %d, 内容不一致: %d\n", replayed, skipped, failed, mismatched)
+}
+
+// replay 重放单个请求
+// 流式请求读取完整的SSE流并拼装内容，非流式请求直接解析响应
+// 返回：助手回复内容、HTTP状态码、耗时、错误
+func replay(client *http.Client, endpoint, apiKey string, req *model.ChatRequest) (string, int, time.Duration, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("marshal request failed: %w", err)
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("create request failed: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	start := time.Now()
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("http request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	elapsed := time.Since(start)
+	if err != nil {
+		return "", httpResp.StatusCode, elapsed, fmt.Errorf("read response failed: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return "", httpResp.StatusCode, elapsed, fmt.Errorf("%s", strings.TrimSpace(string(respBody)))
+	}
+
+	if !req.Stream {
+		var resp model.ChatResponse
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			return "", httpResp.StatusCode, elapsed, fmt.Errorf("unmarshal response failed: %w", err)
+		}
+		return resp.GetContent(), httpResp.StatusCode, elapsed, nil
+	}
+
+	acc := model.NewStreamAccumulator()
+	for _, line := range strings.Split(string(respBody), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok || data == "[DONE]" {
+			continue
+		}
+		var chunk model.StreamResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		acc.Add(&chunk)
+	}
+	return acc.Response().GetContent(), httpResp.StatusCode, elapsed, nil
+}
@@ -1,116 +1,575 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"syscall"
 
 	"github.com/AtSunset1/prism/internal/adapter"
 	"github.com/AtSunset1/prism/internal/adapter/glm"
+	"github.com/AtSunset1/prism/internal/adapter/openai"
+	"github.com/AtSunset1/prism/internal/adapter/upstream"
+	"github.com/AtSunset1/prism/internal/audit"
+	"github.com/AtSunset1/prism/internal/auth"
+	"github.com/AtSunset1/prism/internal/batch"
+	"github.com/AtSunset1/prism/internal/cache"
 	"github.com/AtSunset1/prism/internal/handler"
+	"github.com/AtSunset1/prism/internal/health"
+	"github.com/AtSunset1/prism/internal/lifecycle"
+	"github.com/AtSunset1/prism/internal/media"
+	"github.com/AtSunset1/prism/internal/pipeline"
+	"github.com/AtSunset1/prism/internal/responses"
 	"github.com/AtSunset1/prism/internal/router"
+	"github.com/AtSunset1/prism/internal/server"
+	"github.com/AtSunset1/prism/internal/structured"
+	"github.com/AtSunset1/prism/internal/tokenizer"
+	"github.com/AtSunset1/prism/internal/tracing"
+	"github.com/AtSunset1/prism/internal/truncate"
 	"github.com/AtSunset1/prism/pkg/config"
+	"github.com/AtSunset1/prism/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
+// configPath 配置文件路径
+const configPath = "configs/config.yaml"
+
 func main() {
+	// 0. 启动阶段Logger（配置加载前使用）
+	zap.ReplaceGlobals(logger.NewBootstrap())
+
 	// 1. 加载配置
 	cfg := loadConfig()
 
-	// 2. 初始化适配器和处理器
-	chatHandler := initHandlers(cfg)
+	// 2. 根据日志配置初始化Logger
+	log := initLogger(cfg)
+	defer log.Sync()
+
+	// 3. 初始化链路追踪
+	shutdownTracing := initTracing(cfg)
+	defer shutdownTracing(context.Background())
+
+	// 4. 初始化审计日志
+	auditor := initAuditor(cfg)
+	defer auditor.Close()
+
+	// 5. 初始化响应缓存
+	store := initCache(cfg)
+	if store != nil {
+		defer store.Close()
+	}
+
+	// 6. 加载本地分词器词表
+	initTokenizer(cfg)
+
+	// 7. 初始化适配器和处理器
+	// drainer 在健康检查、流式响应和服务器之间共享关闭状态
+	drainer := lifecycle.NewDrainer()
+	gw := initHandlers(cfg, auditor, store, drainer)
+	manager := gw.manager
 
-	// 3. 设置路由
-	r := router.SetupRouter(chatHandler)
+	// 8. 启动上游健康探测
+	prober := initProber(cfg, manager)
+	stopProber := prober.Start()
 
-	// 4. 启动服务器
-	startServer(r, cfg)
+	// 9. 启动批处理器（继续上次未完成的任务）
+	files, processor := initBatch(cfg, gw, auditor)
+	stopBatch := processor.Start()
+
+	handlers := router.Handlers{
+		Chat:       gw.chatHandler,
+		Responses:  handler.NewResponsesHandler(gw.chatHandler, initResponseStore(cfg)),
+		Models:     handler.NewModelsHandler(manager),
+		Embeddings: handler.NewEmbeddingsHandler(manager),
+		Tokenize:   handler.NewTokenizeHandler(manager),
+		Health:     handler.NewHealthHandler(drainer, prober),
+		Files:      handler.NewFilesHandler(files),
+		Batches:    handler.NewBatchesHandler(processor),
+	}
+
+	// 10. 初始化API Key鉴权
+	authenticator := auth.New(cfg.Auth)
+
+	// 11. 监听配置文件，热加载适配器、API Key和可以在运行时调整的限制
+	watchConfig(gw, prober, processor, authenticator)
+
+	// 12. 设置路由
+	gin.SetMode(cfg.Server.Mode)
+	r := router.SetupRouter(cfg, handlers, authenticator, log)
+
+	// 13. 启动服务器，收到 SIGINT/SIGTERM 后优雅关闭
+	runServer(r, cfg, drainer)
+
+	// 14. 请求排空后停止批处理（未完成的任务下次启动时继续）和健康探测，关闭上游连接
+	stopBatch()
+	stopProber()
+	if err := manager.Close(); err != nil {
+		zap.L().Warn("关闭适配器失败", zap.Error(err))
+	}
+	zap.L().Info("Prism AI Gateway 已退出")
 }
 
 // loadConfig 加载配置文件
 // 返回：*config.Config 配置实例
 func loadConfig() *config.Config {
-	log.Println("========================================")
-	log.Println("📋 加载配置文件...")
-
-	cfg, err := config.Load("configs/config.yaml")
+	cfg, err := config.Load(configPath)
 	if err != nil {
-		log.Fatalf("❌ 加载配置失败: %v", err)
+		zap.L().Fatal("加载配置失败", zap.Error(err))
 	}
 
-	log.Println("✓ 配置加载成功")
-	log.Printf("✓ 服务器模式: %s", cfg.Server.Mode)
-	log.Printf("✓ 服务器端口: %d", cfg.Server.Port)
-	log.Printf("✓ 日志级别: %s", cfg.Logging.Level)
-	log.Printf("✓ 已配置适配器: %d 个", len(cfg.Adapters))
-	log.Println("========================================")
+	zap.L().Info("配置加载成功",
+		zap.String("mode", cfg.Server.Mode),
+		zap.Int("port", cfg.Server.Port),
+		zap.String("log_level", cfg.Logging.Level),
+		zap.Int("adapters", len(cfg.Adapters)),
+	)
 
 	return cfg
 }
 
+// initLogger 根据日志配置创建Logger并替换全局Logger
+// 参数：
+//   - cfg: 配置实例
+// 返回：
+//   - *zap.Logger: Logger实例
+func initLogger(cfg *config.Config) *zap.Logger {
+	l, err := logger.New(cfg.Logging)
+	if err != nil {
+		zap.L().Fatal("初始化日志失败", zap.Error(err))
+	}
+
+	zap.ReplaceGlobals(l)
+	return l
+}
+
+// initTracing 初始化OpenTelemetry链路追踪
+// 参数：
+//   - cfg: 配置实例
+// 返回：
+//   - tracing.ShutdownFunc: 退出前调用，导出剩余span
+func initTracing(cfg *config.Config) tracing.ShutdownFunc {
+	shutdown, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		zap.L().Fatal("初始化链路追踪失败", zap.Error(err))
+	}
+
+	if cfg.Tracing.Enabled {
+		zap.L().Info("链路追踪已启用",
+			zap.String("endpoint", cfg.Tracing.Endpoint),
+			zap.Float64("sample_ratio", cfg.Tracing.SampleRatio),
+		)
+	}
+	return shutdown
+}
+
+// initAuditor 初始化请求/响应审计日志
+// 参数：
+//   - cfg: 配置实例
+// 返回：
+//   - *audit.Auditor: 审计记录器（未启用时为nil）
+func initAuditor(cfg *config.Config) *audit.Auditor {
+	auditor, err := audit.New(cfg.Audit)
+	if err != nil {
+		zap.L().Fatal("初始化审计日志失败", zap.Error(err))
+	}
+
+	if cfg.Audit.Enabled {
+		zap.L().Info("审计日志已启用",
+			zap.String("sink", cfg.Audit.Sink),
+			zap.Bool("redact_content", cfg.Audit.RedactContent),
+		)
+	}
+	return auditor
+}
+
+// initCache 初始化响应缓存
+// 参数：
+//   - cfg: 配置实例
+// 返回：
+//   - cache.Store: 缓存后端（未启用时为nil）
+func initCache(cfg *config.Config) cache.Store {
+	if !cfg.Cache.Enabled {
+		return nil
+	}
+
+	store, err := cache.NewStore(cfg.Cache)
+	if err != nil {
+		zap.L().Fatal("初始化响应缓存失败", zap.Error(err))
+	}
+
+	zap.L().Info("响应缓存已启用",
+		zap.String("backend", cfg.Cache.Backend),
+		zap.Duration("ttl", cfg.Cache.TTL),
+		zap.Int("max_entries", cfg.Cache.MaxEntries),
+		zap.Int("entries", store.Len()),
+	)
+	return store
+}
+
+// initResponseStore 初始化 Responses API 的响应存储
+// 参数：
+//   - cfg: 配置实例
+// 返回：
+//   - *responses.Store: 响应存储
+func initResponseStore(cfg *config.Config) *responses.Store {
+	store, err := responses.NewStore(cfg.Responses.Dir, cfg.Responses.TTL)
+	if err != nil {
+		zap.L().Fatal("初始化响应存储失败", zap.Error(err))
+	}
+	return store
+}
+
+// initBatch 初始化 Batch API 的文件存储和批处理器
+// 批处理请求与在线请求使用相同的适配器链和请求处理流程，并同样记录审计日志
+// 参数：
+//   - cfg: 配置实例
+//   - gw: 聊天请求链路（适配器链、请求处理流程、按适配器限制并发和速率的管理器）
+//   - auditor: 审计记录器（可为nil）
+// 返回：
+//   - *batch.FileStore: 文件存储
+//   - *batch.Processor: 批处理器（调用 Start 后开始执行任务）
+func initBatch(cfg *config.Config, gw *gateway, auditor *audit.Auditor) (*batch.FileStore, *batch.Processor) {
+	files, err := batch.NewFileStore(filepath.Join(cfg.Batch.Dir, "files"), int64(cfg.Batch.MaxFileSize)<<20)
+	if err != nil {
+		zap.L().Fatal("初始化批处理文件存储失败", zap.Error(err))
+	}
+	processor, err := batch.NewProcessor(cfg.Batch, files, gw.chat, gw.manager,
+		batch.WithPipeline(gw.pipeline),
+		batch.WithAuditor(auditor),
+	)
+	if err != nil {
+		zap.L().Fatal("初始化批处理器失败", zap.Error(err))
+	}
+	return files, processor
+}
+
+// initTokenizer 加载分词器词表并设置为全局注册表
+// 参数：
+//   - cfg: 配置实例
+func initTokenizer(cfg *config.Config) {
+	registry, err := tokenizer.NewRegistry(cfg.Tokenizer)
+	if err != nil {
+		zap.L().Fatal("初始化分词器失败", zap.Error(err))
+	}
+	tokenizer.SetGlobal(registry)
+}
+
+// initProber 创建上游健康探测器
+// 按配置让路由跳过探测失败的适配器
+// 参数：
+//   - cfg: 配置实例
+//   - manager: 适配器管理器
+// 返回：
+//   - *health.Prober: 健康探测器（未启用时 Start 不做任何事）
+func initProber(cfg *config.Config, manager *adapter.AdapterManager) *health.Prober {
+	prober := health.NewProber(manager, cfg.Health)
+	if !prober.Enabled() {
+		return prober
+	}
+
+	if cfg.Health.RejectUnhealthy {
+		manager.SetHealthReporter(prober)
+	}
+	zap.L().Info("上游健康探测已启用",
+		zap.Duration("interval", cfg.Health.Interval),
+		zap.Bool("reject_unhealthy", cfg.Health.RejectUnhealthy),
+	)
+	return prober
+}
+
+// gateway 聊天请求链路上的组件（在线接口和批处理共用）
+type gateway struct {
+	manager     *adapter.AdapterManager // 适配器管理器（热加载时替换其注册关系）
+	structured  *structured.Adapter     // 结构化输出校验（热加载时调整重试次数）
+	chat        adapter.ModelAdapter    // 聊天适配器（管理器外包装结构化输出校验和缓存）
+	media       *media.Processor        // 多模态内容处理器（热加载时替换限制）
+	pipeline    *pipeline.Pipeline      // 上游调用前的请求处理（截断、上下文窗口校验、多模态处理）
+	chatHandler *handler.ChatHandler    // 聊天处理器
+}
+
 // initHandlers 初始化适配器和处理器
 // 参数：
 //   - cfg: 配置实例
+//   - auditor: 审计记录器（可为nil）
+//   - store: 响应缓存后端（可为nil）
+//   - drainer: 排空状态（关闭时中断流式响应）
 // 返回：
-//   - *handler.ChatHandler: 聊天处理器
-func initHandlers(cfg *config.Config) *handler.ChatHandler {
-	log.Println("🔧 初始化适配器...")
+//   - *gateway: 聊天请求链路（批处理也使用其中的适配器链和请求处理流程）
+func initHandlers(cfg *config.Config, auditor *audit.Auditor, store cache.Store, drainer *lifecycle.Drainer) *gateway {
+	// 根据配置创建适配器
+	adapters, err := buildAdapters(cfg)
+	if err != nil {
+		zap.L().Fatal("初始化适配器失败", zap.Error(err))
+	}
 
-	// 创建适配器管理器
+	// 创建适配器管理器并注册
 	manager := adapter.NewAdapterManager()
+	if err := manager.Reload(adapters); err != nil {
+		zap.L().Fatal("注册适配器失败", zap.Error(err))
+	}
 
-	// 遍历配置，动态注册适配器
-	for adapterName, adapterCfg := range cfg.Adapters {
-		log.Printf("  └─ 初始化适配器: %s", adapterName)
+	zap.L().Info("适配器管理器初始化成功", zap.Strings("models", manager.ListModels()))
+
+	// 校验结构化输出（response_format），不符合时重试
+	structuredAdapter := structured.NewAdapter(manager, cfg.Structured.MaxRetries)
+	var chatAdapter adapter.ModelAdapter = structuredAdapter
+
+	// 启用缓存时在外层再包装一层缓存（只缓存通过校验的响应）
+	if store != nil {
+		var opts []cache.Option
+		if cfg.Cache.Semantic.Enabled {
+			semantic, err := cache.NewSemanticFromConfig(cfg.Cache.Semantic)
+			if err != nil {
+				zap.L().Fatal("初始化语义缓存失败", zap.Error(err))
+			}
+			opts = append(opts, cache.WithSemantic(semantic))
+
+			zap.L().Info("语义缓存已启用",
+				zap.String("model", cfg.Cache.Semantic.Model),
+				zap.Float64("threshold", cfg.Cache.Semantic.Threshold),
+			)
+		}
+		chatAdapter = cache.NewCachingAdapter(chatAdapter, store, cfg.Cache.DeterministicOnly, opts...)
+	}
 
+	// 上游调用前的请求处理（批处理共用）
+	mediaProcessor := media.New(cfg.Media)
+	prep := pipeline.New(
+		pipeline.WithTruncator(truncate.New(manager)),
+		pipeline.WithMedia(mediaProcessor),
+	)
+
+	// 创建ChatHandler
+	chatHandler := handler.NewChatHandler(chatAdapter,
+		handler.WithAuditor(auditor),
+		handler.WithPipeline(prep),
+		handler.WithDrainer(drainer),
+		handler.WithModels(manager),
+	)
+
+	return &gateway{
+		manager:     manager,
+		structured:  structuredAdapter,
+		chat:        chatAdapter,
+		media:       mediaProcessor,
+		pipeline:    prep,
+		chatHandler: chatHandler,
+	}
+}
+
+// buildAdapters 根据配置创建所有适配器实例
+// 参数：
+//   - cfg: 配置实例
+// 返回：
+//   - map[string]adapter.ModelAdapter: 模型名称到适配器的映射
+//   - error: 模型重复注册时返回错误
+func buildAdapters(cfg *config.Config) (map[string]adapter.ModelAdapter, error) {
+	adapters := make(map[string]adapter.ModelAdapter)
+
+	// 遍历配置，动态创建适配器
+	for adapterName, adapterCfg := range cfg.Adapters {
 		// 根据适配器类型创建实例
+		// 未指定类型时按适配器名称识别，便于用不同名称接入多个OpenAI兼容供应商
+		adapterType := adapterCfg.Type
+		if adapterType == "" {
+			adapterType = adapterName
+		}
+
+		if adapterType != "glm" && adapterType != "openai" {
+			zap.L().Warn("跳过未实现的适配器", zap.String("adapter", adapterName))
+			continue
+		}
+
+		// 每个适配器使用独立的上游连接池（代理、CA证书、各阶段超时）
+		client, err := upstream.NewClient(adapterCfg.Timeout, adapterCfg.StreamIdleTimeout, adapterCfg.Transport)
+		if err != nil {
+			return nil, fmt.Errorf("adapter %s: %w", adapterName, err)
+		}
+
 		var adp adapter.ModelAdapter
-		switch adapterName {
+		switch adapterType {
 		case "glm":
 			// 创建GLM适配器
-			adp = glm.NewGLMAdapter(adapterCfg.APIKey)
-			log.Printf("     ✓ GLM适配器创建成功 (API Key: %s...)", maskAPIKey(adapterCfg.APIKey))
-
-			// 为每个模型注册适配器
-			for _, modelName := range adapterCfg.Models {
-				if err := manager.Register(modelName, adp); err != nil {
-					log.Fatalf("❌ 注册模型 %s 失败: %v", modelName, err)
-				}
-				log.Printf("     ✓ 模型 %s 注册成功", modelName)
-			}
+			adp = glm.NewGLMAdapterWithClient(adapterName, adapterCfg.APIKey, adapterCfg.BaseURL, client)
+
+		case "openai":
+			// 创建OpenAI兼容适配器
+			adp = openai.NewOpenAIAdapterWithClient(adapterName, adapterCfg.APIKey, adapterCfg.BaseURL, client)
+		}
 
-		default:
-			log.Printf("     ⚠️  跳过未实现的适配器: %s", adapterName)
+		// 为每个模型注册适配器
+		for _, modelName := range adapterCfg.Models {
+			if _, exists := adapters[modelName]; exists {
+				return nil, fmt.Errorf("model %s already registered", modelName)
+			}
+			adapters[modelName] = adp
 		}
+
+		zap.L().Info("适配器创建成功",
+			zap.String("adapter", adapterName),
+			zap.String("api_key", maskAPIKey(adapterCfg.APIKey)),
+			zap.Strings("models", adapterCfg.Models),
+		)
 	}
 
-	log.Println("✓ 适配器管理器初始化成功")
-	log.Printf("✓ 已注册模型: %v", manager.ListModels())
+	return adapters, nil
+}
 
-	// 创建ChatHandler
-	chatHandler := handler.NewChatHandler(manager)
-	log.Println("✓ ChatHandler初始化成功")
-	log.Println("========================================")
+// watchConfig 监听配置文件变化
+// 新配置通过验证后先完成所有可能失败的步骤（创建适配器、解析日志级别、加载词表），
+// 最后整体替换适配器注册关系；任何一步失败时不修改运行中的状态，继续使用当前配置。
+// 注册关系替换成功后立即生效的配置：
+//   - adapters、models：进行中的请求继续使用旧适配器直到结束
+//   - auth：API Key和可访问的模型
+//   - logging.level
+//   - tokenizer
+//   - media、structured_output.max_retries
+//   - batch 的请求数、并发数、速率和重试限制（执行中的请求沿用原来的限制）
+//   - health.reject_unhealthy（需要启动时已启用健康探测）
+//
+// 其余配置变更时记录警告，需要重启后生效
+// 参数：
+//   - gw: 聊天请求链路
+//   - prober: 健康探测器
+//   - processor: 批处理器
+//   - authenticator: API Key鉴权器
+func watchConfig(gw *gateway, prober *health.Prober, processor *batch.Processor, authenticator *auth.Authenticator) {
+	manager := gw.manager
+	onReload := func(newCfg *config.Config) error {
+		zap.L().Info("检测到配置文件变更，重新加载适配器")
+		oldCfg := config.GetConfig()
+
+		// 1. 完成所有可能失败的步骤，失败时运行中的状态保持不变
+		adapters, err := buildAdapters(newCfg)
+		if err != nil {
+			return err
+		}
+		lvl, err := logger.ParseLevel(newCfg.Logging.Level)
+		if err != nil {
+			return err
+		}
+		var registry *tokenizer.Registry
+		if oldCfg == nil || !reflect.DeepEqual(oldCfg.Tokenizer, newCfg.Tokenizer) {
+			if registry, err = tokenizer.NewRegistry(newCfg.Tokenizer); err != nil {
+				return err
+			}
+		}
+
+		// 2. 替换适配器注册关系（最后一个可能失败的步骤）
+		if err := manager.Reload(adapters); err != nil {
+			return err
+		}
+
+		// 3. 其余配置直接替换
+		authenticator.Reload(newCfg.Auth)
+		logger.SetLevel(lvl)
+		if registry != nil {
+			tokenizer.SetGlobal(registry)
+		}
+		gw.media.Reload(newCfg.Media)
+		gw.structured.SetMaxRetries(newCfg.Structured.MaxRetries)
+		processor.Reload(newCfg.Batch)
+		if prober.Enabled() {
+			if newCfg.Health.RejectUnhealthy {
+				manager.SetHealthReporter(prober)
+			} else {
+				manager.SetHealthReporter(nil)
+			}
+		}
+
+		// 4. 需要重启的配置
+		if oldCfg != nil {
+			warnRestartRequired(oldCfg, newCfg)
+		}
 
-	return chatHandler
+		zap.L().Info("适配器重新加载完成", zap.Strings("models", manager.ListModels()))
+		return nil
+	}
+
+	if err := config.Watch(context.Background(), configPath, onReload); err != nil {
+		zap.L().Warn("配置热加载未启用", zap.Error(err))
+		return
+	}
+	zap.L().Info("正在监听配置文件", zap.String("path", configPath))
 }
 
-// startServer 启动HTTP服务器
+// warnRestartRequired 对无法在运行时切换的配置变更记录警告
+// 参数：
+//   - oldCfg: 当前生效的配置
+//   - newCfg: 新配置
+func warnRestartRequired(oldCfg, newCfg *config.Config) {
+	if !reflect.DeepEqual(oldCfg.Server, newCfg.Server) {
+		zap.L().Warn("server 配置已变更，需要重启后生效（TLS证书文件变更会自动重新加载）")
+	}
+	oldLogging, newLogging := oldCfg.Logging, newCfg.Logging
+	oldLogging.Level, newLogging.Level = "", ""
+	if oldLogging != newLogging {
+		zap.L().Warn("logging 配置已变更，除日志级别外需要重启后生效")
+	}
+	if oldCfg.Metrics != newCfg.Metrics {
+		zap.L().Warn("metrics 配置已变更，需要重启后生效")
+	}
+	if oldCfg.Tracing != newCfg.Tracing {
+		zap.L().Warn("tracing 配置已变更，需要重启后生效")
+	}
+	if !reflect.DeepEqual(oldCfg.Audit, newCfg.Audit) {
+		zap.L().Warn("audit 配置已变更，需要重启后生效")
+	}
+	if oldCfg.Cache != newCfg.Cache {
+		zap.L().Warn("cache 配置已变更，需要重启后生效")
+	}
+	if oldCfg.Responses != newCfg.Responses {
+		zap.L().Warn("responses 配置已变更，需要重启后生效")
+	}
+	if oldCfg.Health.Interval != newCfg.Health.Interval || oldCfg.Health.Timeout != newCfg.Health.Timeout {
+		zap.L().Warn("health 探测间隔和超时已变更，需要重启后生效")
+	}
+	if oldCfg.Batch.Dir != newCfg.Batch.Dir || oldCfg.Batch.MaxFileSize != newCfg.Batch.MaxFileSize {
+		zap.L().Warn("batch 存储目录和文件大小限制已变更，需要重启后生效")
+	}
+}
+
+// runServer 启动HTTP服务器，阻塞直到收到退出信号并完成优雅关闭
 // 参数：
 //   - r: Gin路由器
 //   - cfg: 配置实例
-func startServer(r interface{ Run(addr ...string) error }, cfg *config.Config) {
-	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
+//   - drainer: 排空状态
+func runServer(r http.Handler, cfg *config.Config, drainer *lifecycle.Drainer) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// 收到第一次信号后恢复默认处理：再次 Ctrl+C 立即退出
+		<-ctx.Done()
+		stop()
+	}()
 
-	log.Println("🚀 Prism AI Gateway 启动成功")
-	log.Printf("📍 监听地址: http://localhost:%d", cfg.Server.Port)
-	log.Printf("🔧 运行模式: %s", cfg.Server.Mode)
-	log.Println("📚 可用接口:")
-	log.Println("   - GET  /              欢迎页面")
-	log.Println("   - GET  /health        健康检查")
-	log.Println("   - POST /v1/chat/completions  聊天补全")
-	log.Println("========================================")
+	srv, err := server.New(r, cfg.Server, drainer)
+	if err != nil {
+		zap.L().Fatal("创建服务器失败", zap.Error(err))
+	}
 
-	if err := r.Run(addr); err != nil {
-		log.Fatalf("❌ 启动失败: %v", err)
+	zap.L().Info("Prism AI Gateway 启动成功",
+		zap.String("addr", srv.Addr()),
+		zap.Strings("protocols", srv.Protocols()),
+		zap.String("mode", cfg.Server.Mode),
+		zap.Strings("endpoints", []string{
+			"GET  /",
+			"GET  /health",
+			"GET  /livez",
+			"GET  /readyz",
+			"GET  " + cfg.Metrics.Path,
+			"POST /v1/chat/completions",
+		}),
+	)
+
+	if err := srv.Run(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		zap.L().Fatal("服务器异常退出", zap.Error(err))
 	}
 }
 
@@ -120,4 +579,4 @@ func maskAPIKey(apiKey string) string {
 		return "***"
 	}
 	return apiKey[:8] + "..."
-}
\ No newline at end of file
+}
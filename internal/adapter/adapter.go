@@ -15,4 +15,11 @@ type ModelAdapter interface {
     Name() string
 	//健康检查
     HealthCheck(ctx context.Context) error
-}
\ No newline at end of file
+}
+// EmbeddingAdapter 向量化适配器（可选接口）
+// 支持 /v1/embeddings 的适配器在实现 ModelAdapter 的同时实现该接口，
+// AdapterManager 路由到模型对应的适配器后通过类型断言调用
+type EmbeddingAdapter interface {
+	//向量化
+	Embed(ctx context.Context, req *model.EmbeddingRequest) (*model.EmbeddingResponse, error)
+}
@@ -0,0 +1,235 @@
+package glm
+
+import (
+	"strings"
+
+	"github.com/AtSunset1/prism/internal/media"
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// ===== OpenAI格式与GLM格式的转换 =====
+// GLM的接口与OpenAI基本兼容，差异主要在工具调用和多模态内容：
+//   - tool_choice 只支持 "auto"
+//   - 函数定义不支持 strict，请求不支持 parallel_tool_calls
+//   - 非流式响应的 tool_calls 带有 index 字段；流式增量可能缺少 index
+//   - 纯文本模型只接受字符串内容；视觉模型（glm-4v）的base64图片不带 data URL 前缀
+//   - response_format 只支持 text 和 json_object，不支持 json_schema
+//   - 不支持 stream_options；流式用量总是附带在最后一个数据块中（由网关移到单独的用量数据块）
+
+// toGLMRequest 将OpenAI格式的请求转换为GLM格式
+// 返回新的请求，不修改调用方的请求
+//
+// tool_choice 的转换规则：
+//   - "none"：不发送工具，模型只能生成文本
+//   - "auto" / 未设置：原样发送
+//   - "required"：GLM无法强制调用，退化为 "auto"
+//   - 指定函数：只发送该函数，tool_choice 为 "auto"
+//
+// response_format 的转换规则见 toGLMResponseFormat
+func toGLMRequest(req *model.ChatRequest) *model.ChatRequest {
+	glmReq := *req
+	glmReq.StreamOptions = nil
+	glmReq.Messages = toGLMMessages(req.Messages)
+	toGLMResponseFormat(&glmReq)
+
+	if len(req.Tools) == 0 && req.ToolChoice == nil && req.ParallelToolCalls == nil {
+		return &glmReq
+	}
+
+	glmReq.ParallelToolCalls = nil
+	glmReq.ToolChoice = nil
+
+	tools := req.Tools
+	if tc := req.ToolChoice; tc != nil {
+		switch {
+		case tc.Mode == model.ToolChoiceNone:
+			tools = nil
+		case tc.Function != "":
+			tools = filterTools(tools, tc.Function)
+		}
+	}
+
+	glmReq.Tools = make([]model.Tool, len(tools))
+	for i, tool := range tools {
+		tool.Function.Strict = nil
+		glmReq.Tools[i] = tool
+	}
+	if len(glmReq.Tools) > 0 {
+		glmReq.ToolChoice = &model.ToolChoice{Mode: model.ToolChoiceAuto}
+	} else {
+		glmReq.Tools = nil
+	}
+
+	return &glmReq
+}
+
+// toGLMResponseFormat 转换结构化输出格式
+//   - text / json_object：原样发送
+//   - json_schema：以 json_object 发送，Schema通过system消息告知模型（最终输出由网关校验）
+//
+// 参数：
+//   - glmReq: 待发送的请求副本（替换其 Messages，不修改原切片）
+func toGLMResponseFormat(glmReq *model.ChatRequest) {
+	format := glmReq.ResponseFormat
+	if format == nil || format.Type != model.ResponseFormatJSONSchema {
+		return
+	}
+
+	glmReq.ResponseFormat = &model.ResponseFormat{Type: model.ResponseFormatJSONObject}
+
+	// 合并到已有的system消息，否则在最前面插入一条
+	instruction := format.Instruction()
+	messages := make([]model.Message, 0, len(glmReq.Messages)+1)
+	if len(glmReq.Messages) > 0 && glmReq.Messages[0].Role == "system" && !glmReq.Messages[0].Content.IsMultipart() {
+		first := glmReq.Messages[0]
+		first.Content = model.NewTextContent(first.Content.Text() + "\n\n" + instruction)
+		messages = append(messages, first)
+		messages = append(messages, glmReq.Messages[1:]...)
+	} else {
+		messages = append(messages, model.Message{Role: "system", Content: model.NewTextContent(instruction)})
+		messages = append(messages, glmReq.Messages...)
+	}
+	glmReq.Messages = messages
+}
+
+// toGLMMessages 转换多模态消息内容
+//   - 只有文本片段：合并为字符串，纯文本模型也能接受
+//   - 图片 data URL：去掉 "data:image/xxx;base64," 前缀，只保留base64数据
+//   - 其他片段原样透传
+//
+// 没有多模态内容时原样返回
+func toGLMMessages(messages []model.Message) []model.Message {
+	var out []model.Message
+	for i, msg := range messages {
+		if !msg.Content.IsMultipart() {
+			continue
+		}
+		if out == nil {
+			out = make([]model.Message, len(messages))
+			copy(out, messages)
+		}
+
+		if !msg.Content.HasMedia() {
+			out[i].Content = model.NewTextContent(msg.Content.Text())
+			continue
+		}
+
+		parts := make([]model.ContentPart, len(msg.Content.Parts()))
+		for j, part := range msg.Content.Parts() {
+			if part.ImageURL != nil && strings.HasPrefix(part.ImageURL.URL, "data:") {
+				image := *part.ImageURL
+				if _, data, err := media.ParseDataURL(image.URL); err == nil {
+					image.URL = data
+				}
+				part.ImageURL = &image
+			}
+			parts[j] = part
+		}
+		out[i].Content = model.NewMultipartContent(parts)
+	}
+
+	if out == nil {
+		return messages
+	}
+	return out
+}
+
+// filterTools 只保留指定名称的函数
+func filterTools(tools []model.Tool, name string) []model.Tool {
+	for _, tool := range tools {
+		if tool.Function.Name == name {
+			return []model.Tool{tool}
+		}
+	}
+	return nil
+}
+
+// fromGLMResponse 将GLM的非流式响应转换为OpenAI格式
+// OpenAI的非流式响应中工具调用不带 index
+func fromGLMResponse(resp *model.ChatResponse) {
+	for _, choice := range resp.Choices {
+		if choice.Message == nil {
+			continue
+		}
+		for i := range choice.Message.ToolCalls {
+			choice.Message.ToolCalls[i].Index = nil
+		}
+	}
+}
+
+// streamConverter 单个流的格式转换状态
+// OpenAI SDK按 index 拼接工具调用增量，GLM缺少 index 时需要补齐：
+// 同一个调用可能分散在多个数据块中，按数据块内的位置补齐会让不同的调用共用 index，
+// 因此按调用ID记录整个流中已分配的 index
+type streamConverter struct {
+	// toolIndexes 每个choice中已出现的工具调用ID及其 index
+	toolIndexes map[int]map[string]int
+	// lastIndex 每个choice最近一个工具调用的 index（不带ID的增量属于该调用）
+	lastIndex map[int]int
+}
+
+// newStreamConverter 为一个流创建转换状态
+func newStreamConverter() *streamConverter {
+	return &streamConverter{
+		toolIndexes: make(map[int]map[string]int),
+		lastIndex:   make(map[int]int),
+	}
+}
+
+// convert 将GLM的流式数据块转换为OpenAI格式
+func (c *streamConverter) convert(chunk *model.StreamResponse) {
+	for _, choice := range chunk.Choices {
+		for i := range choice.Delta.ToolCalls {
+			call := &choice.Delta.ToolCalls[i]
+			index := c.toolIndex(choice.Index, call)
+			call.Index = &index
+		}
+	}
+}
+
+// toolIndex 返回工具调用增量的 index
+//   - 上游已给出 index：直接使用，并记录ID对应关系
+//   - 带ID：同一ID复用之前的 index，新ID分配下一个 index
+//   - 不带ID：归入最近一个调用；之前没有调用时分配下一个 index
+func (c *streamConverter) toolIndex(choice int, call *model.ToolCall) int {
+	ids, ok := c.toolIndexes[choice]
+	if !ok {
+		ids = make(map[string]int)
+		c.toolIndexes[choice] = ids
+	}
+
+	var index int
+	switch last, hasLast := c.lastIndex[choice]; {
+	case call.Index != nil:
+		index = *call.Index
+	case call.ID != "":
+		known, seen := ids[call.ID]
+		if seen {
+			index = known
+		} else {
+			index = c.nextIndex(choice)
+		}
+	case hasLast:
+		index = last
+	default:
+		index = c.nextIndex(choice)
+	}
+
+	if call.ID != "" {
+		ids[call.ID] = index
+	}
+	c.lastIndex[choice] = index
+	return index
+}
+
+// nextIndex 返回choice中下一个未使用的 index
+func (c *streamConverter) nextIndex(choice int) int {
+	next := 0
+	for _, index := range c.toolIndexes[choice] {
+		next = max(next, index+1)
+	}
+	if last, ok := c.lastIndex[choice]; ok {
+		next = max(next, last+1)
+	}
+	return next
+}
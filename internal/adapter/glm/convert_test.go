@@ -0,0 +1,176 @@
+package glm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// toolDelta 构造只含一个工具调用增量的数据块
+func toolDelta(choice int, index *int, id, name, args string) *model.StreamResponse {
+	return &model.StreamResponse{Choices: []model.StreamChoice{{
+		Index: choice,
+		Delta: model.StreamDelta{ToolCalls: []model.ToolCall{
+			{Index: index, ID: id, Function: model.FunctionCall{Name: name, Arguments: args}},
+		}},
+	}}}
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestStreamConverterToolIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []*model.StreamResponse
+		want   []int
+	}{
+		{
+			name: "one call per chunk",
+			chunks: []*model.StreamResponse{
+				toolDelta(0, nil, "call_a", "get_weather", `{"city":"Paris"}`),
+				toolDelta(0, nil, "call_b", "get_time", `{}`),
+			},
+			want: []int{0, 1},
+		},
+		{
+			name: "repeated id keeps its index",
+			chunks: []*model.StreamResponse{
+				toolDelta(0, nil, "call_a", "get_weather", `{"city":`),
+				toolDelta(0, nil, "call_b", "get_time", `{}`),
+				toolDelta(0, nil, "call_a", "", `"Paris"}`),
+			},
+			want: []int{0, 1, 0},
+		},
+		{
+			name: "delta without id continues the last call",
+			chunks: []*model.StreamResponse{
+				toolDelta(0, nil, "call_a", "get_weather", `{"city":`),
+				toolDelta(0, nil, "", "", `"Paris"}`),
+				toolDelta(0, nil, "call_b", "get_time", `{}`),
+			},
+			want: []int{0, 0, 1},
+		},
+		{
+			name: "upstream index is kept",
+			chunks: []*model.StreamResponse{
+				toolDelta(0, intPtr(2), "call_a", "get_weather", `{}`),
+				toolDelta(0, nil, "call_b", "get_time", `{}`),
+				toolDelta(0, nil, "call_a", "", `{}`),
+			},
+			want: []int{2, 3, 2},
+		},
+		{
+			name: "choices are numbered independently",
+			chunks: []*model.StreamResponse{
+				toolDelta(0, nil, "call_a", "get_weather", `{}`),
+				toolDelta(1, nil, "call_b", "get_time", `{}`),
+			},
+			want: []int{0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newStreamConverter()
+			for i, chunk := range tt.chunks {
+				c.convert(chunk)
+				got := chunk.Choices[0].Delta.ToolCalls[0].Index
+				if got == nil || *got != tt.want[i] {
+					t.Errorf("chunk %d index = %v, want %d", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+
+	// 同一数据块中的多个新调用按位置编号
+	chunk := &model.StreamResponse{Choices: []model.StreamChoice{{Delta: model.StreamDelta{ToolCalls: []model.ToolCall{
+		{ID: "call_a"}, {ID: "call_b"},
+	}}}}}
+	newStreamConverter().convert(chunk)
+	for i, call := range chunk.Choices[0].Delta.ToolCalls {
+		if call.Index == nil || *call.Index != i {
+			t.Errorf("call %d index = %v", i, call.Index)
+		}
+	}
+}
+
+func TestChatStreamToolCallsAcrossChunks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, name := range []string{"get_weather", "get_time"} {
+			fmt.Fprintf(w, "data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"id\":\"call_%d\",\"type\":\"function\",\"function\":{\"name\":%q,\"arguments\":\"{}\"}}]}}]}\n\n", i, name)
+		}
+		fmt.Fprint(w, "data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"tool_calls\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	ch, err := NewGLMAdapterWithConfig("", "test-key", srv.URL, 0).ChatStream(context.Background(), &model.ChatRequest{Model: "glm-4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := model.NewStreamAccumulator()
+	for chunk := range ch {
+		acc.Add(chunk)
+	}
+
+	calls := acc.Response().Choices[0].Message.ToolCalls
+	if len(calls) != 2 {
+		t.Fatalf("tool calls = %+v, want 2", calls)
+	}
+	for i, name := range []string{"get_weather", "get_time"} {
+		if calls[i].Function.Name != name || calls[i].ID != fmt.Sprintf("call_%d", i) {
+			t.Errorf("call %d = %+v", i, calls[i])
+		}
+	}
+}
+
+func TestToGLMRequestToolChoice(t *testing.T) {
+	strict := true
+	tools := []model.Tool{
+		{Type: "function", Function: model.FunctionDefinition{Name: "get_weather", Strict: &strict}},
+		{Type: "function", Function: model.FunctionDefinition{Name: "get_time"}},
+	}
+	tests := []struct {
+		name       string
+		choice     *model.ToolChoice
+		wantTools  []string
+		wantChoice string
+	}{
+		{"unset", nil, []string{"get_weather", "get_time"}, model.ToolChoiceAuto},
+		{"none drops tools", &model.ToolChoice{Mode: model.ToolChoiceNone}, nil, ""},
+		{"required falls back to auto", &model.ToolChoice{Mode: model.ToolChoiceRequired}, []string{"get_weather", "get_time"}, model.ToolChoiceAuto},
+		{"named function", &model.ToolChoice{Function: "get_time"}, []string{"get_time"}, model.ToolChoiceAuto},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &model.ChatRequest{Model: "glm-4", Tools: tools, ToolChoice: tt.choice}
+			got := toGLMRequest(req)
+
+			var names []string
+			for _, tool := range got.Tools {
+				names = append(names, tool.Function.Name)
+				if tool.Function.Strict != nil {
+					t.Error("strict was not removed")
+				}
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.wantTools) {
+				t.Errorf("tools = %v, want %v", names, tt.wantTools)
+			}
+			var choice string
+			if got.ToolChoice != nil {
+				choice = got.ToolChoice.Mode
+			}
+			if choice != tt.wantChoice {
+				t.Errorf("tool_choice = %q, want %q", choice, tt.wantChoice)
+			}
+			// 不修改调用方的请求
+			if req.Tools[0].Function.Strict == nil || req.ToolChoice != tt.choice {
+				t.Error("caller request was modified")
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/AtSunset1/prism/internal/adapter/upstream"
 	"github.com/AtSunset1/prism/internal/model"
 )
 
@@ -19,27 +20,33 @@ const (
 	// DefaultGLMURL GLM API 默认地址
 	DefaultGLMURL = "https://open.bigmodel.cn/api/paas/v4/chat/completions"
 
+	// chatCompletionsPath 聊天接口路径（用于从baseURL推导其他接口地址）
+	chatCompletionsPath = "/chat/completions"
+
+	// embeddingsPath 向量接口路径
+	embeddingsPath = "/embeddings"
+
 	// DefaultTimeout 默认超时时间
 	DefaultTimeout = 30 * time.Second
 
-	// GLMName 适配器名称
+	// GLMName 默认适配器名称
 	GLMName = "glm"
 )
 
 // GLMAdapter 智谱GLM适配器
 // 实现 ModelAdapter 接口，用于调用智谱GLM API
 type GLMAdapter struct {
+	// name 适配器名称（配置中的适配器名，用于日志和指标）
+	name string
+
 	// apiKey API密钥
 	apiKey string
 
 	// baseURL API基础URL（默认使用 DefaultGLMURL）
 	baseURL string
 
-	// client HTTP客户端（复用连接，提高性能）
-	client *http.Client
-
-	// timeout 请求超时时间
-	timeout time.Duration
+	// client 上游HTTP客户端（每个适配器独立的连接池，Close 时关闭空闲连接）
+	client *upstream.Client
 }
 
 // NewGLMAdapter 创建GLM适配器实例
@@ -48,43 +55,49 @@ type GLMAdapter struct {
 // 返回：
 //   - *GLMAdapter: 适配器实例
 func NewGLMAdapter(apiKey string) *GLMAdapter {
-	return &GLMAdapter{
-		apiKey:  apiKey,
-		baseURL: DefaultGLMURL,
-		client: &http.Client{
-			Timeout: DefaultTimeout,
-		},
-		timeout: DefaultTimeout,
-	}
+	return NewGLMAdapterWithConfig(GLMName, apiKey, DefaultGLMURL, DefaultTimeout)
 }
 
 // NewGLMAdapterWithConfig 使用自定义配置创建GLM适配器
 // 参数：
+//   - name: 适配器名称（为空则使用 GLMName）
 //   - apiKey: 智谱API密钥
 //   - baseURL: 自定义API地址（如果为空则使用默认值）
 //   - timeout: 超时时间（如果为0则使用默认值）
-func NewGLMAdapterWithConfig(apiKey, baseURL string, timeout time.Duration) *GLMAdapter {
-	if baseURL == "" {
-		baseURL = DefaultGLMURL
-	}
+func NewGLMAdapterWithConfig(name, apiKey, baseURL string, timeout time.Duration) *GLMAdapter {
 	if timeout == 0 {
 		timeout = DefaultTimeout
 	}
 
+	return NewGLMAdapterWithClient(name, apiKey, baseURL, upstream.NewDefaultClient(timeout))
+}
+
+// NewGLMAdapterWithClient 使用指定的上游客户端创建GLM适配器
+// 参数：
+//   - name: 适配器名称（为空则使用 GLMName）
+//   - apiKey: 智谱API密钥
+//   - baseURL: 自定义API地址（如果为空则使用默认值）
+//   - client: 上游HTTP客户端（连接池、代理、超时等由其决定）
+func NewGLMAdapterWithClient(name, apiKey, baseURL string, client *upstream.Client) *GLMAdapter {
+	if name == "" {
+		name = GLMName
+	}
+	if baseURL == "" {
+		baseURL = DefaultGLMURL
+	}
+
 	return &GLMAdapter{
+		name:    name,
 		apiKey:  apiKey,
 		baseURL: baseURL,
-		client: &http.Client{
-			Timeout: timeout,
-		},
-		timeout: timeout,
+		client:  client,
 	}
 }
 
 // Name 返回适配器名称
 // 实现 ModelAdapter 接口
 func (a *GLMAdapter) Name() string {
-	return GLMName
+	return a.name
 }
 
 // Chat 非流式聊天接口
@@ -96,8 +109,8 @@ func (a *GLMAdapter) Name() string {
 //   - *model.ChatResponse: 聊天响应
 //   - error: 错误信息
 func (a *GLMAdapter) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
-	// 1. 构造请求体（GLM格式与我们的模型完全兼容）
-	reqBody, err := json.Marshal(req)
+	// 1. 构造请求体（GLM格式与我们的模型基本兼容，工具调用需要转换）
+	reqBody, err := json.Marshal(toGLMRequest(req))
 	if err != nil {
 		return nil, fmt.Errorf("marshal request failed: %w", err)
 	}
@@ -127,7 +140,7 @@ func (a *GLMAdapter) Chat(ctx context.Context, req *model.ChatRequest) (*model.C
 
 	// 6. 检查HTTP状态码
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GLM API error (status %d): %s", httpResp.StatusCode, string(respBody))
+		return nil, &upstream.StatusError{Provider: "GLM", StatusCode: httpResp.StatusCode, Body: string(respBody)}
 	}
 
 	// 7. 解析响应（GLM格式与我们的模型兼容）
@@ -135,6 +148,7 @@ func (a *GLMAdapter) Chat(ctx context.Context, req *model.ChatRequest) (*model.C
 	if err := json.Unmarshal(respBody, &chatResp); err != nil {
 		return nil, fmt.Errorf("unmarshal response failed: %w", err)
 	}
+	fromGLMResponse(&chatResp)
 
 	return &chatResp, nil
 }
@@ -151,8 +165,8 @@ func (a *GLMAdapter) ChatStream(ctx context.Context, req *model.ChatRequest) (<-
 	// 1. 强制启用流式模式
 	req.Stream = true
 
-	// 2. 构造请求体
-	reqBody, err := json.Marshal(req)
+	// 2. 构造请求体（工具调用需要转换为GLM格式）
+	reqBody, err := json.Marshal(toGLMRequest(req))
 	if err != nil {
 		return nil, fmt.Errorf("marshal request failed: %w", err)
 	}
@@ -168,8 +182,8 @@ func (a *GLMAdapter) ChatStream(ctx context.Context, req *model.ChatRequest) (<-
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "text/event-stream")
 
-	// 5. 发送请求
-	httpResp, err := a.client.Do(httpReq)
+	// 5. 发送请求（不设总超时，数据块之间的空闲超时由客户端控制）
+	httpResp, err := a.client.DoStream(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("http request failed: %w", err)
 	}
@@ -178,7 +192,7 @@ func (a *GLMAdapter) ChatStream(ctx context.Context, req *model.ChatRequest) (<-
 	if httpResp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(httpResp.Body)
 		httpResp.Body.Close()
-		return nil, fmt.Errorf("GLM API error (status %d): %s", httpResp.StatusCode, string(body))
+		return nil, &upstream.StatusError{Provider: "GLM", StatusCode: httpResp.StatusCode, Body: string(body)}
 	}
 
 	// 7. 创建channel用于传递流式响应
@@ -191,6 +205,7 @@ func (a *GLMAdapter) ChatStream(ctx context.Context, req *model.ChatRequest) (<-
 
 		// 使用 Scanner 逐行读取 SSE 数据
 		scanner := bufio.NewScanner(httpResp.Body)
+		converter := newStreamConverter()
 
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -210,6 +225,7 @@ func (a *GLMAdapter) ChatStream(ctx context.Context, req *model.ChatRequest) (<-
 					// 解析失败，忽略这条数据
 					continue
 				}
+				converter.convert(&streamResp)
 
 				// 发送到channel（检查context是否已取消）
 				select {
@@ -222,43 +238,103 @@ func (a *GLMAdapter) ChatStream(ctx context.Context, req *model.ChatRequest) (<-
 			}
 		}
 
-		// 检查扫描错误
-		if err := scanner.Err(); err != nil {
-			// 流读取失败，但无法通过channel传递错误
-			// 可以考虑在最后发送一个特殊的错误响应
-			return
+		// 检查扫描错误（调用方取消除外），作为错误数据块发送
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			select {
+			case streamChan <- model.NewStreamErrorResponse(fmt.Errorf("read stream failed: %w", err)):
+			case <-ctx.Done():
+			}
 		}
 	}()
 
 	return streamChan, nil
 }
 
+// Embed 向量化接口
+// 实现 EmbeddingAdapter 接口，支持 embedding-2、embedding-3 等模型
+// 参数：
+//   - ctx: 上下文（用于超时控制）
+//   - req: 向量化请求（OpenAI兼容格式）
+// 返回：
+//   - *model.EmbeddingResponse: 向量化响应（浮点数格式，base64由网关统一转换）
+//   - error: 错误信息
+func (a *GLMAdapter) Embed(ctx context.Context, req *model.EmbeddingRequest) (*model.EmbeddingResponse, error) {
+	// 1. 构造请求体（GLM只支持浮点数格式，去掉encoding_format）
+	upstreamReq := *req
+	upstreamReq.EncodingFormat = ""
+	reqBody, err := json.Marshal(&upstreamReq)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request failed: %w", err)
+	}
+
+	// 2. 创建HTTP请求（向量接口与聊天接口同一前缀）
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", a.embeddingsURL(), bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+a.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	// 3. 发送请求并读取响应体
+	httpResp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("http request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response failed: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, &upstream.StatusError{Provider: "GLM", StatusCode: httpResp.StatusCode, Body: string(respBody)}
+	}
+
+	// 4. 解析响应（GLM格式与OpenAI兼容）
+	var embResp model.EmbeddingResponse
+	if err := json.Unmarshal(respBody, &embResp); err != nil {
+		return nil, fmt.Errorf("unmarshal response failed: %w", err)
+	}
+
+	return &embResp, nil
+}
+
+// embeddingsURL 向量接口地址
+// 由聊天接口地址推导，例如 .../v4/chat/completions -> .../v4/embeddings
+func (a *GLMAdapter) embeddingsURL() string {
+	return strings.TrimSuffix(a.baseURL, chatCompletionsPath) + embeddingsPath
+}
+
 // HealthCheck 健康检查
 // 实现 ModelAdapter 接口
-// 发送一个简单的测试请求，验证API是否可用
+// GLM没有免费的只读接口，探测请求故意不带消息：上游在参数校验阶段返回400，
+// 不会调用模型、不消耗token。收到400说明网络和API密钥都正常，401/403说明密钥无效；
+// 429只是限流，上游仍然可用
 func (a *GLMAdapter) HealthCheck(ctx context.Context) error {
-	// 构造简单的测试请求
-	testReq := &model.ChatRequest{
-		Model: "glm-4",
-		Messages: []model.Message{
-			{
-				Role:    "user",
-				Content: "hi",
-			},
-		},
-		MaxTokens: intPtr(5), // 限制token数量，节省配额
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", a.baseURL, strings.NewReader(`{"model":"glm-4","messages":[]}`))
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
 	}
+	httpReq.Header.Set("Authorization", "Bearer "+a.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
 
-	// 调用Chat接口
-	_, err := a.Chat(ctx, testReq)
+	httpResp, err := a.client.Do(httpReq)
 	if err != nil {
 		return fmt.Errorf("health check failed: %w", err)
 	}
+	defer httpResp.Body.Close()
+	io.Copy(io.Discard, httpResp.Body)
 
-	return nil
+	switch httpResp.StatusCode {
+	case http.StatusOK, http.StatusBadRequest, http.StatusTooManyRequests:
+		return nil
+	default:
+		return fmt.Errorf("health check failed: status %d", httpResp.StatusCode)
+	}
 }
 
-// intPtr 辅助函数：返回int指针
-func intPtr(i int) *int {
-	return &i
+// Close 关闭空闲的上游连接
+// 网关退出前调用；进行中的请求不受影响
+func (a *GLMAdapter) Close() error {
+	return a.client.Close()
 }
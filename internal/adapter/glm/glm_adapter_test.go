@@ -0,0 +1,50 @@
+package glm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthCheckStatus(t *testing.T) {
+	tests := []struct {
+		status  int
+		healthy bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusBadRequest, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusUnauthorized, false},
+		{http.StatusForbidden, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusServiceUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+					t.Errorf("Authorization = %q", got)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			err := NewGLMAdapterWithConfig("", "test-key", srv.URL, 0).HealthCheck(context.Background())
+			if (err == nil) != tt.healthy {
+				t.Errorf("HealthCheck() error = %v, want healthy=%v", err, tt.healthy)
+			}
+		})
+	}
+}
+
+func TestName(t *testing.T) {
+	if got := NewGLMAdapter("test-key").Name(); got != GLMName {
+		t.Errorf("default name = %q, want %q", got, GLMName)
+	}
+	// 同一类型的多个适配器按配置中的名称区分（健康状态、路由、指标、批处理限制）
+	if got := NewGLMAdapterWithConfig("glm-backup", "test-key", "", 0).Name(); got != "glm-backup" {
+		t.Errorf("name = %q, want glm-backup", got)
+	}
+}
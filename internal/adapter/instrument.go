@@ -0,0 +1,153 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/metrics"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/tokenizer"
+	"github.com/AtSunset1/prism/internal/tracing"
+	"github.com/AtSunset1/prism/pkg/logger"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// retryKey context中标记重试调用的key
+type retryKey struct{}
+
+// WithRetry 标记本次调用是一次重试
+// 同一个请求需要再次调用上游时（如输出不符合要求、遇到限流或上游故障）通过它标记，
+// AdapterManager 按实际路由到的适配器记录重试指标
+//
+// 示例：
+//
+//	resp, err := next.Chat(adapter.WithRetry(ctx), req)
+func WithRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryKey{}, true)
+}
+
+// observeRetry 调用被标记为重试时记录重试指标
+func observeRetry(ctx context.Context, modelName, adapterName string) {
+	if retry, _ := ctx.Value(retryKey{}).(bool); retry {
+		metrics.ObserveRetry(modelName, adapterName)
+	}
+}
+
+// instrumentStream 为流式响应channel添加指标统计、链路追踪和用量统计
+// 参数：
+//   - ctx: 请求上下文
+//   - span: 上游调用span（流结束时由这里负责结束）
+//   - req: 聊天请求（上游没有返回用量时用于本地计算输入token）
+//   - adapterName: 适配器名称
+//   - start: 上游调用开始时间
+//   - upstream: 适配器返回的原始channel
+//
+// 返回：
+//   - <-chan *model.StreamResponse: 透传所有数据块的新channel
+//
+// 说明：
+//   - 首个带内容的数据块到达时记录首token延迟（同时作为span事件）
+//   - 用量统一为流末尾单独的用量数据块：上游附带在内容数据块中的用量会被移出；
+//     上游没有返回用量时使用本地分词器计算。是否转发给客户端由调用方决定
+//   - 原始channel关闭时记录流总耗时、结束原因、token用量，并减少活跃流计数
+//   - ctx取消后不再向下游发送，但继续读空原始channel，
+//     避免适配器goroutine阻塞（适配器在ctx取消后也会关闭原始channel）
+func instrumentStream(ctx context.Context, span trace.Span, req *model.ChatRequest, adapterName string, start time.Time, upstream <-chan *model.StreamResponse) <-chan *model.StreamResponse {
+	modelName := req.Model
+	out := make(chan *model.StreamResponse, cap(upstream))
+
+	activeStreams := metrics.ActiveStreams.WithLabelValues(modelName, adapterName)
+	activeStreams.Inc()
+
+	go func() {
+		defer close(out)
+		defer activeStreams.Dec()
+		defer span.End()
+
+		var (
+			firstToken   = true
+			last         *model.StreamResponse
+			finishReason string
+			usage        *model.Usage
+			acc          = model.NewStreamAccumulator()
+			failed       *model.StreamResponse
+		)
+		for chunk := range upstream {
+			// 错误数据块留到最后转发
+			if chunk.IsError() {
+				failed = chunk
+				continue
+			}
+			if firstToken && chunk.GetContent() != "" {
+				metrics.TimeToFirstToken.WithLabelValues(modelName, adapterName).Observe(time.Since(start).Seconds())
+				span.AddEvent("first_token")
+				firstToken = false
+			}
+			if chunk.IsEnd() {
+				finishReason = chunk.GetFinishReason()
+			}
+			last = chunk
+			acc.Add(chunk)
+
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+				if len(chunk.Choices) == 0 {
+					continue
+				}
+				withoutUsage := *chunk
+				withoutUsage.Usage = nil
+				chunk = &withoutUsage
+			}
+
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+			}
+		}
+
+		if usage == nil && last != nil {
+			tok, _ := tokenizer.ForModel(modelName)
+			estimated := tokenizer.EstimateUsage(tok, req, acc.Response())
+			usage = &estimated
+			logger.FromContext(ctx).Debug("上游未返回流式用量，使用本地计算结果",
+				zap.Int("prompt_tokens", usage.PromptTokens),
+				zap.Int("completion_tokens", usage.CompletionTokens),
+			)
+		}
+		if usage != nil {
+			metrics.ObserveTokens(modelName, adapterName, usage.PromptTokens, usage.CompletionTokens)
+			if ctx.Err() == nil && failed == nil {
+				resp := acc.Response()
+				select {
+				case out <- model.NewStreamUsageResponse(resp.ID, resp.Model, resp.Created, *usage):
+				case <-ctx.Done():
+				}
+			}
+		}
+
+		span.SetAttributes(tracing.StreamAttributes(last, finishReason, usage)...)
+
+		if failed != nil {
+			span.RecordError(failed.Err)
+			select {
+			case out <- failed:
+			case <-ctx.Done():
+			}
+		}
+
+		// 客户端断开或超时导致流提前结束，记为失败
+		status := metrics.StatusSuccess
+		if err := ctx.Err(); err != nil {
+			status = metrics.StatusError
+			span.SetStatus(codes.Error, err.Error())
+		} else if failed != nil {
+			status = metrics.StatusError
+			span.SetStatus(codes.Error, failed.Err.Error())
+		}
+		metrics.ObserveUpstream(modelName, adapterName, status, time.Since(start))
+	}()
+
+	return out
+}
@@ -0,0 +1,106 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// collect 读完数据块
+func collect(ch <-chan *model.StreamResponse) []*model.StreamResponse {
+	var chunks []*model.StreamResponse
+	for chunk := range ch {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+func TestChatStreamUsageChunk(t *testing.T) {
+	stop := "stop"
+	upstreamUsage := model.Usage{PromptTokens: 9, CompletionTokens: 2, TotalTokens: 11}
+	tests := []struct {
+		name      string
+		chunks    []*model.StreamResponse
+		wantUsage *model.Usage
+	}{
+		{
+			name: "usage on the finish chunk is moved",
+			chunks: []*model.StreamResponse{
+				model.NewStreamResponse("chatcmpl-1", "glm-4", "", true),
+				model.NewStreamResponse("chatcmpl-1", "glm-4", "Hello", false),
+				{ID: "chatcmpl-1", Model: "glm-4", Choices: []model.StreamChoice{{FinishReason: &stop}}, Usage: &upstreamUsage},
+			},
+			wantUsage: &upstreamUsage,
+		},
+		{
+			name: "separate usage chunk is kept last",
+			chunks: []*model.StreamResponse{
+				model.NewStreamResponse("chatcmpl-1", "glm-4", "", true),
+				model.NewStreamResponse("chatcmpl-1", "glm-4", "Hello", false),
+				{ID: "chatcmpl-1", Model: "glm-4", Choices: []model.StreamChoice{{FinishReason: &stop}}},
+				model.NewStreamUsageResponse("chatcmpl-1", "glm-4", 0, upstreamUsage),
+			},
+			wantUsage: &upstreamUsage,
+		},
+		{
+			name: "missing usage is estimated",
+			chunks: []*model.StreamResponse{
+				model.NewStreamResponse("chatcmpl-1", "glm-4", "", true),
+				model.NewStreamResponse("chatcmpl-1", "glm-4", "Hello", false),
+				{ID: "chatcmpl-1", Model: "glm-4", Choices: []model.StreamChoice{{FinishReason: &stop}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewAdapterManager()
+			if err := m.Reload(map[string]ModelAdapter{"glm-4": &stubAdapter{name: "glm", chunks: tt.chunks}}); err != nil {
+				t.Fatal(err)
+			}
+			req := &model.ChatRequest{Model: "glm-4", Stream: true, Messages: []model.Message{{Role: "user", Content: model.NewTextContent("hi")}}}
+			ch, err := m.ChatStream(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			chunks := collect(ch)
+
+			// 只有最后一个数据块是用量数据块
+			last := chunks[len(chunks)-1]
+			if !last.IsUsage() || last.ID != "chatcmpl-1" {
+				t.Fatalf("last chunk = %+v, want usage chunk", last)
+			}
+			for _, chunk := range chunks[:len(chunks)-1] {
+				if chunk.Usage != nil {
+					t.Errorf("content chunk carries usage: %+v", chunk)
+				}
+			}
+
+			if tt.wantUsage != nil {
+				if *last.Usage != *tt.wantUsage {
+					t.Errorf("usage = %+v, want %+v", *last.Usage, *tt.wantUsage)
+				}
+				return
+			}
+			if last.Usage.PromptTokens == 0 || last.Usage.CompletionTokens == 0 ||
+				last.Usage.TotalTokens != last.Usage.PromptTokens+last.Usage.CompletionTokens {
+				t.Errorf("estimated usage = %+v", *last.Usage)
+			}
+		})
+	}
+}
+
+func TestChatStreamEmptyHasNoUsage(t *testing.T) {
+	m := NewAdapterManager()
+	if err := m.Reload(map[string]ModelAdapter{"glm-4": &stubAdapter{name: "glm"}}); err != nil {
+		t.Fatal(err)
+	}
+	ch, err := m.ChatStream(context.Background(), &model.ChatRequest{Model: "glm-4", Stream: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 上游没有返回任何数据块时不发送用量
+	if chunks := collect(ch); len(chunks) != 0 {
+		t.Errorf("chunks = %d, want 0", len(chunks))
+	}
+}
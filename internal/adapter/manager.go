@@ -2,12 +2,41 @@ package adapter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/AtSunset1/prism/internal/adapter/upstream"
+	"github.com/AtSunset1/prism/internal/metrics"
 	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/tracing"
+	"github.com/AtSunset1/prism/pkg/logger"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
+// ErrModelNotFound 请求的模型未注册
+// 调用方可以用 errors.Is 判断，返回 not_found_error 而不是上游错误
+var ErrModelNotFound = errors.New("model not found")
+
+// ErrEmbeddingsNotSupported 模型对应的适配器不支持向量化
+var ErrEmbeddingsNotSupported = errors.New("model does not support embeddings")
+
+// ErrAdapterUnavailable 模型对应的适配器在最近一次健康探测中不可用
+// 请求直接失败，不再等待上游超时
+var ErrAdapterUnavailable = errors.New("adapter unavailable")
+
+// HealthReporter 适配器健康状态的来源（通常是后台探测器）
+type HealthReporter interface {
+	// Healthy 返回适配器是否可用；尚未探测过的适配器视为可用
+	Healthy(adapterName string) bool
+}
+
 // AdapterManager 适配器管理器
 // 负责管理多个模型适配器，根据模型名称路由到对应的适配器
 //
@@ -26,6 +55,9 @@ type AdapterManager struct {
 	// value: 适配器实例
 	adapters map[string]ModelAdapter
 
+	// health 健康状态来源（可选，nil表示路由时不考虑健康状态）
+	health HealthReporter
+
 	// mu 读写锁，保护adapters map的并发安全
 	// 使用RWMutex而非Mutex：允许多个并发读，提高性能
 	mu sync.RWMutex
@@ -79,6 +111,62 @@ func (m *AdapterManager) Register(modelName string, adapter ModelAdapter) error
 	return nil
 }
 
+// Reload 用一组新的注册关系整体替换当前映射表
+// 参数：
+//   - adapters: 新的模型名称到适配器的映射
+//
+// 返回：
+//   - error: 如果映射为空或包含无效项则返回错误，此时保留原映射
+//
+// 说明：
+//   - 替换在写锁内一次完成，请求要么看到旧映射，要么看到新映射
+//   - 正在进行的流式请求已持有旧适配器实例，会在旧适配器上自然结束
+//   - 替换后关闭不再使用的旧适配器（只释放空闲连接，不影响进行中的请求）
+//
+// 示例：
+//
+//	newAdapters := map[string]ModelAdapter{"glm-4": glmAdapter}
+//	manager.Reload(newAdapters)
+func (m *AdapterManager) Reload(adapters map[string]ModelAdapter) error {
+	if len(adapters) == 0 {
+		return fmt.Errorf("no adapters to register")
+	}
+
+	// 先复制并校验，避免调用方后续修改传入的map
+	registry := make(map[string]ModelAdapter, len(adapters))
+	for modelName, adapter := range adapters {
+		if modelName == "" {
+			return fmt.Errorf("model name cannot be empty")
+		}
+		if adapter == nil {
+			return fmt.Errorf("adapter for model %s cannot be nil", modelName)
+		}
+		registry[modelName] = adapter
+	}
+
+	m.mu.Lock()
+	previous := m.adapters
+	m.adapters = registry
+	m.mu.Unlock()
+
+	// 新映射中仍在使用的实例不关闭
+	inUse := make(map[ModelAdapter]bool, len(registry))
+	for _, adapter := range registry {
+		inUse[adapter] = true
+	}
+	closed := make(map[ModelAdapter]bool, len(previous))
+	for _, adapter := range previous {
+		if inUse[adapter] || closed[adapter] {
+			continue
+		}
+		closed[adapter] = true
+		if err := closeAdapter(adapter); err != nil {
+			zap.L().Warn("关闭旧适配器失败", zap.Error(err))
+		}
+	}
+	return nil
+}
+
 // GetAdapter 获取指定模型的适配器
 // 参数：
 //   - modelName: 模型名称
@@ -99,12 +187,44 @@ func (m *AdapterManager) GetAdapter(modelName string) (ModelAdapter, error) {
 
 	adapter, exists := m.adapters[modelName]
 	if !exists {
-		return nil, fmt.Errorf("model %s not found", modelName)
+		return nil, fmt.Errorf("model %s: %w", modelName, ErrModelNotFound)
 	}
 
 	return adapter, nil
 }
 
+// SetHealthReporter 设置健康状态来源
+// 设置后，路由到最近一次探测不可用的适配器时直接返回 ErrAdapterUnavailable
+// 参数：
+//   - reporter: 健康状态来源（nil表示不考虑健康状态）
+func (m *AdapterManager) SetHealthReporter(reporter HealthReporter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.health = reporter
+}
+
+// Adapters 列出所有已注册的适配器（多个模型共用的适配器只出现一次），按名称排序
+// 返回：
+//   - []ModelAdapter: 适配器列表
+func (m *AdapterManager) Adapters() []ModelAdapter {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := make(map[ModelAdapter]bool, len(m.adapters))
+	adapters := make([]ModelAdapter, 0, len(m.adapters))
+	for _, adapter := range m.adapters {
+		if !seen[adapter] {
+			seen[adapter] = true
+			adapters = append(adapters, adapter)
+		}
+	}
+	sort.Slice(adapters, func(i, j int) bool {
+		return adapters[i].Name() < adapters[j].Name()
+	})
+	return adapters
+}
+
 // ListModels 列出所有已注册的模型名称
 // 返回：
 //   - []string: 模型名称列表
@@ -140,13 +260,30 @@ func (m *AdapterManager) ListModels() []string {
 //   - error: 错误信息
 func (m *AdapterManager) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
 	// 1. 根据请求中的model字段获取对应的适配器
-	adapter, err := m.GetAdapter(req.Model)
+	adapter, err := m.route(ctx, req.Model)
 	if err != nil {
-		return nil, fmt.Errorf("获取适配器失败: %w", err)
+		return nil, err
 	}
 
-	// 2. 调用对应适配器的Chat方法
-	return adapter.Chat(ctx, req)
+	// 2. 调用对应适配器的Chat方法，记录上游span和指标
+	observeRetry(ctx, req.Model, adapter.Name())
+	ctx, span := startUpstreamSpan(ctx, req, adapter.Name())
+	defer span.End()
+
+	start := time.Now()
+	resp, err := adapter.Chat(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		metrics.ObserveUpstream(req.Model, adapter.Name(), metrics.StatusError, time.Since(start))
+		return nil, err
+	}
+
+	span.SetAttributes(tracing.ResponseAttributes(resp)...)
+	metrics.ObserveUpstream(req.Model, adapter.Name(), metrics.StatusSuccess, time.Since(start))
+	metrics.ObserveTokens(req.Model, adapter.Name(), resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+
+	return resp, nil
 }
 
 // ChatStream 流式聊天接口
@@ -161,13 +298,115 @@ func (m *AdapterManager) Chat(ctx context.Context, req *model.ChatRequest) (*mod
 //   - error: 错误信息
 func (m *AdapterManager) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
 	// 1. 根据请求中的model字段获取对应的适配器
-	adapter, err := m.GetAdapter(req.Model)
+	adapter, err := m.route(ctx, req.Model)
 	if err != nil {
-		return nil, fmt.Errorf("获取适配器失败: %w", err)
+		return nil, err
 	}
 
 	// 2. 调用对应适配器的ChatStream方法
-	return adapter.ChatStream(ctx, req)
+	// 上游span在流结束时才结束，由 instrumentStream 负责
+	observeRetry(ctx, req.Model, adapter.Name())
+	ctx, span := startUpstreamSpan(ctx, req, adapter.Name())
+
+	start := time.Now()
+	streamChan, err := adapter.ChatStream(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		metrics.ObserveUpstream(req.Model, adapter.Name(), metrics.StatusError, time.Since(start))
+		return nil, err
+	}
+
+	// 3. 包装channel，统计首token延迟、活跃流数量、流总耗时和token用量
+	return instrumentStream(ctx, span, req, adapter.Name(), start, streamChan), nil
+}
+
+// Embed 向量化接口
+// 根据请求中的model字段路由到对应的适配器，适配器需要实现 EmbeddingAdapter
+//
+// 参数：
+//   - ctx: 上下文（用于超时控制）
+//   - req: 向量化请求
+//
+// 返回：
+//   - *model.EmbeddingResponse: 向量化响应
+//   - error: 模型未注册返回 ErrModelNotFound，适配器不支持返回 ErrEmbeddingsNotSupported
+func (m *AdapterManager) Embed(ctx context.Context, req *model.EmbeddingRequest) (*model.EmbeddingResponse, error) {
+	// 1. 路由到模型对应的适配器，并确认其支持向量化
+	adapter, err := m.route(ctx, req.Model)
+	if err != nil {
+		return nil, err
+	}
+	embedder, ok := adapter.(EmbeddingAdapter)
+	if !ok {
+		return nil, fmt.Errorf("model %s (adapter %s): %w", req.Model, adapter.Name(), ErrEmbeddingsNotSupported)
+	}
+
+	// 2. 调用适配器，记录上游span和指标
+	attrs := append(tracing.EmbeddingRequestAttributes(req), tracing.ProviderAttribute(adapter.Name()))
+	ctx, span := tracing.Tracer().Start(ctx, "embeddings "+req.Model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	defer span.End()
+
+	start := time.Now()
+	resp, err := embedder.Embed(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		metrics.ObserveUpstream(req.Model, adapter.Name(), metrics.StatusError, time.Since(start))
+		return nil, err
+	}
+
+	span.SetAttributes(tracing.EmbeddingResponseAttributes(resp)...)
+	metrics.ObserveUpstream(req.Model, adapter.Name(), metrics.StatusSuccess, time.Since(start))
+	metrics.ObserveTokens(req.Model, adapter.Name(), resp.Usage.PromptTokens, 0)
+
+	return resp, nil
+}
+
+// route 路由决策：根据模型名称选择适配器
+// 记录一个 prism.route span，并在请求级日志中记录实际使用的适配器
+func (m *AdapterManager) route(ctx context.Context, modelName string) (ModelAdapter, error) {
+	_, span := tracing.Tracer().Start(ctx, "prism.route",
+		trace.WithAttributes(semconv.GenAIRequestModel(modelName)),
+	)
+	defer span.End()
+
+	adapter, err := m.GetAdapter(modelName)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("获取适配器失败: %w", err)
+	}
+
+	span.SetAttributes(tracing.ProviderAttribute(adapter.Name()))
+	logger.AddFields(ctx, zap.String("adapter", adapter.Name()))
+	upstream.MetaFromContext(ctx).SetAdapter(adapter.Name())
+
+	m.mu.RLock()
+	health := m.health
+	m.mu.RUnlock()
+	if health != nil && !health.Healthy(adapter.Name()) {
+		err := fmt.Errorf("model %s (adapter %s): %w", modelName, adapter.Name(), ErrAdapterUnavailable)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return adapter, nil
+}
+
+// startUpstreamSpan 为一次上游调用创建client span
+// span名称遵循GenAI语义约定：{operation} {model}
+func startUpstreamSpan(ctx context.Context, req *model.ChatRequest, adapterName string) (context.Context, trace.Span) {
+	attrs := append(tracing.RequestAttributes(req), tracing.ProviderAttribute(adapterName))
+	return tracing.Tracer().Start(ctx, "chat "+req.Model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
 }
 
 // Name 返回管理器名称
@@ -177,29 +416,61 @@ func (m *AdapterManager) Name() string {
 }
 
 // HealthCheck 健康检查
-// 检查所有已注册适配器的健康状态
+// 并发检查所有已注册的适配器（多个模型共用的适配器只检查一次）
 //
 // 参数：
 //   - ctx: 上下文（用于超时控制）
 //
 // 返回：
-//   - error: 如果任何一个适配器不健康则返回错误
+//   - error: 不健康的适配器的错误（合并返回），全部健康时返回nil
 func (m *AdapterManager) HealthCheck(ctx context.Context) error {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	adapters := m.Adapters()
 
 	// 如果没有注册任何适配器，返回错误
-	if len(m.adapters) == 0 {
+	if len(adapters) == 0 {
 		return fmt.Errorf("no adapters registered")
 	}
 
-	// 检查所有适配器的健康状态
-	// 注意：这里是串行检查，如果需要提高性能可以改为并发检查
-	for modelName, adapter := range m.adapters {
-		if err := adapter.HealthCheck(ctx); err != nil {
-			return fmt.Errorf("adapter %s health check failed: %w", modelName, err)
+	errs := make([]error, len(adapters))
+	var wg sync.WaitGroup
+	for i, adapter := range adapters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := adapter.HealthCheck(ctx); err != nil {
+				errs[i] = fmt.Errorf("adapter %s health check failed: %w", adapter.Name(), err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Close 关闭所有已注册适配器的上游连接
+// 网关退出、进行中的请求排空后调用；多个模型共用的适配器只关闭一次
+// 适配器实现 io.Closer 时才会关闭
+//
+// 返回：
+//   - error: 各适配器关闭时的错误（合并返回）
+func (m *AdapterManager) Close() error {
+	var errs []error
+	for _, adapter := range m.Adapters() {
+		if err := closeAdapter(adapter); err != nil {
+			errs = append(errs, err)
 		}
 	}
+	return errors.Join(errs...)
+}
 
+// closeAdapter 关闭实现了 io.Closer 的适配器
+func closeAdapter(adapter ModelAdapter) error {
+	closer, ok := adapter.(io.Closer)
+	if !ok {
+		return nil
+	}
+	if err := closer.Close(); err != nil {
+		return fmt.Errorf("close adapter %s: %w", adapter.Name(), err)
+	}
 	return nil
 }
@@ -0,0 +1,139 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/metrics"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// stubAdapter 测试用适配器：返回固定的响应和数据块，记录是否被关闭
+type stubAdapter struct {
+	name   string
+	resp   *model.ChatResponse
+	chunks []*model.StreamResponse
+	err    error
+	closed int
+}
+
+func (a *stubAdapter) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	return a.resp, nil
+}
+
+func (a *stubAdapter) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	ch := make(chan *model.StreamResponse, len(a.chunks))
+	for _, chunk := range a.chunks {
+		ch <- chunk
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (a *stubAdapter) Name() string { return a.name }
+
+func (a *stubAdapter) HealthCheck(ctx context.Context) error { return nil }
+
+func (a *stubAdapter) Close() error {
+	a.closed++
+	return nil
+}
+
+func TestReloadClosesReplacedAdapters(t *testing.T) {
+	kept := &stubAdapter{name: "kept"}
+	replaced := &stubAdapter{name: "replaced"}
+	added := &stubAdapter{name: "added"}
+
+	m := NewAdapterManager()
+	if err := m.Reload(map[string]ModelAdapter{"a": kept, "b": replaced, "c": replaced}); err != nil {
+		t.Fatalf("initial reload: %v", err)
+	}
+	if err := m.Reload(map[string]ModelAdapter{"a": kept, "b": added}); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	if kept.closed != 0 {
+		t.Errorf("adapter still in use was closed %d times", kept.closed)
+	}
+	if replaced.closed != 1 {
+		t.Errorf("replaced adapter closed %d times, want 1", replaced.closed)
+	}
+	if added.closed != 0 {
+		t.Errorf("new adapter was closed %d times", added.closed)
+	}
+
+	if _, err := m.GetAdapter("c"); !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("GetAdapter(c) error = %v, want ErrModelNotFound", err)
+	}
+	if got, _ := m.GetAdapter("b"); got != added {
+		t.Errorf("GetAdapter(b) = %v, want the reloaded adapter", got)
+	}
+}
+
+func TestReloadRejectsInvalidRegistry(t *testing.T) {
+	old := &stubAdapter{name: "old"}
+	m := NewAdapterManager()
+	if err := m.Reload(map[string]ModelAdapter{"a": old}); err != nil {
+		t.Fatalf("initial reload: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		adapters map[string]ModelAdapter
+	}{
+		{"empty", map[string]ModelAdapter{}},
+		{"empty model name", map[string]ModelAdapter{"": &stubAdapter{name: "x"}}},
+		{"nil adapter", map[string]ModelAdapter{"a": nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := m.Reload(tt.adapters); err == nil {
+				t.Fatal("expected error")
+			}
+			if got, _ := m.GetAdapter("a"); got != old {
+				t.Errorf("registry replaced after rejected reload")
+			}
+			if old.closed != 0 {
+				t.Errorf("current adapter closed after rejected reload")
+			}
+		})
+	}
+}
+
+func TestRetryMetric(t *testing.T) {
+	m := NewAdapterManager()
+	if err := m.Reload(map[string]ModelAdapter{"retry-model": &stubAdapter{name: "retry-adapter", resp: &model.ChatResponse{}}}); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	retries := metrics.RetriesTotal.WithLabelValues("retry-model", "retry-adapter")
+	before := testutil.ToFloat64(retries)
+
+	req := &model.ChatRequest{Model: "retry-model"}
+	if _, err := m.Chat(context.Background(), req); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if got := testutil.ToFloat64(retries) - before; got != 0 {
+		t.Errorf("first call counted %v retries, want 0", got)
+	}
+
+	if _, err := m.Chat(WithRetry(context.Background()), req); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	stream, err := m.ChatStream(WithRetry(context.Background()), req)
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	for range stream {
+	}
+	if got := testutil.ToFloat64(retries) - before; got != 2 {
+		t.Errorf("retries = %v, want 2", got)
+	}
+}
@@ -0,0 +1,259 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/adapter/upstream"
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// OpenAI API 默认配置
+const (
+	// DefaultBaseURL OpenAI API 默认地址（不含具体接口路径）
+	DefaultBaseURL = "https://api.openai.com/v1"
+
+	// DefaultTimeout 默认超时时间
+	DefaultTimeout = 30 * time.Second
+
+	// OpenAIName 适配器默认名称
+	OpenAIName = "openai"
+)
+
+// OpenAIAdapter OpenAI兼容适配器
+// 实现 ModelAdapter 和 EmbeddingAdapter 接口
+// 适用于OpenAI以及所有提供OpenAI兼容接口的供应商（DeepSeek、Moonshot、vLLM、Ollama等）
+type OpenAIAdapter struct {
+	// name 适配器名称（配置中的适配器名，用于日志和指标）
+	name string
+
+	// apiKey API密钥
+	apiKey string
+
+	// baseURL API基础URL，如 https://api.openai.com/v1
+	baseURL string
+
+	// client 上游HTTP客户端（每个适配器独立的连接池，Close 时关闭空闲连接）
+	client *upstream.Client
+}
+
+// NewOpenAIAdapter 创建OpenAI兼容适配器
+// 参数：
+//   - name: 适配器名称（为空则使用 OpenAIName）
+//   - apiKey: API密钥
+//   - baseURL: API基础URL（为空则使用 DefaultBaseURL），不含 /chat/completions 等路径
+//   - timeout: 超时时间（为0则使用默认值）
+func NewOpenAIAdapter(name, apiKey, baseURL string, timeout time.Duration) *OpenAIAdapter {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	return NewOpenAIAdapterWithClient(name, apiKey, baseURL, upstream.NewDefaultClient(timeout))
+}
+
+// NewOpenAIAdapterWithClient 使用指定的上游客户端创建OpenAI兼容适配器
+// 参数：
+//   - name: 适配器名称（为空则使用 OpenAIName）
+//   - apiKey: API密钥
+//   - baseURL: API基础URL（为空则使用 DefaultBaseURL），不含 /chat/completions 等路径
+//   - client: 上游HTTP客户端（连接池、代理、超时等由其决定）
+func NewOpenAIAdapterWithClient(name, apiKey, baseURL string, client *upstream.Client) *OpenAIAdapter {
+	if name == "" {
+		name = OpenAIName
+	}
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &OpenAIAdapter{
+		name:    name,
+		apiKey:  apiKey,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}
+}
+
+// Name 返回适配器名称
+// 实现 ModelAdapter 接口
+func (a *OpenAIAdapter) Name() string {
+	return a.name
+}
+
+// Chat 非流式聊天接口
+// 实现 ModelAdapter 接口
+func (a *OpenAIAdapter) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
+	var chatResp model.ChatResponse
+	if err := a.post(ctx, "/chat/completions", req, &chatResp); err != nil {
+		return nil, err
+	}
+	return &chatResp, nil
+}
+
+// ChatStream 流式聊天接口
+// 实现 ModelAdapter 接口
+func (a *OpenAIAdapter) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
+	// 1. 强制启用流式模式并构造请求
+	// 总是要求上游返回用量数据块，是否转发给客户端由网关决定
+	upstreamReq := *req
+	upstreamReq.Stream = true
+	upstreamReq.StreamOptions = &model.StreamOptions{IncludeUsage: true}
+	httpReq, err := a.newRequest(ctx, http.MethodPost, "/chat/completions", &upstreamReq)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	// 2. 发送请求并检查HTTP状态码（不设总超时，数据块之间的空闲超时由客户端控制）
+	httpResp, err := a.client.DoStream(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("http request failed: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(httpResp.Body)
+		httpResp.Body.Close()
+		return nil, &upstream.StatusError{Provider: a.name, StatusCode: httpResp.StatusCode, Body: string(body)}
+	}
+
+	// 3. 逐行读取SSE数据，解析后发送到channel
+	streamChan := make(chan *model.StreamResponse, 10)
+	go func() {
+		defer httpResp.Body.Close()
+		defer close(streamChan)
+
+		scanner := bufio.NewScanner(httpResp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				return
+			}
+
+			var streamResp model.StreamResponse
+			if err := json.Unmarshal([]byte(data), &streamResp); err != nil {
+				continue
+			}
+
+			select {
+			case streamChan <- &streamResp:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		// 读取失败（调用方取消除外）时发送错误数据块
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			select {
+			case streamChan <- model.NewStreamErrorResponse(fmt.Errorf("read stream failed: %w", err)):
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return streamChan, nil
+}
+
+// Embed 向量化接口
+// 实现 EmbeddingAdapter 接口
+// 上游统一请求浮点数格式，base64由网关统一转换
+func (a *OpenAIAdapter) Embed(ctx context.Context, req *model.EmbeddingRequest) (*model.EmbeddingResponse, error) {
+	upstreamReq := *req
+	upstreamReq.EncodingFormat = model.EncodingFormatFloat
+
+	var embResp model.EmbeddingResponse
+	if err := a.post(ctx, "/embeddings", &upstreamReq, &embResp); err != nil {
+		return nil, err
+	}
+	return &embResp, nil
+}
+
+// HealthCheck 健康检查
+// 实现 ModelAdapter 接口
+// 请求 GET /models，不消耗token；429只是限流，上游仍然可用
+func (a *OpenAIAdapter) HealthCheck(ctx context.Context) error {
+	httpReq, err := a.newRequest(ctx, http.MethodGet, "/models", nil)
+	if err != nil {
+		return err
+	}
+
+	httpResp, err := a.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+	io.Copy(io.Discard, httpResp.Body)
+
+	switch httpResp.StatusCode {
+	case http.StatusOK, http.StatusTooManyRequests:
+		return nil
+	default:
+		return fmt.Errorf("health check failed: status %d", httpResp.StatusCode)
+	}
+}
+
+// Close 关闭空闲的上游连接
+// 网关退出前调用；进行中的请求不受影响
+func (a *OpenAIAdapter) Close() error {
+	return a.client.Close()
+}
+
+// post 发送JSON请求并解析JSON响应
+func (a *OpenAIAdapter) post(ctx context.Context, path string, body, out any) error {
+	httpReq, err := a.newRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return err
+	}
+
+	httpResp, err := a.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("http request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("read response failed: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return &upstream.StatusError{Provider: a.name, StatusCode: httpResp.StatusCode, Body: string(respBody)}
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response failed: %w", err)
+	}
+	return nil
+}
+
+// newRequest 创建带鉴权头的HTTP请求
+// body为nil时不发送请求体
+func (a *OpenAIAdapter) newRequest(ctx context.Context, method, path string, body any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reqBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("marshal request failed: %w", err)
+		}
+		reader = bytes.NewReader(reqBody)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+
+	if a.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+a.apiKey)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	return httpReq, nil
+}
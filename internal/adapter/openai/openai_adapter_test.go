@@ -0,0 +1,224 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// upstreamRequest 测试服务器收到的请求
+type upstreamRequest struct {
+	method string
+	path   string
+	header http.Header
+	body   map[string]any
+}
+
+// newUpstream 创建记录请求并按路径返回固定响应的测试服务器
+func newUpstream(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) (*httptest.Server, *[]upstreamRequest) {
+	t.Helper()
+
+	var requests []upstreamRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := upstreamRequest{method: r.Method, path: r.URL.Path, header: r.Header.Clone()}
+		if r.ContentLength != 0 {
+			json.NewDecoder(r.Body).Decode(&req.body)
+		}
+		requests = append(requests, req)
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestNewOpenAIAdapter(t *testing.T) {
+	a := NewOpenAIAdapter("", "", "", 0)
+	if a.Name() != OpenAIName || a.baseURL != DefaultBaseURL {
+		t.Errorf("defaults: name = %q, baseURL = %q", a.Name(), a.baseURL)
+	}
+
+	a = NewOpenAIAdapter("deepseek", "sk-test", "https://api.deepseek.com/v1/", 0)
+	if a.Name() != "deepseek" || a.baseURL != "https://api.deepseek.com/v1" {
+		t.Errorf("name = %q, baseURL = %q", a.Name(), a.baseURL)
+	}
+}
+
+func TestChat(t *testing.T) {
+	srv, requests := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o-2024-08-06",
+			"choices":[{"index":0,"message":{"role":"assistant","content":"你好"},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`))
+	})
+
+	a := NewOpenAIAdapter("my-openai", "sk-test", srv.URL+"/v1", 0)
+	resp, err := a.Chat(context.Background(), &model.ChatRequest{
+		Model:    "gpt-4o",
+		Messages: []model.Message{{Role: "user", Content: model.NewTextContent("hi")}},
+	})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.GetContent() != "你好" || resp.Model != "gpt-4o-2024-08-06" || resp.Usage.TotalTokens != 7 {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	got := (*requests)[0]
+	if got.method != http.MethodPost || got.path != "/v1/chat/completions" {
+		t.Errorf("request = %s %s", got.method, got.path)
+	}
+	if got.header.Get("Authorization") != "Bearer sk-test" || got.header.Get("Content-Type") != "application/json" {
+		t.Errorf("headers = %v", got.header)
+	}
+	if got.body["model"] != "gpt-4o" {
+		t.Errorf("body = %v", got.body)
+	}
+}
+
+func TestChatErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"upstream error", http.StatusUnauthorized, `{"error":{"message":"bad key"}}`, "my-openai API error (status 401)"},
+		{"server error", http.StatusInternalServerError, `oops`, "my-openai API error (status 500): oops"},
+		{"invalid json", http.StatusOK, `{"id":`, "unmarshal response failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := NewOpenAIAdapter("my-openai", "sk-test", srv.URL, 0).Chat(context.Background(), &model.ChatRequest{Model: "gpt-4o"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChatStream(t *testing.T) {
+	srv, requests := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, content := range []string{"你", "好"} {
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-1\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", content)
+		}
+		// 非 data 行和无法解析的数据被忽略
+		w.Write([]byte(": keep-alive\n\ndata: not-json\n\n"))
+		w.Write([]byte("data: {\"id\":\"chatcmpl-1\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	})
+
+	a := NewOpenAIAdapter("my-openai", "sk-test", srv.URL, 0)
+	ch, err := a.ChatStream(context.Background(), &model.ChatRequest{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	acc := model.NewStreamAccumulator()
+	chunks := 0
+	for chunk := range ch {
+		acc.Add(chunk)
+		chunks++
+	}
+	if chunks != 3 {
+		t.Errorf("chunks = %d, want 3", chunks)
+	}
+	resp := acc.Response()
+	if resp.GetContent() != "你好" || resp.Choices[0].FinishReason != "stop" {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	got := (*requests)[0]
+	if got.path != "/chat/completions" || got.header.Get("Accept") != "text/event-stream" || got.body["stream"] != true {
+		t.Errorf("request = %s %v %v", got.path, got.header, got.body)
+	}
+}
+
+func TestChatStreamStatusError(t *testing.T) {
+	srv, _ := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"slow down"}}`))
+	})
+
+	_, err := NewOpenAIAdapter("my-openai", "sk-test", srv.URL, 0).ChatStream(context.Background(), &model.ChatRequest{Model: "gpt-4o"})
+	if err == nil || !strings.Contains(err.Error(), "status 429") || !strings.Contains(err.Error(), "slow down") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	srv, requests := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"object":"list","model":"text-embedding-3-small",
+			"data":[{"object":"embedding","index":0,"embedding":[0.1,0.2]},{"object":"embedding","index":1,"embedding":[0.3,0.4]}],
+			"usage":{"prompt_tokens":4,"total_tokens":4}}`))
+	})
+
+	a := NewOpenAIAdapter("my-openai", "sk-test", srv.URL, 0)
+	resp, err := a.Embed(context.Background(), &model.EmbeddingRequest{
+		Model:          "text-embedding-3-small",
+		Input:          model.EmbeddingInput{"a", "b"},
+		EncodingFormat: model.EncodingFormatBase64,
+	})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(resp.Data) != 2 || len(resp.Data[1].Embedding.Float) != 2 || resp.Usage.PromptTokens != 4 {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	// 上游总是请求浮点数格式，base64由网关转换
+	got := (*requests)[0]
+	if got.path != "/embeddings" || got.body["encoding_format"] != model.EncodingFormatFloat {
+		t.Errorf("request = %s %v", got.path, got.body)
+	}
+	if input, _ := got.body["input"].([]any); len(input) != 2 {
+		t.Errorf("input = %v", got.body["input"])
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	tests := []struct {
+		status  int
+		healthy bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusUnauthorized, false},
+		{http.StatusNotFound, false},
+		{http.StatusServiceUnavailable, false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv, requests := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+
+			err := NewOpenAIAdapter("my-openai", "sk-test", srv.URL, 0).HealthCheck(context.Background())
+			if (err == nil) != tt.healthy {
+				t.Errorf("HealthCheck() error = %v, want healthy=%v", err, tt.healthy)
+			}
+			got := (*requests)[0]
+			if got.method != http.MethodGet || got.path != "/models" || got.header.Get("Authorization") != "Bearer sk-test" {
+				t.Errorf("request = %s %s %v", got.method, got.path, got.header)
+			}
+		})
+	}
+
+	// 未配置API Key时不发送鉴权头
+	srv, requests := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {})
+	if err := NewOpenAIAdapter("ollama", "", srv.URL, 0).HealthCheck(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := (*requests)[0].header["Authorization"]; ok {
+		t.Error("Authorization header sent without API key")
+	}
+}
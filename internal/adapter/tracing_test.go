@@ -0,0 +1,199 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/tracing"
+	"github.com/AtSunset1/prism/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+// setupTracing 注册使用内存导出器的TracerProvider，测试结束后恢复
+func setupTracing(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := tracing.NewTracerProvider(
+		config.TracingConfig{ServiceName: "prism-test", SampleRatio: 1},
+		sdktrace.WithSyncer(exporter),
+	)
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		tp.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+// findSpan 按名称查找已结束的span
+func findSpan(t *testing.T, exporter *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range exporter.GetSpans() {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("span %q not found (have %v)", name, spanNames(exporter))
+	return tracetest.SpanStub{}
+}
+
+func spanNames(exporter *tracetest.InMemoryExporter) []string {
+	var names []string
+	for _, span := range exporter.GetSpans() {
+		names = append(names, span.Name)
+	}
+	return names
+}
+
+// checkAttributes 检查span包含期望的属性
+func checkAttributes(t *testing.T, span tracetest.SpanStub, want ...attribute.KeyValue) {
+	t.Helper()
+	got := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		got[kv.Key] = kv.Value
+	}
+	for _, kv := range want {
+		v, ok := got[kv.Key]
+		if !ok {
+			t.Errorf("span %q missing attribute %s", span.Name, kv.Key)
+			continue
+		}
+		if v.Emit() != kv.Value.Emit() {
+			t.Errorf("span %q attribute %s = %s, want %s", span.Name, kv.Key, v.Emit(), kv.Value.Emit())
+		}
+	}
+}
+
+func TestChatSpans(t *testing.T) {
+	exporter := setupTracing(t)
+
+	stub := &stubAdapter{
+		name: "glm",
+		resp: &model.ChatResponse{
+			ID:    "chatcmpl-1",
+			Model: "glm-4-0520",
+			Choices: []model.Choice{
+				{Index: 0, Message: &model.Message{Role: "assistant", Content: model.NewTextContent("hi")}, FinishReason: "stop"},
+			},
+			Usage: model.Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15},
+		},
+	}
+	m := NewAdapterManager()
+	if err := m.Reload(map[string]ModelAdapter{"glm-4": stub}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Chat(context.Background(), &model.ChatRequest{Model: "glm-4"}); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	route := findSpan(t, exporter, "prism.route")
+	checkAttributes(t, route,
+		semconv.GenAIRequestModel("glm-4"),
+		tracing.ProviderAttribute("glm"),
+	)
+
+	chat := findSpan(t, exporter, "chat glm-4")
+	checkAttributes(t, chat,
+		semconv.GenAIOperationNameChat,
+		semconv.GenAIRequestModel("glm-4"),
+		tracing.ProviderAttribute("glm"),
+		semconv.GenAIResponseID("chatcmpl-1"),
+		semconv.GenAIResponseModel("glm-4-0520"),
+		semconv.GenAIResponseFinishReasons("stop"),
+		semconv.GenAIUsageInputTokens(12),
+		semconv.GenAIUsageOutputTokens(3),
+	)
+	if chat.SpanKind.String() != "client" {
+		t.Errorf("chat span kind = %s, want client", chat.SpanKind)
+	}
+}
+
+func TestChatStreamSpan(t *testing.T) {
+	exporter := setupTracing(t)
+
+	stop := "length"
+	stub := &stubAdapter{
+		name: "openai",
+		chunks: []*model.StreamResponse{
+			model.NewStreamResponse("chatcmpl-2", "gpt-4o-2024", "", true),
+			model.NewStreamResponse("chatcmpl-2", "gpt-4o-2024", "Hello", false),
+			{ID: "chatcmpl-2", Model: "gpt-4o-2024", Choices: []model.StreamChoice{{FinishReason: &stop}}},
+			model.NewStreamUsageResponse("chatcmpl-2", "gpt-4o-2024", 0, model.Usage{PromptTokens: 7, CompletionTokens: 1, TotalTokens: 8}),
+		},
+	}
+	m := NewAdapterManager()
+	if err := m.Reload(map[string]ModelAdapter{"gpt-4o": stub}); err != nil {
+		t.Fatal(err)
+	}
+
+	ch, err := m.ChatStream(context.Background(), &model.ChatRequest{Model: "gpt-4o", Stream: true})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	for range ch {
+	}
+
+	// 上游span在流结束时才结束
+	span := findSpan(t, exporter, "chat gpt-4o")
+	checkAttributes(t, span,
+		semconv.GenAIRequestModel("gpt-4o"),
+		tracing.ProviderAttribute("openai"),
+		semconv.GenAIResponseID("chatcmpl-2"),
+		semconv.GenAIResponseModel("gpt-4o-2024"),
+		semconv.GenAIResponseFinishReasons("length"),
+		semconv.GenAIUsageInputTokens(7),
+		semconv.GenAIUsageOutputTokens(1),
+	)
+
+	var firstToken bool
+	for _, event := range span.Events {
+		if event.Name == "first_token" {
+			firstToken = true
+		}
+	}
+	if !firstToken {
+		t.Error("stream span has no first_token event")
+	}
+	if span.Status.Code != codes.Unset {
+		t.Errorf("stream span status = %v, want unset", span.Status.Code)
+	}
+}
+
+func TestChatSpanRecordsError(t *testing.T) {
+	exporter := setupTracing(t)
+
+	stub := &stubAdapter{name: "glm", err: context.DeadlineExceeded}
+	m := NewAdapterManager()
+	if err := m.Reload(map[string]ModelAdapter{"glm-4": stub}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Chat(context.Background(), &model.ChatRequest{Model: "glm-4"}); err == nil {
+		t.Fatal("expected error")
+	}
+	span := findSpan(t, exporter, "chat glm-4")
+	if span.Status.Code != codes.Error {
+		t.Errorf("span status = %v, want error", span.Status.Code)
+	}
+
+	// 未注册的模型只记录 route span
+	exporter.Reset()
+	if _, err := m.Chat(context.Background(), &model.ChatRequest{Model: "missing"}); err == nil {
+		t.Fatal("expected error")
+	}
+	route := findSpan(t, exporter, "prism.route")
+	if route.Status.Code != codes.Error {
+		t.Errorf("route span status = %v, want error", route.Status.Code)
+	}
+	if len(exporter.GetSpans()) != 1 {
+		t.Errorf("spans = %v, want only prism.route", spanNames(exporter))
+	}
+}
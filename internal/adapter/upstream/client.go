@@ -0,0 +1,296 @@
+// Package upstream 适配器共用的上游HTTP客户端
+// 按适配器配置构建独立的连接池（代理、自定义CA、各阶段超时），
+// 非流式请求受总超时约束，流式请求只受上下文和数据块空闲超时约束
+package upstream
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/AtSunset1/prism/pkg/config"
+	"github.com/AtSunset1/prism/pkg/requestid"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// 默认配置
+const (
+	// DefaultTimeout 非流式请求的默认总超时
+	DefaultTimeout = 30 * time.Second
+
+	// DefaultStreamIdleTimeout 流式响应两个数据块之间的默认最长间隔
+	DefaultStreamIdleTimeout = 60 * time.Second
+)
+
+// ErrStreamIdle 流式响应超过空闲超时仍未收到新数据
+var ErrStreamIdle = errors.New("upstream stream idle timeout")
+
+// StatusError 上游返回了非200状态码
+// 调用方可以按状态码区分限流、上游故障和请求本身的错误（如批处理决定是否重试）
+type StatusError struct {
+	// Provider 上游名称（错误消息前缀，如 GLM、适配器名称）
+	Provider string
+
+	// StatusCode HTTP状态码
+	StatusCode int
+
+	// Body 响应体（上游的错误详情）
+	Body string
+}
+
+// Error 实现 error 接口
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s API error (status %d): %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Retryable 限流（429）、上游超时（408）和服务端错误（5xx）可以重试，其他4xx是请求本身的问题
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusRequestTimeout || e.StatusCode >= 500
+}
+
+// Client 上游HTTP客户端
+// 每个适配器独立持有一个，连接池互不影响
+type Client struct {
+	// http 底层客户端（不设置总超时，由上下文控制）
+	// Transport经过otelhttp包装：为每次HTTP调用创建span，并注入traceparent请求头
+	http *http.Client
+
+	// transport 底层连接池（Close 时关闭空闲连接）
+	transport *http.Transport
+
+	// timeout 非流式请求的总超时（含读取响应体）
+	timeout time.Duration
+
+	// streamIdleTimeout 流式响应的数据块空闲超时
+	streamIdleTimeout time.Duration
+}
+
+// NewClient 按适配器配置创建上游客户端
+// 参数：
+//   - timeout: 非流式请求总超时（为0则使用 DefaultTimeout）
+//   - streamIdleTimeout: 流式数据块空闲超时（为0则使用 DefaultStreamIdleTimeout）
+//   - cfg: 连接池配置（代理、CA证书、各阶段超时）
+// 返回：
+//   - *Client: 客户端实例
+//   - error: 代理地址无效或CA证书加载失败
+func NewClient(timeout, streamIdleTimeout time.Duration, cfg config.TransportConfig) (*Client, error) {
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if streamIdleTimeout <= 0 {
+		streamIdleTimeout = DefaultStreamIdleTimeout
+	}
+
+	return &Client{
+		http:              &http.Client{Transport: otelhttp.NewTransport(transport)},
+		transport:         transport,
+		timeout:           timeout,
+		streamIdleTimeout: streamIdleTimeout,
+	}, nil
+}
+
+// NewDefaultClient 使用默认连接池配置创建上游客户端
+// 默认配置不读取任何文件，不会失败
+func NewDefaultClient(timeout time.Duration) *Client {
+	client, _ := NewClient(timeout, 0, config.TransportConfig{})
+	return client
+}
+
+// NewTransport 按配置构建连接池
+// 以 http.DefaultTransport 为基础，只覆盖配置了的字段
+// 参数：
+//   - cfg: 连接池配置
+// 返回：
+//   - *http.Transport: 连接池
+//   - error: 代理地址无效或CA证书加载失败
+func NewTransport(cfg config.TransportConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	// 1. 连接数与空闲连接
+	if cfg.MaxIdleConns > 0 {
+		transport.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = cfg.MaxConnsPerHost
+	}
+	if cfg.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+
+	// 2. 各阶段超时
+	if cfg.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+	}
+	if cfg.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+	}
+	transport.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+
+	// 3. 代理（http、https、socks5，为空时沿用环境变量 HTTPS_PROXY 等）
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %q: %w", cfg.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	// 4. 自定义CA（追加到系统根证书之后）
+	if len(cfg.CAFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for _, file := range cfg.CAFiles {
+			pem, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("read CA file failed: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA file %s", file)
+			}
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	// 5. HTTP/2（关闭后只使用HTTP/1.1）
+	if cfg.DisableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return transport, nil
+}
+
+// Do 发送非流式请求
+// 请求和读取响应体总计不超过 timeout，关闭响应体后释放超时上下文
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), c.timeout)
+	resp, err := c.send(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// DoStream 发送流式请求
+// 不设总超时：等待响应头以及之后任意两个数据块之间超过 streamIdleTimeout 时取消请求，
+// 此后读取响应体返回 ErrStreamIdle
+func (c *Client) DoStream(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	body := &idleBody{cancel: cancel, timeout: c.streamIdleTimeout}
+	body.timer = time.AfterFunc(c.streamIdleTimeout, body.expire)
+
+	resp, err := c.send(req.WithContext(ctx))
+	if err != nil {
+		body.stop()
+		if body.isExpired() {
+			return nil, ErrStreamIdle
+		}
+		return nil, err
+	}
+	body.ReadCloser = resp.Body
+	resp.Body = body
+	return resp, nil
+}
+
+// send 发送请求
+// 带上网关的请求ID（X-Request-ID），便于与上游日志对照；记录上游返回的请求ID
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if id := requestid.FromContext(req.Context()); id != "" && req.Header.Get(requestid.Header) == "" {
+		req.Header.Set(requestid.Header, id)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	record(req.Context(), resp)
+	return resp, nil
+}
+
+// Close 关闭空闲的上游连接
+// 网关退出或适配器被替换时调用；进行中的请求不受影响
+func (c *Client) Close() error {
+	c.transport.CloseIdleConnections()
+	return nil
+}
+
+// cancelBody 关闭响应体时释放请求上下文
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// idleBody 每读到数据就重置空闲计时器的响应体
+type idleBody struct {
+	io.ReadCloser
+	cancel  context.CancelFunc
+	timeout time.Duration
+	timer   *time.Timer
+
+	mu      sync.Mutex
+	expired bool
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && b.isExpired() {
+		return n, ErrStreamIdle
+	}
+	if n > 0 {
+		b.timer.Reset(b.timeout)
+	}
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.stop()
+	return err
+}
+
+// expire 空闲超时：取消请求，阻塞中的 Read 随即返回
+func (b *idleBody) expire() {
+	b.mu.Lock()
+	b.expired = true
+	b.mu.Unlock()
+	b.cancel()
+}
+
+func (b *idleBody) isExpired() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.expired
+}
+
+// stop 停止计时并释放请求上下文
+func (b *idleBody) stop() {
+	b.timer.Stop()
+	b.cancel()
+}
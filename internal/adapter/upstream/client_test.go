@@ -0,0 +1,223 @@
+package upstream
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// writeCA 将测试TLS服务器的证书写入PEM文件，返回路径
+func writeCA(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewTransport(t *testing.T) {
+	transport, err := NewTransport(config.TransportConfig{
+		MaxIdleConns:          50,
+		MaxIdleConnsPerHost:   20,
+		MaxConnsPerHost:       30,
+		IdleConnTimeout:       time.Minute,
+		TLSHandshakeTimeout:   3 * time.Second,
+		ResponseHeaderTimeout: 7 * time.Second,
+		DisableHTTP2:          true,
+		Proxy:                 "socks5://127.0.0.1:1080",
+	})
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+
+	if transport.MaxIdleConns != 50 || transport.MaxIdleConnsPerHost != 20 || transport.MaxConnsPerHost != 30 {
+		t.Errorf("conns = %d/%d/%d, want 50/20/30", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost)
+	}
+	if transport.IdleConnTimeout != time.Minute || transport.TLSHandshakeTimeout != 3*time.Second || transport.ResponseHeaderTimeout != 7*time.Second {
+		t.Errorf("timeouts = %v/%v/%v", transport.IdleConnTimeout, transport.TLSHandshakeTimeout, transport.ResponseHeaderTimeout)
+	}
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Error("HTTP/2 not disabled")
+	}
+	proxy, err := transport.Proxy(httptest.NewRequest(http.MethodGet, "https://example.com", nil))
+	if err != nil || proxy == nil || proxy.String() != "socks5://127.0.0.1:1080" {
+		t.Errorf("proxy = %v, %v", proxy, err)
+	}
+
+	// 未配置的字段沿用默认值
+	defaults, err := NewTransport(config.TransportConfig{})
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+	base := http.DefaultTransport.(*http.Transport)
+	if defaults.MaxIdleConns != base.MaxIdleConns || !defaults.ForceAttemptHTTP2 {
+		t.Error("empty config changed default transport settings")
+	}
+}
+
+func TestNewTransportErrors(t *testing.T) {
+	dir := t.TempDir()
+	invalidCA := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalidCA, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.TransportConfig
+	}{
+		{"invalid proxy", config.TransportConfig{Proxy: "://bad"}},
+		{"missing ca file", config.TransportConfig{CAFiles: []string{filepath.Join(dir, "missing.pem")}}},
+		{"ca file without certificates", config.TransportConfig{CAFiles: []string{invalidCA}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTransport(tt.cfg); err == nil {
+				t.Error("NewTransport succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestClientCustomCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	// 不信任自签名证书时失败
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if resp, err := NewDefaultClient(time.Second).Do(req); err == nil {
+		resp.Body.Close()
+		t.Fatal("request to self-signed server succeeded without ca_files")
+	}
+
+	client, err := NewClient(time.Second, 0, config.TransportConfig{CAFiles: []string{writeCA(t, srv)}})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer client.Close()
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request with ca_files failed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+// chunkServer 每隔 interval 发送一个数据块，共 chunks 个
+func chunkServer(interval time.Duration, chunks int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < chunks; i++ {
+			if i > 0 {
+				select {
+				case <-time.After(interval):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			io.WriteString(w, "data: x\n\n")
+			w.(http.Flusher).Flush()
+		}
+	}))
+}
+
+func TestDoTimeout(t *testing.T) {
+	srv := chunkServer(200*time.Millisecond, 2)
+	defer srv.Close()
+
+	client := NewDefaultClient(100 * time.Millisecond)
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 总超时包括读取响应体
+	if _, err := io.ReadAll(resp.Body); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("read error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestDoStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		wantErr  error
+	}{
+		// 数据块间隔小于空闲超时：总时长超过 timeout 也不中断
+		{"steady stream outlives total timeout", 50 * time.Millisecond, nil},
+		{"idle stream is cut", 300 * time.Millisecond, ErrStreamIdle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := chunkServer(tt.interval, 5)
+			defer srv.Close()
+
+			client, err := NewClient(100*time.Millisecond, 150*time.Millisecond, config.TransportConfig{})
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			resp, err := client.DoStream(req)
+			if err != nil {
+				t.Fatalf("DoStream: %v", err)
+			}
+			defer resp.Body.Close()
+
+			_, err = io.ReadAll(resp.Body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("read error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDoStreamIdleBeforeHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(time.Second, 100*time.Millisecond, config.TransportConfig{})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if _, err := client.DoStream(req); !errors.Is(err, ErrStreamIdle) {
+		t.Errorf("DoStream error = %v, want ErrStreamIdle", err)
+	}
+}
+
+func TestStatusErrorRetryable(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusTooManyRequests, true},
+		{http.StatusRequestTimeout, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusNotFound, false},
+	}
+	for _, tt := range tests {
+		err := &StatusError{Provider: "test", StatusCode: tt.status}
+		if got := err.Retryable(); got != tt.want {
+			t.Errorf("Retryable() for %d = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package upstream
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/AtSunset1/prism/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// upstreamRequestIDHeaders 上游返回请求ID的响应头（按顺序取第一个非空值）
+var upstreamRequestIDHeaders = []string{"X-Request-Id", "Request-Id", "X-Log-Id"}
+
+// metaKey context中存放 Meta 的key
+type metaKey struct{}
+
+// Meta 一次网关请求中上游调用的元数据
+// handler 在调用适配器前放入context，管理器和上游客户端陆续填充，
+// 调用返回后由 handler 写入响应头；同一请求多次调用上游（如结构化输出重试）时保留最后一次
+type Meta struct {
+	mu        sync.Mutex
+	adapter   string
+	requestID string
+}
+
+// NewContext 在context中创建空的 Meta
+// 返回：携带 Meta 的context，以及 Meta 本身
+func NewContext(ctx context.Context) (context.Context, *Meta) {
+	meta := &Meta{}
+	return context.WithValue(ctx, metaKey{}, meta), meta
+}
+
+// MetaFromContext 获取context中的 Meta
+// 不存在时返回nil（nil的 Meta 可以安全调用所有方法）
+func MetaFromContext(ctx context.Context) *Meta {
+	meta, _ := ctx.Value(metaKey{}).(*Meta)
+	return meta
+}
+
+// SetAdapter 记录处理请求的适配器名称
+func (m *Meta) SetAdapter(name string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.adapter = name
+}
+
+// Adapter 返回处理请求的适配器名称（未调用上游时为空）
+func (m *Meta) Adapter() string {
+	if m == nil {
+		return ""
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.adapter
+}
+
+// RequestID 返回上游响应中的请求ID（上游未返回时为空）
+func (m *Meta) RequestID() string {
+	if m == nil {
+		return ""
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.requestID
+}
+
+// record 从上游响应头中记录上游请求ID，并补充到请求级日志
+func record(ctx context.Context, resp *http.Response) {
+	var id string
+	for _, h := range upstreamRequestIDHeaders {
+		if id = resp.Header.Get(h); id != "" {
+			break
+		}
+	}
+	if id == "" {
+		return
+	}
+
+	logger.AddFields(ctx, zap.String("upstream_request_id", id))
+	if m := MetaFromContext(ctx); m != nil {
+		m.mu.Lock()
+		m.requestID = id
+		m.mu.Unlock()
+	}
+}
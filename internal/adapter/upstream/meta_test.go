@@ -0,0 +1,72 @@
+package upstream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AtSunset1/prism/pkg/requestid"
+)
+
+func TestClientRequestID(t *testing.T) {
+	tests := []struct {
+		name           string
+		gatewayID      string
+		callerHeader   string
+		upstreamHeader string
+		wantSent       string
+		wantUpstream   string
+	}{
+		{"gateway id sent, x-request-id recorded", "gw-1", "", "X-Request-Id", "gw-1", "up-1"},
+		{"request-id header recorded", "gw-1", "", "Request-Id", "gw-1", "up-1"},
+		{"x-log-id header recorded", "gw-1", "", "X-Log-Id", "gw-1", "up-1"},
+		{"adapter header kept", "gw-1", "custom", "", "custom", ""},
+		{"no gateway id", "", "", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sent = r.Header.Get(requestid.Header)
+				if tt.upstreamHeader != "" {
+					w.Header().Set(tt.upstreamHeader, "up-1")
+				}
+			}))
+			defer srv.Close()
+
+			ctx, meta := NewContext(context.Background())
+			if tt.gatewayID != "" {
+				ctx = requestid.NewContext(ctx, tt.gatewayID)
+			}
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+			if tt.callerHeader != "" {
+				req.Header.Set(requestid.Header, tt.callerHeader)
+			}
+			resp, err := NewDefaultClient(time.Second).Do(req)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			resp.Body.Close()
+
+			if sent != tt.wantSent {
+				t.Errorf("upstream received %s %q, want %q", requestid.Header, sent, tt.wantSent)
+			}
+			if got := meta.RequestID(); got != tt.wantUpstream {
+				t.Errorf("meta.RequestID() = %q, want %q", got, tt.wantUpstream)
+			}
+		})
+	}
+}
+
+func TestNilMeta(t *testing.T) {
+	meta := MetaFromContext(context.Background())
+	if meta != nil {
+		t.Fatal("MetaFromContext returned a meta for a bare context")
+	}
+	meta.SetAdapter("glm")
+	if meta.Adapter() != "" || meta.RequestID() != "" {
+		t.Error("nil meta returned values")
+	}
+}
@@ -0,0 +1,300 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// ===== Anthropic格式与OpenAI格式的转换 =====
+// 两种格式的主要差异：
+//   - system 是请求的顶层字段，而不是一条消息
+//   - 工具调用是 assistant 消息中的 tool_use 内容块；工具结果是 user 消息中的 tool_result 内容块，
+//     OpenAI格式中分别对应 tool_calls 和独立的 tool 消息
+//   - 图片、文档以 source（base64 / url）描述，OpenAI格式中为 URL 或 data URL
+//   - tool_choice 的 any 对应 required，tool 对应指定函数
+
+// ToChatRequest 将 Messages API 请求转换为网关内部的聊天请求
+// 参数：
+//   - req: Messages API 请求
+//
+// 返回：
+//   - *model.ChatRequest: 聊天请求
+//   - error: 请求中包含无法转换的内容（如Anthropic内置工具），返回 *model.ParamError
+func ToChatRequest(req *MessagesRequest) (*model.ChatRequest, error) {
+	maxTokens := req.MaxTokens
+	chatReq := &model.ChatRequest{
+		Model:       req.Model,
+		MaxTokens:   &maxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stop:        req.StopSequences,
+		Stream:      req.Stream,
+	}
+	if req.Metadata != nil {
+		chatReq.User = req.Metadata.UserID
+	}
+
+	// 1. system 转换为第一条消息
+	if system := req.System.Text(); system != "" {
+		chatReq.Messages = append(chatReq.Messages, model.Message{
+			Role:    "system",
+			Content: model.NewTextContent(system),
+		})
+	}
+
+	// 2. 逐条转换消息
+	for i, msg := range req.Messages {
+		var (
+			messages []model.Message
+			err      error
+		)
+		if msg.Role == "assistant" {
+			messages, err = toAssistantMessages(msg.Content)
+		} else {
+			messages, err = toUserMessages(msg.Content)
+		}
+		if err != nil {
+			return nil, &model.ParamError{Param: "messages", Err: fmt.Errorf("messages[%d]: %w", i, err)}
+		}
+		chatReq.Messages = append(chatReq.Messages, messages...)
+	}
+
+	// 3. 工具定义和选择策略
+	for i, tool := range req.Tools {
+		if tool.Type != "" && tool.Type != "custom" {
+			return nil, &model.ParamError{Param: "tools", Err: fmt.Errorf("tools[%d]: tool type %q is not supported", i, tool.Type)}
+		}
+		chatReq.Tools = append(chatReq.Tools, model.Tool{
+			Type: model.ToolTypeFunction,
+			Function: model.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.InputSchema,
+			},
+		})
+	}
+	if tc := req.ToolChoice; tc != nil {
+		choice, err := toToolChoice(tc)
+		if err != nil {
+			return nil, &model.ParamError{Param: "tool_choice", Err: err}
+		}
+		chatReq.ToolChoice = choice
+		if tc.DisableParallelToolUse {
+			parallel := false
+			chatReq.ParallelToolCalls = &parallel
+		}
+	}
+
+	return chatReq, nil
+}
+
+// toUserMessages 转换 user 消息
+// tool_result 块各自转换为一条 tool 消息（排在最前面，紧跟上一条 assistant 的工具调用），
+// 其余内容块合并为一条 user 消息
+func toUserMessages(content Content) ([]model.Message, error) {
+	var (
+		messages []model.Message
+		parts    []model.ContentPart
+	)
+	for _, block := range content {
+		switch block.Type {
+		case BlockTypeToolResult:
+			messages = append(messages, model.Message{
+				Role:       "tool",
+				ToolCallID: block.ToolUseID,
+				Content:    model.NewTextContent(block.Content.Text()),
+			})
+		case BlockTypeText:
+			parts = append(parts, model.ContentPart{Type: model.PartTypeText, Text: block.Text})
+		case BlockTypeImage, BlockTypeDocument:
+			part, err := toMediaPart(block)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+		default:
+			return nil, fmt.Errorf("content block type %q is not supported in user messages", block.Type)
+		}
+	}
+
+	switch {
+	case len(parts) == 0:
+		// 只有工具结果
+	case len(parts) == 1 && parts[0].Type == model.PartTypeText:
+		// 纯文本以字符串发送，兼容不接受内容数组的上游
+		messages = append(messages, model.Message{Role: "user", Content: model.NewTextContent(parts[0].Text)})
+	default:
+		messages = append(messages, model.Message{Role: "user", Content: model.NewMultipartContent(parts)})
+	}
+	return messages, nil
+}
+
+// toAssistantMessages 转换 assistant 消息
+// 文本块拼接为消息内容，tool_use 块转换为 tool_calls，thinking 块丢弃
+func toAssistantMessages(content Content) ([]model.Message, error) {
+	msg := model.Message{Role: "assistant"}
+	var text strings.Builder
+	for _, block := range content {
+		switch block.Type {
+		case BlockTypeText:
+			text.WriteString(block.Text)
+		case BlockTypeToolUse:
+			args := string(block.Input)
+			if args == "" {
+				args = "{}"
+			}
+			msg.ToolCalls = append(msg.ToolCalls, model.ToolCall{
+				ID:       block.ID,
+				Type:     model.ToolTypeFunction,
+				Function: model.FunctionCall{Name: block.Name, Arguments: args},
+			})
+		case BlockTypeThinking:
+		default:
+			return nil, fmt.Errorf("content block type %q is not supported in assistant messages", block.Type)
+		}
+	}
+	msg.Content = model.NewTextContent(text.String())
+	return []model.Message{msg}, nil
+}
+
+// toMediaPart 将图片、文档内容块转换为内容片段
+//   - 图片：base64 转换为 data URL，url 原样使用
+//   - 文档：只支持 base64（作为文件片段透传给上游）
+func toMediaPart(block ContentBlock) (model.ContentPart, error) {
+	src := block.Source
+	if src == nil {
+		return model.ContentPart{}, fmt.Errorf("%s block requires source", block.Type)
+	}
+
+	var url string
+	switch src.Type {
+	case "base64":
+		url = "data:" + src.MediaType + ";base64," + src.Data
+	case "url":
+		url = src.URL
+	default:
+		return model.ContentPart{}, fmt.Errorf("%s source type %q is not supported", block.Type, src.Type)
+	}
+
+	if block.Type == BlockTypeImage {
+		return model.ContentPart{Type: model.PartTypeImageURL, ImageURL: &model.ImageURL{URL: url}}, nil
+	}
+	if src.Type != "base64" {
+		return model.ContentPart{}, fmt.Errorf("document source type %q is not supported", src.Type)
+	}
+	return model.ContentPart{Type: model.PartTypeFile, File: &model.FilePart{FileData: url}}, nil
+}
+
+// toToolChoice 转换工具选择策略
+//   - auto → auto，any → required，none → none
+//   - tool → 指定函数
+func toToolChoice(tc *ToolChoice) (*model.ToolChoice, error) {
+	switch tc.Type {
+	case "auto":
+		return &model.ToolChoice{Mode: model.ToolChoiceAuto}, nil
+	case "any":
+		return &model.ToolChoice{Mode: model.ToolChoiceRequired}, nil
+	case "none":
+		return &model.ToolChoice{Mode: model.ToolChoiceNone}, nil
+	case "tool":
+		if tc.Name == "" {
+			return nil, fmt.Errorf("tool_choice of type tool requires name")
+		}
+		return &model.ToolChoice{Function: tc.Name}, nil
+	default:
+		return nil, fmt.Errorf("tool_choice type must be 'auto', 'any', 'tool' or 'none'")
+	}
+}
+
+// FromChatResponse 将聊天响应转换为 Messages API 响应
+// 只使用第一个choice（Messages API 不支持 n）
+func FromChatResponse(resp *model.ChatResponse) *MessagesResponse {
+	stopReason := StopReasonEndTurn
+	out := &MessagesResponse{
+		ID:      MessageID(resp.ID),
+		Type:    "message",
+		Role:    "assistant",
+		Model:   resp.Model,
+		Content: []ContentBlock{},
+		Usage: Usage{
+			InputTokens:  resp.Usage.PromptTokens,
+			OutputTokens: resp.Usage.CompletionTokens,
+		},
+		StopReason: &stopReason,
+	}
+
+	choice := resp.GetFirstChoice()
+	if choice == nil {
+		return out
+	}
+	stopReason = StopReason(choice.FinishReason)
+
+	if choice.Message == nil {
+		return out
+	}
+	if text := choice.Message.Content.Text(); text != "" {
+		out.Content = append(out.Content, ContentBlock{Type: BlockTypeText, Text: text})
+	}
+	for _, call := range choice.Message.ToolCalls {
+		out.Content = append(out.Content, ContentBlock{
+			Type:  BlockTypeToolUse,
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: toolInput(call.Function.Arguments),
+		})
+	}
+	return out
+}
+
+// MessageID 由聊天响应ID生成消息ID（chatcmpl-xxx → msg_xxx），便于与审计记录对照
+func MessageID(chatID string) string {
+	return "msg_" + strings.TrimPrefix(chatID, model.IDPrefixChatCompletion)
+}
+
+// StopReason 将OpenAI格式的结束原因转换为 Messages API 的结束原因
+//   - length → max_tokens
+//   - tool_calls → tool_use
+//   - content_filter → refusal
+//   - 其他（stop等）→ end_turn
+func StopReason(finishReason string) string {
+	switch finishReason {
+	case "length":
+		return StopReasonMaxTokens
+	case model.FinishReasonToolCalls:
+		return StopReasonToolUse
+	case "content_filter":
+		return StopReasonRefusal
+	default:
+		return StopReasonEndTurn
+	}
+}
+
+// toolInput 将工具参数字符串转换为JSON对象
+// 模型生成的参数不保证合法，不合法时返回空对象
+func toolInput(arguments string) json.RawMessage {
+	if arguments == "" || !json.Valid([]byte(arguments)) {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(arguments)
+}
+
+// FromError 将网关错误转换为 Messages API 错误
+// 错误类型按 Anthropic 的取值映射，HTTP状态码沿用网关错误的状态码
+func FromError(errResp *model.ErrorResponse) *ErrorResponse {
+	errType := errResp.Error.Type
+	switch errType {
+	case model.ErrorTypeInvalidRequest, model.ErrorTypeAuthentication, model.ErrorTypePermission,
+		model.ErrorTypeNotFound, model.ErrorTypeRateLimit, model.ErrorTypeTimeout:
+	case model.ErrorTypeUnavailable:
+		errType = "overloaded_error"
+	default:
+		errType = model.ErrorTypeAPIError
+	}
+	return &ErrorResponse{
+		Type:  "error",
+		Error: ErrorDetail{Type: errType, Message: errResp.Error.Message},
+	}
+}
@@ -0,0 +1,287 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// parseRequest 解析 Messages API 请求JSON
+func parseRequest(t *testing.T, body string) *MessagesRequest {
+	t.Helper()
+	var req MessagesRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	return &req
+}
+
+// roles 返回消息的角色序列
+func roles(messages []model.Message) []string {
+	out := make([]string, len(messages))
+	for i, msg := range messages {
+		out[i] = msg.Role
+	}
+	return out
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestToChatRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantRoles []string
+		check     func(t *testing.T, req *model.ChatRequest)
+	}{
+		{
+			name:      "system string and parameters",
+			body:      `{"model":"glm-4","max_tokens":64,"system":"be brief","temperature":0.2,"stop_sequences":["END"],"metadata":{"user_id":"u-1"},"messages":[{"role":"user","content":"hi"}]}`,
+			wantRoles: []string{"system", "user"},
+			check: func(t *testing.T, req *model.ChatRequest) {
+				if req.Messages[0].Content.Text() != "be brief" || req.Messages[1].Content.Text() != "hi" {
+					t.Errorf("messages = %+v", req.Messages)
+				}
+				if req.GetMaxTokens() != 64 || *req.Temperature != 0.2 || req.Stop[0] != "END" || req.User != "u-1" {
+					t.Errorf("parameters not carried over: %+v", req)
+				}
+			},
+		},
+		{
+			name:      "system blocks joined",
+			body:      `{"model":"glm-4","max_tokens":1,"system":[{"type":"text","text":"a"},{"type":"text","text":"b"}],"messages":[{"role":"user","content":"hi"}]}`,
+			wantRoles: []string{"system", "user"},
+			check: func(t *testing.T, req *model.ChatRequest) {
+				if got := req.Messages[0].Content.Text(); got != "ab" {
+					t.Errorf("system = %q, want ab", got)
+				}
+			},
+		},
+		{
+			name: "tool use and tool result",
+			body: `{"model":"glm-4","max_tokens":1,"messages":[
+				{"role":"user","content":"weather?"},
+				{"role":"assistant","content":[{"type":"thinking","thinking":"..."},{"type":"text","text":"checking"},{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}}]},
+				{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":[{"type":"text","text":"sunny"}]},{"type":"text","text":"thanks"}]}
+			]}`,
+			wantRoles: []string{"user", "assistant", "tool", "user"},
+			check: func(t *testing.T, req *model.ChatRequest) {
+				assistant := req.Messages[1]
+				if assistant.Content.Text() != "checking" || len(assistant.ToolCalls) != 1 {
+					t.Fatalf("assistant = %+v", assistant)
+				}
+				call := assistant.ToolCalls[0]
+				if call.ID != "toolu_1" || call.Function.Name != "get_weather" || call.Function.Arguments != `{"city":"Paris"}` {
+					t.Errorf("tool call = %+v", call)
+				}
+				if tool := req.Messages[2]; tool.ToolCallID != "toolu_1" || tool.Content.Text() != "sunny" {
+					t.Errorf("tool message = %+v", tool)
+				}
+			},
+		},
+		{
+			name:      "tool use without input",
+			body:      `{"model":"glm-4","max_tokens":1,"messages":[{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"now"}]}]}`,
+			wantRoles: []string{"assistant"},
+			check: func(t *testing.T, req *model.ChatRequest) {
+				if got := req.Messages[0].ToolCalls[0].Function.Arguments; got != "{}" {
+					t.Errorf("arguments = %q, want {}", got)
+				}
+			},
+		},
+		{
+			name:      "image and document",
+			body:      `{"model":"glm-4v","max_tokens":1,"messages":[{"role":"user","content":[{"type":"text","text":"describe"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AAAA"}},{"type":"image","source":{"type":"url","url":"https://example.com/a.png"}},{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":"BBBB"}}]}]}`,
+			wantRoles: []string{"user"},
+			check: func(t *testing.T, req *model.ChatRequest) {
+				parts := req.Messages[0].Content.Parts()
+				if len(parts) != 4 {
+					t.Fatalf("got %d parts, want 4", len(parts))
+				}
+				if parts[1].ImageURL == nil || parts[1].ImageURL.URL != "data:image/png;base64,AAAA" {
+					t.Errorf("base64 image = %+v", parts[1])
+				}
+				if parts[2].ImageURL == nil || parts[2].ImageURL.URL != "https://example.com/a.png" {
+					t.Errorf("url image = %+v", parts[2])
+				}
+				if parts[3].File == nil || parts[3].File.FileData != "data:application/pdf;base64,BBBB" {
+					t.Errorf("document = %+v", parts[3])
+				}
+			},
+		},
+		{
+			name:      "tools and tool choice any",
+			body:      `{"model":"glm-4","max_tokens":1,"tools":[{"name":"get_weather","description":"weather","input_schema":{"type":"object"}}],"tool_choice":{"type":"any","disable_parallel_tool_use":true},"messages":[{"role":"user","content":"hi"}]}`,
+			wantRoles: []string{"user"},
+			check: func(t *testing.T, req *model.ChatRequest) {
+				if len(req.Tools) != 1 || req.Tools[0].Function.Name != "get_weather" || string(req.Tools[0].Function.Parameters) != `{"type":"object"}` {
+					t.Errorf("tools = %+v", req.Tools)
+				}
+				if req.ToolChoice == nil || req.ToolChoice.Mode != model.ToolChoiceRequired {
+					t.Errorf("tool_choice = %+v, want required", req.ToolChoice)
+				}
+				if req.ParallelToolCalls == nil || *req.ParallelToolCalls {
+					t.Error("parallel_tool_calls not disabled")
+				}
+			},
+		},
+		{
+			name:      "tool choice named tool",
+			body:      `{"model":"glm-4","max_tokens":1,"tools":[{"name":"get_weather"}],"tool_choice":{"type":"tool","name":"get_weather"},"messages":[{"role":"user","content":"hi"}]}`,
+			wantRoles: []string{"user"},
+			check: func(t *testing.T, req *model.ChatRequest) {
+				if req.ToolChoice == nil || req.ToolChoice.Function != "get_weather" || req.ParallelToolCalls != nil {
+					t.Errorf("tool_choice = %+v, parallel = %v", req.ToolChoice, req.ParallelToolCalls)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := ToChatRequest(parseRequest(t, tt.body))
+			if err != nil {
+				t.Fatalf("ToChatRequest: %v", err)
+			}
+			if got := roles(req.Messages); !equalStrings(got, tt.wantRoles) {
+				t.Fatalf("roles = %v, want %v", got, tt.wantRoles)
+			}
+			tt.check(t, req)
+		})
+	}
+}
+
+func TestToChatRequestErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantParam string
+	}{
+		{"built-in tool", `{"model":"m","max_tokens":1,"tools":[{"type":"web_search_20250305","name":"web_search"}],"messages":[{"role":"user","content":"hi"}]}`, "tools"},
+		{"tool choice without name", `{"model":"m","max_tokens":1,"tool_choice":{"type":"tool"},"messages":[{"role":"user","content":"hi"}]}`, "tool_choice"},
+		{"unknown tool choice", `{"model":"m","max_tokens":1,"tool_choice":{"type":"sometimes"},"messages":[{"role":"user","content":"hi"}]}`, "tool_choice"},
+		{"tool use in user message", `{"model":"m","max_tokens":1,"messages":[{"role":"user","content":[{"type":"tool_use","id":"t","name":"f"}]}]}`, "messages"},
+		{"tool result in assistant message", `{"model":"m","max_tokens":1,"messages":[{"role":"assistant","content":[{"type":"tool_result","tool_use_id":"t"}]}]}`, "messages"},
+		{"image without source", `{"model":"m","max_tokens":1,"messages":[{"role":"user","content":[{"type":"image"}]}]}`, "messages"},
+		{"document url source", `{"model":"m","max_tokens":1,"messages":[{"role":"user","content":[{"type":"document","source":{"type":"url","url":"https://example.com/a.pdf"}}]}]}`, "messages"},
+		{"unknown source type", `{"model":"m","max_tokens":1,"messages":[{"role":"user","content":[{"type":"image","source":{"type":"file","file_id":"f"}}]}]}`, "messages"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ToChatRequest(parseRequest(t, tt.body))
+			var paramErr *model.ParamError
+			if !errors.As(err, &paramErr) {
+				t.Fatalf("error = %v, want *model.ParamError", err)
+			}
+			if paramErr.Param != tt.wantParam {
+				t.Errorf("param = %q, want %q", paramErr.Param, tt.wantParam)
+			}
+		})
+	}
+}
+
+func TestContentUnmarshal(t *testing.T) {
+	tests := []struct {
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{`"hello"`, "hello", false},
+		{`[{"type":"text","text":"a"},{"type":"image","source":{"type":"url","url":"u"}},{"type":"text","text":"b"}]`, "ab", false},
+		{`42`, "", true},
+	}
+	for _, tt := range tests {
+		var c Content
+		err := json.Unmarshal([]byte(tt.body), &c)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.body, err, tt.wantErr)
+			continue
+		}
+		if got := c.Text(); got != tt.want {
+			t.Errorf("Unmarshal(%s).Text() = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestFromChatResponse(t *testing.T) {
+	resp := &model.ChatResponse{
+		ID:    "chatcmpl-abc",
+		Model: "glm-4",
+		Choices: []model.Choice{{
+			Message: &model.Message{
+				Role:    "assistant",
+				Content: model.NewTextContent("checking"),
+				ToolCalls: []model.ToolCall{
+					{ID: "call_1", Function: model.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+					{ID: "call_2", Function: model.FunctionCall{Name: "broken", Arguments: `{"city":`}},
+				},
+			},
+			FinishReason: model.FinishReasonToolCalls,
+		}},
+		Usage: model.Usage{PromptTokens: 5, CompletionTokens: 7},
+	}
+
+	data, err := json.Marshal(FromChatResponse(resp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"msg_abc","type":"message","role":"assistant","model":"glm-4","content":[` +
+		`{"type":"text","text":"checking"},` +
+		`{"type":"tool_use","id":"call_1","name":"get_weather","input":{"city":"Paris"}},` +
+		`{"type":"tool_use","id":"call_2","name":"broken","input":{}}],` +
+		`"stop_reason":"tool_use","stop_sequence":null,"usage":{"input_tokens":5,"output_tokens":7}}`
+	if string(data) != want {
+		t.Errorf("FromChatResponse =\n%s\nwant\n%s", data, want)
+	}
+
+	empty := FromChatResponse(&model.ChatResponse{ID: "chatcmpl-x"})
+	if *empty.StopReason != StopReasonEndTurn || len(empty.Content) != 0 {
+		t.Errorf("empty response = %+v", empty)
+	}
+}
+
+func TestStopReason(t *testing.T) {
+	tests := map[string]string{
+		"stop":                      StopReasonEndTurn,
+		"":                          StopReasonEndTurn,
+		"length":                    StopReasonMaxTokens,
+		model.FinishReasonToolCalls: StopReasonToolUse,
+		"content_filter":            StopReasonRefusal,
+	}
+	for finish, want := range tests {
+		if got := StopReason(finish); got != want {
+			t.Errorf("StopReason(%q) = %q, want %q", finish, got, want)
+		}
+	}
+}
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		errResp *model.ErrorResponse
+		want    string
+	}{
+		{model.NewInvalidRequestError("bad", "model"), model.ErrorTypeInvalidRequest},
+		{model.NewRateLimitError("slow down"), model.ErrorTypeRateLimit},
+		{model.NewTimeoutError("chat"), model.ErrorTypeTimeout},
+		{model.NewUnavailableError("down"), "overloaded_error"},
+		{model.NewServerError("boom"), model.ErrorTypeAPIError},
+	}
+	for _, tt := range tests {
+		got := FromError(tt.errResp)
+		if got.Type != "error" || got.Error.Type != tt.want || got.Error.Message != tt.errResp.Error.Message {
+			t.Errorf("FromError(%s) = %+v, want type %s", tt.errResp.Error.Type, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package anthropic
+
+import (
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// 流式事件类型（SSE的 event 字段）
+const (
+	EventMessageStart      = "message_start"
+	EventContentBlockStart = "content_block_start"
+	EventPing              = "ping"
+	EventContentBlockDelta = "content_block_delta"
+	EventContentBlockStop  = "content_block_stop"
+	EventMessageDelta      = "message_delta"
+	EventMessageStop       = "message_stop"
+	EventError             = "error"
+)
+
+// Event 一个流式事件
+type Event struct {
+	// Type 事件类型，同时作为SSE的 event 字段
+	Type string
+
+	// Data 事件数据（序列化为SSE的 data 字段）
+	Data any
+}
+
+// messageStart message_start 事件数据
+type messageStart struct {
+	Type    string            `json:"type"`
+	Message *MessagesResponse `json:"message"`
+}
+
+// blockStart content_block_start 事件数据
+type blockStart struct {
+	Type         string       `json:"type"`
+	Index        int          `json:"index"`
+	ContentBlock ContentBlock `json:"content_block"`
+}
+
+// blockDelta content_block_delta 事件数据
+type blockDelta struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+	Delta delta  `json:"delta"`
+}
+
+// delta 内容块增量：text_delta 或 input_json_delta
+type delta struct {
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`
+	PartialJSON string `json:"partial_json,omitempty"`
+}
+
+// blockStop content_block_stop 事件数据
+type blockStop struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+}
+
+// messageDelta message_delta 事件数据
+type messageDelta struct {
+	Type  string `json:"type"`
+	Delta struct {
+		StopReason   string  `json:"stop_reason"`
+		StopSequence *string `json:"stop_sequence"`
+	} `json:"delta"`
+	Usage Usage `json:"usage"`
+}
+
+// typeOnly 只有类型字段的事件数据（ping、message_stop）
+type typeOnly struct {
+	Type string `json:"type"`
+}
+
+// Stream 将 chat.completion.chunk 数据块序列转换为 Messages API 的流式事件序列：
+//
+//	message_start → ping →
+//	  (content_block_start → content_block_delta... → content_block_stop)... →
+//	message_delta → message_stop
+//
+// 文本增量合并为 text 块，每个工具调用对应一个 tool_use 块（参数以 input_json_delta 发送）
+// 非并发安全，每个流式响应使用一个实例
+type Stream struct {
+	// inputTokens message_start 和 message_delta 中报告的输入token数（网关估算）
+	// message_start 必须在上游返回用量之前发送，两个事件使用同一个值，避免客户端看到两个不同的输入token数
+	inputTokens int
+
+	started bool
+
+	// block 当前打开的内容块序号（-1表示没有）
+	block     int
+	blockType string
+	nextBlock int
+
+	// toolBlocks 工具调用序号（ToolCall.Index）→ 内容块序号
+	toolBlocks map[int]int
+
+	finishReason string
+	usage        *model.Usage
+}
+
+// NewStream 创建流式事件转换器
+// 参数：
+//   - inputTokens: 估算的输入token数（message_start 需要在上游返回用量之前发送）
+func NewStream(inputTokens int) *Stream {
+	return &Stream{
+		inputTokens: inputTokens,
+		block:       -1,
+		toolBlocks:  make(map[int]int),
+	}
+}
+
+// Chunk 转换一个数据块
+// 第一个数据块前发送 message_start；用量数据块只记录用量，在 Finish 时发送
+func (s *Stream) Chunk(chunk *model.StreamResponse) []Event {
+	var events []Event
+	if !s.started {
+		events = append(events, s.start(chunk.ID, chunk.Model)...)
+	}
+	if chunk.Usage != nil {
+		s.usage = chunk.Usage
+	}
+	if len(chunk.Choices) == 0 {
+		return events
+	}
+
+	// Messages API 不支持 n，只转换第一个choice
+	choice := chunk.Choices[0]
+	if text := choice.Delta.Content; text != "" {
+		if s.blockType != BlockTypeText {
+			events = append(events, s.open(ContentBlock{Type: BlockTypeText})...)
+		}
+		events = append(events, Event{EventContentBlockDelta, blockDelta{
+			Type:  EventContentBlockDelta,
+			Index: s.block,
+			Delta: delta{Type: "text_delta", Text: text},
+		}})
+	}
+
+	for i, call := range choice.Delta.ToolCalls {
+		toolIndex := i
+		if call.Index != nil {
+			toolIndex = *call.Index
+		}
+		index, ok := s.toolBlocks[toolIndex]
+		if !ok {
+			events = append(events, s.open(ContentBlock{Type: BlockTypeToolUse, ID: call.ID, Name: call.Function.Name})...)
+			index = s.block
+			s.toolBlocks[toolIndex] = index
+		}
+		if call.Function.Arguments != "" {
+			events = append(events, Event{EventContentBlockDelta, blockDelta{
+				Type:  EventContentBlockDelta,
+				Index: index,
+				Delta: delta{Type: "input_json_delta", PartialJSON: call.Function.Arguments},
+			}})
+		}
+	}
+
+	if choice.FinishReason != nil {
+		s.finishReason = *choice.FinishReason
+	}
+	return events
+}
+
+// Finish 结束流：关闭打开的内容块，发送 message_delta（结束原因和用量）和 message_stop
+// 输出token数取自上游用量，输入token数与 message_start 相同
+// 参数：
+//   - resp: 拼装后的完整响应（没有收到任何数据块时用于生成 message_start）
+func (s *Stream) Finish(resp *model.ChatResponse) []Event {
+	var events []Event
+	if !s.started {
+		events = append(events, s.start(resp.ID, resp.Model)...)
+	}
+	events = append(events, s.close()...)
+
+	md := messageDelta{Type: EventMessageDelta}
+	md.Delta.StopReason = StopReason(s.finishReason)
+	md.Usage = Usage{InputTokens: s.inputTokens}
+	if s.usage != nil {
+		md.Usage.OutputTokens = s.usage.CompletionTokens
+	}
+	return append(events,
+		Event{EventMessageDelta, md},
+		Event{EventMessageStop, typeOnly{Type: EventMessageStop}},
+	)
+}
+
+// ErrorEvent 生成流式错误事件
+func ErrorEvent(errResp *model.ErrorResponse) Event {
+	return Event{EventError, FromError(errResp)}
+}
+
+// start 发送 message_start 和 ping
+func (s *Stream) start(id, modelName string) []Event {
+	s.started = true
+	msg := &MessagesResponse{
+		ID:      MessageID(id),
+		Type:    "message",
+		Role:    "assistant",
+		Model:   modelName,
+		Content: []ContentBlock{},
+		Usage:   Usage{InputTokens: s.inputTokens},
+	}
+	return []Event{
+		{EventMessageStart, messageStart{Type: EventMessageStart, Message: msg}},
+		{EventPing, typeOnly{Type: EventPing}},
+	}
+}
+
+// open 关闭当前内容块并打开新的内容块
+func (s *Stream) open(block ContentBlock) []Event {
+	events := s.close()
+	s.block = s.nextBlock
+	s.blockType = block.Type
+	s.nextBlock++
+	return append(events, Event{EventContentBlockStart, blockStart{
+		Type:         EventContentBlockStart,
+		Index:        s.block,
+		ContentBlock: block,
+	}})
+}
+
+// close 关闭当前内容块（没有打开的内容块时不做任何处理）
+func (s *Stream) close() []Event {
+	if s.block < 0 {
+		return nil
+	}
+	index := s.block
+	s.block = -1
+	s.blockType = ""
+	return []Event{{EventContentBlockStop, blockStop{Type: EventContentBlockStop, Index: index}}}
+}
@@ -0,0 +1,111 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// toolChunk 构造只含一个工具调用增量的数据块
+func toolChunk(index int, id, name, args string) *model.StreamResponse {
+	chunk := model.NewStreamResponse("chatcmpl-1", "glm-4", "", false)
+	chunk.Choices[0].Delta.ToolCalls = []model.ToolCall{
+		{Index: &index, ID: id, Function: model.FunctionCall{Name: name, Arguments: args}},
+	}
+	return chunk
+}
+
+// eventLines 将事件序列化为 "类型 数据" 形式，便于比较
+func eventLines(t *testing.T, events []Event) []string {
+	t.Helper()
+	lines := make([]string, len(events))
+	for i, ev := range events {
+		data, err := json.Marshal(ev.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines[i] = ev.Type + " " + string(data)
+	}
+	return lines
+}
+
+func TestStreamEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []*model.StreamResponse
+		want   []string
+	}{
+		{
+			name: "text then tool calls",
+			chunks: []*model.StreamResponse{
+				model.NewStreamResponse("chatcmpl-1", "glm-4", "", true),
+				model.NewStreamResponse("chatcmpl-1", "glm-4", "Let me ", false),
+				model.NewStreamResponse("chatcmpl-1", "glm-4", "check.", false),
+				toolChunk(0, "call_a", "get_weather", `{"city":`),
+				toolChunk(0, "", "", `"Paris"}`),
+				toolChunk(1, "call_b", "get_time", ``),
+				model.NewStreamEndResponse("chatcmpl-1", "glm-4", model.FinishReasonToolCalls),
+				model.NewStreamUsageResponse("chatcmpl-1", "glm-4", 0, model.Usage{PromptTokens: 5, CompletionTokens: 9}),
+			},
+			want: []string{
+				`message_start {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"glm-4","content":[],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":8,"output_tokens":0}}}`,
+				`ping {"type":"ping"}`,
+				`content_block_start {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+				`content_block_delta {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me "}}`,
+				`content_block_delta {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"check."}}`,
+				`content_block_stop {"type":"content_block_stop","index":0}`,
+				`content_block_start {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"call_a","name":"get_weather","input":{}}}`,
+				`content_block_delta {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+				`content_block_delta {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+				`content_block_stop {"type":"content_block_stop","index":1}`,
+				`content_block_start {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"call_b","name":"get_time","input":{}}}`,
+				`content_block_stop {"type":"content_block_stop","index":2}`,
+				// 输入token数与 message_start 一致，输出token数取自上游用量
+				`message_delta {"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"input_tokens":8,"output_tokens":9}}`,
+				`message_stop {"type":"message_stop"}`,
+			},
+		},
+		{
+			name:   "no chunks",
+			chunks: nil,
+			want: []string{
+				`message_start {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"glm-4","content":[],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":8,"output_tokens":0}}}`,
+				`ping {"type":"ping"}`,
+				`message_delta {"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"input_tokens":8,"output_tokens":0}}`,
+				`message_stop {"type":"message_stop"}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStream(8)
+			var events []Event
+			for _, chunk := range tt.chunks {
+				events = append(events, s.Chunk(chunk)...)
+			}
+			events = append(events, s.Finish(&model.ChatResponse{ID: "chatcmpl-1", Model: "glm-4"})...)
+
+			got := eventLines(t, events)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d events, want %d:\n%s", len(got), len(tt.want), strings.Join(got, "\n"))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("event %d =\n%s\nwant\n%s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestErrorEvent(t *testing.T) {
+	ev := ErrorEvent(model.NewUnavailableError("down"))
+	got := eventLines(t, []Event{ev})[0]
+	want := `error {"type":"error","error":{"type":"overloaded_error","message":"down"}}`
+	if got != want {
+		t.Errorf("ErrorEvent = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,270 @@
+// Package anthropic 实现 Anthropic Messages API 与网关内部（OpenAI格式）之间的转换
+// 入站的 /v1/messages 请求转换为 ChatRequest 后走与 /v1/chat/completions 相同的处理流程，
+// 响应和流式数据块再转换回 Anthropic 格式
+package anthropic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// 内容块类型（ContentBlock.Type 的取值）
+const (
+	BlockTypeText       = "text"
+	BlockTypeImage      = "image"
+	BlockTypeDocument   = "document"
+	BlockTypeToolUse    = "tool_use"
+	BlockTypeToolResult = "tool_result"
+	BlockTypeThinking   = "thinking"
+)
+
+// 结束原因（MessagesResponse.StopReason 的取值）
+const (
+	StopReasonEndTurn   = "end_turn"
+	StopReasonMaxTokens = "max_tokens"
+	StopReasonToolUse   = "tool_use"
+	StopReasonRefusal   = "refusal"
+)
+
+// MessagesRequest Messages API 请求（POST /v1/messages）
+type MessagesRequest struct {
+	// Model 模型名称（与 /v1/chat/completions 使用同一套模型路由）
+	Model string `json:"model" binding:"required"`
+
+	// MaxTokens 最大生成token数（Anthropic要求必填）
+	MaxTokens int `json:"max_tokens" binding:"required,min=1"`
+
+	// System 系统提示词（字符串或文本块数组）
+	System Content `json:"system,omitempty"`
+
+	// Messages 对话消息，user 和 assistant 交替出现
+	Messages []Message `json:"messages" binding:"required,min=1,dive"`
+
+	// StopSequences 停止序列
+	StopSequences []string `json:"stop_sequences,omitempty"`
+
+	// Temperature 温度
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// TopP 核采样
+	TopP *float64 `json:"top_p,omitempty"`
+
+	// TopK 只从概率最高的K个token中采样（OpenAI格式没有对应参数，忽略）
+	TopK *int `json:"top_k,omitempty"`
+
+	// Stream 是否流式返回
+	Stream bool `json:"stream,omitempty"`
+
+	// Tools 可用工具
+	Tools []Tool `json:"tools,omitempty"`
+
+	// ToolChoice 工具选择策略
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+
+	// Metadata 请求元数据
+	Metadata *Metadata `json:"metadata,omitempty"`
+}
+
+// Message 对话消息
+type Message struct {
+	// Role 角色：user, assistant（system通过请求的 system 字段传递）
+	Role string `json:"role" binding:"required,oneof=user assistant"`
+
+	// Content 消息内容（字符串或内容块数组）
+	Content Content `json:"content"`
+}
+
+// Content 消息内容
+// JSON中可以是字符串（等价于一个文本块），也可以是内容块数组
+type Content []ContentBlock
+
+// UnmarshalJSON 同时接受字符串和内容块数组两种格式
+func (c *Content) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*c = Content{{Type: BlockTypeText, Text: text}}
+		return nil
+	}
+
+	var blocks []ContentBlock
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return errors.New("content must be a string or an array of content blocks")
+	}
+	*c = blocks
+	return nil
+}
+
+// Text 拼接所有文本块
+func (c Content) Text() string {
+	var text string
+	for _, block := range c {
+		if block.Type == BlockTypeText {
+			text += block.Text
+		}
+	}
+	return text
+}
+
+// ContentBlock 内容块
+// 不同类型使用不同的字段
+type ContentBlock struct {
+	// Type 内容块类型：text, image, document, tool_use, tool_result, thinking
+	Type string `json:"type"`
+
+	// Text 文本（type=text）
+	Text string `json:"text,omitempty"`
+
+	// Source 图片或文档来源（type=image / document）
+	Source *Source `json:"source,omitempty"`
+
+	// ID 工具调用ID（type=tool_use）
+	ID string `json:"id,omitempty"`
+
+	// Name 工具名称（type=tool_use）
+	Name string `json:"name,omitempty"`
+
+	// Input 工具参数（type=tool_use），JSON对象
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// ToolUseID 对应的工具调用ID（type=tool_result）
+	ToolUseID string `json:"tool_use_id,omitempty"`
+
+	// Content 工具执行结果（type=tool_result），字符串或内容块数组
+	Content Content `json:"content,omitempty"`
+
+	// IsError 工具执行是否出错（type=tool_result）
+	IsError bool `json:"is_error,omitempty"`
+
+	// Thinking 推理内容（type=thinking），转换时丢弃
+	Thinking string `json:"thinking,omitempty"`
+}
+
+// MarshalJSON 按内容块类型输出
+// text 块总是带 text 字段、tool_use 块总是带 input 字段（流式的 content_block_start 中二者为空）
+func (b ContentBlock) MarshalJSON() ([]byte, error) {
+	switch b.Type {
+	case BlockTypeText:
+		return json.Marshal(struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}{b.Type, b.Text})
+	case BlockTypeToolUse:
+		input := b.Input
+		if len(input) == 0 {
+			input = json.RawMessage("{}")
+		}
+		return json.Marshal(struct {
+			Type  string          `json:"type"`
+			ID    string          `json:"id"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		}{b.Type, b.ID, b.Name, input})
+	default:
+		type plain ContentBlock
+		return json.Marshal(plain(b))
+	}
+}
+
+// Source 图片或文档来源
+type Source struct {
+	// Type 来源类型：base64, url
+	Type string `json:"type"`
+
+	// MediaType 媒体类型（type=base64），如 image/png、application/pdf
+	MediaType string `json:"media_type,omitempty"`
+
+	// Data base64编码的数据（type=base64）
+	Data string `json:"data,omitempty"`
+
+	// URL 远程地址（type=url）
+	URL string `json:"url,omitempty"`
+}
+
+// Tool 工具定义
+type Tool struct {
+	// Type 工具类型（自定义工具为空或 custom；Anthropic内置工具不支持）
+	Type string `json:"type,omitempty"`
+
+	// Name 工具名称
+	Name string `json:"name"`
+
+	// Description 工具描述
+	Description string `json:"description,omitempty"`
+
+	// InputSchema 参数的JSON Schema
+	InputSchema json.RawMessage `json:"input_schema,omitempty"`
+}
+
+// ToolChoice 工具选择策略
+type ToolChoice struct {
+	// Type 选择模式：auto, any, tool, none
+	Type string `json:"type"`
+
+	// Name 指定的工具名称（type=tool）
+	Name string `json:"name,omitempty"`
+
+	// DisableParallelToolUse 禁止一次回复中调用多个工具
+	DisableParallelToolUse bool `json:"disable_parallel_tool_use,omitempty"`
+}
+
+// Metadata 请求元数据
+type Metadata struct {
+	// UserID 终端用户标识（转换为OpenAI格式的 user 字段）
+	UserID string `json:"user_id,omitempty"`
+}
+
+// MessagesResponse Messages API 响应
+type MessagesResponse struct {
+	// ID 消息ID，格式：msg_{随机字符串}
+	ID string `json:"id"`
+
+	// Type 固定值："message"
+	Type string `json:"type"`
+
+	// Role 固定值："assistant"
+	Role string `json:"role"`
+
+	// Model 模型名称
+	Model string `json:"model"`
+
+	// Content 回复内容块（文本、工具调用）
+	Content []ContentBlock `json:"content"`
+
+	// StopReason 结束原因：end_turn, max_tokens, tool_use, refusal（流式 message_start 中为null）
+	StopReason *string `json:"stop_reason"`
+
+	// StopSequence 命中的停止序列（上游不报告，总是null）
+	StopSequence *string `json:"stop_sequence"`
+
+	// Usage token用量
+	Usage Usage `json:"usage"`
+}
+
+// Usage token用量
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// ErrorResponse 错误响应
+type ErrorResponse struct {
+	// Type 固定值："error"
+	Type string `json:"type"`
+
+	// Error 错误详情
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail 错误详情
+type ErrorDetail struct {
+	// Type 错误类型，如 invalid_request_error、overloaded_error
+	Type string `json:"type"`
+
+	// Message 错误消息
+	Message string `json:"message"`
+}
@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/AtSunset1/prism/pkg/config"
+	"go.uber.org/zap"
+)
+
+// DefaultBufferSize 审计队列默认长度
+const DefaultBufferSize = 1024
+
+// Auditor 审计记录器
+// 请求处理路径只负责把记录放入队列，由后台goroutine顺序写入后端，
+// 这样慢速后端（如webhook）不会拖慢请求；队列满时丢弃并记录告警日志
+type Auditor struct {
+	sink   Sink
+	redact bool
+
+	queue chan *Record
+	wg    sync.WaitGroup
+
+	// mu 保护closed，避免关闭后继续向queue发送导致panic
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New 根据配置创建审计记录器
+// 参数：
+//   - cfg: 审计配置
+//
+// 返回：
+//   - *Auditor: 记录器实例；未启用审计时返回nil（nil记录器的方法都是空操作）
+//   - error: 后端创建失败时返回错误
+func New(cfg config.AuditConfig) (*Auditor, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	sink, err := NewSink(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithSink(sink, cfg.RedactContent, cfg.BufferSize), nil
+}
+
+// NewWithSink 使用指定后端创建审计记录器
+// 参数：
+//   - sink: 审计后端
+//   - redact: 是否对消息内容脱敏
+//   - bufferSize: 队列长度（为0则使用默认值）
+func NewWithSink(sink Sink, redact bool, bufferSize int) *Auditor {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	a := &Auditor{
+		sink:   sink,
+		redact: redact,
+		queue:  make(chan *Record, bufferSize),
+	}
+
+	a.wg.Add(1)
+	go a.run()
+
+	return a
+}
+
+// Record 提交一条审计记录（不阻塞）
+func (a *Auditor) Record(rec *Record) {
+	if a == nil || rec == nil {
+		return
+	}
+
+	if a.redact {
+		rec = rec.Redact()
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return
+	}
+
+	select {
+	case a.queue <- rec:
+	default:
+		zap.L().Warn("审计队列已满，丢弃记录", zap.String("request_id", rec.RequestID))
+	}
+}
+
+// Close 停止接收新记录，等待队列中的记录写完后关闭后端
+func (a *Auditor) Close() error {
+	if a == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+
+	a.wg.Wait()
+	return a.sink.Close()
+}
+
+// run 后台写入循环
+func (a *Auditor) run() {
+	defer a.wg.Done()
+
+	for rec := range a.queue {
+		if err := a.sink.Write(context.Background(), rec); err != nil {
+			zap.L().Warn("写入审计记录失败",
+				zap.String("request_id", rec.RequestID),
+				zap.Error(err),
+			)
+		}
+	}
+}
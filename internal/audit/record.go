@@ -0,0 +1,159 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// RedactedContent 脱敏后的消息内容占位符
+const RedactedContent = "[REDACTED]"
+
+// Record 一条审计记录（JSONL中的一行）
+// 记录一次聊天请求从进入网关到返回的完整信息
+type Record struct {
+	// RequestID 请求ID（与日志、响应头中的请求ID一致）
+	RequestID string `json:"request_id"`
+
+	// KeyID 调用方API密钥的标识（密钥指纹，不含密钥本身）
+	KeyID string `json:"key_id,omitempty"`
+
+	// Model 请求的模型名称
+	Model string `json:"model"`
+
+	// Stream 是否为流式请求
+	Stream bool `json:"stream"`
+
+	// StartedAt 请求开始时间
+	StartedAt time.Time `json:"started_at"`
+
+	// FinishedAt 请求结束时间（流式请求为流结束时间）
+	FinishedAt time.Time `json:"finished_at"`
+
+	// LatencyMs 请求总耗时（毫秒）
+	LatencyMs int64 `json:"latency_ms"`
+
+	// Status 返回给客户端的HTTP状态码
+	Status int `json:"status"`
+
+	// Redacted 消息内容是否已脱敏（脱敏记录无法重放）
+	Redacted bool `json:"redacted"`
+
+	// Request 原始请求
+	Request *model.ChatRequest `json:"request"`
+
+	// Response 最终响应；流式请求为拼装后的完整响应
+	Response *model.ChatResponse `json:"response,omitempty"`
+
+	// Usage token用量
+	Usage *model.Usage `json:"usage,omitempty"`
+
+	// Error 错误详情（请求失败时）
+	Error *model.ErrorDetail `json:"error,omitempty"`
+}
+
+// Redact 返回消息内容脱敏后的记录副本
+// 只替换消息内容，保留角色、模型、采样参数等结构信息，
+// 并在占位符中附带原内容长度，方便排查超长输入
+func (r *Record) Redact() *Record {
+	redacted := *r
+	redacted.Redacted = true
+
+	if r.Request != nil {
+		req := *r.Request
+		req.Messages = redactMessages(r.Request.Messages)
+		redacted.Request = &req
+	}
+
+	if r.Response != nil {
+		resp := *r.Response
+		resp.Choices = make([]model.Choice, len(r.Response.Choices))
+		for i, choice := range r.Response.Choices {
+			if choice.Message != nil {
+				msg := redactMessage(*choice.Message)
+				choice.Message = &msg
+			}
+			resp.Choices[i] = choice
+		}
+		redacted.Response = &resp
+	}
+
+	return &redacted
+}
+
+// redactMessages 脱敏消息列表（返回新切片，不修改原请求）
+func redactMessages(messages []model.Message) []model.Message {
+	out := make([]model.Message, len(messages))
+	for i, msg := range messages {
+		out[i] = redactMessage(msg)
+	}
+	return out
+}
+
+// redactMessage 脱敏单条消息的内容和工具调用参数（函数名保留）
+func redactMessage(msg model.Message) model.Message {
+	msg.Content = redactMessageContent(msg.Content)
+	if len(msg.ToolCalls) > 0 {
+		calls := make([]model.ToolCall, len(msg.ToolCalls))
+		for i, call := range msg.ToolCalls {
+			call.Function.Arguments = redactContent(call.Function.Arguments)
+			calls[i] = call
+		}
+		msg.ToolCalls = calls
+	}
+	return msg
+}
+
+// redactMessageContent 脱敏消息内容
+// 多模态内容保留片段类型，文本、图片地址、音频和文件数据均替换为占位符
+func redactMessageContent(content model.Content) model.Content {
+	if !content.IsMultipart() {
+		return model.NewTextContent(redactContent(content.Text()))
+	}
+
+	parts := make([]model.ContentPart, len(content.Parts()))
+	for i, part := range content.Parts() {
+		part.Text = redactContent(part.Text)
+		if part.ImageURL != nil {
+			image := *part.ImageURL
+			image.URL = redactContent(image.URL)
+			part.ImageURL = &image
+		}
+		if part.InputAudio != nil {
+			audio := *part.InputAudio
+			audio.Data = redactContent(audio.Data)
+			part.InputAudio = &audio
+		}
+		if part.File != nil {
+			file := *part.File
+			file.FileData = redactContent(file.FileData)
+			part.File = &file
+		}
+		parts[i] = part
+	}
+	return model.NewMultipartContent(parts)
+}
+
+// redactContent 将内容替换为带长度的占位符
+func redactContent(content string) string {
+	if content == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s(len=%d)", RedactedContent, len([]rune(content)))
+}
+
+// KeyID 根据Authorization请求头计算调用方密钥标识
+// 返回密钥SHA-256指纹的前12位，审计日志中不会出现密钥原文
+// 没有携带密钥时返回空字符串
+func KeyID(authorization string) string {
+	key := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "key_" + hex.EncodeToString(sum[:])[:12]
+}
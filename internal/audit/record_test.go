@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+func TestRedact(t *testing.T) {
+	rec := &Record{
+		RequestID: "req-1",
+		Model:     "glm-4",
+		Request: &model.ChatRequest{
+			Model: "glm-4",
+			Messages: []model.Message{
+				{Role: "system", Content: model.NewTextContent("你是助手")},
+				{Role: "user", Content: model.NewMultipartContent([]model.ContentPart{
+					{Type: "text", Text: "看这张图"},
+					{Type: "image_url", ImageURL: &model.ImageURL{URL: "https://example.com/a.png"}},
+				})},
+				{Role: "assistant", ToolCalls: []model.ToolCall{
+					{ID: "call_1", Type: "function", Function: model.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+				}},
+			},
+		},
+		Response: &model.ChatResponse{
+			Choices: []model.Choice{{Message: &model.Message{Role: "assistant", Content: model.NewTextContent("hello")}}},
+		},
+	}
+
+	redacted := rec.Redact()
+	if !redacted.Redacted {
+		t.Error("Redacted flag not set")
+	}
+
+	msgs := redacted.Request.Messages
+	if got := msgs[0].Content.Text(); got != RedactedContent+"(len=4)" {
+		t.Errorf("system content = %q", got)
+	}
+	parts := msgs[1].Content.Parts()
+	if parts[0].Text != RedactedContent+"(len=4)" {
+		t.Errorf("text part = %q", parts[0].Text)
+	}
+	if parts[1].Type != "image_url" || !strings.HasPrefix(parts[1].ImageURL.URL, RedactedContent) {
+		t.Errorf("image part = %+v", parts[1])
+	}
+	call := msgs[2].ToolCalls[0]
+	if call.Function.Name != "get_weather" || !strings.HasPrefix(call.Function.Arguments, RedactedContent) {
+		t.Errorf("tool call = %+v", call.Function)
+	}
+	if got := redacted.Response.Choices[0].Message.Content.Text(); got != RedactedContent+"(len=5)" {
+		t.Errorf("response content = %q", got)
+	}
+
+	// 原记录不被修改
+	if rec.Redacted || rec.Request.Messages[0].Content.Text() != "你是助手" {
+		t.Error("original record was modified")
+	}
+	if rec.Request.Messages[1].Content.Parts()[1].ImageURL.URL != "https://example.com/a.png" {
+		t.Error("original image url was modified")
+	}
+	if rec.Response.Choices[0].Message.Content.Text() != "hello" {
+		t.Error("original response was modified")
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		empty         bool
+	}{
+		{"bearer", "Bearer sk-test", false},
+		{"raw key", "sk-test", false},
+		{"missing", "", true},
+		{"bearer only", "Bearer ", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := KeyID(tt.authorization)
+			if tt.empty {
+				if got != "" {
+					t.Errorf("KeyID(%q) = %q, want empty", tt.authorization, got)
+				}
+				return
+			}
+			if !strings.HasPrefix(got, "key_") || len(got) != len("key_")+12 {
+				t.Errorf("KeyID(%q) = %q", tt.authorization, got)
+			}
+			if strings.Contains(got, "sk-test") {
+				t.Error("key id contains the key")
+			}
+		})
+	}
+	if KeyID("Bearer sk-test") != KeyID("sk-test") {
+		t.Error("Bearer prefix changes the key id")
+	}
+}
+
+func TestAuditorFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewWithSink(sink, true, 0)
+	for _, id := range []string{"req-1", "req-2"} {
+		a.Record(&Record{
+			RequestID: id,
+			Request:   &model.ChatRequest{Model: "glm-4", Messages: []model.Message{{Role: "user", Content: model.NewTextContent("secret")}}},
+		})
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// 关闭后提交的记录被忽略
+	a.Record(&Record{RequestID: "late"})
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var ids []string
+	err = ReadRecords(f, func(rec *Record) error {
+		ids = append(ids, rec.RequestID)
+		if !rec.Redacted || strings.Contains(rec.Request.Messages[0].Content.Text(), "secret") {
+			t.Errorf("record %s not redacted", rec.RequestID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(ids, ",") != "req-1,req-2" {
+		t.Errorf("records = %v", ids)
+	}
+}
+
+func TestReadRecordsErrors(t *testing.T) {
+	err := ReadRecords(strings.NewReader("{\"request_id\":\"a\"}\n\nnot json\n"), func(*Record) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("err = %v, want line 3 parse error", err)
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	err = ReadRecords(strings.NewReader("{}\n{}\n"), func(*Record) error {
+		count++
+		return stop
+	})
+	if !errors.Is(err, stop) || count != 1 {
+		t.Errorf("err = %v, count = %d", err, count)
+	}
+}
+
+func TestNewDisabled(t *testing.T) {
+	a, err := New(config.AuditConfig{Enabled: false})
+	if err != nil || a != nil {
+		t.Fatalf("New(disabled) = %v, %v", a, err)
+	}
+	// nil记录器的方法都是空操作
+	a.Record(&Record{})
+	if err := a.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// maxRecordSize 单条审计记录的最大长度（长对话的记录可能很大）
+const maxRecordSize = 16 * 1024 * 1024
+
+// ReadRecords 逐行读取JSONL格式的审计记录
+// 参数：
+//   - r: 审计日志内容
+//   - fn: 每读到一条记录调用一次，返回error时停止读取
+//
+// 返回：
+//   - error: 解析失败（附带行号）或fn返回的错误
+func ReadRecords(r io.Reader, fn func(rec *Record) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("line %d: unmarshal audit record failed: %w", line, err)
+		}
+		if err := fn(&rec); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,181 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/AtSunset1/prism/pkg/config"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// 审计日志后端类型
+const (
+	SinkFile         = "file"
+	SinkRotatingFile = "rotating_file"
+	SinkWebhook      = "webhook"
+)
+
+// DefaultWebhookTimeout webhook请求默认超时时间
+const DefaultWebhookTimeout = 5 * time.Second
+
+// Sink 审计日志后端
+// 实现方需要保证 Write 可以被单个goroutine顺序调用（Auditor保证不会并发调用）
+type Sink interface {
+	// Write 写入一条审计记录
+	Write(ctx context.Context, rec *Record) error
+	// Close 刷新缓冲并释放资源
+	Close() error
+}
+
+// NewSink 根据配置创建审计日志后端
+// 参数：
+//   - cfg: 审计配置
+//
+// 返回：
+//   - Sink: 后端实例
+//   - error: 后端类型未知或创建失败时返回错误
+func NewSink(cfg config.AuditConfig) (Sink, error) {
+	switch cfg.Sink {
+	case SinkFile:
+		return NewFileSink(cfg.FilePath)
+	case SinkRotatingFile:
+		return NewRotatingFileSink(cfg.FilePath, cfg.MaxSize, cfg.MaxBackups, cfg.MaxAge)
+	case SinkWebhook:
+		return NewWebhookSink(cfg.WebhookURL, cfg.WebhookHeaders, cfg.WebhookTimeout), nil
+	default:
+		return nil, fmt.Errorf("unknown audit sink: %s", cfg.Sink)
+	}
+}
+
+// ===== JSONL写入 =====
+
+// jsonlSink 将记录按行写入任意 io.WriteCloser
+// 文件后端和滚动文件后端共用
+type jsonlSink struct {
+	mu  sync.Mutex
+	w   io.WriteCloser
+	enc *json.Encoder
+}
+
+func newJSONLSink(w io.WriteCloser) *jsonlSink {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &jsonlSink{w: w, enc: enc}
+}
+
+// Write 写入一行JSON（json.Encoder会在末尾追加换行）
+func (s *jsonlSink) Write(_ context.Context, rec *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(rec)
+}
+
+// Close 关闭底层文件
+func (s *jsonlSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Close()
+}
+
+// NewFileSink 创建追加写入单个文件的后端
+// 参数：
+//   - path: 文件路径（目录不存在时自动创建）
+func NewFileSink(path string) (Sink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create audit dir failed: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("open audit file failed: %w", err)
+	}
+	return newJSONLSink(f), nil
+}
+
+// NewRotatingFileSink 创建按大小滚动的文件后端
+// 参数：
+//   - path: 文件路径
+//   - maxSize: 单个文件最大大小（MB）
+//   - maxBackups: 最多保留的历史文件数
+//   - maxAge: 历史文件保留天数
+func NewRotatingFileSink(path string, maxSize, maxBackups, maxAge int) (Sink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create audit dir failed: %w", err)
+	}
+
+	return newJSONLSink(&lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSize,
+		MaxBackups: maxBackups,
+		MaxAge:     maxAge,
+		LocalTime:  true,
+	}), nil
+}
+
+// ===== Webhook =====
+
+// WebhookSink 将每条记录以JSON POST到指定地址
+type WebhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhookSink 创建webhook后端
+// 参数：
+//   - url: 接收地址
+//   - headers: 额外请求头（如鉴权头）
+//   - timeout: 单次请求超时时间（为0则使用默认值）
+func NewWebhookSink(url string, headers map[string]string, timeout time.Duration) *WebhookSink {
+	if timeout == 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	return &WebhookSink{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Write 发送一条记录，非2xx状态码视为失败
+func (s *WebhookSink) Write(ctx context.Context, rec *Record) error {
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal audit record failed: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create webhook request failed: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	httpResp, err := s.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+	io.Copy(io.Discard, httpResp.Body)
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", httpResp.StatusCode)
+	}
+	return nil
+}
+
+// Close 释放空闲连接
+func (s *WebhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// Principal 通过鉴权的调用方
+type Principal struct {
+	// Name 调用方名称（来自配置）
+	Name string
+
+	// Models 允许访问的模型（为空表示全部），支持 * 通配符，如 glm-*
+	Models []string
+}
+
+// CanAccess 判断调用方是否可以访问指定模型
+// nil Principal（未启用鉴权）可以访问全部模型
+func (p *Principal) CanAccess(model string) bool {
+	if p == nil || len(p.Models) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(p.Models, func(pattern string) bool {
+		return matchModel(pattern, model)
+	})
+}
+
+// matchModel 判断模型名称是否匹配配置中的模式
+// * 匹配任意长度的字符（包括 /），其余字符按原样比较
+func matchModel(pattern, model string) bool {
+	pieces := strings.Split(pattern, "*")
+	if len(pieces) == 1 {
+		return pattern == model
+	}
+
+	// 第一段必须是前缀，最后一段必须是后缀，中间各段按顺序出现
+	first, last := pieces[0], pieces[len(pieces)-1]
+	if !strings.HasPrefix(model, first) || !strings.HasSuffix(model[len(first):], last) {
+		return false
+	}
+	rest := model[len(first) : len(model)-len(last)]
+	for _, piece := range pieces[1 : len(pieces)-1] {
+		i := strings.Index(rest, piece)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(piece):]
+	}
+	return true
+}
+
+// Authenticator API Key鉴权
+// Key集合可以在配置热加载时整体替换（见 Reload）
+type Authenticator struct {
+	state atomic.Pointer[keySet]
+}
+
+// keySet 一份鉴权配置快照
+type keySet struct {
+	enabled bool
+	// keys 以Key的SHA-256为索引，避免按原文比较带来的时序差异
+	keys map[[sha256.Size]byte]*Principal
+}
+
+// New 根据配置创建鉴权器
+// 参数：
+//   - cfg: 鉴权配置
+func New(cfg config.AuthConfig) *Authenticator {
+	a := &Authenticator{}
+	a.Reload(cfg)
+	return a
+}
+
+// Reload 使用新配置替换Key集合
+func (a *Authenticator) Reload(cfg config.AuthConfig) {
+	set := &keySet{
+		enabled: cfg.Enabled,
+		keys:    make(map[[sha256.Size]byte]*Principal, len(cfg.Keys)),
+	}
+	for _, key := range cfg.Keys {
+		set.keys[sha256.Sum256([]byte(key.Key))] = &Principal{
+			Name:   key.Name,
+			Models: slices.Clone(key.Models),
+		}
+	}
+	a.state.Store(set)
+}
+
+// Enabled 是否启用鉴权
+func (a *Authenticator) Enabled() bool {
+	return a.state.Load().enabled
+}
+
+// Authenticate 根据Authorization请求头查找调用方
+// 返回：
+//   - *Principal: 调用方（Key不存在时为nil）
+//   - bool: Key是否有效
+func (a *Authenticator) Authenticate(authorization string) (*Principal, bool) {
+	token := BearerToken(authorization)
+	if token == "" {
+		return nil, false
+	}
+
+	p, ok := a.state.Load().keys[sha256.Sum256([]byte(token))]
+	return p, ok
+}
+
+// BearerToken 从Authorization请求头中取出token
+func BearerToken(authorization string) string {
+	return strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+func TestCanAccess(t *testing.T) {
+	tests := []struct {
+		name   string
+		models []string
+		model  string
+		want   bool
+	}{
+		{"empty list", nil, "glm-4", true},
+		{"exact match", []string{"glm-4"}, "glm-4", true},
+		{"not listed", []string{"glm-4"}, "glm-4-flash", false},
+		{"prefix wildcard", []string{"glm-*"}, "glm-4-flash", true},
+		{"prefix wildcard mismatch", []string{"glm-*"}, "gpt-4o", false},
+		{"suffix wildcard", []string{"*-flash"}, "glm-4-flash", true},
+		{"middle wildcard", []string{"glm-*-flash"}, "glm-4-flash", true},
+		{"middle wildcard mismatch", []string{"glm-*-flash"}, "glm-4-plus", false},
+		{"wildcard matches slash", []string{"meta-llama/*"}, "meta-llama/Llama-3-8B", true},
+		{"match all", []string{"*"}, "anything", true},
+		{"wildcard needs prefix and suffix room", []string{"ab*ba"}, "aba", false},
+		{"second pattern", []string{"gpt-*", "glm-4"}, "glm-4", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Principal{Name: "test", Models: tt.models}
+			if got := p.CanAccess(tt.model); got != tt.want {
+				t.Errorf("CanAccess(%q) with %v = %v, want %v", tt.model, tt.models, got, tt.want)
+			}
+		})
+	}
+
+	var anonymous *Principal
+	if !anonymous.CanAccess("glm-4") {
+		t.Error("nil principal should access every model")
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	a := New(config.AuthConfig{
+		Enabled: true,
+		Keys: []config.APIKeyConfig{
+			{Name: "batch-jobs", Key: "sk-prism-a", Models: []string{"glm-4-flash"}},
+			{Name: "admin", Key: "sk-prism-b"},
+		},
+	})
+	if !a.Enabled() {
+		t.Fatal("Enabled = false")
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantName      string
+		wantOK        bool
+	}{
+		{"valid key", "Bearer sk-prism-a", "batch-jobs", true},
+		{"surrounding spaces", "Bearer  sk-prism-b ", "admin", true},
+		{"unknown key", "Bearer sk-prism-c", "", false},
+		{"missing header", "", "", false},
+		{"empty token", "Bearer ", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := a.Authenticate(tt.authorization)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				if p != nil {
+					t.Errorf("principal = %+v, want nil", p)
+				}
+				return
+			}
+			if p.Name != tt.wantName {
+				t.Errorf("name = %q, want %q", p.Name, tt.wantName)
+			}
+		})
+	}
+
+	p, _ := a.Authenticate("Bearer sk-prism-a")
+	if p.CanAccess("glm-4") || !p.CanAccess("glm-4-flash") {
+		t.Errorf("principal models = %v", p.Models)
+	}
+}
+
+func TestReload(t *testing.T) {
+	models := []string{"glm-4"}
+	a := New(config.AuthConfig{Enabled: true, Keys: []config.APIKeyConfig{{Name: "old", Key: "sk-old", Models: models}}})
+
+	// 配置中的切片被修改不影响已加载的Key
+	models[0] = "gpt-4o"
+	if p, _ := a.Authenticate("Bearer sk-old"); !p.CanAccess("glm-4") {
+		t.Error("principal shares the config slice")
+	}
+
+	old, _ := a.Authenticate("Bearer sk-old")
+	a.Reload(config.AuthConfig{Enabled: false, Keys: []config.APIKeyConfig{{Name: "new", Key: "sk-new"}}})
+
+	if a.Enabled() {
+		t.Error("Enabled = true after reload")
+	}
+	if _, ok := a.Authenticate("Bearer sk-old"); ok {
+		t.Error("removed key still accepted")
+	}
+	if p, ok := a.Authenticate("Bearer sk-new"); !ok || p.Name != "new" {
+		t.Errorf("new key: %+v, %v", p, ok)
+	}
+	// 进行中的请求持有的调用方不受影响
+	if old.Name != "old" || !old.CanAccess("glm-4") {
+		t.Errorf("principal held by in-flight request changed: %+v", old)
+	}
+}
@@ -0,0 +1,281 @@
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// 文件存储使用的扩展名：数据文件和元数据文件
+const (
+	fileDataExt = ".jsonl"
+	fileMetaExt = ".json"
+)
+
+// ErrNotFound 文件或批处理任务不存在（或属于其他API Key）
+var ErrNotFound = errors.New("not found")
+
+// ErrFileTooLarge 上传的文件超过 batch.max_file_size
+var ErrFileTooLarge = errors.New("file too large")
+
+// fileRecord 文件元数据（保存在 {id}.json）
+type fileRecord struct {
+	File File `json:"file"`
+
+	// KeyID 上传文件的调用方API Key指纹，只有同一个Key可以读取和删除
+	KeyID string `json:"key_id,omitempty"`
+}
+
+// FileStore 本地文件存储
+// 每个文件保存为两个文件：{dir}/{id}.jsonl（内容）和 {dir}/{id}.json（元数据）
+type FileStore struct {
+	dir     string
+	maxSize int64
+
+	mu sync.Mutex
+}
+
+// NewFileStore 创建文件存储，并清理上次进程退出时残留的临时文件
+// 参数：
+//   - dir: 存储目录（不存在时自动创建）
+//   - maxSize: 上传文件最大大小（字节）
+func NewFileStore(dir string, maxSize int64) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create files dir failed: %w", err)
+	}
+	removeTempFiles(dir)
+	return &FileStore{dir: dir, maxSize: maxSize}, nil
+}
+
+// Create 保存上传的文件
+// 参数：
+//   - r: 文件内容
+//   - filename: 文件名
+//   - purpose: 用途
+//   - keyID: 调用方API Key指纹
+//
+// 返回：
+//   - *File: 文件信息
+//   - error: 超过大小限制时返回 ErrFileTooLarge
+func (s *FileStore) Create(r io.Reader, filename, purpose, keyID string) (*File, error) {
+	id := model.NewID(model.IDPrefixFile)
+	tmp, err := os.CreateTemp(s.dir, id+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("create file failed: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	// 多读一个字节判断是否超过限制
+	n, copyErr := io.Copy(tmp, io.LimitReader(r, s.maxSize+1))
+	closeErr := tmp.Close()
+	if copyErr != nil || closeErr != nil {
+		return nil, fmt.Errorf("write file failed: %w", errors.Join(copyErr, closeErr))
+	}
+	if n > s.maxSize {
+		return nil, ErrFileTooLarge
+	}
+
+	return s.add(id, tmp.Name(), n, filename, purpose, keyID)
+}
+
+// Import 将已经写好的文件（如批处理的输出文件）加入存储，原文件被移动
+func (s *FileStore) Import(path, filename, purpose, keyID string) (*File, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat file failed: %w", err)
+	}
+	return s.add(model.NewID(model.IDPrefixFile), path, info.Size(), filename, purpose, keyID)
+}
+
+// add 移动数据文件并写入元数据
+func (s *FileStore) add(id, src string, size int64, filename, purpose, keyID string) (*File, error) {
+	rec := fileRecord{
+		File: File{
+			ID:        id,
+			Object:    "file",
+			Bytes:     size,
+			CreatedAt: time.Now().Unix(),
+			Filename:  filename,
+			Purpose:   purpose,
+			Status:    "processed",
+		},
+		KeyID: keyID,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Rename(src, s.dataPath(id)); err != nil {
+		return nil, fmt.Errorf("move file failed: %w", err)
+	}
+	if err := writeJSONFile(s.metaPath(id), &rec); err != nil {
+		os.Remove(s.dataPath(id))
+		return nil, err
+	}
+	return &rec.File, nil
+}
+
+// Get 读取文件信息
+// 返回：不存在或属于其他调用方时返回 ErrNotFound
+func (s *FileStore) Get(id, keyID string) (*File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.load(id)
+	if err != nil || rec.KeyID != keyID {
+		return nil, ErrNotFound
+	}
+	return &rec.File, nil
+}
+
+// Open 打开文件内容（调用方负责关闭）
+func (s *FileStore) Open(id, keyID string) (*os.File, *File, error) {
+	file, err := s.Get(id, keyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(s.dataPath(id))
+	if err != nil {
+		return nil, nil, ErrNotFound
+	}
+	return f, file, nil
+}
+
+// List 列出调用方的文件，按创建时间从新到旧排列
+// 参数：
+//   - keyID: 调用方API Key指纹
+//   - purpose: 只列出指定用途的文件（为空表示全部）
+func (s *FileStore) List(keyID, purpose string) ([]File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("scan files dir failed: %w", err)
+	}
+
+	files := []File{}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), fileMetaExt)
+		if !ok {
+			continue
+		}
+		rec, err := s.load(id)
+		if err != nil || rec.KeyID != keyID || (purpose != "" && rec.File.Purpose != purpose) {
+			continue
+		}
+		files = append(files, rec.File)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].CreatedAt > files[j].CreatedAt
+	})
+	return files, nil
+}
+
+// Delete 删除文件
+// 返回：不存在或属于其他调用方时返回 ErrNotFound
+func (s *FileStore) Delete(id, keyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.load(id)
+	if err != nil || rec.KeyID != keyID {
+		return ErrNotFound
+	}
+	if err := os.Remove(s.metaPath(id)); err != nil {
+		return fmt.Errorf("delete file failed: %w", err)
+	}
+	os.Remove(s.dataPath(id))
+	return nil
+}
+
+// load 读取元数据（调用方持有锁）
+func (s *FileStore) load(id string) (*fileRecord, error) {
+	if !validID(id, model.IDPrefixFile) {
+		return nil, ErrNotFound
+	}
+	var rec fileRecord
+	if err := readJSONFile(s.metaPath(id), &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// dataPath 文件内容的路径（处理器读取输入文件时使用）
+func (s *FileStore) dataPath(id string) string {
+	return filepath.Join(s.dir, id+fileDataExt)
+}
+
+// metaPath 元数据文件的路径
+func (s *FileStore) metaPath(id string) string {
+	return filepath.Join(s.dir, id+fileMetaExt)
+}
+
+// validID 校验ID是否为网关生成的格式（前缀加字母数字），避免路径穿越
+func validID(id, prefix string) bool {
+	suffix, ok := strings.CutPrefix(id, prefix)
+	if !ok || suffix == "" {
+		return false
+	}
+	for _, r := range suffix {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// writeJSONFile 写入JSON文件（先写临时文件再重命名，避免读到写了一半的文件）
+func writeJSONFile(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal %s failed: %w", filepath.Base(path), err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write %s failed: %w", filepath.Base(path), err)
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write %s failed: %w", filepath.Base(path), errors.Join(writeErr, closeErr))
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write %s failed: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// readJSONFile 读取JSON文件
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// removeTempFiles 删除目录中上次进程退出时残留的临时文件
+func removeTempFiles(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tmp") {
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package batch
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), 1024)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	file, err := store.Create(strings.NewReader("line\n"), "input.jsonl", PurposeBatch, "key-a")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if file.Bytes != 5 || file.Filename != "input.jsonl" || file.Purpose != PurposeBatch || file.Object != "file" {
+		t.Errorf("file = %+v", file)
+	}
+
+	f, got, err := store.Open(file.ID, "key-a")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "line\n" || got.ID != file.ID {
+		t.Errorf("Open = %q, %+v", data, got)
+	}
+
+	// 其他API Key看不到该文件
+	if _, err := store.Get(file.ID, "key-b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get with other key: %v, want ErrNotFound", err)
+	}
+	if _, _, err := store.Open(file.ID, "key-b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open with other key: %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(file.ID, "key-b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete with other key: %v, want ErrNotFound", err)
+	}
+	if files, _ := store.List("key-b", ""); len(files) != 0 {
+		t.Errorf("List with other key = %+v", files)
+	}
+
+	if err := store.Delete(file.ID, "key-a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(file.ID, "key-a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: %v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(store.dataPath(file.ID)); !os.IsNotExist(err) {
+		t.Error("file content not removed")
+	}
+}
+
+func TestFileStoreList(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), 1024)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	var ids []string
+	for i, purpose := range []string{PurposeBatch, PurposeBatchOutput, PurposeBatch} {
+		file, err := store.Create(strings.NewReader("x"), "f.jsonl", purpose, "key-a")
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		ids = append(ids, file.ID)
+
+		// 创建时间只精确到秒，直接改写元数据使顺序确定
+		file.CreatedAt = int64(1000 + i)
+		if err := writeJSONFile(store.metaPath(file.ID), &fileRecord{File: *file, KeyID: "key-a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		purpose string
+		want    []string
+	}{
+		{"", []string{ids[2], ids[1], ids[0]}},
+		{PurposeBatch, []string{ids[2], ids[0]}},
+		{PurposeBatchOutput, []string{ids[1]}},
+	}
+	for _, tt := range tests {
+		files, err := store.List("key-a", tt.purpose)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		got := make([]string, len(files))
+		for i, f := range files {
+			got[i] = f.ID
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("List(%q) = %v, want %v", tt.purpose, got, tt.want)
+		}
+	}
+}
+
+func TestFileStoreLimits(t *testing.T) {
+	dir := t.TempDir()
+	leftover := filepath.Join(dir, "file-abc.123.tmp")
+	if err := os.WriteFile(leftover, []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewFileStore(dir, 4)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Error("leftover temp file not removed")
+	}
+
+	if _, err := store.Create(strings.NewReader("12345"), "big.jsonl", PurposeBatch, ""); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("Create over limit: %v, want ErrFileTooLarge", err)
+	}
+	if _, err := store.Create(strings.NewReader("1234"), "ok.jsonl", PurposeBatch, ""); err != nil {
+		t.Errorf("Create at limit: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("temp file %s left behind", entry.Name())
+		}
+	}
+
+	for _, id := range []string{"", "file-", "file-../x", "batch_abc", "file-a/b"} {
+		if _, err := store.Get(id, ""); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) = %v, want ErrNotFound", id, err)
+		}
+	}
+}
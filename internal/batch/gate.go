@@ -0,0 +1,137 @@
+package batch
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// gate 单个适配器的批处理限制
+// 并发数限制同时处理的请求（含重试等待），速率限制两次上游调用之间的最小间隔；
+// 同一个适配器的所有批处理任务共享一个 gate
+type gate struct {
+	slots chan struct{}
+
+	// interval 两次请求之间的最小间隔（0表示不限制速率）
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// newGate 创建适配器的限制
+// 参数：
+//   - concurrency: 同时处理的请求数
+//   - requestsPerMinute: 每分钟最多发出的请求数（0表示不限制）
+func newGate(concurrency, requestsPerMinute int) *gate {
+	g := &gate{slots: make(chan struct{}, concurrency)}
+	if requestsPerMinute > 0 {
+		g.interval = time.Minute / time.Duration(requestsPerMinute)
+	}
+	return g
+}
+
+// acquire 占用一个并发名额，ctx取消时返回错误
+func (g *gate) acquire(ctx context.Context) error {
+	select {
+	case g.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release 释放并发名额
+func (g *gate) release() {
+	<-g.slots
+}
+
+// wait 等待到可以发出下一个请求（每次上游调用前调用，包括重试）
+func (g *gate) wait(ctx context.Context) error {
+	if g.interval == 0 {
+		return ctx.Err()
+	}
+
+	// 预约下一个时间点，等待期间其他请求预约之后的时间点
+	g.mu.Lock()
+	now := time.Now()
+	at := g.next
+	if at.Before(now) {
+		at = now
+	}
+	g.next = at.Add(g.interval)
+	g.mu.Unlock()
+
+	return sleep(ctx, at.Sub(now))
+}
+
+// gates 按适配器名称管理 gate（首次使用时按配置创建）
+type gates struct {
+	cfg config.BatchConfig
+
+	mu    sync.Mutex
+	gates map[string]*gate
+}
+
+// newGates 创建适配器限制集合
+func newGates(cfg config.BatchConfig) *gates {
+	return &gates{cfg: cfg, gates: make(map[string]*gate)}
+}
+
+// reload 替换默认限制和按适配器的覆盖配置
+// 之后首次使用的适配器按新配置创建 gate；已取得旧 gate 的请求继续使用旧 gate 直到结束
+func (gs *gates) reload(cfg config.BatchConfig) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.cfg = cfg
+	gs.gates = make(map[string]*gate)
+}
+
+// get 获取适配器的 gate
+// 并发数和速率优先使用 batch.adapters 中的配置，未配置（或为0）时使用默认值
+func (gs *gates) get(adapter string) *gate {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if g, ok := gs.gates[adapter]; ok {
+		return g
+	}
+
+	concurrency, rpm := gs.cfg.Concurrency, gs.cfg.RequestsPerMinute
+	// viper会将map的key转为小写，先按原名查找，再按小写查找
+	override, ok := gs.cfg.Adapters[adapter]
+	if !ok {
+		override = gs.cfg.Adapters[strings.ToLower(adapter)]
+	}
+	if override.Concurrency > 0 {
+		concurrency = override.Concurrency
+	}
+	if override.RequestsPerMinute > 0 {
+		rpm = override.RequestsPerMinute
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	g := newGate(concurrency, rpm)
+	gs.gates[adapter] = g
+	return g
+}
+
+// sleep 等待指定时间，ctx取消时提前返回错误
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,89 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+func TestGatesConfig(t *testing.T) {
+	gs := newGates(config.BatchConfig{
+		Concurrency:       4,
+		RequestsPerMinute: 60,
+		Adapters: map[string]config.BatchAdapterConfig{
+			// viper 加载的配置中 key 为小写
+			"glm":    {Concurrency: 2},
+			"openai": {RequestsPerMinute: 600},
+		},
+	})
+
+	tests := []struct {
+		adapter         string
+		wantConcurrency int
+		wantInterval    time.Duration
+	}{
+		{"GLM", 2, time.Second},
+		{"openai", 4, 100 * time.Millisecond},
+		{"other", 4, time.Second},
+	}
+	for _, tt := range tests {
+		g := gs.get(tt.adapter)
+		if cap(g.slots) != tt.wantConcurrency || g.interval != tt.wantInterval {
+			t.Errorf("gate %s = concurrency %d, interval %v, want %d, %v",
+				tt.adapter, cap(g.slots), g.interval, tt.wantConcurrency, tt.wantInterval)
+		}
+		if gs.get(tt.adapter) != g {
+			t.Errorf("gate %s not shared", tt.adapter)
+		}
+	}
+
+	// 未配置并发数时至少为1，未配置速率时不限制
+	if g := newGates(config.BatchConfig{}).get("glm"); cap(g.slots) != 1 || g.interval != 0 {
+		t.Errorf("default gate = concurrency %d, interval %v", cap(g.slots), g.interval)
+	}
+}
+
+func TestGateAcquire(t *testing.T) {
+	g := newGate(1, 0)
+	if err := g.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	// 名额用完时等待，ctx 取消后返回错误
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := g.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire when full = %v, want deadline exceeded", err)
+	}
+
+	g.release()
+	if err := g.acquire(context.Background()); err != nil {
+		t.Errorf("acquire after release: %v", err)
+	}
+}
+
+func TestGateWait(t *testing.T) {
+	// 每分钟1200次：相邻请求至少间隔50ms
+	g := newGate(1, 1200)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := g.wait(context.Background()); err != nil {
+			t.Fatalf("wait: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("4 requests took %v, want at least 150ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := g.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait with canceled ctx = %v, want canceled", err)
+	}
+	if err := newGate(1, 0).wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("unlimited wait with canceled ctx = %v, want canceled", err)
+	}
+}
@@ -0,0 +1,217 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/adapter/upstream"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/structured"
+)
+
+// errStopReading readLines 的回调返回该错误时停止读取（不视为失败）
+var errStopReading = errors.New("stop reading")
+
+// readLines 逐行读取输入文件，空行跳过
+// 参数：
+//   - path: 输入文件路径
+//   - fn: 每行的回调（lineNo 从1开始；行无法解析时 err 不为nil），返回 errStopReading 时停止
+func readLines(path string, fn func(lineNo int, line *RequestLine, err error) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open input file failed: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for lineNo := 1; ; lineNo++ {
+		data, readErr := r.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("read input file failed: %w", readErr)
+		}
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			line, err := parseLine(data, lineNo)
+			if err := fn(lineNo, line, err); err != nil {
+				if errors.Is(err, errStopReading) {
+					return nil
+				}
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+// parseLine 解析输入文件中的一行
+// 没有 body 字段时整行按聊天请求解析；没有 custom_id 时使用 request-{行号}
+func parseLine(data []byte, lineNo int) (*RequestLine, error) {
+	var line RequestLine
+	if err := json.Unmarshal(data, &line); err != nil {
+		return nil, err
+	}
+	if line.Body == nil {
+		var req model.ChatRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, err
+		}
+		line.Body = &req
+	}
+	if line.CustomID == "" {
+		line.CustomID = fmt.Sprintf("request-%d", lineNo)
+	}
+	return &line, nil
+}
+
+// resultWriter 追加写入输出/错误文件（多个请求并发写入）
+type resultWriter struct {
+	mu     sync.Mutex
+	output *os.File
+	errors *os.File
+}
+
+// openResultWriter 以追加模式打开输出/错误文件
+func openResultWriter(outputPath, errorPath string) (*resultWriter, error) {
+	output, err := os.OpenFile(outputPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open output file failed: %w", err)
+	}
+	errFile, err := os.OpenFile(errorPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		output.Close()
+		return nil, fmt.Errorf("open error file failed: %w", err)
+	}
+	return &resultWriter{output: output, errors: errFile}, nil
+}
+
+// write 写入一行结果（failed 决定写入错误文件还是输出文件）
+// 一行一次 Write 调用，进程中途退出时最多留下最后一行不完整（恢复时截掉）
+func (w *resultWriter) write(result *ResultLine, failed bool) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	f := w.output
+	if failed {
+		f = w.errors
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// close 关闭文件
+func (w *resultWriter) close() error {
+	return errors.Join(w.output.Close(), w.errors.Close())
+}
+
+// scanResults 读取已写入的结果，用于任务恢复时跳过已处理的请求
+// 末尾不完整的行（进程写入一半时退出）会被截掉
+// 返回：
+//   - int: 完整的结果行数
+//   - error: 读取失败
+func scanResults(path string, done map[string]bool) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read result file failed: %w", err)
+	}
+
+	count := 0
+	valid := 0
+	for valid < len(data) {
+		end := bytes.IndexByte(data[valid:], '\n')
+		if end < 0 {
+			break
+		}
+		var result ResultLine
+		if err := json.Unmarshal(data[valid:valid+end], &result); err != nil {
+			break
+		}
+		done[result.CustomID] = true
+		count++
+		valid += end + 1
+	}
+	if valid < len(data) {
+		if err := os.Truncate(path, int64(valid)); err != nil {
+			return 0, fmt.Errorf("truncate result file failed: %w", err)
+		}
+	}
+	return count, nil
+}
+
+// errorResult 将适配器错误转换为结果中的错误响应
+//   - 上游返回非200状态码：沿用上游的状态码（429为 rate_limit_error）
+//   - 模型未注册：not_found_error（404）
+//   - 适配器在健康探测中不可用：service_unavailable_error（503）
+//   - response_format 中的Schema无法编译：invalid_request_error（400）
+//   - 模型输出重试后仍不符合 response_format：api_error（500），code为 invalid_model_output
+//   - 其他错误：api_error（500）
+//
+// 返回：
+//   - int: 结果中的HTTP状态码
+//   - *model.ErrorResponse: 错误响应
+func errorResult(err error) (int, *model.ErrorResponse) {
+	var statusErr *upstream.StatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode == http.StatusTooManyRequests {
+			return statusErr.StatusCode, model.NewRateLimitError(err.Error())
+		}
+		return statusErr.StatusCode, model.NewAPIError(err.Error()).WithCode("upstream_error")
+	}
+
+	var errResp *model.ErrorResponse
+	switch {
+	case errors.Is(err, adapter.ErrModelNotFound):
+		errResp = model.NewNotFoundError("model").WithCode("model_not_found")
+	case errors.Is(err, adapter.ErrAdapterUnavailable):
+		errResp = model.NewUnavailableError("上游暂时不可用").WithCode("upstream_unavailable")
+	default:
+		var schemaErr *structured.SchemaError
+		var validationErr *structured.ValidationError
+		switch {
+		case errors.As(err, &schemaErr):
+			errResp = model.NewInvalidRequestError(schemaErr.Error(), "response_format").WithCode("invalid_json_schema")
+		case errors.As(err, &validationErr):
+			errResp = model.NewAPIError(validationErr.Error()).WithCode("invalid_model_output")
+		default:
+			errResp = model.NewAPIError("模型调用失败: " + err.Error())
+		}
+	}
+	return errResp.GetHTTPStatus(), errResp
+}
+
+// retryable 判断失败的请求是否值得重试
+//   - 上游返回429、408、5xx：重试
+//   - 适配器暂时不可用、网络错误、上游超时：重试
+//   - 模型未注册、Schema错误、输出不符合格式（结构化输出已经重试过）、其他4xx：不重试
+func retryable(err error) bool {
+	var statusErr *upstream.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable()
+	}
+	var schemaErr *structured.SchemaError
+	var validationErr *structured.ValidationError
+	switch {
+	case errors.Is(err, adapter.ErrModelNotFound), errors.As(err, &schemaErr), errors.As(err, &validationErr):
+		return false
+	case errors.Is(err, context.Canceled):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,189 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/adapter/upstream"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/structured"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantCustomID string
+		wantMethod   string
+		wantModel    string
+		wantErr      bool
+	}{
+		{
+			name:         "openai format",
+			data:         `{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"glm-4","messages":[{"role":"user","content":"hi"}]}}`,
+			wantCustomID: "a",
+			wantMethod:   "POST",
+			wantModel:    "glm-4",
+		},
+		{
+			name:         "bare chat request with custom_id",
+			data:         `{"custom_id":"b","model":"glm-4","messages":[{"role":"user","content":"hi"}]}`,
+			wantCustomID: "b",
+			wantModel:    "glm-4",
+		},
+		{
+			name:         "default custom_id",
+			data:         `{"model":"glm-4","messages":[{"role":"user","content":"hi"}]}`,
+			wantCustomID: "request-7",
+			wantModel:    "glm-4",
+		},
+		{name: "invalid json", data: `{"custom_id":`, wantErr: true},
+		{name: "invalid body", data: `{"custom_id":"c","body":{"messages":"x"}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, err := parseLine([]byte(tt.data), 7)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if line.CustomID != tt.wantCustomID || line.Method != tt.wantMethod || line.Body.Model != tt.wantModel {
+				t.Errorf("line = %+v, body model %s", line, line.Body.Model)
+			}
+		})
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.jsonl")
+	content := `{"custom_id":"a","model":"m"}` + "\n\n" +
+		"not json\n" +
+		`  {"custom_id":"c","model":"m"}  ` + "\n" +
+		`{"custom_id":"d","model":"m"}` // 最后一行没有换行符
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	err := readLines(path, func(lineNo int, line *RequestLine, err error) error {
+		if err != nil {
+			got = append(got, fmt.Sprintf("%d:error", lineNo))
+		} else {
+			got = append(got, fmt.Sprintf("%d:%s", lineNo, line.CustomID))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readLines: %v", err)
+	}
+	want := []string{"1:a", "3:error", "4:c", "5:d"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+
+	// errStopReading 停止读取且不视为失败
+	count := 0
+	err = readLines(path, func(int, *RequestLine, error) error {
+		count++
+		return errStopReading
+	})
+	if err != nil || count != 1 {
+		t.Errorf("stop reading: count = %d, err = %v", count, err)
+	}
+
+	// 其他错误原样返回
+	boom := errors.New("boom")
+	if err := readLines(path, func(int, *RequestLine, error) error { return boom }); !errors.Is(err, boom) {
+		t.Errorf("callback error = %v, want %v", err, boom)
+	}
+	if err := readLines(filepath.Join(t.TempDir(), "missing"), nil); err == nil {
+		t.Error("readLines on missing file succeeded")
+	}
+}
+
+func TestScanResults(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantCount int
+		wantIDs   []string
+		wantSize  int
+	}{
+		{"complete lines", `{"custom_id":"a"}` + "\n" + `{"custom_id":"b"}` + "\n", 2, []string{"a", "b"}, 36},
+		// 进程写入一半时退出，末尾不完整的行被截掉
+		{"partial last line", `{"custom_id":"a"}` + "\n" + `{"custom_id":"b"`, 1, []string{"a"}, 18},
+		{"corrupt line stops scan", `{"custom_id":"a"}` + "\n" + "garbage\n" + `{"custom_id":"c"}` + "\n", 1, []string{"a"}, 18},
+		{"empty", "", 0, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "results.jsonl")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			done := make(map[string]bool)
+			count, err := scanResults(path, done)
+			if err != nil {
+				t.Fatalf("scanResults: %v", err)
+			}
+			if count != tt.wantCount || len(done) != len(tt.wantIDs) {
+				t.Errorf("count = %d, done = %v, want %d %v", count, done, tt.wantCount, tt.wantIDs)
+			}
+			for _, id := range tt.wantIDs {
+				if !done[id] {
+					t.Errorf("%s not marked done", id)
+				}
+			}
+			if info, _ := os.Stat(path); info.Size() != int64(tt.wantSize) {
+				t.Errorf("file size = %d, want %d", info.Size(), tt.wantSize)
+			}
+		})
+	}
+
+	count, err := scanResults(filepath.Join(t.TempDir(), "missing"), map[string]bool{})
+	if count != 0 || err != nil {
+		t.Errorf("missing file: count = %d, err = %v", count, err)
+	}
+}
+
+func TestErrorResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantType   string
+		wantCode   string
+		wantRetry  bool
+	}{
+		{"upstream 429", &upstream.StatusError{Provider: "GLM", StatusCode: http.StatusTooManyRequests}, 429, model.ErrorTypeRateLimit, "rate_limit_exceeded", true},
+		{"upstream 503", &upstream.StatusError{Provider: "GLM", StatusCode: http.StatusServiceUnavailable}, 503, model.ErrorTypeAPIError, "upstream_error", true},
+		{"upstream 400", &upstream.StatusError{Provider: "GLM", StatusCode: http.StatusBadRequest}, 400, model.ErrorTypeAPIError, "upstream_error", false},
+		{"model not found", fmt.Errorf("route: %w", adapter.ErrModelNotFound), 404, model.ErrorTypeNotFound, "model_not_found", false},
+		{"adapter unavailable", adapter.ErrAdapterUnavailable, 503, model.ErrorTypeUnavailable, "upstream_unavailable", true},
+		{"schema error", &structured.SchemaError{Err: errors.New("bad")}, 400, model.ErrorTypeInvalidRequest, "invalid_json_schema", false},
+		{"invalid output", &structured.ValidationError{Attempts: 2, Err: errors.New("bad")}, 500, model.ErrorTypeAPIError, "invalid_model_output", false},
+		{"canceled", context.Canceled, 500, model.ErrorTypeAPIError, "", false},
+		{"network error", errors.New("connection reset"), 500, model.ErrorTypeAPIError, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, errResp := errorResult(tt.err)
+			if status != tt.wantStatus || errResp.Error.Type != tt.wantType || errResp.Error.Code != tt.wantCode {
+				t.Errorf("errorResult = %d %s/%s, want %d %s/%s",
+					status, errResp.Error.Type, errResp.Error.Code, tt.wantStatus, tt.wantType, tt.wantCode)
+			}
+			if got := retryable(tt.err); got != tt.wantRetry {
+				t.Errorf("retryable = %v, want %v", got, tt.wantRetry)
+			}
+		})
+	}
+}
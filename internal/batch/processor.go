@@ -0,0 +1,830 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/audit"
+	"github.com/AtSunset1/prism/internal/auth"
+	"github.com/AtSunset1/prism/internal/cache"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/pipeline"
+	"github.com/AtSunset1/prism/pkg/config"
+	"github.com/AtSunset1/prism/pkg/requestid"
+	"go.uber.org/zap"
+)
+
+// completionWindow 完成期限（CompletionWindow 对应的时长）
+const completionWindow = 24 * time.Hour
+
+// maxLineErrors 校验失败时最多报告的错误数
+const maxLineErrors = 100
+
+// persistInterval 任务执行过程中保存进度的间隔
+// 进程异常退出时进度以结果文件为准，这里只影响 GET 读取到的计数
+const persistInterval = 5 * time.Second
+
+// 任务目录中的文件扩展名：任务状态、执行中的输出文件和错误文件
+const (
+	jobExt    = ".json"
+	outputExt = ".output.jsonl"
+	errorsExt = ".errors.jsonl"
+)
+
+// ErrNotCancellable 任务已结束，无法取消
+var ErrNotCancellable = errors.New("batch is not cancellable")
+
+// AdapterResolver 按模型名称查找适配器（用于确认模型已注册和按适配器限流）
+// *adapter.AdapterManager 实现了该接口
+type AdapterResolver interface {
+	GetAdapter(modelName string) (adapter.ModelAdapter, error)
+}
+
+// jobRecord 任务状态（保存在 {id}.json）
+type jobRecord struct {
+	Batch Batch `json:"batch"`
+
+	// KeyID 创建任务的调用方API Key指纹，只有同一个Key可以读取和取消
+	KeyID string `json:"key_id,omitempty"`
+
+	// Models 调用方允许访问的模型（为空表示全部），校验输入文件时使用
+	Models []string `json:"models,omitempty"`
+}
+
+// job 一个批处理任务
+type job struct {
+	mu  sync.Mutex
+	rec jobRecord
+
+	// cancel 中断任务执行（任务开始执行后设置）
+	cancel context.CancelFunc
+}
+
+// snapshot 复制当前的任务状态
+func (j *job) snapshot() Batch {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.rec.Batch
+}
+
+// Processor 批处理任务的管理和执行
+// 任务状态保存在 {dir}/{id}.json，执行中的结果追加到 {dir}/{id}.output.jsonl / .errors.jsonl，
+// 任务结束后结果文件移入文件存储；Start 时继续执行上次未完成的任务
+type Processor struct {
+	dir      string
+	files    *FileStore
+	chat     adapter.ModelAdapter
+	resolver AdapterResolver
+	gates    *gates
+
+	// limits 请求数、重试等限制（热加载时整体替换）
+	limits atomic.Pointer[config.BatchConfig]
+
+	// pipeline 上游调用前的请求处理（与在线请求共用：截断、上下文窗口校验、多模态处理）
+	pipeline *pipeline.Pipeline
+
+	// auditor 审计记录器（可选，nil表示不记录）
+	auditor *audit.Auditor
+
+	mu   sync.Mutex
+	jobs map[string]*job
+
+	// ctx 处理器的生命周期（Start 时创建，stop 时取消）
+	ctx context.Context
+	wg  sync.WaitGroup
+}
+
+// Option Processor的可选配置
+type Option func(*Processor)
+
+// WithPipeline 设置上游调用前的请求处理流程（应与在线请求使用同一个）
+// 未设置时只校验上下文窗口，不截断、不处理多模态内容
+func WithPipeline(p *pipeline.Pipeline) Option {
+	return func(proc *Processor) {
+		proc.pipeline = p
+	}
+}
+
+// WithAuditor 为批处理中的每个请求记录审计日志
+func WithAuditor(auditor *audit.Auditor) Option {
+	return func(p *Processor) {
+		p.auditor = auditor
+	}
+}
+
+// NewProcessor 创建批处理器，并加载已保存的任务
+// 参数：
+//   - cfg: 批处理配置
+//   - files: 文件存储（读取输入文件、保存结果文件）
+//   - chat: 聊天适配器（与在线请求使用同一条适配器链：缓存、结构化输出校验、路由）
+//   - resolver: 按模型查找适配器
+//   - opts: 可选配置，如 WithPipeline、WithAuditor
+func NewProcessor(cfg config.BatchConfig, files *FileStore, chat adapter.ModelAdapter, resolver AdapterResolver, opts ...Option) (*Processor, error) {
+	dir := filepath.Join(cfg.Dir, "batches")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create batches dir failed: %w", err)
+	}
+	removeTempFiles(dir)
+
+	p := &Processor{
+		dir:      dir,
+		files:    files,
+		chat:     chat,
+		resolver: resolver,
+		gates:    newGates(cfg),
+		pipeline: pipeline.New(),
+		jobs:     make(map[string]*job),
+	}
+	p.limits.Store(&cfg)
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("scan batches dir failed: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, jobExt) || strings.HasSuffix(name, outputExt) || strings.HasSuffix(name, errorsExt) {
+			continue
+		}
+		var rec jobRecord
+		if err := readJSONFile(filepath.Join(dir, name), &rec); err != nil {
+			zap.L().Warn("批处理任务文件损坏，已跳过", zap.String("file", name), zap.Error(err))
+			continue
+		}
+		p.jobs[rec.Batch.ID] = &job{rec: rec}
+	}
+	return p, nil
+}
+
+// Reload 替换请求数、并发数、速率和重试限制
+// 存储目录和文件大小限制需要重启后生效；执行中的请求继续使用原来的并发名额和速率
+func (p *Processor) Reload(cfg config.BatchConfig) {
+	p.limits.Store(&cfg)
+	p.gates.reload(cfg)
+}
+
+// Start 开始执行任务：继续上次未完成的任务，之后创建的任务立即开始
+// 返回：
+//   - stop: 停止执行（等待执行中的请求退出）；未完成的任务保持原状态，下次 Start 时继续
+func (p *Processor) Start() (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p.mu.Lock()
+	p.ctx = ctx
+	resumed := 0
+	for _, j := range p.jobs {
+		if !j.rec.Batch.Done() {
+			p.launch(j)
+			resumed++
+		}
+	}
+	p.mu.Unlock()
+
+	if resumed > 0 {
+		zap.L().Info("继续执行未完成的批处理任务", zap.Int("count", resumed))
+	}
+
+	return func() {
+		cancel()
+		p.wg.Wait()
+	}
+}
+
+// Create 创建批处理任务
+// 输入文件在后台校验，校验通过后开始执行
+// 参数：
+//   - req: 创建请求
+//   - keyID: 调用方API Key指纹
+//   - principal: 调用方（限制输入文件中可以使用的模型，nil表示不限制）
+//
+// 返回：
+//   - *Batch: 创建的任务（status=validating）
+//   - error: 参数不合法时返回 *model.ParamError，输入文件不存在时返回 ErrNotFound
+func (p *Processor) Create(req *CreateRequest, keyID string, principal *auth.Principal) (*Batch, error) {
+	if req.Endpoint != EndpointChatCompletions {
+		return nil, &model.ParamError{Param: "endpoint", Err: fmt.Errorf("endpoint must be %s", EndpointChatCompletions)}
+	}
+	if req.CompletionWindow != CompletionWindow {
+		return nil, &model.ParamError{Param: "completion_window", Err: fmt.Errorf("completion_window must be %s", CompletionWindow)}
+	}
+	file, err := p.files.Get(req.InputFileID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if file.Purpose != PurposeBatch {
+		return nil, &model.ParamError{Param: "input_file_id", Err: fmt.Errorf("input file purpose must be %s", PurposeBatch)}
+	}
+
+	now := time.Now()
+	rec := jobRecord{
+		Batch: Batch{
+			ID:               model.NewID(model.IDPrefixBatch),
+			Object:           "batch",
+			Endpoint:         req.Endpoint,
+			InputFileID:      req.InputFileID,
+			CompletionWindow: req.CompletionWindow,
+			Status:           StatusValidating,
+			CreatedAt:        now.Unix(),
+			ExpiresAt:        now.Add(completionWindow).Unix(),
+			Metadata:         req.Metadata,
+		},
+		KeyID: keyID,
+	}
+	if principal != nil {
+		rec.Models = principal.Models
+	}
+	if rec.Batch.Metadata == nil {
+		rec.Batch.Metadata = map[string]string{}
+	}
+
+	j := &job{rec: rec}
+	if err := p.persist(j); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.jobs[rec.Batch.ID] = j
+	if p.ctx != nil {
+		p.launch(j)
+	}
+	p.mu.Unlock()
+
+	batch := j.snapshot()
+	return &batch, nil
+}
+
+// Get 读取任务状态
+// 返回：不存在或属于其他调用方时返回 ErrNotFound
+func (p *Processor) Get(id, keyID string) (*Batch, error) {
+	j, err := p.find(id, keyID)
+	if err != nil {
+		return nil, err
+	}
+	batch := j.snapshot()
+	return &batch, nil
+}
+
+// List 列出调用方的任务，按创建时间从新到旧排列
+// 参数：
+//   - keyID: 调用方API Key指纹
+//   - after: 从该任务之后开始列出（分页游标，为空表示从头开始）
+//   - limit: 最多返回的任务数
+//
+// 返回：
+//   - []Batch: 任务列表
+//   - bool: 之后是否还有任务
+func (p *Processor) List(keyID, after string, limit int) ([]Batch, bool) {
+	p.mu.Lock()
+	var batches []Batch
+	for _, j := range p.jobs {
+		if j.rec.KeyID == keyID {
+			batches = append(batches, j.snapshot())
+		}
+	}
+	p.mu.Unlock()
+
+	sort.Slice(batches, func(i, k int) bool {
+		if batches[i].CreatedAt != batches[k].CreatedAt {
+			return batches[i].CreatedAt > batches[k].CreatedAt
+		}
+		return batches[i].ID > batches[k].ID
+	})
+	if after != "" {
+		for i, b := range batches {
+			if b.ID == after {
+				batches = batches[i+1:]
+				break
+			}
+		}
+	}
+	if len(batches) > limit {
+		return batches[:limit], true
+	}
+	return batches, false
+}
+
+// Cancel 取消任务
+// 执行中的请求被中断，已完成的结果仍然写入输出文件
+// 返回：
+//   - *Batch: 任务状态（status=cancelling，结束后变为 cancelled）
+//   - error: 不存在时返回 ErrNotFound，已结束时返回 ErrNotCancellable
+func (p *Processor) Cancel(id, keyID string) (*Batch, error) {
+	j, err := p.find(id, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	j.mu.Lock()
+	switch j.rec.Batch.Status {
+	case StatusValidating, StatusInProgress:
+	case StatusCancelling:
+		batch := j.rec.Batch
+		j.mu.Unlock()
+		return &batch, nil
+	default:
+		j.mu.Unlock()
+		return nil, ErrNotCancellable
+	}
+	j.rec.Batch.Status = StatusCancelling
+	j.rec.Batch.CancellingAt = unixNow()
+	cancel := j.cancel
+	j.mu.Unlock()
+
+	if err := p.persist(j); err != nil {
+		zap.L().Warn("保存批处理任务失败", zap.String("batch_id", id), zap.Error(err))
+	}
+	if cancel != nil {
+		cancel()
+	}
+	batch := j.snapshot()
+	return &batch, nil
+}
+
+// find 查找调用方的任务
+func (p *Processor) find(id, keyID string) (*job, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	j, ok := p.jobs[id]
+	if !ok || j.rec.KeyID != keyID {
+		return nil, ErrNotFound
+	}
+	return j, nil
+}
+
+// launch 在后台执行任务（调用方持有 p.mu）
+func (p *Processor) launch(j *job) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run(j)
+	}()
+}
+
+// run 执行任务直到结束（或处理器停止）
+//
+//	validating → in_progress → finalizing → completed / expired / cancelled
+func (p *Processor) run(j *job) {
+	batch := j.snapshot()
+	log := zap.L().With(zap.String("batch_id", batch.ID))
+
+	// 超过完成期限时停止执行，未处理的请求记为过期
+	ctx, cancel := context.WithDeadline(p.ctx, time.Unix(batch.ExpiresAt, 0))
+	defer cancel()
+	j.mu.Lock()
+	j.cancel = cancel
+	j.mu.Unlock()
+
+	// 1. 校验输入文件
+	if batch.Status == StatusValidating {
+		total, lineErrors, err := p.validate(j)
+		if err != nil {
+			lineErrors = []LineError{{Code: "invalid_file", Message: err.Error()}}
+		}
+		if len(lineErrors) > 0 {
+			log.Info("批处理输入文件校验失败", zap.Int("errors", len(lineErrors)))
+			p.transition(j, func(b *Batch) {
+				b.Status = StatusFailed
+				b.FailedAt = unixNow()
+				b.Errors = &Errors{Object: "list", Data: lineErrors}
+			})
+			return
+		}
+		if !p.transition(j, func(b *Batch) {
+			b.Status = StatusInProgress
+			b.InProgressAt = unixNow()
+			b.RequestCounts = RequestCounts{Total: total}
+		}) {
+			// 校验期间被取消
+			p.finalize(j, StatusCancelled)
+			return
+		}
+		log.Info("批处理任务开始执行", zap.Int("total", total))
+	}
+
+	// 2. 执行请求
+	var processErr error
+	if j.snapshot().Status == StatusInProgress {
+		if processErr = p.process(ctx, j); processErr != nil {
+			log.Error("批处理任务执行失败", zap.Error(processErr))
+		}
+	}
+
+	// 3. 处理器停止时保持原状态，下次启动时继续
+	if p.ctx.Err() != nil {
+		p.persist(j)
+		return
+	}
+
+	status := StatusCompleted
+	switch {
+	case j.snapshot().Status == StatusCancelling:
+		status = StatusCancelled
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		status = StatusExpired
+	case processErr != nil:
+		// 输入文件被删除等无法继续执行的错误，已完成的结果仍然保留
+		status = StatusFailed
+		j.mu.Lock()
+		j.rec.Batch.Errors = &Errors{Object: "list", Data: []LineError{{Code: "processing_error", Message: processErr.Error()}}}
+		j.mu.Unlock()
+	}
+	p.finalize(j, status)
+	log.Info("批处理任务结束", zap.String("status", status))
+}
+
+// validate 校验输入文件
+// 返回：
+//   - int: 请求数
+//   - []LineError: 校验错误（最多 maxLineErrors 条）
+//   - error: 文件无法读取
+func (p *Processor) validate(j *job) (int, []LineError, error) {
+	batch := j.snapshot()
+	principal := &auth.Principal{Models: j.rec.Models}
+
+	var (
+		total      int
+		lineErrors []LineError
+		seen       = make(map[string]bool)
+	)
+	addError := func(lineNo int, code, param, message string) {
+		if len(lineErrors) < maxLineErrors {
+			line := lineNo
+			lineErrors = append(lineErrors, LineError{Code: code, Message: message, Param: param, Line: &line})
+		}
+	}
+
+	err := readLines(p.files.dataPath(batch.InputFileID), func(lineNo int, line *RequestLine, err error) error {
+		total++
+		if err != nil {
+			addError(lineNo, "invalid_json", "", "invalid JSON: "+err.Error())
+			return nil
+		}
+		switch {
+		case seen[line.CustomID]:
+			addError(lineNo, "duplicate_custom_id", "custom_id", fmt.Sprintf("custom_id %q is duplicated", line.CustomID))
+		case line.Method != "" && line.Method != "POST":
+			addError(lineNo, "invalid_method", "method", "method must be POST")
+		case line.URL != "" && line.URL != batch.Endpoint:
+			addError(lineNo, "mismatched_url", "url", "url must match the batch endpoint "+batch.Endpoint)
+		case line.Body.Model == "":
+			addError(lineNo, "invalid_request", "body.model", "model is required")
+		case !principal.CanAccess(line.Body.Model):
+			addError(lineNo, "model_not_found", "body.model", fmt.Sprintf("model %s not found", line.Body.Model))
+		default:
+			if _, err := p.resolver.GetAdapter(line.Body.Model); err != nil {
+				addError(lineNo, "model_not_found", "body.model", fmt.Sprintf("model %s not found", line.Body.Model))
+			} else if err := line.Body.Validate(); err != nil {
+				addError(lineNo, "invalid_request", "body", err.Error())
+			}
+		}
+		seen[line.CustomID] = true
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	switch {
+	case total == 0:
+		lineErrors = append(lineErrors, LineError{Code: "empty_file", Message: "input file contains no requests"})
+	case total > p.limits.Load().MaxRequests:
+		lineErrors = append(lineErrors, LineError{
+			Code:    "too_many_requests",
+			Message: fmt.Sprintf("input file contains %d requests (limit %d)", total, p.limits.Load().MaxRequests),
+		})
+	}
+	return total, lineErrors, nil
+}
+
+// process 执行输入文件中尚未处理的请求
+// 请求按适配器占用并发名额后并发执行；过期后剩余的请求写入错误文件，取消或停止时直接返回
+func (p *Processor) process(ctx context.Context, j *job) error {
+	batch := j.snapshot()
+
+	// 1. 从结果文件恢复进度（首次执行时为空）
+	done := make(map[string]bool)
+	completed, err := scanResults(p.path(batch.ID, outputExt), done)
+	if err != nil {
+		return err
+	}
+	failed, err := scanResults(p.path(batch.ID, errorsExt), done)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	j.rec.Batch.RequestCounts.Completed = completed
+	j.rec.Batch.RequestCounts.Failed = failed
+	j.mu.Unlock()
+
+	w, err := openResultWriter(p.path(batch.ID, outputExt), p.path(batch.ID, errorsExt))
+	if err != nil {
+		return err
+	}
+	defer w.close()
+
+	// 2. 定期保存进度
+	stopPersist := make(chan struct{})
+	defer close(stopPersist)
+	go func() {
+		ticker := time.NewTicker(persistInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.persist(j)
+			case <-stopPersist:
+				return
+			}
+		}
+	}()
+
+	// 3. 逐行分发请求
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	return readLines(p.files.dataPath(batch.InputFileID), func(lineNo int, line *RequestLine, err error) error {
+		if err != nil || done[line.CustomID] {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return p.interrupted(ctx, j, w, line)
+		}
+
+		target, err := p.resolver.GetAdapter(line.Body.Model)
+		if err != nil {
+			// 任务执行期间模型被热加载移除
+			status, errResp := errorResult(err)
+			p.record(j, w, line, model.NewID(model.IDPrefixBatchRequest), status, nil, errResp)
+			return nil
+		}
+		g := p.gates.get(target.Name())
+		if err := g.acquire(ctx); err != nil {
+			return p.interrupted(ctx, j, w, line)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer g.release()
+			p.execute(ctx, j, w, g, line)
+		}()
+		return nil
+	})
+}
+
+// interrupted 处理任务中断后遇到的请求
+// 过期时写入 batch_expired 错误并继续处理剩余的请求，取消或停止时停止读取
+func (p *Processor) interrupted(ctx context.Context, j *job, w *resultWriter, line *RequestLine) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return errStopReading
+	}
+	p.expire(j, w, line)
+	return nil
+}
+
+// execute 执行一个请求，限流和上游故障时按退避重试
+// 请求与在线请求经过相同的处理：截断、上下文窗口校验、多模态处理、按调用方隔离的缓存和审计
+// 重试等待期间占用适配器的并发名额，避免故障期间继续向上游施压
+func (p *Processor) execute(ctx context.Context, j *job, w *resultWriter, g *gate, line *RequestLine) {
+	start := time.Now()
+	id := model.NewID(model.IDPrefixBatchRequest)
+	limits := p.limits.Load()
+	req := *line.Body
+	req.Stream = false
+	req.StreamOptions = nil
+	// 保存原始请求副本用于审计（截断、多模态处理都可能修改请求）
+	original := req
+	reqCtx := cache.NewContext(requestid.NewContext(ctx, id), &cache.Lookup{KeyID: j.rec.KeyID})
+
+	if _, errResp := p.pipeline.Prepare(reqCtx, &req); errResp != nil {
+		switch {
+		case ctx.Err() == nil:
+			p.finish(j, w, line, id, &original, start, nil, errResp.GetHTTPStatus(), errResp)
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			p.expire(j, w, line)
+		}
+		return
+	}
+
+	backoff := limits.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		if err := g.wait(ctx); err != nil {
+			break
+		}
+
+		resp, err := p.chat.Chat(reqCtx, &req)
+		if ctx.Err() != nil {
+			break
+		}
+		if err == nil {
+			p.finish(j, w, line, id, &original, start, resp, http.StatusOK, nil)
+			return
+		}
+		if attempt >= limits.MaxRetries || !retryable(err) {
+			status, errResp := errorResult(err)
+			p.finish(j, w, line, id, &original, start, nil, status, errResp)
+			return
+		}
+
+		zap.L().Debug("批处理请求失败，稍后重试",
+			zap.String("batch_id", j.rec.Batch.ID),
+			zap.String("custom_id", line.CustomID),
+			zap.Int("attempt", attempt+1),
+			zap.Error(err),
+		)
+		if err := sleep(ctx, backoff); err != nil {
+			break
+		}
+		backoff *= 2
+		// 之后的调用由 AdapterManager 记入重试指标
+		reqCtx = adapter.WithRetry(reqCtx)
+	}
+
+	// 任务被中断：过期时记为失败，取消或停止时不写入（停止后重新执行）
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		p.expire(j, w, line)
+	}
+}
+
+// finish 写入请求结果并提交审计记录
+// 参数：
+//   - original: 截断、多模态处理之前的请求
+//   - start: 请求开始执行的时间
+//   - resp: 成功时的响应（失败时为nil）
+//   - status: 结果中的HTTP状态码
+//   - errResp: 失败时的错误响应（成功时为nil）
+func (p *Processor) finish(j *job, w *resultWriter, line *RequestLine, id string, original *model.ChatRequest, start time.Time, resp *model.ChatResponse, status int, errResp *model.ErrorResponse) {
+	p.record(j, w, line, id, status, resp, errResp)
+	if p.auditor == nil {
+		return
+	}
+
+	finished := time.Now()
+	rec := &audit.Record{
+		RequestID:  id,
+		KeyID:      j.rec.KeyID,
+		Model:      original.Model,
+		StartedAt:  start,
+		FinishedAt: finished,
+		LatencyMs:  finished.Sub(start).Milliseconds(),
+		Status:     status,
+		Request:    original,
+		Response:   resp,
+	}
+	if resp != nil {
+		usage := resp.Usage
+		rec.Usage = &usage
+	}
+	if errResp != nil {
+		rec.Error = &errResp.Error
+	}
+	p.auditor.Record(rec)
+}
+
+// record 写入请求结果并更新计数
+// 参数：
+//   - status: 结果中的HTTP状态码
+//   - resp: 成功时的响应
+//   - errResp: 失败时的错误响应（为nil表示成功）
+func (p *Processor) record(j *job, w *resultWriter, line *RequestLine, id string, status int, resp *model.ChatResponse, errResp *model.ErrorResponse) {
+	result := &ResultLine{ID: id, CustomID: line.CustomID}
+	if errResp == nil {
+		result.Response = &ResultResponse{StatusCode: status, RequestID: id, Body: resp}
+	} else {
+		result.Response = &ResultResponse{StatusCode: status, RequestID: id, Body: errResp}
+	}
+	p.write(j, w, result, errResp != nil)
+}
+
+// expire 写入过期的请求
+func (p *Processor) expire(j *job, w *resultWriter, line *RequestLine) {
+	p.write(j, w, &ResultLine{
+		ID:       model.NewID(model.IDPrefixBatchRequest),
+		CustomID: line.CustomID,
+		Error:    &ResultError{Code: "batch_expired", Message: "This request could not be executed before the completion window expired."},
+	}, true)
+}
+
+// write 写入一行结果并更新计数
+func (p *Processor) write(j *job, w *resultWriter, result *ResultLine, failed bool) {
+	if err := w.write(result, failed); err != nil {
+		zap.L().Error("写入批处理结果失败", zap.String("batch_id", j.rec.Batch.ID), zap.Error(err))
+		return
+	}
+	j.mu.Lock()
+	if failed {
+		j.rec.Batch.RequestCounts.Failed++
+	} else {
+		j.rec.Batch.RequestCounts.Completed++
+	}
+	j.mu.Unlock()
+}
+
+// finalize 将结果文件移入文件存储并结束任务
+func (p *Processor) finalize(j *job, status string) {
+	j.mu.Lock()
+	if j.rec.Batch.Status != StatusCancelling {
+		j.rec.Batch.Status = StatusFinalizing
+	}
+	if j.rec.Batch.FinalizingAt == nil {
+		j.rec.Batch.FinalizingAt = unixNow()
+	}
+	j.mu.Unlock()
+	p.persist(j)
+	batch := j.snapshot()
+
+	outputID := p.importResult(j, outputExt, "output")
+	errorID := p.importResult(j, errorsExt, "error")
+
+	j.mu.Lock()
+	b := &j.rec.Batch
+	b.Status = status
+	b.OutputFileID = outputID
+	b.ErrorFileID = errorID
+	now := unixNow()
+	switch status {
+	case StatusCompleted:
+		b.CompletedAt = now
+	case StatusExpired:
+		b.ExpiredAt = now
+	case StatusCancelled:
+		b.CancelledAt = now
+	case StatusFailed:
+		b.FailedAt = now
+	}
+	j.mu.Unlock()
+
+	if err := p.persist(j); err != nil {
+		zap.L().Error("保存批处理任务失败", zap.String("batch_id", batch.ID), zap.Error(err))
+	}
+}
+
+// importResult 将非空的结果文件移入文件存储
+// 返回：文件ID（没有结果时为nil）
+func (p *Processor) importResult(j *job, ext, kind string) *string {
+	batch := j.snapshot()
+	path := p.path(batch.ID, ext)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if info.Size() == 0 {
+		os.Remove(path)
+		return nil
+	}
+
+	file, err := p.files.Import(path, batch.ID+"_"+kind+".jsonl", PurposeBatchOutput, j.rec.KeyID)
+	if err != nil {
+		zap.L().Error("保存批处理结果文件失败", zap.String("batch_id", batch.ID), zap.Error(err))
+		return nil
+	}
+	return &file.ID
+}
+
+// transition 在任务未被取消时更新状态并保存
+// 返回：任务已被取消（status=cancelling）时不更新，返回false
+func (p *Processor) transition(j *job, update func(b *Batch)) bool {
+	j.mu.Lock()
+	if j.rec.Batch.Status == StatusCancelling {
+		j.mu.Unlock()
+		return false
+	}
+	update(&j.rec.Batch)
+	j.mu.Unlock()
+
+	if err := p.persist(j); err != nil {
+		zap.L().Warn("保存批处理任务失败", zap.String("batch_id", j.rec.Batch.ID), zap.Error(err))
+	}
+	return true
+}
+
+// persist 保存任务状态
+func (p *Processor) persist(j *job) error {
+	j.mu.Lock()
+	rec := j.rec
+	j.mu.Unlock()
+	return writeJSONFile(p.path(rec.Batch.ID, jobExt), &rec)
+}
+
+// path 任务相关文件的路径
+func (p *Processor) path(id, ext string) string {
+	return filepath.Join(p.dir, id+ext)
+}
+
+// unixNow 当前时间戳（用于可为null的时间戳字段）
+func unixNow() *int64 {
+	now := time.Now().Unix()
+	return &now
+}
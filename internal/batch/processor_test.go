@@ -0,0 +1,694 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/adapter/upstream"
+	"github.com/AtSunset1/prism/internal/audit"
+	"github.com/AtSunset1/prism/internal/auth"
+	"github.com/AtSunset1/prism/internal/cache"
+	"github.com/AtSunset1/prism/internal/media"
+	"github.com/AtSunset1/prism/internal/metrics"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/pipeline"
+	"github.com/AtSunset1/prism/pkg/config"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// stubAdapter 测试用聊天适配器：按 custom_id 对应的用户消息决定结果，并记录调用
+type stubAdapter struct {
+	// reply 返回响应或错误（为nil时返回固定回复）
+	reply func(ctx context.Context, prompt string, attempt int) (*model.ChatResponse, error)
+
+	mu    sync.Mutex
+	calls map[string]int
+	reqs  []*model.ChatRequest
+}
+
+func (s *stubAdapter) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
+	prompt := req.Messages[len(req.Messages)-1].Content.Text()
+	s.mu.Lock()
+	if s.calls == nil {
+		s.calls = make(map[string]int)
+	}
+	attempt := s.calls[prompt]
+	s.calls[prompt]++
+	s.reqs = append(s.reqs, req)
+	s.mu.Unlock()
+
+	if s.reply != nil {
+		return s.reply(ctx, prompt, attempt)
+	}
+	return okResponse(prompt), nil
+}
+
+func (s *stubAdapter) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *stubAdapter) Name() string { return "stub" }
+
+func (s *stubAdapter) HealthCheck(ctx context.Context) error { return nil }
+
+// callCount 返回指定用户消息的调用次数
+func (s *stubAdapter) callCount(prompt string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[prompt]
+}
+
+// stubResolver 只注册 glm-4 和 glm-4-flash
+type stubResolver struct{ adapter *stubAdapter }
+
+func (r stubResolver) GetAdapter(modelName string) (adapter.ModelAdapter, error) {
+	if modelName != "glm-4" && modelName != "glm-4-flash" {
+		return nil, adapter.ErrModelNotFound
+	}
+	return r.adapter, nil
+}
+
+// okResponse 回显用户消息的响应
+func okResponse(prompt string) *model.ChatResponse {
+	return &model.ChatResponse{
+		ID:      "chatcmpl-1",
+		Model:   "glm-4",
+		Choices: []model.Choice{{Message: &model.Message{Role: "assistant", Content: model.NewTextContent("re: " + prompt)}, FinishReason: "stop"}},
+	}
+}
+
+// requestLine 构造输入文件中的一行，用户消息与 custom_id 相同
+func requestLine(customID, modelName string) string {
+	return `{"custom_id":"` + customID + `","method":"POST","url":"/v1/chat/completions","body":{"model":"` + modelName +
+		`","messages":[{"role":"user","content":"` + customID + `"}]}}`
+}
+
+// testBatchConfig 测试用批处理配置
+func testBatchConfig(dir string) config.BatchConfig {
+	return config.BatchConfig{
+		Dir:          dir,
+		MaxRequests:  10,
+		Concurrency:  2,
+		MaxRetries:   2,
+		RetryBackoff: 10 * time.Millisecond,
+	}
+}
+
+// newTestProcessor 在 dir 中创建文件存储和批处理器
+func newTestProcessor(t *testing.T, dir string, chat *stubAdapter) (*Processor, *FileStore) {
+	t.Helper()
+	files, err := NewFileStore(dir+"/files", 1<<20)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	p, err := NewProcessor(testBatchConfig(dir), files, chat, stubResolver{chat})
+	if err != nil {
+		t.Fatalf("NewProcessor: %v", err)
+	}
+	return p, files
+}
+
+// uploadInput 上传输入文件，返回文件ID
+func uploadInput(t *testing.T, files *FileStore, lines ...string) string {
+	t.Helper()
+	file, err := files.Create(strings.NewReader(strings.Join(lines, "\n")+"\n"), "input.jsonl", PurposeBatch, "key-a")
+	if err != nil {
+		t.Fatalf("Create file: %v", err)
+	}
+	return file.ID
+}
+
+// createBatch 创建批处理任务
+func createBatch(t *testing.T, p *Processor, fileID string, principal *auth.Principal) *Batch {
+	t.Helper()
+	batch, err := p.Create(&CreateRequest{InputFileID: fileID, Endpoint: EndpointChatCompletions, CompletionWindow: CompletionWindow}, "key-a", principal)
+	if err != nil {
+		t.Fatalf("Create batch: %v", err)
+	}
+	return batch
+}
+
+// waitBatch 等待任务满足条件
+func waitBatch(t *testing.T, p *Processor, id string, cond func(b *Batch) bool) *Batch {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		batch, err := p.Get(id, "key-a")
+		if err != nil {
+			t.Fatalf("Get batch: %v", err)
+		}
+		if cond(batch) {
+			return batch
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("batch did not reach expected state: %+v", batch)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitDone 等待任务结束
+func waitDone(t *testing.T, p *Processor, id string) *Batch {
+	t.Helper()
+	return waitBatch(t, p, id, func(b *Batch) bool { return b.Done() })
+}
+
+// readResults 读取结果文件，返回 custom_id → 结果
+func readResults(t *testing.T, files *FileStore, fileID *string) map[string]ResultLine {
+	t.Helper()
+	results := make(map[string]ResultLine)
+	if fileID == nil {
+		return results
+	}
+	f, file, err := files.Open(*fileID, "key-a")
+	if err != nil {
+		t.Fatalf("Open result file: %v", err)
+	}
+	defer f.Close()
+	if file.Purpose != PurposeBatchOutput {
+		t.Errorf("result file purpose = %s, want %s", file.Purpose, PurposeBatchOutput)
+	}
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var result ResultLine
+		if err := dec.Decode(&result); err != nil {
+			t.Fatalf("decode result: %v", err)
+		}
+		results[result.CustomID] = result
+	}
+	return results
+}
+
+func TestProcessorLifecycle(t *testing.T) {
+	chat := &stubAdapter{reply: func(ctx context.Context, prompt string, attempt int) (*model.ChatResponse, error) {
+		switch {
+		case prompt == "bad":
+			return nil, &upstream.StatusError{Provider: "stub", StatusCode: http.StatusBadRequest}
+		case prompt == "limited" && attempt == 0:
+			return nil, &upstream.StatusError{Provider: "stub", StatusCode: http.StatusTooManyRequests}
+		case prompt == "down":
+			return nil, &upstream.StatusError{Provider: "stub", StatusCode: http.StatusServiceUnavailable}
+		}
+		return okResponse(prompt), nil
+	}}
+	p, files := newTestProcessor(t, t.TempDir(), chat)
+	stop := p.Start()
+	defer stop()
+
+	fileID := uploadInput(t, files,
+		requestLine("ok", "glm-4"),
+		requestLine("bad", "glm-4"),
+		requestLine("limited", "glm-4-flash"),
+		requestLine("down", "glm-4"),
+	)
+	created := createBatch(t, p, fileID, nil)
+	if created.Status != StatusValidating || created.Object != "batch" || created.Metadata == nil {
+		t.Errorf("created = %+v", created)
+	}
+
+	batch := waitDone(t, p, created.ID)
+	if batch.Status != StatusCompleted {
+		t.Fatalf("status = %s, errors = %+v", batch.Status, batch.Errors)
+	}
+	if batch.RequestCounts != (RequestCounts{Total: 4, Completed: 2, Failed: 2}) {
+		t.Errorf("request_counts = %+v", batch.RequestCounts)
+	}
+	if batch.InProgressAt == nil || batch.FinalizingAt == nil || batch.CompletedAt == nil {
+		t.Errorf("timestamps not set: %+v", batch)
+	}
+
+	output := readResults(t, files, batch.OutputFileID)
+	errorsOut := readResults(t, files, batch.ErrorFileID)
+	tests := []struct {
+		customID   string
+		results    map[string]ResultLine
+		wantStatus int
+		wantCalls  int
+	}{
+		{"ok", output, http.StatusOK, 1},
+		// 429 重试后成功
+		{"limited", output, http.StatusOK, 2},
+		// 4xx 不重试
+		{"bad", errorsOut, http.StatusBadRequest, 1},
+		// 5xx 重试 max_retries 次后记为失败
+		{"down", errorsOut, http.StatusServiceUnavailable, 3},
+	}
+	for _, tt := range tests {
+		result, ok := tt.results[tt.customID]
+		if !ok {
+			t.Errorf("%s: result not found", tt.customID)
+			continue
+		}
+		if result.Response == nil || result.Response.StatusCode != tt.wantStatus || result.Response.RequestID != result.ID {
+			t.Errorf("%s: response = %+v", tt.customID, result.Response)
+		}
+		if !strings.HasPrefix(result.ID, model.IDPrefixBatchRequest) {
+			t.Errorf("%s: id = %s", tt.customID, result.ID)
+		}
+		if calls := chat.callCount(tt.customID); calls != tt.wantCalls {
+			t.Errorf("%s: adapter called %d times, want %d", tt.customID, calls, tt.wantCalls)
+		}
+	}
+	if len(output) != 2 || len(errorsOut) != 2 {
+		t.Errorf("got %d output and %d error lines", len(output), len(errorsOut))
+	}
+
+	// 任务结束后不能取消
+	if _, err := p.Cancel(created.ID, "key-a"); !errors.Is(err, ErrNotCancellable) {
+		t.Errorf("Cancel finished batch = %v, want ErrNotCancellable", err)
+	}
+}
+
+func TestProcessorValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []string
+		principal *auth.Principal
+		wantCodes []string
+	}{
+		{"empty file", []string{""}, nil, []string{"empty_file"}},
+		{"invalid json", []string{requestLine("a", "glm-4"), "{"}, nil, []string{"invalid_json"}},
+		{"duplicate custom_id", []string{requestLine("a", "glm-4"), requestLine("a", "glm-4")}, nil, []string{"duplicate_custom_id"}},
+		{"invalid method", []string{`{"custom_id":"a","method":"GET","body":{"model":"glm-4","messages":[{"role":"user","content":"a"}]}}`}, nil, []string{"invalid_method"}},
+		{"mismatched url", []string{`{"custom_id":"a","url":"/v1/embeddings","body":{"model":"glm-4","messages":[{"role":"user","content":"a"}]}}`}, nil, []string{"mismatched_url"}},
+		{"missing model", []string{`{"custom_id":"a","body":{"messages":[{"role":"user","content":"a"}]}}`}, nil, []string{"invalid_request"}},
+		{"unknown model", []string{requestLine("a", "gpt-9")}, nil, []string{"model_not_found"}},
+		{"model not allowed for key", []string{requestLine("a", "glm-4")}, &auth.Principal{Models: []string{"glm-4-flash"}}, []string{"model_not_found"}},
+		{"invalid body", []string{`{"custom_id":"a","body":{"model":"glm-4","messages":[{"role":"user","content":""}]}}`}, nil, []string{"invalid_request"}},
+		{"too many requests", strings.Split(strings.Repeat(`{"model":"glm-4","messages":[{"role":"user","content":"x"}]}`+"\n", 11), "\n"), nil, []string{"too_many_requests"}},
+		{"multiple errors", []string{"{", requestLine("a", "gpt-9"), requestLine("b", "glm-4")}, nil, []string{"invalid_json", "model_not_found"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := &stubAdapter{}
+			p, files := newTestProcessor(t, t.TempDir(), chat)
+			stop := p.Start()
+			defer stop()
+
+			batch := waitDone(t, p, createBatch(t, p, uploadInput(t, files, tt.lines...), tt.principal).ID)
+			if batch.Status != StatusFailed || batch.Errors == nil || batch.FailedAt == nil {
+				t.Fatalf("batch = %+v", batch)
+			}
+			codes := make([]string, len(batch.Errors.Data))
+			for i, lineErr := range batch.Errors.Data {
+				codes[i] = lineErr.Code
+			}
+			if strings.Join(codes, ",") != strings.Join(tt.wantCodes, ",") {
+				t.Errorf("error codes = %v, want %v", codes, tt.wantCodes)
+			}
+			if len(chat.reqs) != 0 {
+				t.Error("requests were executed for an invalid file")
+			}
+		})
+	}
+}
+
+func TestProcessorCreateErrors(t *testing.T) {
+	p, files := newTestProcessor(t, t.TempDir(), &stubAdapter{})
+	input := uploadInput(t, files, requestLine("a", "glm-4"))
+	output, err := files.Create(strings.NewReader("x"), "out.jsonl", PurposeBatchOutput, "key-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		req       CreateRequest
+		keyID     string
+		wantParam string
+		wantErr   error
+	}{
+		{"endpoint", CreateRequest{InputFileID: input, Endpoint: "/v1/embeddings", CompletionWindow: CompletionWindow}, "key-a", "endpoint", nil},
+		{"completion window", CreateRequest{InputFileID: input, Endpoint: EndpointChatCompletions, CompletionWindow: "1h"}, "key-a", "completion_window", nil},
+		{"file purpose", CreateRequest{InputFileID: output.ID, Endpoint: EndpointChatCompletions, CompletionWindow: CompletionWindow}, "key-a", "input_file_id", nil},
+		{"missing file", CreateRequest{InputFileID: "file-missing", Endpoint: EndpointChatCompletions, CompletionWindow: CompletionWindow}, "key-a", "", ErrNotFound},
+		{"other key's file", CreateRequest{InputFileID: input, Endpoint: EndpointChatCompletions, CompletionWindow: CompletionWindow}, "key-b", "", ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Create(&tt.req, tt.keyID, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Create = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			var paramErr *model.ParamError
+			if !errors.As(err, &paramErr) || paramErr.Param != tt.wantParam {
+				t.Errorf("Create = %v, want param error on %s", err, tt.wantParam)
+			}
+		})
+	}
+}
+
+func TestProcessorCancel(t *testing.T) {
+	// "slow" 请求一直等到被中断
+	chat := &stubAdapter{reply: func(ctx context.Context, prompt string, attempt int) (*model.ChatResponse, error) {
+		if prompt == "slow" {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return okResponse(prompt), nil
+	}}
+	p, files := newTestProcessor(t, t.TempDir(), chat)
+	stop := p.Start()
+	defer stop()
+
+	created := createBatch(t, p, uploadInput(t, files, requestLine("fast", "glm-4"), requestLine("slow", "glm-4")), nil)
+	waitBatch(t, p, created.ID, func(b *Batch) bool { return b.RequestCounts.Completed == 1 && chat.callCount("slow") == 1 })
+
+	if _, err := p.Cancel(created.ID, "key-b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Cancel with other key = %v, want ErrNotFound", err)
+	}
+	cancelling, err := p.Cancel(created.ID, "key-a")
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if cancelling.Status != StatusCancelling && cancelling.Status != StatusCancelled {
+		t.Errorf("status after Cancel = %s", cancelling.Status)
+	}
+
+	batch := waitDone(t, p, created.ID)
+	if batch.Status != StatusCancelled || batch.CancellingAt == nil || batch.CancelledAt == nil {
+		t.Fatalf("batch = %+v", batch)
+	}
+	// 已完成的结果保留，被中断的请求不写入
+	output := readResults(t, files, batch.OutputFileID)
+	if _, ok := output["fast"]; !ok || len(output) != 1 || batch.ErrorFileID != nil {
+		t.Errorf("output = %+v, error file = %v", output, batch.ErrorFileID)
+	}
+}
+
+func TestProcessorResume(t *testing.T) {
+	dir := t.TempDir()
+	first := &stubAdapter{reply: func(ctx context.Context, prompt string, attempt int) (*model.ChatResponse, error) {
+		if prompt == "b" {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return okResponse(prompt), nil
+	}}
+	p, files := newTestProcessor(t, dir, first)
+	stop := p.Start()
+	created := createBatch(t, p, uploadInput(t, files, requestLine("a", "glm-4"), requestLine("b", "glm-4")), nil)
+	waitBatch(t, p, created.ID, func(b *Batch) bool { return b.RequestCounts.Completed == 1 && first.callCount("b") == 1 })
+
+	// 停止时任务保持执行中，已完成的结果留在结果文件中
+	stop()
+	if batch, _ := p.Get(created.ID, "key-a"); batch.Status != StatusInProgress {
+		t.Fatalf("status after stop = %s, want %s", batch.Status, StatusInProgress)
+	}
+
+	// 模拟进程在写入结果时退出，末尾留下不完整的行
+	f, err := os.OpenFile(p.path(created.ID, outputExt), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"id":"batch_req_x","custom_id":"b","resp`)
+	f.Close()
+
+	second := &stubAdapter{}
+	p, files = newTestProcessor(t, dir, second)
+	stop = p.Start()
+	defer stop()
+
+	batch := waitDone(t, p, created.ID)
+	if batch.Status != StatusCompleted || batch.RequestCounts != (RequestCounts{Total: 2, Completed: 2}) {
+		t.Fatalf("batch = %+v", batch)
+	}
+	if second.callCount("a") != 0 || second.callCount("b") != 1 {
+		t.Errorf("resumed calls: a=%d b=%d, want 0 and 1", second.callCount("a"), second.callCount("b"))
+	}
+	output := readResults(t, files, batch.OutputFileID)
+	if len(output) != 2 || output["b"].Response == nil {
+		t.Errorf("output = %+v", output)
+	}
+}
+
+func TestProcessorExpired(t *testing.T) {
+	chat := &stubAdapter{}
+	p, files := newTestProcessor(t, t.TempDir(), chat)
+	created := createBatch(t, p, uploadInput(t, files, requestLine("a", "glm-4"), requestLine("b", "glm-4")), nil)
+
+	// 未启动时创建的任务在 Start 时开始执行；将完成期限改到过去
+	j, _ := p.find(created.ID, "key-a")
+	j.rec.Batch.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	stop := p.Start()
+	defer stop()
+
+	batch := waitDone(t, p, created.ID)
+	if batch.Status != StatusExpired || batch.ExpiredAt == nil || batch.RequestCounts.Failed != 2 {
+		t.Fatalf("batch = %+v", batch)
+	}
+	for customID, result := range readResults(t, files, batch.ErrorFileID) {
+		if result.Error == nil || result.Error.Code != "batch_expired" {
+			t.Errorf("%s: error = %+v", customID, result.Error)
+		}
+	}
+	if len(chat.reqs) != 0 {
+		t.Error("requests were executed after expiry")
+	}
+}
+
+func TestProcessorList(t *testing.T) {
+	dir := t.TempDir()
+	p, files := newTestProcessor(t, dir, &stubAdapter{})
+	input := uploadInput(t, files, requestLine("a", "glm-4"))
+
+	// 未启动时任务保持 validating
+	var created []*Batch
+	for i := 0; i < 3; i++ {
+		created = append(created, createBatch(t, p, input, nil))
+	}
+	// 按创建时间从新到旧，相同时按ID从大到小排列
+	sort.Slice(created, func(i, j int) bool {
+		if created[i].CreatedAt != created[j].CreatedAt {
+			return created[i].CreatedAt > created[j].CreatedAt
+		}
+		return created[i].ID > created[j].ID
+	})
+	want := make([]string, len(created))
+	for i, batch := range created {
+		want[i] = batch.ID
+	}
+
+	page, more := p.List("key-a", "", 2)
+	if len(page) != 2 || !more || page[0].ID != want[0] || page[1].ID != want[1] {
+		t.Errorf("first page = %v, more %v", page, more)
+	}
+	page, more = p.List("key-a", want[1], 2)
+	if len(page) != 1 || more || page[0].ID != want[2] {
+		t.Errorf("second page = %v, more %v", page, more)
+	}
+	if page, _ := p.List("key-b", "", 10); len(page) != 0 {
+		t.Errorf("other key sees %d batches", len(page))
+	}
+
+	// 重新创建的处理器从目录加载任务
+	reloaded, _ := newTestProcessor(t, dir, &stubAdapter{})
+	for _, id := range want {
+		if batch, err := reloaded.Get(id, "key-a"); err != nil || batch.Status != StatusValidating {
+			t.Errorf("reloaded %s = %+v, %v", id, batch, err)
+		}
+	}
+}
+
+// memorySink 测试用审计后端，保存写入的记录
+type memorySink struct {
+	mu      sync.Mutex
+	records []*audit.Record
+}
+
+func (s *memorySink) Write(_ context.Context, rec *audit.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, rec)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+// baseConfig 不限制上下文窗口的配置
+const baseConfig = `
+adapters:
+  glm:
+    api_key: "test-key"
+    base_url: "https://example.com/v1"
+    models: ["glm-4", "glm-4-flash"]
+`
+
+// contextWindowConfig glm-4-flash 的上下文窗口为20个token
+const contextWindowConfig = baseConfig + `
+models:
+  glm-4-flash:
+    context_window: 20
+`
+
+// loadConfig 加载测试配置，测试结束后恢复为不限制上下文窗口的配置
+func loadConfig(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	load := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := config.Load(path); err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+	}
+	load("config.yaml", content)
+	t.Cleanup(func() { load("base.yaml", baseConfig) })
+}
+
+func TestProcessorSharedSteps(t *testing.T) {
+	loadConfig(t, contextWindowConfig)
+	large := "data:image/png;base64," + strings.Repeat("A", 2<<20)
+
+	var (
+		mu      sync.Mutex
+		lookups []*cache.Lookup
+	)
+	chat := &stubAdapter{reply: func(ctx context.Context, prompt string, attempt int) (*model.ChatResponse, error) {
+		mu.Lock()
+		lookups = append(lookups, cache.FromContext(ctx))
+		mu.Unlock()
+		return okResponse(prompt), nil
+	}}
+	sink := &memorySink{}
+	auditor := audit.NewWithSink(sink, false, 16)
+
+	dir := t.TempDir()
+	files, err := NewFileStore(dir+"/files", 4<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewProcessor(testBatchConfig(dir), files, chat, stubResolver{chat},
+		WithPipeline(pipeline.New(pipeline.WithMedia(media.New(config.MediaConfig{MaxImageSize: 1})))),
+		WithAuditor(auditor),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := p.Start()
+	defer stop()
+
+	batch := waitDone(t, p, createBatch(t, p, uploadInput(t, files,
+		requestLine("ok", "glm-4-flash"),
+		`{"custom_id":"long","body":{"model":"glm-4-flash","messages":[{"role":"user","content":"`+strings.Repeat("word ", 100)+`"}]}}`,
+		`{"custom_id":"image","body":{"model":"glm-4","messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"`+large+`"}}]}]}}`,
+	), nil).ID)
+	if batch.RequestCounts != (RequestCounts{Total: 3, Completed: 1, Failed: 2}) {
+		t.Fatalf("request_counts = %+v", batch.RequestCounts)
+	}
+
+	// 超出上下文窗口、多模态内容不合法的请求与在线请求一样返回400，不调用上游
+	errorsOut := readResults(t, files, batch.ErrorFileID)
+	for customID, wantCode := range map[string]string{"long": "context_length_exceeded", "image": ""} {
+		result := errorsOut[customID]
+		if result.Response == nil || result.Response.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: response = %+v", customID, result.Response)
+			continue
+		}
+		body, _ := result.Response.Body.(map[string]any)
+		detail, _ := body["error"].(map[string]any)
+		if detail["type"] != "invalid_request_error" || (wantCode != "" && detail["code"] != wantCode) {
+			t.Errorf("%s: error = %v", customID, detail)
+		}
+	}
+	if len(chat.reqs) != 1 {
+		t.Errorf("adapter called %d times, want 1", len(chat.reqs))
+	}
+
+	// 缓存按创建任务的调用方隔离
+	if len(lookups) != 1 || lookups[0] == nil || lookups[0].KeyID != "key-a" {
+		t.Errorf("cache lookups = %+v", lookups)
+	}
+
+	// 每个请求提交一条审计记录
+	if err := auditor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	statuses := make(map[int]int)
+	for _, rec := range sink.records {
+		statuses[rec.Status]++
+		if rec.KeyID != "key-a" || rec.Request == nil || !strings.HasPrefix(rec.RequestID, model.IDPrefixBatchRequest) {
+			t.Errorf("audit record = %+v", rec)
+		}
+		if rec.Status == http.StatusOK && (rec.Response == nil || rec.Usage == nil) {
+			t.Errorf("audit record without response: %+v", rec)
+		}
+		if rec.Status != http.StatusOK && rec.Error == nil {
+			t.Errorf("audit record without error: %+v", rec)
+		}
+	}
+	if statuses[http.StatusOK] != 1 || statuses[http.StatusBadRequest] != 2 {
+		t.Errorf("audit statuses = %v", statuses)
+	}
+}
+
+func TestProcessorRetryMetric(t *testing.T) {
+	chat := &stubAdapter{reply: func(ctx context.Context, prompt string, attempt int) (*model.ChatResponse, error) {
+		if attempt < 2 {
+			return nil, &upstream.StatusError{Provider: "stub", StatusCode: http.StatusTooManyRequests}
+		}
+		return okResponse(prompt), nil
+	}}
+	manager := adapter.NewAdapterManager()
+	if err := manager.Reload(map[string]adapter.ModelAdapter{"glm-4": chat}); err != nil {
+		t.Fatal(err)
+	}
+	retries := metrics.RetriesTotal.WithLabelValues("glm-4", "stub")
+	before := testutil.ToFloat64(retries)
+
+	dir := t.TempDir()
+	files, err := NewFileStore(dir+"/files", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewProcessor(testBatchConfig(dir), files, manager, manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := p.Start()
+	defer stop()
+
+	batch := waitDone(t, p, createBatch(t, p, uploadInput(t, files, requestLine("limited", "glm-4")), nil).ID)
+	if batch.RequestCounts.Completed != 1 {
+		t.Fatalf("request_counts = %+v", batch.RequestCounts)
+	}
+	// 首次调用不计入，之后的两次调用由 AdapterManager 记为重试
+	if got := testutil.ToFloat64(retries) - before; got != 2 {
+		t.Errorf("retries = %v, want 2", got)
+	}
+}
+
+func TestProcessorReload(t *testing.T) {
+	chat := &stubAdapter{}
+	p, files := newTestProcessor(t, t.TempDir(), chat)
+	stop := p.Start()
+	defer stop()
+
+	cfg := testBatchConfig(t.TempDir())
+	cfg.MaxRequests = 1
+	cfg.Adapters = map[string]config.BatchAdapterConfig{"stub": {Concurrency: 3}}
+	p.Reload(cfg)
+
+	batch := waitDone(t, p, createBatch(t, p, uploadInput(t, files, requestLine("a", "glm-4"), requestLine("b", "glm-4")), nil).ID)
+	if batch.Status != StatusFailed || batch.Errors == nil || batch.Errors.Data[0].Code != "too_many_requests" {
+		t.Fatalf("batch = %+v", batch)
+	}
+	if g := p.gates.get("stub"); cap(g.slots) != 3 {
+		t.Errorf("concurrency = %d, want 3", cap(g.slots))
+	}
+}
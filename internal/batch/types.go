@@ -0,0 +1,230 @@
+// Package batch 实现 OpenAI 风格的 Batch API（/v1/files、/v1/batches）
+// 调用方上传每行一个聊天请求的JSONL文件并创建批处理任务，任务在后台执行：
+// 每个适配器的并发数和速率受限（所有任务共享），限流和上游故障按退避重试，
+// 结果逐行追加到输出/错误文件；任务状态保存在本地目录，进程重启后继续执行
+package batch
+
+import (
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// 文件用途（File.Purpose 的取值）
+const (
+	PurposeBatch       = "batch"
+	PurposeBatchOutput = "batch_output"
+)
+
+// EndpointChatCompletions 批处理支持的接口（目前只有聊天补全）
+const EndpointChatCompletions = "/v1/chat/completions"
+
+// CompletionWindow 批处理的完成期限（与OpenAI一致，只支持24h）
+const CompletionWindow = "24h"
+
+// 批处理状态（Batch.Status 的取值）
+//
+//	validating → in_progress → finalizing → completed
+//	validating → failed（输入文件不合法）
+//	in_progress → cancelling → cancelled
+//	in_progress → expired（超过完成期限，未处理的请求写入错误文件）
+const (
+	StatusValidating = "validating"
+	StatusFailed     = "failed"
+	StatusInProgress = "in_progress"
+	StatusFinalizing = "finalizing"
+	StatusCompleted  = "completed"
+	StatusExpired    = "expired"
+	StatusCancelling = "cancelling"
+	StatusCancelled  = "cancelled"
+)
+
+// File 上传或生成的文件
+type File struct {
+	// ID 文件ID，格式：file-{随机字符串}
+	ID string `json:"id"`
+
+	// Object 固定值："file"
+	Object string `json:"object"`
+
+	// Bytes 文件大小（字节）
+	Bytes int64 `json:"bytes"`
+
+	// CreatedAt 创建时间戳（Unix时间戳，秒）
+	CreatedAt int64 `json:"created_at"`
+
+	// Filename 文件名
+	Filename string `json:"filename"`
+
+	// Purpose 用途：batch（上传的输入文件）, batch_output（批处理的输出/错误文件）
+	Purpose string `json:"purpose"`
+
+	// Status 固定值："processed"（文件上传后即可使用）
+	Status string `json:"status"`
+}
+
+// Batch 批处理任务
+type Batch struct {
+	// ID 任务ID，格式：batch_{随机字符串}
+	ID string `json:"id"`
+
+	// Object 固定值："batch"
+	Object string `json:"object"`
+
+	// Endpoint 请求的接口（/v1/chat/completions）
+	Endpoint string `json:"endpoint"`
+
+	// Errors 输入文件校验失败的原因（status=failed）
+	Errors *Errors `json:"errors"`
+
+	// InputFileID 输入文件ID
+	InputFileID string `json:"input_file_id"`
+
+	// CompletionWindow 完成期限
+	CompletionWindow string `json:"completion_window"`
+
+	// Status 任务状态
+	Status string `json:"status"`
+
+	// OutputFileID 成功请求的结果文件ID（任务结束后生成）
+	OutputFileID *string `json:"output_file_id"`
+
+	// ErrorFileID 失败请求的结果文件ID（任务结束后生成，没有失败的请求时为null）
+	ErrorFileID *string `json:"error_file_id"`
+
+	// 各状态的时间戳（Unix时间戳，秒，未经过该状态时为null）
+	CreatedAt    int64  `json:"created_at"`
+	InProgressAt *int64 `json:"in_progress_at"`
+	ExpiresAt    int64  `json:"expires_at"`
+	FinalizingAt *int64 `json:"finalizing_at"`
+	CompletedAt  *int64 `json:"completed_at"`
+	FailedAt     *int64 `json:"failed_at"`
+	ExpiredAt    *int64 `json:"expired_at"`
+	CancellingAt *int64 `json:"cancelling_at"`
+	CancelledAt  *int64 `json:"cancelled_at"`
+
+	// RequestCounts 请求计数（任务执行过程中持续更新）
+	RequestCounts RequestCounts `json:"request_counts"`
+
+	// Metadata 调用方自定义的键值对
+	Metadata map[string]string `json:"metadata"`
+}
+
+// Done 任务是否已结束（不会再变化）
+func (b *Batch) Done() bool {
+	switch b.Status {
+	case StatusFailed, StatusCompleted, StatusExpired, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// RequestCounts 批处理请求计数
+type RequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// Errors 输入文件校验错误列表
+type Errors struct {
+	// Object 固定值："list"
+	Object string `json:"object"`
+
+	// Data 错误详情
+	Data []LineError `json:"data"`
+}
+
+// LineError 输入文件中一行的校验错误
+type LineError struct {
+	// Code 错误代码，如 invalid_json、duplicate_custom_id、model_not_found
+	Code string `json:"code"`
+
+	// Message 错误消息
+	Message string `json:"message"`
+
+	// Param 出错的字段
+	Param string `json:"param,omitempty"`
+
+	// Line 出错的行号（从1开始，文件级错误为null）
+	Line *int `json:"line"`
+}
+
+// CreateRequest 创建批处理请求（POST /v1/batches）
+type CreateRequest struct {
+	// InputFileID 输入文件ID（purpose=batch）
+	InputFileID string `json:"input_file_id" binding:"required"`
+
+	// Endpoint 请求的接口（只支持 /v1/chat/completions）
+	Endpoint string `json:"endpoint" binding:"required"`
+
+	// CompletionWindow 完成期限（只支持 24h）
+	CompletionWindow string `json:"completion_window" binding:"required"`
+
+	// Metadata 调用方自定义的键值对
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// RequestLine 输入文件中的一行
+// 与OpenAI格式一致：{"custom_id": "...", "method": "POST", "url": "/v1/chat/completions", "body": {...}}；
+// 也接受直接是聊天请求的行（custom_id 可以写在请求中，省略时为 request-{行号}）
+type RequestLine struct {
+	// CustomID 调用方指定的请求标识（在文件中唯一，结果按它对应）
+	CustomID string `json:"custom_id"`
+
+	// Method HTTP方法（只支持 POST，可以省略）
+	Method string `json:"method,omitempty"`
+
+	// URL 请求的接口（必须与批处理的 endpoint 一致，可以省略）
+	URL string `json:"url,omitempty"`
+
+	// Body 聊天请求
+	Body *model.ChatRequest `json:"body"`
+}
+
+// ResultLine 输出/错误文件中的一行
+type ResultLine struct {
+	// ID 结果ID，格式：batch_req_{随机字符串}（同时作为请求上游时的请求ID）
+	ID string `json:"id"`
+
+	// CustomID 对应输入行的 custom_id
+	CustomID string `json:"custom_id"`
+
+	// Response 上游响应（请求已发出时存在）
+	Response *ResultResponse `json:"response"`
+
+	// Error 请求未能执行的原因（如任务过期）
+	Error *ResultError `json:"error"`
+}
+
+// ResultResponse 单个请求的响应
+type ResultResponse struct {
+	// StatusCode HTTP状态码（成功为200）
+	StatusCode int `json:"status_code"`
+
+	// RequestID 请求ID
+	RequestID string `json:"request_id"`
+
+	// Body 响应体：成功时为 ChatResponse，失败时为 ErrorResponse
+	Body any `json:"body"`
+}
+
+// ResultError 请求未能执行的原因
+type ResultError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ListResponse 列表响应（GET /v1/files、GET /v1/batches）
+type ListResponse[T any] struct {
+	Object  string  `json:"object"`
+	Data    []T     `json:"data"`
+	FirstID *string `json:"first_id,omitempty"`
+	LastID  *string `json:"last_id,omitempty"`
+	HasMore bool    `json:"has_more"`
+}
+
+// DeletedFile 删除文件的结果（DELETE /v1/files/{id}）
+type DeletedFile struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Deleted bool   `json:"deleted"`
+}
@@ -0,0 +1,227 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// CachingAdapter 带响应缓存的适配器
+// 包装另一个 ModelAdapter（通常是 AdapterManager），对可缓存的请求先查缓存：
+//   - 命中：直接返回缓存的响应；流式请求回放为合成的SSE数据块
+//   - 未命中：调用被包装的适配器，成功且完整的响应写入缓存
+//
+// 查询顺序为精确匹配、语义匹配（启用时）
+// 缓存控制通过context传递（见 NewContext），结果回填到 Lookup.Result
+type CachingAdapter struct {
+	next              adapter.ModelAdapter
+	store             Store
+	deterministicOnly bool
+
+	// semantic 语义缓存（可选，nil表示只做精确匹配）
+	semantic *Semantic
+}
+
+// Option CachingAdapter的可选配置
+type Option func(*CachingAdapter)
+
+// WithSemantic 启用语义缓存
+func WithSemantic(semantic *Semantic) Option {
+	return func(a *CachingAdapter) {
+		a.semantic = semantic
+	}
+}
+
+// NewCachingAdapter 创建带缓存的适配器
+// 参数：
+//   - next: 被包装的适配器
+//   - store: 缓存后端
+//   - deterministicOnly: 是否只缓存 temperature=0 的请求
+//   - opts: 可选配置，如 WithSemantic
+//
+// 示例：
+//
+//	store, _ := cache.NewStore(cfg.Cache)
+//	chatHandler := handler.NewChatHandler(cache.NewCachingAdapter(manager, store, true))
+func NewCachingAdapter(next adapter.ModelAdapter, store Store, deterministicOnly bool, opts ...Option) *CachingAdapter {
+	a := &CachingAdapter{
+		next:              next,
+		store:             store,
+		deterministicOnly: deterministicOnly,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Chat 普通调用（带缓存）
+func (a *CachingAdapter) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
+	key, lookup, ok := a.prepare(ctx, req)
+	if !ok {
+		return a.next.Chat(ctx, req)
+	}
+
+	cached, query := a.lookup(ctx, key, req, lookup)
+	if cached != nil {
+		return cached, nil
+	}
+
+	resp, err := a.next.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	a.save(ctx, key, lookup, query, resp)
+	return resp, nil
+}
+
+// ChatStream 流式调用（带缓存）
+// 命中时回放缓存的响应；未命中时透传上游数据块，流正常结束后将拼装结果写入缓存
+func (a *CachingAdapter) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
+	key, lookup, ok := a.prepare(ctx, req)
+	if !ok {
+		return a.next.ChatStream(ctx, req)
+	}
+
+	cached, query := a.lookup(ctx, key, req, lookup)
+	if cached != nil {
+		return model.ReplayStream(ctx, cached), nil
+	}
+
+	upstream, err := a.next.ChatStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if lookup.NoStore {
+		return upstream, nil
+	}
+
+	out := make(chan *model.StreamResponse, cap(upstream))
+	go func() {
+		defer close(out)
+
+		acc := model.NewStreamAccumulator()
+		failed := false
+		for chunk := range upstream {
+			failed = failed || chunk.IsError()
+			acc.Add(chunk)
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+			}
+		}
+
+		// 客户端中途断开或上游流读取失败时不缓存残缺的响应
+		if ctx.Err() == nil && !failed {
+			a.save(ctx, key, lookup, query, acc.Response())
+		}
+	}()
+	return out, nil
+}
+
+// Name 返回被包装适配器的名称
+func (a *CachingAdapter) Name() string {
+	return a.next.Name()
+}
+
+// HealthCheck 委托给被包装的适配器
+func (a *CachingAdapter) HealthCheck(ctx context.Context) error {
+	return a.next.HealthCheck(ctx)
+}
+
+// prepare 判断请求是否可缓存并计算缓存键
+// 返回：缓存键、缓存控制（来自context，不存在时使用默认值）、是否可缓存
+func (a *CachingAdapter) prepare(ctx context.Context, req *model.ChatRequest) (string, *Lookup, bool) {
+	if a.deterministicOnly && !IsDeterministic(req) {
+		return "", nil, false
+	}
+
+	key, err := Key(req)
+	if err != nil {
+		logger.FromContext(ctx).Warn("计算缓存键失败，跳过缓存", zap.Error(err))
+		return "", nil, false
+	}
+
+	lookup := FromContext(ctx)
+	if lookup == nil {
+		lookup = &Lookup{}
+	}
+	return key, lookup, true
+}
+
+// lookup 查询缓存（精确匹配优先，其次语义匹配）
+// 返回：
+//   - *model.ChatResponse: 命中时返回响应副本，未命中时为nil
+//   - *semanticQuery: 语义缓存状态（未启用或请求不参与时为nil），供 save 复用
+func (a *CachingAdapter) lookup(ctx context.Context, key string, req *model.ChatRequest, lookup *Lookup) (*model.ChatResponse, *semanticQuery) {
+	var query *semanticQuery
+	if a.semantic != nil {
+		query = a.semantic.query(req, lookup.KeyID)
+	}
+
+	lookup.Result = ResultMiss
+	if lookup.NoCache {
+		return nil, query
+	}
+
+	if cached, hit := a.store.Get(key); hit {
+		lookup.Result = ResultHit
+		return fromCache(cached), query
+	}
+
+	if query == nil {
+		return nil, nil
+	}
+	similarKey, score, found, err := a.semantic.search(ctx, query)
+	if err != nil {
+		logger.FromContext(ctx).Warn("语义缓存查询失败", zap.Error(err))
+		return nil, nil
+	}
+	if !found {
+		return nil, query
+	}
+	cached, hit := a.store.Get(similarKey)
+	if !hit {
+		// 响应已过期或被淘汰，清理对应的向量
+		a.semantic.remove(query, similarKey)
+		return nil, query
+	}
+
+	lookup.Result = ResultSemanticHit
+	lookup.Similarity = score
+	return fromCache(cached), query
+}
+
+// save 保存完整的响应，并在启用语义缓存时记录问题向量
+func (a *CachingAdapter) save(ctx context.Context, key string, lookup *Lookup, query *semanticQuery, resp *model.ChatResponse) {
+	if lookup.NoStore || !resp.IsComplete() {
+		return
+	}
+
+	a.store.Set(key, resp)
+	if query != nil {
+		if err := a.semantic.add(ctx, query, key); err != nil {
+			logger.FromContext(ctx).Warn("记录语义缓存向量失败", zap.Error(err))
+		}
+	}
+}
+
+// fromCache 复制缓存的响应并更新创建时间
+// 缓存后端可能返回共享的实例，调用方修改副本不会影响缓存内容
+func fromCache(cached *model.ChatResponse) *model.ChatResponse {
+	resp := *cached
+	resp.Choices = make([]model.Choice, len(cached.Choices))
+	for i, choice := range cached.Choices {
+		if choice.Message != nil {
+			msg := *choice.Message
+			choice.Message = &msg
+		}
+		resp.Choices[i] = choice
+	}
+	resp.Created = time.Now().Unix()
+	return &resp
+}
@@ -0,0 +1,283 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// stubNext 测试用上游适配器：返回固定响应并统计调用次数
+type stubNext struct {
+	resp   *model.ChatResponse
+	chunks []*model.StreamResponse
+	calls  int
+}
+
+func (s *stubNext) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
+	s.calls++
+	resp := *s.resp
+	return &resp, nil
+}
+
+func (s *stubNext) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
+	s.calls++
+	ch := make(chan *model.StreamResponse, len(s.chunks))
+	for _, chunk := range s.chunks {
+		ch <- chunk
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (s *stubNext) Name() string { return "stub" }
+
+func (s *stubNext) HealthCheck(ctx context.Context) error { return nil }
+
+// stubEmbedder 按文本返回预设向量
+type stubEmbedder map[string][]float32
+
+func (e stubEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e[text], nil
+}
+
+// chatRequest 构造单条用户消息的请求
+func chatRequest(content string, temperature float64) *model.ChatRequest {
+	return &model.ChatRequest{
+		Model:       "glm-4",
+		Messages:    []model.Message{{Role: "user", Content: model.NewTextContent(content)}},
+		Temperature: &temperature,
+	}
+}
+
+// drain 读完数据块并拼装响应
+func drain(ch <-chan *model.StreamResponse) *model.ChatResponse {
+	acc := model.NewStreamAccumulator()
+	for chunk := range ch {
+		acc.Add(chunk)
+	}
+	return acc.Response()
+}
+
+func TestCachingAdapterChat(t *testing.T) {
+	incomplete := &model.ChatResponse{
+		ID:      "partial",
+		Choices: []model.Choice{{Message: &model.Message{Role: "assistant", Content: model.NewTextContent("half")}}},
+	}
+	tests := []struct {
+		name              string
+		resp              *model.ChatResponse
+		deterministicOnly bool
+		temperature       float64
+		second            Lookup
+		wantCalls         int
+		wantResults       [2]string
+	}{
+		{"miss then hit", completeResponse("1", "hi"), true, 0, Lookup{}, 1, [2]string{ResultMiss, ResultHit}},
+		{"no-cache skips lookup", completeResponse("1", "hi"), true, 0, Lookup{NoCache: true}, 2, [2]string{ResultMiss, ResultMiss}},
+		{"non-deterministic bypasses cache", completeResponse("1", "hi"), true, 0.7, Lookup{}, 2, [2]string{"", ""}},
+		{"any temperature when not deterministic-only", completeResponse("1", "hi"), false, 0.7, Lookup{}, 1, [2]string{ResultMiss, ResultHit}},
+		{"incomplete response not stored", incomplete, true, 0, Lookup{}, 2, [2]string{ResultMiss, ResultMiss}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &stubNext{resp: tt.resp}
+			a := NewCachingAdapter(next, NewMemoryStore(10, 0), tt.deterministicOnly)
+
+			lookups := [2]*Lookup{{}, &tt.second}
+			for i, lookup := range lookups {
+				resp, err := a.Chat(NewContext(context.Background(), lookup), chatRequest("hi", tt.temperature))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.ID != tt.resp.ID {
+					t.Errorf("request %d: id = %q", i, resp.ID)
+				}
+				if lookup.Result != tt.wantResults[i] {
+					t.Errorf("request %d: result = %q, want %q", i, lookup.Result, tt.wantResults[i])
+				}
+			}
+			if next.calls != tt.wantCalls {
+				t.Errorf("upstream calls = %d, want %d", next.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCachingAdapterNoStore(t *testing.T) {
+	next := &stubNext{resp: completeResponse("1", "hi")}
+	store := NewMemoryStore(10, 0)
+	a := NewCachingAdapter(next, store, true)
+
+	ctx := NewContext(context.Background(), &Lookup{NoStore: true})
+	if _, err := a.Chat(ctx, chatRequest("hi", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if store.Len() != 0 {
+		t.Errorf("store len = %d, want 0", store.Len())
+	}
+}
+
+func TestCachingAdapterHitReturnsCopy(t *testing.T) {
+	next := &stubNext{resp: completeResponse("1", "hi")}
+	a := NewCachingAdapter(next, NewMemoryStore(10, 0), true)
+
+	a.Chat(context.Background(), chatRequest("hi", 0))
+	first, _ := a.Chat(context.Background(), chatRequest("hi", 0))
+	first.Choices[0].Message.Content = model.NewTextContent("modified")
+
+	second, _ := a.Chat(context.Background(), chatRequest("hi", 0))
+	if got := second.Choices[0].Message.Content.Text(); got != "hi" {
+		t.Errorf("cached content = %q, want hi", got)
+	}
+}
+
+func TestCachingAdapterStream(t *testing.T) {
+	stop := "stop"
+	next := &stubNext{chunks: []*model.StreamResponse{
+		model.NewStreamResponse("chatcmpl-1", "glm-4", "", true),
+		model.NewStreamResponse("chatcmpl-1", "glm-4", strings.Repeat("流式内容", 20), false),
+		{ID: "chatcmpl-1", Model: "glm-4", Choices: []model.StreamChoice{{FinishReason: &stop}}},
+	}}
+	a := NewCachingAdapter(next, NewMemoryStore(10, 0), true)
+
+	req := chatRequest("hi", 0)
+	req.Stream = true
+	miss := &Lookup{}
+	ch, err := a.ChatStream(NewContext(context.Background(), miss), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := drain(ch)
+	if miss.Result != ResultMiss {
+		t.Errorf("first result = %q, want miss", miss.Result)
+	}
+
+	// 流式结果写入缓存后，非流式和流式请求都能命中
+	resp, err := a.Chat(context.Background(), chatRequest("hi", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Choices[0].Message.Content.Text() != want.Choices[0].Message.Content.Text() {
+		t.Errorf("non-stream hit content = %q", resp.Choices[0].Message.Content.Text())
+	}
+
+	hit := &Lookup{}
+	ch, err = a.ChatStream(NewContext(context.Background(), hit), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := drain(ch)
+	if hit.Result != ResultHit {
+		t.Errorf("replay result = %q, want hit", hit.Result)
+	}
+	if got := replayed.Choices[0].Message.Content.Text(); got != want.Choices[0].Message.Content.Text() {
+		t.Errorf("replayed content = %q", got)
+	}
+	if replayed.Choices[0].FinishReason != "stop" {
+		t.Errorf("replayed finish_reason = %q", replayed.Choices[0].FinishReason)
+	}
+	if next.calls != 1 {
+		t.Errorf("upstream calls = %d, want 1", next.calls)
+	}
+}
+
+func TestCachingAdapterStreamErrorNotStored(t *testing.T) {
+	next := &stubNext{chunks: []*model.StreamResponse{
+		model.NewStreamResponse("chatcmpl-1", "glm-4", "partial", false),
+		model.NewStreamErrorResponse(errors.New("upstream closed")),
+	}}
+	store := NewMemoryStore(10, 0)
+	a := NewCachingAdapter(next, store, true)
+
+	ch, err := a.ChatStream(context.Background(), chatRequest("hi", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	drain(ch)
+	if store.Len() != 0 {
+		t.Errorf("store len = %d, want 0", store.Len())
+	}
+}
+
+func TestCachingAdapterSemantic(t *testing.T) {
+	embedder := stubEmbedder{
+		"今天天气怎么样":  {1, 0},
+		"今天天气如何":   {0.99, 0.05},
+		"给我讲个笑话":   {0, 1},
+		"今天天气怎么样?": {1, 0},
+	}
+	semantic := NewSemantic(embedder, NewBruteForceIndex(10), 0.95, true, true)
+	next := &stubNext{resp: completeResponse("1", "晴")}
+	a := NewCachingAdapter(next, NewMemoryStore(10, 0), true, WithSemantic(semantic))
+
+	tests := []struct {
+		name   string
+		prompt string
+		keyID  string
+		want   string
+	}{
+		{"first question", "今天天气怎么样", "key_a", ResultMiss},
+		{"exact repeat", "今天天气怎么样", "key_a", ResultHit},
+		{"similar question", "今天天气如何", "key_a", ResultSemanticHit},
+		{"unrelated question", "给我讲个笑话", "key_a", ResultMiss},
+		// 精确缓存键不区分调用方，换一个向量相同的问题验证按Key隔离
+		{"other api key", "今天天气怎么样?", "key_b", ResultMiss},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := &Lookup{KeyID: tt.keyID}
+			if _, err := a.Chat(NewContext(context.Background(), lookup), chatRequest(tt.prompt, 0)); err != nil {
+				t.Fatal(err)
+			}
+			if lookup.Result != tt.want {
+				t.Errorf("result = %q, want %q", lookup.Result, tt.want)
+			}
+			if tt.want == ResultSemanticHit && lookup.Similarity < 0.95 {
+				t.Errorf("similarity = %v", lookup.Similarity)
+			}
+		})
+	}
+}
+
+func TestCachingAdapterSemanticContext(t *testing.T) {
+	embedder := stubEmbedder{"今天天气怎么样": {1, 0}, "今天天气如何": {0.99, 0.05}}
+	next := &stubNext{resp: completeResponse("1", "晴")}
+	a := NewCachingAdapter(next, NewMemoryStore(10, 0), true, WithSemantic(NewSemantic(embedder, NewBruteForceIndex(10), 0.95, true, false)))
+
+	withSystem := func(prompt, system string) *model.ChatRequest {
+		req := chatRequest(prompt, 0)
+		req.Messages = append([]model.Message{{Role: "system", Content: model.NewTextContent(system)}}, req.Messages...)
+		return req
+	}
+	withFormat := func(prompt string) *model.ChatRequest {
+		req := chatRequest(prompt, 0)
+		req.ResponseFormat = &model.ResponseFormat{Type: model.ResponseFormatJSONObject}
+		return req
+	}
+
+	tests := []struct {
+		name string
+		req  *model.ChatRequest
+		want string
+	}{
+		{"first question", withSystem("今天天气怎么样", "用一句话回答"), ResultMiss},
+		{"similar question same system prompt", withSystem("今天天气如何", "用一句话回答"), ResultSemanticHit},
+		{"similar question other system prompt", withSystem("今天天气如何", "用英文回答"), ResultMiss},
+		{"similar question without system prompt", chatRequest("今天天气如何", 0), ResultMiss},
+		{"similar question as json", withFormat("今天天气如何"), ResultMiss},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := &Lookup{}
+			if _, err := a.Chat(NewContext(context.Background(), lookup), tt.req); err != nil {
+				t.Fatal(err)
+			}
+			if lookup.Result != tt.want {
+				t.Errorf("result = %q, want %q", lookup.Result, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package cache
+
+import "context"
+
+// 缓存查询结果（X-Prism-Cache 响应头的取值）
+const (
+	ResultHit         = "hit"
+	ResultSemanticHit = "semantic-hit"
+	ResultMiss        = "miss"
+)
+
+// Lookup 单次请求的缓存控制与结果
+// handler 根据请求头填写控制字段并放入context，CachingAdapter 处理后回填 Result
+type Lookup struct {
+	// NoCache 跳过缓存查询，直接请求上游（Cache-Control: no-cache）
+	NoCache bool
+
+	// NoStore 不保存本次响应（Cache-Control: no-store）
+	NoStore bool
+
+	// KeyID 调用方API Key标识（语义缓存按Key隔离时使用）
+	KeyID string
+
+	// Result 查询结果：ResultHit / ResultSemanticHit / ResultMiss；请求不可缓存时为空
+	Result string
+
+	// Similarity 语义缓存命中时的相似度
+	Similarity float32
+}
+
+// lookupKey context中存放Lookup的key
+type lookupKey struct{}
+
+// NewContext 将缓存控制放入context
+func NewContext(ctx context.Context, lookup *Lookup) context.Context {
+	return context.WithValue(ctx, lookupKey{}, lookup)
+}
+
+// FromContext 获取缓存控制；不存在时返回nil
+func FromContext(ctx context.Context) *Lookup {
+	lookup, _ := ctx.Value(lookupKey{}).(*Lookup)
+	return lookup
+}
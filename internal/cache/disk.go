@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"go.uber.org/zap"
+)
+
+// diskFileExt 缓存文件扩展名
+const diskFileExt = ".json"
+
+// DiskStore 磁盘缓存
+// 每个条目保存为一个JSON文件：{dir}/{key前2位}/{key}.json
+// 内存中只维护LRU索引；过期时间按文件修改时间 + TTL 计算，
+// 这样进程重启后可以直接扫描目录重建索引，无需额外的元数据文件
+type DiskStore struct {
+	dir string
+	ttl time.Duration
+
+	mu  sync.Mutex
+	lru *lru[struct{}]
+}
+
+// NewDiskStore 创建磁盘缓存，并从目录中恢复已有条目
+// 参数：
+//   - dir: 缓存目录（不存在时自动创建）
+//   - maxEntries: 最大条目数（<=0表示不限制）
+//   - ttl: 条目有效期（0表示不过期）
+func NewDiskStore(dir string, maxEntries int, ttl time.Duration) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cache dir failed: %w", err)
+	}
+
+	s := &DiskStore{dir: dir, ttl: ttl}
+	s.lru = newLRU(maxEntries, func(key string, _ struct{}) {
+		os.Remove(s.path(key))
+	})
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get 查找缓存的响应
+func (s *DiskStore) Get(key string) (*model.ChatResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lru.get(key, time.Now()); !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		s.lru.remove(key)
+		return nil, false
+	}
+
+	var resp model.ChatResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		// 文件损坏，删除后视为未命中
+		zap.L().Warn("缓存文件损坏，已删除", zap.String("key", key), zap.Error(err))
+		s.lru.remove(key)
+		return nil, false
+	}
+	return &resp, true
+}
+
+// Set 保存响应（先写临时文件再重命名，避免读到写了一半的文件）
+func (s *DiskStore) Set(key string, resp *model.ChatResponse) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		zap.L().Warn("序列化缓存响应失败", zap.String("key", key), zap.Error(err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		zap.L().Warn("创建缓存目录失败", zap.String("key", key), zap.Error(err))
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		zap.L().Warn("写入缓存文件失败", zap.String("key", key), zap.Error(err))
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(tmp.Name())
+		zap.L().Warn("写入缓存文件失败", zap.String("key", key), zap.Error(errors.Join(writeErr, closeErr)))
+		return
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		zap.L().Warn("写入缓存文件失败", zap.String("key", key), zap.Error(err))
+		return
+	}
+
+	s.lru.add(key, struct{}{}, expiresAt(time.Now(), s.ttl))
+}
+
+// Len 当前缓存条目数
+func (s *DiskStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.len()
+}
+
+// Close 磁盘缓存无需释放资源（文件保留，供下次启动恢复）
+func (s *DiskStore) Close() error {
+	return nil
+}
+
+// path 条目对应的文件路径
+func (s *DiskStore) path(key string) string {
+	prefix := key
+	if len(prefix) > 2 {
+		prefix = prefix[:2]
+	}
+	return filepath.Join(s.dir, prefix, key+diskFileExt)
+}
+
+// load 扫描缓存目录重建LRU索引
+// 按修改时间从旧到新加入索引，超出容量时自然淘汰最旧的条目；已过期的文件直接删除
+func (s *DiskStore) load() error {
+	type fileInfo struct {
+		key     string
+		modTime time.Time
+	}
+
+	var files []fileInfo
+	now := time.Now()
+
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		name := d.Name()
+		if strings.HasSuffix(name, ".tmp") {
+			// 上次进程退出时残留的临时文件
+			os.Remove(path)
+			return nil
+		}
+		if !strings.HasSuffix(name, diskFileExt) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if s.ttl > 0 && now.After(info.ModTime().Add(s.ttl)) {
+			os.Remove(path)
+			return nil
+		}
+
+		files = append(files, fileInfo{key: strings.TrimSuffix(name, diskFileExt), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("scan cache dir failed: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, f := range files {
+		s.lru.add(f.key, struct{}{}, expiresAt(f.modTime, s.ttl))
+	}
+
+	return nil
+}
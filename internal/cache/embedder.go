@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// defaultEmbedTimeout 向量化请求默认超时时间
+const defaultEmbedTimeout = 10 * time.Second
+
+// Embedder 文本向量化
+type Embedder interface {
+	// Embed 返回文本的向量表示
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// HTTPEmbedder 调用OpenAI兼容的 /embeddings 接口进行向量化
+// 智谱、OpenAI等供应商的向量接口均兼容该格式
+type HTTPEmbedder struct {
+	endpoint string
+	apiKey   string
+	model    string
+	client   *http.Client
+}
+
+// NewHTTPEmbedder 创建HTTP向量化客户端
+// 参数：
+//   - endpoint: 向量接口完整地址，如 https://open.bigmodel.cn/api/paas/v4/embeddings
+//   - apiKey: API密钥
+//   - model: 向量模型名称，如 embedding-3
+//   - timeout: 单次请求超时（0表示使用默认值）
+func NewHTTPEmbedder(endpoint, apiKey, model string, timeout time.Duration) *HTTPEmbedder {
+	if timeout == 0 {
+		timeout = defaultEmbedTimeout
+	}
+
+	return &HTTPEmbedder{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		model:    model,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+	}
+}
+
+// embeddingRequest 向量接口请求体
+type embeddingRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+// embeddingResponse 向量接口响应体（只解析需要的字段）
+type embeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed 返回文本的向量表示
+func (e *HTTPEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	reqBody, err := json.Marshal(embeddingRequest{Model: e.model, Input: text})
+	if err != nil {
+		return nil, fmt.Errorf("marshal embedding request failed: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("create embedding request failed: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+e.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := e.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read embedding response failed: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding API error (status %d): %s", httpResp.StatusCode, string(respBody))
+	}
+
+	var embResp embeddingResponse
+	if err := json.Unmarshal(respBody, &embResp); err != nil {
+		return nil, fmt.Errorf("unmarshal embedding response failed: %w", err)
+	}
+	if len(embResp.Data) == 0 || len(embResp.Data[0].Embedding) == 0 {
+		return nil, fmt.Errorf("embedding response contains no vector")
+	}
+	return embResp.Data[0].Embedding, nil
+}
@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// 向量索引类型
+const (
+	IndexBruteForce = "brute_force"
+)
+
+// Index 向量索引
+// 向量按作用域（scope）隔离，只在同一作用域内检索；实现方需要保证并发安全
+type Index interface {
+	// Add 添加向量（同一作用域内id相同时覆盖）
+	Add(scope, id string, vec []float32)
+	// Search 在作用域内查找余弦相似度最高的向量
+	// 返回：向量id、相似度、作用域为空时返回false
+	Search(scope string, vec []float32) (string, float32, bool)
+	// Remove 删除向量
+	Remove(scope, id string)
+	// Len 向量总数
+	Len() int
+}
+
+// NewIndex 根据类型创建向量索引
+// 参数：
+//   - kind: 索引类型（目前只支持 brute_force）
+//   - maxEntries: 最大向量数（<=0表示不限制）
+func NewIndex(kind string, maxEntries int) (Index, error) {
+	switch kind {
+	case IndexBruteForce:
+		return NewBruteForceIndex(maxEntries), nil
+	default:
+		return nil, fmt.Errorf("unknown vector index: %s", kind)
+	}
+}
+
+// BruteForceIndex 暴力检索索引
+// 检索时逐个计算相似度，复杂度 O(n·d)；几万条以内的缓存规模足够快，且结果精确
+// 超出容量时淘汰最早加入的向量
+type BruteForceIndex struct {
+	maxEntries int
+
+	mu     sync.RWMutex
+	scopes map[string]map[string]*list.Element
+	order  *list.List // 按加入顺序排列的 *indexEntry，最早的在队尾
+}
+
+// indexEntry 索引中的单个向量
+type indexEntry struct {
+	scope string
+	id    string
+	vec   []float32 // 已归一化，点积即余弦相似度
+}
+
+// NewBruteForceIndex 创建暴力检索索引
+// 参数：
+//   - maxEntries: 最大向量数（<=0表示不限制）
+func NewBruteForceIndex(maxEntries int) *BruteForceIndex {
+	return &BruteForceIndex{
+		maxEntries: maxEntries,
+		scopes:     make(map[string]map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Add 添加向量
+func (idx *BruteForceIndex) Add(scope, id string, vec []float32) {
+	normalized := normalize(vec)
+	if normalized == nil {
+		return
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entries, ok := idx.scopes[scope]
+	if !ok {
+		entries = make(map[string]*list.Element)
+		idx.scopes[scope] = entries
+	}
+	if elem, ok := entries[id]; ok {
+		idx.order.Remove(elem)
+	}
+	entries[id] = idx.order.PushFront(&indexEntry{scope: scope, id: id, vec: normalized})
+
+	for idx.maxEntries > 0 && idx.order.Len() > idx.maxEntries {
+		idx.removeElement(idx.order.Back())
+	}
+}
+
+// Search 查找相似度最高的向量
+func (idx *BruteForceIndex) Search(scope string, vec []float32) (string, float32, bool) {
+	query := normalize(vec)
+	if query == nil {
+		return "", 0, false
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var (
+		bestID    string
+		bestScore float32 = -2
+	)
+	for id, elem := range idx.scopes[scope] {
+		entry := elem.Value.(*indexEntry)
+		if len(entry.vec) != len(query) {
+			// 向量模型变更后维度不一致，跳过
+			continue
+		}
+		if score := dot(entry.vec, query); score > bestScore {
+			bestID, bestScore = id, score
+		}
+	}
+	if bestID == "" {
+		return "", 0, false
+	}
+	return bestID, bestScore, true
+}
+
+// Remove 删除向量
+func (idx *BruteForceIndex) Remove(scope, id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if elem, ok := idx.scopes[scope][id]; ok {
+		idx.removeElement(elem)
+	}
+}
+
+// Len 向量总数
+func (idx *BruteForceIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.order.Len()
+}
+
+// removeElement 删除向量（调用方持有写锁）
+func (idx *BruteForceIndex) removeElement(elem *list.Element) {
+	entry := idx.order.Remove(elem).(*indexEntry)
+	entries := idx.scopes[entry.scope]
+	delete(entries, entry.id)
+	if len(entries) == 0 {
+		delete(idx.scopes, entry.scope)
+	}
+}
+
+// normalize 返回归一化后的向量副本（零向量返回nil）
+func normalize(vec []float32) []float32 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return nil
+	}
+
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(vec))
+	for i, v := range vec {
+		out[i] = v / norm
+	}
+	return out
+}
+
+// dot 向量点积
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
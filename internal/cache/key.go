@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// Key 计算请求的缓存键
+// 对请求做规范化后取SHA-256：
+//   - 保留模型、消息和所有采样参数（它们决定输出内容）
+//   - 清空 stream、stream_options、user 等不影响输出内容的字段，
+//     这样同一个问题的流式和非流式请求可以共用缓存
+//
+// 结构体按字段声明顺序序列化，因此结果是确定的；
+// ChatRequest 以后新增的字段也会自动参与计算
+func Key(req *model.ChatRequest) (string, error) {
+	canonical := *req
+	canonical.Stream = false
+	canonical.StreamOptions = nil
+	canonical.User = ""
+
+	data, err := json.Marshal(&canonical)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// IsDeterministic 判断请求是否为确定性请求（temperature 显式设置为0）
+// 只有确定性请求的输出才适合复用
+func IsDeterministic(req *model.ChatRequest) bool {
+	return req.Temperature != nil && *req.Temperature == 0
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+func TestKey(t *testing.T) {
+	zero, half := 0.0, 0.5
+	base := func() *model.ChatRequest {
+		return &model.ChatRequest{
+			Model:       "glm-4",
+			Messages:    []model.Message{{Role: "user", Content: model.NewTextContent("hi")}},
+			Temperature: &zero,
+		}
+	}
+	baseKey, err := Key(base())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(req *model.ChatRequest)
+		same   bool
+	}{
+		{"stream", func(req *model.ChatRequest) { req.Stream = true }, true},
+		{"stream options", func(req *model.ChatRequest) { req.StreamOptions = &model.StreamOptions{IncludeUsage: true} }, true},
+		{"user", func(req *model.ChatRequest) { req.User = "u-1" }, true},
+		{"model", func(req *model.ChatRequest) { req.Model = "glm-4-flash" }, false},
+		{"temperature", func(req *model.ChatRequest) { req.Temperature = &half }, false},
+		{"messages", func(req *model.ChatRequest) { req.Messages[0].Content = model.NewTextContent("hello") }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := base()
+			tt.modify(req)
+			key, err := Key(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (key == baseKey) != tt.same {
+				t.Errorf("key equal = %v, want %v", key == baseKey, tt.same)
+			}
+		})
+	}
+
+	// 计算缓存键不修改原请求
+	req := base()
+	req.Stream = true
+	req.User = "u-1"
+	Key(req)
+	if !req.Stream || req.User != "u-1" {
+		t.Error("Key modified the request")
+	}
+}
+
+func TestIsDeterministic(t *testing.T) {
+	zero, one := 0.0, 1.0
+	tests := []struct {
+		name        string
+		temperature *float64
+		want        bool
+	}{
+		{"zero", &zero, true},
+		{"non-zero", &one, false},
+		{"unset", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDeterministic(&model.ChatRequest{Temperature: tt.temperature}); got != tt.want {
+				t.Errorf("IsDeterministic = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
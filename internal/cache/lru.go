@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+// lru 带过期时间的LRU索引
+// 内存后端和磁盘后端共用：内存后端在value中保存响应本身，磁盘后端只保存索引
+// 注意：lru本身不加锁，由调用方（各Store实现）负责并发保护
+type lru[V any] struct {
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+
+	// onEvict 条目被淘汰或过期删除时回调（磁盘后端用于删除文件）
+	onEvict func(key string, value V)
+}
+
+// lruEntry LRU链表节点
+type lruEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// newLRU 创建LRU索引
+// 参数：
+//   - maxEntries: 最大条目数（<=0表示不限制）
+//   - onEvict: 淘汰回调（可为nil）
+func newLRU[V any](maxEntries int, onEvict func(key string, value V)) *lru[V] {
+	return &lru[V]{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		onEvict:    onEvict,
+	}
+}
+
+// get 查找条目；过期条目会被删除并视为不存在
+func (l *lru[V]) get(key string, now time.Time) (V, bool) {
+	var zero V
+
+	elem, ok := l.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	entry := elem.Value.(*lruEntry[V])
+	if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+		l.removeElement(elem)
+		return zero, false
+	}
+
+	l.ll.MoveToFront(elem)
+	return entry.value, true
+}
+
+// add 添加或更新条目，超出容量时淘汰最久未使用的条目
+func (l *lru[V]) add(key string, value V, expiresAt time.Time) {
+	if elem, ok := l.items[key]; ok {
+		entry := elem.Value.(*lruEntry[V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		l.ll.MoveToFront(elem)
+		return
+	}
+
+	elem := l.ll.PushFront(&lruEntry[V]{key: key, value: value, expiresAt: expiresAt})
+	l.items[key] = elem
+
+	for l.maxEntries > 0 && l.ll.Len() > l.maxEntries {
+		l.removeElement(l.ll.Back())
+	}
+}
+
+// remove 删除条目
+func (l *lru[V]) remove(key string) {
+	if elem, ok := l.items[key]; ok {
+		l.removeElement(elem)
+	}
+}
+
+// len 当前条目数
+func (l *lru[V]) len() int {
+	return l.ll.Len()
+}
+
+func (l *lru[V]) removeElement(elem *list.Element) {
+	entry := elem.Value.(*lruEntry[V])
+	l.ll.Remove(elem)
+	delete(l.items, entry.key)
+	if l.onEvict != nil {
+		l.onEvict(entry.key, entry.value)
+	}
+}
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"context"
+	"strings"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// Semantic 语义缓存
+// 对最后一条用户消息做向量化，在向量索引中查找相似度超过阈值的历史问题，
+// 命中后复用该问题的缓存响应
+//
+// 只有最后一条用户消息按相似度匹配，请求的其余部分（系统提示词、之前的对话、工具、
+// response_format、采样参数）必须完全相同：它们的哈希是隔离范围的一部分
+//
+// 索引中的向量id就是精确缓存的键，响应本身仍保存在 Store 中：
+//   - 响应过期或被淘汰后，命中的向量会被顺带清理
+//   - 向量索引只在内存中，进程重启后需要重新积累
+type Semantic struct {
+	embedder     Embedder
+	index        Index
+	threshold    float32
+	scopeByModel bool
+	scopeByKey   bool
+}
+
+// NewSemantic 创建语义缓存
+// 参数：
+//   - embedder: 文本向量化
+//   - index: 向量索引
+//   - threshold: 相似度阈值（余弦相似度，达到该值才视为命中）
+//   - scopeByModel: 是否按模型隔离
+//   - scopeByKey: 是否按调用方API Key隔离
+func NewSemantic(embedder Embedder, index Index, threshold float64, scopeByModel, scopeByKey bool) *Semantic {
+	return &Semantic{
+		embedder:     embedder,
+		index:        index,
+		threshold:    float32(threshold),
+		scopeByModel: scopeByModel,
+		scopeByKey:   scopeByKey,
+	}
+}
+
+// NewSemanticFromConfig 根据配置创建语义缓存
+// 参数：
+//   - cfg: 语义缓存配置
+//
+// 返回：
+//   - *Semantic: 语义缓存实例
+//   - error: 索引类型未知时返回错误
+func NewSemanticFromConfig(cfg config.SemanticCacheConfig) (*Semantic, error) {
+	index, err := NewIndex(cfg.Index, cfg.MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	embedder := NewHTTPEmbedder(cfg.Endpoint, cfg.APIKey, cfg.Model, cfg.Timeout)
+	return NewSemantic(embedder, index, cfg.Threshold, cfg.ScopeByModel, cfg.ScopeByKey), nil
+}
+
+// Len 索引中的向量数
+func (s *Semantic) Len() int {
+	return s.index.Len()
+}
+
+// semanticQuery 单次请求的语义缓存状态
+// 查询时计算的向量在写入缓存时复用，避免重复调用向量接口
+type semanticQuery struct {
+	scope string
+	text  string
+	vec   []float32
+}
+
+// query 创建请求的语义缓存状态
+// 没有用户消息的请求不参与语义缓存，返回nil
+func (s *Semantic) query(req *model.ChatRequest, keyID string) *semanticQuery {
+	// 带图片、音频的消息只看文本无法判断是否相似，不参与语义缓存
+	msg := req.GetLastUserMessage()
+	if msg == nil || msg.Content.HasMedia() || strings.TrimSpace(msg.Content.Text()) == "" {
+		return nil
+	}
+
+	contextHash, err := contextKey(req)
+	if err != nil {
+		return nil
+	}
+
+	var parts []string
+	if s.scopeByModel {
+		parts = append(parts, "model="+req.Model)
+	}
+	if s.scopeByKey {
+		parts = append(parts, "key="+keyID)
+	}
+	parts = append(parts, "context="+contextHash)
+
+	return &semanticQuery{
+		scope: strings.Join(parts, ";"),
+		text:  msg.Content.Text(),
+	}
+}
+
+// contextKey 计算请求中除最后一条用户消息以外部分的哈希
+// 同一个问题在不同的系统提示词、对话历史、工具或输出格式下回答不同，不能互相复用；
+// 模型名称不参与计算，是否按模型隔离由 scope_by_model 决定
+func contextKey(req *model.ChatRequest) (string, error) {
+	canonical := *req
+	canonical.Model = ""
+	canonical.Messages = nil
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == "user" {
+			canonical.Messages = append(append([]model.Message(nil), req.Messages[:i]...), req.Messages[i+1:]...)
+			break
+		}
+	}
+	return Key(&canonical)
+}
+
+// embed 计算向量（已计算过时直接返回）
+func (s *Semantic) embed(ctx context.Context, q *semanticQuery) ([]float32, error) {
+	if q.vec != nil {
+		return q.vec, nil
+	}
+
+	vec, err := s.embedder.Embed(ctx, q.text)
+	if err != nil {
+		return nil, err
+	}
+	q.vec = vec
+	return vec, nil
+}
+
+// search 查找相似问题
+// 返回：相似问题的缓存键、相似度、是否超过阈值
+func (s *Semantic) search(ctx context.Context, q *semanticQuery) (string, float32, bool, error) {
+	vec, err := s.embed(ctx, q)
+	if err != nil {
+		return "", 0, false, err
+	}
+
+	key, score, ok := s.index.Search(q.scope, vec)
+	if !ok || score < s.threshold {
+		return "", score, false, nil
+	}
+	return key, score, true, nil
+}
+
+// add 记录问题向量
+func (s *Semantic) add(ctx context.Context, q *semanticQuery, key string) error {
+	vec, err := s.embed(ctx, q)
+	if err != nil {
+		return err
+	}
+	s.index.Add(q.scope, key, vec)
+	return nil
+}
+
+// remove 删除失效的向量
+func (s *Semantic) remove(q *semanticQuery, key string) {
+	s.index.Remove(q.scope, key)
+}
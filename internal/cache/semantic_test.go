@@ -0,0 +1,207 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+func TestBruteForceIndex(t *testing.T) {
+	idx := NewBruteForceIndex(2)
+	idx.Add("s", "a", []float32{1, 0})
+	idx.Add("s", "b", []float32{0, 2})
+	idx.Add("other", "c", []float32{1, 0})
+
+	// 超出容量淘汰最早加入的 a
+	if idx.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", idx.Len())
+	}
+	if id, _, ok := idx.Search("s", []float32{1, 0.1}); !ok || id != "b" {
+		t.Errorf("Search = %q, %v, want b", id, ok)
+	}
+
+	tests := []struct {
+		name   string
+		scope  string
+		vec    []float32
+		wantID string
+		found  bool
+	}{
+		{"same direction", "other", []float32{3, 0}, "c", true},
+		{"unknown scope", "missing", []float32{1, 0}, "", false},
+		{"zero vector", "other", []float32{0, 0}, "", false},
+		{"dimension mismatch", "other", []float32{1, 0, 0}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, score, ok := idx.Search(tt.scope, tt.vec)
+			if ok != tt.found || id != tt.wantID {
+				t.Errorf("Search = %q, %v, want %q, %v", id, ok, tt.wantID, tt.found)
+			}
+			if ok && (score < 0.999 || score > 1.001) {
+				t.Errorf("score = %v, want 1", score)
+			}
+		})
+	}
+
+	idx.Remove("other", "c")
+	if _, _, ok := idx.Search("other", []float32{1, 0}); ok || idx.Len() != 1 {
+		t.Errorf("entry not removed, Len = %d", idx.Len())
+	}
+	if _, err := NewIndex("hnsw", 0); err == nil {
+		t.Error("NewIndex accepted an unknown kind")
+	}
+}
+
+func TestSemanticQuery(t *testing.T) {
+	tests := []struct {
+		name         string
+		msg          model.Message
+		scopeByModel bool
+		scopeByKey   bool
+		wantScope    string
+		skip         bool
+	}{
+		{"no scope", model.Message{Role: "user", Content: model.NewTextContent("hi")}, false, false, "context=", false},
+		{"model and key", model.Message{Role: "user", Content: model.NewTextContent("hi")}, true, true, "model=glm-4;key=key_a;context=", false},
+		{"blank message", model.Message{Role: "user", Content: model.NewTextContent("  ")}, false, false, "", true},
+		{"media message", model.Message{Role: "user", Content: model.NewMultipartContent([]model.ContentPart{
+			{Type: "text", Text: "看这张图"},
+			{Type: "image_url", ImageURL: &model.ImageURL{URL: "https://example.com/a.png"}},
+		})}, false, false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSemantic(stubEmbedder{}, NewBruteForceIndex(0), 0.9, tt.scopeByModel, tt.scopeByKey)
+			q := s.query(&model.ChatRequest{Model: "glm-4", Messages: []model.Message{tt.msg}}, "key_a")
+			if tt.skip {
+				if q != nil {
+					t.Errorf("query = %+v, want nil", q)
+				}
+				return
+			}
+			if q == nil || !strings.HasPrefix(q.scope, tt.wantScope) || len(q.scope) != len(tt.wantScope)+64 {
+				t.Errorf("query = %+v, want scope %q", q, tt.wantScope)
+			}
+		})
+	}
+}
+
+func TestSemanticQueryContext(t *testing.T) {
+	s := NewSemantic(stubEmbedder{}, NewBruteForceIndex(0), 0.9, false, false)
+	base := func() *model.ChatRequest {
+		return &model.ChatRequest{Model: "glm-4", Messages: []model.Message{
+			{Role: "system", Content: model.NewTextContent("你是天气助手")},
+			{Role: "user", Content: model.NewTextContent("今天天气怎么样")},
+		}}
+	}
+	scope := s.query(base(), "").scope
+
+	// 只有最后一条用户消息不同时隔离范围相同
+	similar := base()
+	similar.Messages[1].Content = model.NewTextContent("今天天气如何")
+	if got := s.query(similar, "").scope; got != scope {
+		t.Errorf("scope changed with the last user message: %q vs %q", got, scope)
+	}
+	// 不按模型隔离时模型不影响隔离范围
+	otherModel := base()
+	otherModel.Model = "glm-4-flash"
+	if got := s.query(otherModel, "").scope; got != scope {
+		t.Errorf("scope changed with the model: %q vs %q", got, scope)
+	}
+
+	temperature := 0.5
+	tests := []struct {
+		name   string
+		modify func(req *model.ChatRequest)
+	}{
+		{"system prompt", func(req *model.ChatRequest) { req.Messages[0].Content = model.NewTextContent("你是翻译助手") }},
+		{"history", func(req *model.ChatRequest) {
+			req.Messages = append([]model.Message{{Role: "user", Content: model.NewTextContent("你好")}}, req.Messages...)
+		}},
+		{"temperature", func(req *model.ChatRequest) { req.Temperature = &temperature }},
+		{"response format", func(req *model.ChatRequest) {
+			req.ResponseFormat = &model.ResponseFormat{Type: model.ResponseFormatJSONObject}
+		}},
+		{"tools", func(req *model.ChatRequest) {
+			req.Tools = []model.Tool{{Type: model.ToolTypeFunction, Function: model.FunctionDefinition{Name: "get_weather"}}}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := base()
+			tt.modify(req)
+			if got := s.query(req, "").scope; got == scope {
+				t.Errorf("scope unchanged: %q", got)
+			}
+		})
+	}
+}
+
+func TestHTTPEmbedder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"bad key"}`))
+			return
+		}
+		var req embeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Input == "empty" {
+			w.Write([]byte(`{"data":[]}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"embedding":[0.1,0.2,0.3]}]}`))
+	}))
+	defer srv.Close()
+
+	vec, err := NewHTTPEmbedder(srv.URL, "sk-test", "embedding-3", 0).Embed(context.Background(), "hi")
+	if err != nil || len(vec) != 3 {
+		t.Fatalf("Embed = %v, %v", vec, err)
+	}
+
+	tests := []struct {
+		name   string
+		apiKey string
+		text   string
+		errMsg string
+	}{
+		{"upstream error", "wrong", "hi", "status 401"},
+		{"no vector", "sk-test", "empty", "no vector"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewHTTPEmbedder(srv.URL, tt.apiKey, "embedding-3", 0).Embed(context.Background(), tt.text)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("err = %v, want %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestSemanticExpiredEntryRemoved(t *testing.T) {
+	embedder := stubEmbedder{"今天天气怎么样": {1, 0}, "今天天气如何": {0.99, 0.05}}
+	index := NewBruteForceIndex(0)
+	store := NewMemoryStore(1, 0)
+	next := &stubNext{resp: completeResponse("1", "晴")}
+	a := NewCachingAdapter(next, store, true, WithSemantic(NewSemantic(embedder, index, 0.95, false, false)))
+
+	a.Chat(context.Background(), chatRequest("今天天气怎么样", 0))
+	// 另一个请求挤掉缓存中的响应，向量仍在索引里
+	store.Set("other", completeResponse("2", "x"))
+
+	lookup := &Lookup{}
+	a.Chat(NewContext(context.Background(), lookup), chatRequest("今天天气如何", 0))
+	if lookup.Result != ResultMiss {
+		t.Errorf("result = %q, want miss", lookup.Result)
+	}
+	// 失效的向量被清理，新问题的向量被记录
+	if index.Len() != 1 {
+		t.Errorf("index len = %d, want 1", index.Len())
+	}
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// 缓存后端类型
+const (
+	BackendMemory = "memory"
+	BackendDisk   = "disk"
+)
+
+// Store 响应缓存后端
+// 实现方需要自行处理TTL过期和容量淘汰，并保证并发安全
+type Store interface {
+	// Get 查找缓存的响应
+	Get(key string) (*model.ChatResponse, bool)
+	// Set 保存响应
+	Set(key string, resp *model.ChatResponse)
+	// Len 当前缓存条目数
+	Len() int
+	// Close 释放资源
+	Close() error
+}
+
+// NewStore 根据配置创建缓存后端
+// 参数：
+//   - cfg: 缓存配置
+//
+// 返回：
+//   - Store: 后端实例
+//   - error: 后端类型未知或创建失败时返回错误
+func NewStore(cfg config.CacheConfig) (Store, error) {
+	switch cfg.Backend {
+	case BackendMemory:
+		return NewMemoryStore(cfg.MaxEntries, cfg.TTL), nil
+	case BackendDisk:
+		return NewDiskStore(cfg.Dir, cfg.MaxEntries, cfg.TTL)
+	default:
+		return nil, fmt.Errorf("unknown cache backend: %s", cfg.Backend)
+	}
+}
+
+// ===== 内存后端 =====
+
+// MemoryStore 进程内LRU缓存
+type MemoryStore struct {
+	mu  sync.Mutex
+	lru *lru[*model.ChatResponse]
+	ttl time.Duration
+}
+
+// NewMemoryStore 创建内存缓存
+// 参数：
+//   - maxEntries: 最大条目数（<=0表示不限制）
+//   - ttl: 条目有效期（0表示不过期）
+func NewMemoryStore(maxEntries int, ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		lru: newLRU[*model.ChatResponse](maxEntries, nil),
+		ttl: ttl,
+	}
+}
+
+// Get 查找缓存的响应
+func (s *MemoryStore) Get(key string) (*model.ChatResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.get(key, time.Now())
+}
+
+// Set 保存响应
+func (s *MemoryStore) Set(key string, resp *model.ChatResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lru.add(key, resp, expiresAt(time.Now(), s.ttl))
+}
+
+// Len 当前缓存条目数
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.len()
+}
+
+// Close 内存缓存无需释放资源
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// expiresAt 计算过期时间（ttl为0表示不过期）
+func expiresAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// completeResponse 构造带 finish_reason 的完整响应
+func completeResponse(id, content string) *model.ChatResponse {
+	return &model.ChatResponse{
+		ID:    id,
+		Model: "glm-4",
+		Choices: []model.Choice{
+			{Index: 0, Message: &model.Message{Role: "assistant", Content: model.NewTextContent(content)}, FinishReason: "stop"},
+		},
+	}
+}
+
+func TestLRU(t *testing.T) {
+	var evicted []string
+	l := newLRU(2, func(key string, _ int) { evicted = append(evicted, key) })
+	now := time.Now()
+
+	l.add("a", 1, time.Time{})
+	l.add("b", 2, time.Time{})
+	// 访问 a 后 b 成为最久未使用的条目
+	if v, ok := l.get("a", now); !ok || v != 1 {
+		t.Fatalf("get(a) = %v, %v", v, ok)
+	}
+	l.add("c", 3, time.Time{})
+	if _, ok := l.get("b", now); ok {
+		t.Error("b was not evicted")
+	}
+	if len(evicted) != 1 || evicted[0] != "b" {
+		t.Errorf("evicted = %v, want [b]", evicted)
+	}
+
+	// 过期条目在访问时删除
+	l.add("d", 4, now.Add(time.Minute))
+	if _, ok := l.get("d", now.Add(2*time.Minute)); ok {
+		t.Error("expired entry returned")
+	}
+	if l.len() != 1 {
+		t.Errorf("len = %d, want 1", l.len())
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore(2, 0)
+	s.Set("a", completeResponse("1", "one"))
+	s.Set("b", completeResponse("2", "two"))
+	s.Set("c", completeResponse("3", "three"))
+
+	if _, ok := s.Get("a"); ok {
+		t.Error("oldest entry was not evicted")
+	}
+	if resp, ok := s.Get("c"); !ok || resp.ID != "3" {
+		t.Errorf("Get(c) = %v, %v", resp, ok)
+	}
+	if s.Len() != 2 {
+		t.Errorf("Len = %d, want 2", s.Len())
+	}
+
+	expiring := NewMemoryStore(0, time.Millisecond)
+	expiring.Set("a", completeResponse("1", "one"))
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := expiring.Get("a"); ok {
+		t.Error("expired entry returned")
+	}
+}
+
+func TestDiskStoreReload(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDiskStore(dir, 10, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set("abcdef", completeResponse("1", "cached"))
+	if resp, ok := s.Get("abcdef"); !ok || resp.Choices[0].Message.Content.Text() != "cached" {
+		t.Fatalf("Get = %v, %v", resp, ok)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ab", "abcdef.json")); err != nil {
+		t.Fatalf("cache file: %v", err)
+	}
+
+	// 残留的临时文件和过期文件在重新加载时清理
+	tmp := filepath.Join(dir, "ab", "abcdef.123.tmp")
+	os.WriteFile(tmp, []byte("{"), 0o644)
+	stale := filepath.Join(dir, "cd", "cdstale.json")
+	os.MkdirAll(filepath.Dir(stale), 0o755)
+	os.WriteFile(stale, []byte("{}"), 0o644)
+	old := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(stale, old, old)
+
+	reopened, err := NewDiskStore(dir, 10, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Len() != 1 {
+		t.Errorf("Len = %d, want 1", reopened.Len())
+	}
+	if resp, ok := reopened.Get("abcdef"); !ok || resp.ID != "1" {
+		t.Errorf("Get after reload = %v, %v", resp, ok)
+	}
+	for _, path := range []string{tmp, stale} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed", filepath.Base(path))
+		}
+	}
+}
+
+func TestDiskStoreEvictionRemovesFile(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDiskStore(dir, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set("aa01", completeResponse("1", "one"))
+	s.Set("bb02", completeResponse("2", "two"))
+
+	if _, err := os.Stat(filepath.Join(dir, "aa", "aa01.json")); !os.IsNotExist(err) {
+		t.Error("evicted entry file still exists")
+	}
+
+	// 损坏的文件视为未命中
+	os.WriteFile(filepath.Join(dir, "bb", "bb02.json"), []byte("not json"), 0o644)
+	if _, ok := s.Get("bb02"); ok {
+		t.Error("corrupted entry returned")
+	}
+	if s.Len() != 0 {
+		t.Errorf("Len = %d, want 0", s.Len())
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.CacheConfig
+		wantErr bool
+	}{
+		{"memory", config.CacheConfig{Backend: BackendMemory}, false},
+		{"disk", config.CacheConfig{Backend: BackendDisk, Dir: t.TempDir()}, false},
+		{"unknown", config.CacheConfig{Backend: "redis"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewStore(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewStore err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if s != nil {
+				s.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/AtSunset1/prism/internal/audit"
+	"github.com/AtSunset1/prism/internal/batch"
+	"github.com/AtSunset1/prism/internal/middleware"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// 批处理任务列表的分页大小
+const (
+	defaultBatchListLimit = 20
+	maxBatchListLimit     = 100
+)
+
+// BatchesHandler 处理 OpenAI Batch API 请求
+// 任务由 batch.Processor 在后台执行，这里只负责创建、查询和取消
+type BatchesHandler struct {
+	processor *batch.Processor
+}
+
+// NewBatchesHandler 创建一个新的BatchesHandler
+// 参数：
+//   - processor: 批处理器
+func NewBatchesHandler(processor *batch.Processor) *BatchesHandler {
+	return &BatchesHandler{processor: processor}
+}
+
+// HandleCreate 创建批处理任务
+// 路由：POST /v1/batches
+// 输入文件中的模型受调用方API Key的模型白名单限制
+//
+// 请求示例：
+//
+//	{
+//	  "input_file_id": "file-Xk3fP9qLw2ZbT7nRc0VdYs1a",
+//	  "endpoint": "/v1/chat/completions",
+//	  "completion_window": "24h"
+//	}
+func (h *BatchesHandler) HandleCreate(c *gin.Context) {
+	var req batch.CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, model.NewInvalidRequestError("无效的请求格式: "+err.Error(), "body"))
+		return
+	}
+
+	created, err := h.processor.Create(&req, audit.KeyID(c.GetHeader("Authorization")), middleware.GetPrincipal(c))
+	if err != nil {
+		var paramErr *model.ParamError
+		switch {
+		case errors.As(err, &paramErr):
+			writeError(c, model.NewInvalidRequestError(err.Error(), paramErr.Param))
+		case errors.Is(err, batch.ErrNotFound):
+			writeError(c, model.NewNotFoundError("input_file_id"))
+		default:
+			writeError(c, model.NewServerError(err.Error()))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, created)
+}
+
+// HandleList 列出批处理任务（从新到旧）
+// 路由：GET /v1/batches?after=batch_xxx&limit=20
+func (h *BatchesHandler) HandleList(c *gin.Context) {
+	limit := defaultBatchListLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxBatchListLimit {
+			writeError(c, model.NewInvalidRequestError("limit must be between 1 and 100", "limit"))
+			return
+		}
+		limit = n
+	}
+
+	batches, hasMore := h.processor.List(audit.KeyID(c.GetHeader("Authorization")), c.Query("after"), limit)
+	resp := batch.ListResponse[batch.Batch]{Object: "list", Data: batches, HasMore: hasMore}
+	if resp.Data == nil {
+		resp.Data = []batch.Batch{}
+	}
+	if len(batches) > 0 {
+		resp.FirstID = &batches[0].ID
+		resp.LastID = &batches[len(batches)-1].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// HandleGet 读取批处理任务状态和进度
+// 路由：GET /v1/batches/:id
+func (h *BatchesHandler) HandleGet(c *gin.Context) {
+	b, err := h.processor.Get(c.Param("id"), audit.KeyID(c.GetHeader("Authorization")))
+	if err != nil {
+		writeError(c, model.NewNotFoundError("batch"))
+		return
+	}
+	c.JSON(http.StatusOK, b)
+}
+
+// HandleCancel 取消批处理任务
+// 路由：POST /v1/batches/:id/cancel
+// 返回 status=cancelling 的任务，执行中的请求退出后变为 cancelled
+func (h *BatchesHandler) HandleCancel(c *gin.Context) {
+	b, err := h.processor.Cancel(c.Param("id"), audit.KeyID(c.GetHeader("Authorization")))
+	if err != nil {
+		switch {
+		case errors.Is(err, batch.ErrNotFound):
+			writeError(c, model.NewNotFoundError("batch"))
+		case errors.Is(err, batch.ErrNotCancellable):
+			writeError(c, model.NewInvalidRequestError("batch cannot be cancelled in its current status", "id").WithCode("batch_not_cancellable"))
+		default:
+			writeError(c, model.NewServerError(err.Error()))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, b)
+}
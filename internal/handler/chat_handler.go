@@ -1,11 +1,82 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/adapter/upstream"
+	"github.com/AtSunset1/prism/internal/audit"
+	"github.com/AtSunset1/prism/internal/cache"
+	"github.com/AtSunset1/prism/internal/lifecycle"
+	"github.com/AtSunset1/prism/internal/metrics"
+	"github.com/AtSunset1/prism/internal/middleware"
 	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/pipeline"
+	"github.com/AtSunset1/prism/internal/structured"
+	"github.com/AtSunset1/prism/internal/tracing"
+	"github.com/AtSunset1/prism/internal/truncate"
+	"github.com/AtSunset1/prism/pkg/config"
+	"github.com/AtSunset1/prism/pkg/logger"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// metricModelKey gin.Context中保存指标用模型名称的key
+// 未注册的模型统一记为 unknownModel，避免任意模型名导致指标标签基数爆炸
+const metricModelKey = "prism.metric_model"
+
+// unknownModel 未注册/无法识别的模型在指标中的标签值
+const unknownModel = "unknown"
+
+// ModelRegistry 查询模型是否已注册（*adapter.AdapterManager 实现了该接口）
+// 用于决定指标中的模型标签，只有已注册的模型使用原名
+type ModelRegistry interface {
+	GetAdapter(modelName string) (adapter.ModelAdapter, error)
+}
+
+// metricModel 指标中使用的模型名称
+// 已注册的模型使用原名，其他（包括无法判断时）统一记为 unknownModel
+func metricModel(registry ModelRegistry, name string) string {
+	if registry == nil {
+		return unknownModel
+	}
+	if _, err := registry.GetAdapter(name); err != nil {
+		return unknownModel
+	}
+	return name
+}
+
+// errorKey gin.Context中保存返回给客户端的错误的key（供审计记录使用）
+const errorKey = "prism.error"
+
+// HeaderCache 响应缓存结果响应头（hit / semantic-hit / miss）
+const HeaderCache = "X-Prism-Cache"
+
+// 对话截断结果响应头（只在发生截断时设置）
+const (
+	// HeaderMessagesDropped 被删除的消息数
+	HeaderMessagesDropped = "X-Prism-Messages-Dropped"
+
+	// HeaderMessagesSummarized 被摘要替换的消息数
+	HeaderMessagesSummarized = "X-Prism-Messages-Summarized"
+)
+
+// 上游调用结果响应头（只在实际调用了上游时设置，缓存命中时不设置）
+const (
+	// HeaderAdapter 处理请求的适配器名称
+	HeaderAdapter = "X-Prism-Adapter"
+
+	// HeaderUpstreamModel 上游响应中的模型名称（可能带版本后缀，如 gpt-4o-mini-2024-07-18）
+	HeaderUpstreamModel = "X-Prism-Upstream-Model"
+
+	// HeaderUpstreamRequestID 上游返回的请求ID（上游未返回时不设置）
+	HeaderUpstreamRequestID = "X-Prism-Upstream-Request-ID"
 )
 
 // ChatHandler 处理聊天相关的HTTP请求
@@ -16,11 +87,57 @@ import (
 //   - 处理错误情况
 type ChatHandler struct {
 	adapter adapter.ModelAdapter // 模型适配器（依赖注入）
+	auditor *audit.Auditor       // 审计记录器（可选，nil表示不记录）
+
+	// pipeline 上游调用前的请求处理（截断、上下文窗口校验、多模态处理，与批处理共用）
+	pipeline *pipeline.Pipeline
+
+	// drainer 排空状态（可选，nil表示关闭时不主动中断流式响应）
+	drainer *lifecycle.Drainer
+
+	// models 模型注册表（可选，nil表示指标中的模型全部记为 unknown）
+	models ModelRegistry
+}
+
+// Option ChatHandler的可选配置
+type Option func(*ChatHandler)
+
+// WithAuditor 为ChatHandler启用审计日志
+// 每个请求结束后记录请求、最终响应（流式为拼装后的完整响应）、耗时和用量
+func WithAuditor(auditor *audit.Auditor) Option {
+	return func(h *ChatHandler) {
+		h.auditor = auditor
+	}
+}
+
+// WithPipeline 设置上游调用前的请求处理流程
+// 未设置时只校验上下文窗口，不截断、不处理多模态内容；截断结果通过响应头报告
+func WithPipeline(p *pipeline.Pipeline) Option {
+	return func(h *ChatHandler) {
+		h.pipeline = p
+	}
+}
+
+// WithDrainer 让流式响应感知网关关闭
+// 排空期限到达时，仍在进行的流式响应发送错误事件后结束，而不是被直接断开
+func WithDrainer(drainer *lifecycle.Drainer) Option {
+	return func(h *ChatHandler) {
+		h.drainer = drainer
+	}
+}
+
+// WithModels 设置模型注册表
+// 指标只使用已注册的模型名作为标签，调用方传入的任意模型名记为 unknown
+func WithModels(registry ModelRegistry) Option {
+	return func(h *ChatHandler) {
+		h.models = registry
+	}
 }
 
 // NewChatHandler 创建一个新的ChatHandler
 // 参数：
 //   - adapter: 模型适配器（实现了ModelAdapter接口）
+//   - opts: 可选配置，如 WithAuditor、WithPipeline、WithDrainer、WithModels
 // 返回：
 //   - *ChatHandler: ChatHandler实例指针
 //
@@ -28,10 +145,15 @@ type ChatHandler struct {
 //
 //	glmAdapter := glm.NewGLMAdapter(apiKey)
 //	handler := NewChatHandler(glmAdapter)
-func NewChatHandler(adapter adapter.ModelAdapter) *ChatHandler {
-	return &ChatHandler{
-		adapter: adapter,
+func NewChatHandler(adapter adapter.ModelAdapter, opts ...Option) *ChatHandler {
+	h := &ChatHandler{
+		adapter:  adapter,
+		pipeline: pipeline.New(),
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 // HandleChatCompletion 处理聊天补全请求
@@ -46,93 +168,435 @@ func NewChatHandler(adapter adapter.ModelAdapter) *ChatHandler {
 //	  "stream": false
 //	}
 func (h *ChatHandler) HandleChatCompletion(c *gin.Context) {
+	start := time.Now()
+
 	// 1. 解析请求body为ChatRequest
 	var req model.ChatRequest
+	c.Set(metricModelKey, unknownModel)
+	defer func() {
+		// 请求结束后记录网关层指标（流式请求包含整个传输过程）
+		metrics.ObserveRequest(c.GetString(metricModelKey), req.Stream, c.Writer.Status(), time.Since(start))
+	}()
+
 	if err := c.ShouldBindJSON(&req); err != nil {
 		// 请求格式错误（JSON格式不正确或必填字段缺失）
 		errResp := model.NewInvalidRequestError("无效的请求格式: "+err.Error(), "body")
-		c.JSON(errResp.GetHTTPStatus(), errResp)
+		writeError(c, errResp)
+		return
+	}
+
+	// 2. 经过公共处理流程，按OpenAI格式返回
+	h.serve(c, &req, openAIChat{includeUsage: req.IncludeUsage()}, start)
+}
+
+// serve 聊天请求的公共处理流程
+// /v1/chat/completions、/v1/messages 等接口把请求转换为 ChatRequest 后都经过这里，
+// 校验、截断、多模态、缓存、期限和审计对所有接口一致，只有响应格式由 protocol 决定
+// 参数：
+//   - req: 聊天请求（处理过程中会被截断、内联图片等修改）
+//   - protocol: 接口协议（负责输出响应和错误）
+//   - start: 请求开始时间
+func (h *ChatHandler) serve(c *gin.Context, req *model.ChatRequest, protocol chatProtocol, start time.Time) {
+	// 1. 校验请求
+	if err := req.Validate(); err != nil {
+		param := "messages"
+		var paramErr *model.ParamError
+		if errors.As(err, &paramErr) {
+			param = paramErr.Param
+		}
+		protocolError(c, protocol, model.NewInvalidRequestError(err.Error(), param))
+		return
+	}
+	// 调用方无权访问的模型与未注册的模型一样返回404
+	if !middleware.GetPrincipal(c).CanAccess(req.Model) {
+		protocolError(c, protocol, model.NewNotFoundError("model").WithCode("model_not_found"))
+		return
+	}
+	c.Set(metricModelKey, metricModel(h.models, req.Model))
+
+	// 2. 按请求类型设置期限（截断摘要、远程图片下载也计入期限）
+	cancel := setDeadline(c, req.Stream)
+	defer cancel()
+
+	// 3. 在请求级日志和请求span中记录模型和请求模式
+	logger.AddFields(c.Request.Context(),
+		zap.String("model", req.Model),
+		zap.Bool("stream", req.Stream),
+	)
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(tracing.RequestAttributes(req)...)
+
+	// 4. 保存原始请求副本用于审计（截断、适配器都可能修改请求）
+	original := *req
+
+	// 5. 截断对话历史、校验上下文窗口、处理多模态内容（审计中保留原始请求）
+	// 仍然超出上下文窗口或多模态内容不合法时直接拒绝，不再请求上游
+	result, errResp := h.pipeline.Prepare(c.Request.Context(), req)
+	h.reportTruncation(c, result)
+	if errResp != nil {
+		protocolError(c, protocol, errResp)
 		return
 	}
 
-	// 2. 判断是否为流式请求
+	// 6. 根据 Cache-Control 请求头设置本次请求的缓存控制
+	// 未启用缓存时适配器不会读取它，Result 保持为空
+	// 同时放入上游调用元数据，适配器返回后写入响应头（截断摘要的上游调用不计入）
+	lookup := cacheLookup(c.GetHeader("Cache-Control"))
+	lookup.KeyID = audit.KeyID(c.GetHeader("Authorization"))
+	ctx, _ := upstream.NewContext(cache.NewContext(c.Request.Context(), lookup))
+	c.Request = c.Request.WithContext(ctx)
+
+	// 7. 判断是否为流式请求
+	var resp *model.ChatResponse
 	if req.Stream {
 		// 处理流式请求（SSE）
-		h.handleStreamResponse(c, &req)
+		resp = h.handleStreamResponse(c, req, lookup, protocol)
 	} else {
 		// 处理非流式请求（JSON）
-		h.handleNormalResponse(c, &req)
+		resp = h.handleNormalResponse(c, req, lookup, protocol)
 	}
+
+	// 8. 记录审计日志
+	h.audit(c, &original, resp, start)
 }
 
 // handleNormalResponse 处理非流式响应
 // 一次性返回完整的AI回复
-func (h *ChatHandler) handleNormalResponse(c *gin.Context, req *model.ChatRequest) {
+// 返回：成功时返回响应，失败时返回nil（错误已写入客户端）
+func (h *ChatHandler) handleNormalResponse(c *gin.Context, req *model.ChatRequest, lookup *cache.Lookup, protocol chatProtocol) *model.ChatResponse {
 	// 1. 调用适配器获取响应
 	// ⚠️ 重点：传递 c.Request.Context() 而不是 c
 	// Context包含超时、取消等控制信息
 	resp, err := h.adapter.Chat(c.Request.Context(), req)
 	if err != nil {
-		// 适配器调用失败（可能是API错误、网络错误、超时等）
-		errResp := model.NewAPIError("模型调用失败: " + err.Error())
-		c.JSON(errResp.GetHTTPStatus(), errResp)
-		return
+		// 适配器调用失败（可能是模型不存在、API错误、网络错误、超时等）
+		logger.FromContext(c.Request.Context()).Warn("模型调用失败", zap.Error(err))
+		errResp := adapterError(c, err)
+		h.reportCache(c, lookup)
+		reportUpstream(c, "")
+		protocolError(c, protocol, errResp)
+		return nil
 	}
+	h.reportCache(c, lookup)
+	reportUpstream(c, resp.Model)
+
+	// 2. 在请求级日志和请求span中记录token用量
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(tracing.ResponseAttributes(resp)...)
+	logger.AddFields(c.Request.Context(),
+		zap.Int("prompt_tokens", resp.Usage.PromptTokens),
+		zap.Int("completion_tokens", resp.Usage.CompletionTokens),
+		zap.Int("total_tokens", resp.Usage.TotalTokens),
+	)
 
-	// 2. 返回成功响应
-	c.JSON(200, resp)
+	// 3. 返回成功响应
+	protocol.writeResponse(c, resp)
+	return resp
 }
 
 // handleStreamResponse 处理流式响应
 // 使用SSE（Server-Sent Events）协议逐步返回AI回复
-func (h *ChatHandler) handleStreamResponse(c *gin.Context, req *model.ChatRequest) {
+// 返回：拼装后的完整响应；流式调用初始化失败时返回nil
+func (h *ChatHandler) handleStreamResponse(c *gin.Context, req *model.ChatRequest, lookup *cache.Lookup, protocol chatProtocol) *model.ChatResponse {
 	// 1. 设置SSE响应头
 	c.Header("Content-Type", "text/event-stream") // 声明SSE格式
 	c.Header("Cache-Control", "no-cache")         // 禁止缓存
-	c.Header("Connection", "keep-alive")          // 保持连接
 	c.Header("X-Accel-Buffering", "no")           // 禁用nginx缓冲
-	c.Header("Transfer-Encoding", "chunked")      // 分块传输
+	if c.Request.ProtoMajor == 1 {
+		// 连接相关的头只适用于HTTP/1.1，HTTP/2、HTTP/3禁止发送
+		c.Header("Connection", "keep-alive")     // 保持连接
+		c.Header("Transfer-Encoding", "chunked") // 分块传输
+	}
 
 	// 2. 调用适配器获取流式channel
+	// ChatStream 返回前不会写入响应，缓存结果头仍可以设置
+	stream := protocol.newStream()
 	streamChan, err := h.adapter.ChatStream(c.Request.Context(), req)
 	if err != nil {
-		// 流式调用初始化失败
-		// 注意：流式模式下也要以SSE格式返回错误
-		h.sendSSEError(c, "模型调用失败: "+err.Error())
-		return
+		// 流式调用初始化失败，由协议决定以SSE事件还是HTTP错误返回
+		logger.FromContext(c.Request.Context()).Warn("流式模型调用失败", zap.Error(err))
+		errResp := adapterError(c, err)
+		h.reportCache(c, lookup)
+		reportUpstream(c, "")
+		streamError(c, stream, errResp)
+		return nil
 	}
+	h.reportCache(c, lookup)
+	reportUpstream(c, "")
 
 	// 3. 从channel读取数据并逐步发送
 	// 每次从channel收到一个StreamResponse就立即发送给客户端
-	for streamResp := range streamChan {
-		// 将StreamResponse序列化为JSON
-		data, err := json.Marshal(streamResp)
-		if err != nil {
-			// JSON序列化失败（理论上不应该发生）
-			h.sendSSEError(c, "数据序列化失败: "+err.Error())
-			continue
+	// 同时拼装完整响应，供审计记录使用
+	// 网关关闭且排空期限已到时，发送错误事件后结束（不发送 [DONE]）
+	acc := model.NewStreamAccumulator()
+	for {
+		var streamResp *model.StreamResponse
+		var ok bool
+		select {
+		case streamResp, ok = <-streamChan:
+		case <-h.drainer.Expired():
+			logger.FromContext(c.Request.Context()).Warn("网关关闭，中断流式响应")
+			streamError(c, stream, model.NewUnavailableError("server is shutting down, please retry").WithCode("server_shutting_down"))
+			return acc.Response()
+		}
+		if !ok {
+			break
+		}
+
+		// 上游流读取失败（如数据块空闲超时），发送错误事件后结束（不发送 [DONE]）
+		if streamResp.IsError() {
+			logger.FromContext(c.Request.Context()).Warn("上游流式响应中断", zap.Error(streamResp.Err))
+			streamError(c, stream, upstreamStreamError(streamResp.Err))
+			return acc.Response()
+		}
+		acc.Add(streamResp)
+
+		// 上游模型名称从第一个数据块得到，响应头随第一次写入发送
+		if !c.Writer.Written() {
+			reportUpstream(c, streamResp.Model)
 		}
 
-		// 发送SSE数据（标准OpenAI格式）
-		// SSE格式：data: {json}\n\n
-		c.Writer.Write([]byte("data: "))
-		c.Writer.Write(data)
-		c.Writer.Write([]byte("\n\n"))
-		c.Writer.Flush() // ⚠️ 关键：立即发送，不缓存（实现逐字输出）
+		// 按协议格式发送
+		stream.chunk(c, streamResp)
+	}
+
+	// 4. 超过最长持续时间（server.max_stream_duration）时上游流被取消，发送错误事件代替结束标记
+	if errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		logger.FromContext(c.Request.Context()).Warn("流式响应超过最长持续时间，已中断")
+		streamError(c, stream, model.NewTimeoutError("stream").WithCode("stream_duration_exceeded"))
+		return acc.Response()
 	}
 
-	// 4. 发送结束标记
-	c.Writer.Write([]byte("data: [DONE]\n\n"))
-	c.Writer.Flush()
+	// 5. 发送结束标记
+	resp := acc.Response()
+	stream.finish(c, resp)
+
+	// 6. 在请求级日志中记录token用量
+	logger.AddFields(c.Request.Context(),
+		zap.Int("prompt_tokens", resp.Usage.PromptTokens),
+		zap.Int("completion_tokens", resp.Usage.CompletionTokens),
+		zap.Int("total_tokens", resp.Usage.TotalTokens),
+	)
+	return resp
+}
+
+// streamError 在流式响应中发送错误并记录错误指标
+func streamError(c *gin.Context, stream chatStream, errResp *model.ErrorResponse) {
+	recordError(c, errResp)
+	stream.fail(c, errResp)
 }
 
-// sendSSEError 以SSE格式发送错误
-// 用于流式响应中的错误处理
-func (h *ChatHandler) sendSSEError(c *gin.Context, message string) {
-	errResp := model.NewAPIError(message)
-	data, _ := json.Marshal(errResp)
+// writeSSE 发送一个SSE事件（标准OpenAI格式：data: {json}\n\n）并立即刷新
+func writeSSE(c *gin.Context, data []byte) {
+	writeEvent(c, "", data)
+}
+
+// writeEvent 发送一个带事件类型的SSE事件（event: {type}\ndata: {json}\n\n）并立即刷新
+// event为空时省略 event 行
+// 发送前按 server.stream_write_timeout 顺延连接写超时
+func writeEvent(c *gin.Context, event string, data []byte) {
+	var timeout time.Duration
+	if cfg := config.GetConfig(); cfg != nil {
+		timeout = cfg.Server.StreamWriteTimeout
+	}
+	middleware.ExtendWriteDeadline(c, timeout)
+
+	if event != "" {
+		c.Writer.Write([]byte("event: " + event + "\n"))
+	}
 	c.Writer.Write([]byte("data: "))
 	c.Writer.Write(data)
 	c.Writer.Write([]byte("\n\n"))
-	c.Writer.Flush()
-}
\ No newline at end of file
+	c.Writer.Flush() // ⚠️ 关键：立即发送，不缓存（实现逐字输出）
+}
+
+// setDeadline 按请求类型设置期限
+//   - 非流式：server.write_timeout 作为总期限
+//   - 流式：server.max_stream_duration 作为最长持续时间，写超时在每次发送前顺延（见 writeSSE）
+//
+// 请求体此时已读完，同时清除连接的读超时：否则 server.read_timeout 到期时
+// 连接上的后台读取会失败并取消请求上下文，长时间的流式响应会被中断
+//
+// 返回：释放上下文的函数（请求结束时调用）
+func setDeadline(c *gin.Context, stream bool) context.CancelFunc {
+	http.NewResponseController(c.Writer).SetReadDeadline(time.Time{})
+
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return func() {}
+	}
+	if !stream {
+		return middleware.SetDeadline(c, cfg.Server.WriteTimeout)
+	}
+	cancel := middleware.SetDeadline(c, cfg.Server.MaxStreamDuration)
+	middleware.ExtendWriteDeadline(c, cfg.Server.StreamWriteTimeout)
+	return cancel
+}
+
+// writeError 以JSON格式返回错误，并记录错误指标
+func writeError(c *gin.Context, errResp *model.ErrorResponse) {
+	recordError(c, errResp)
+	c.JSON(errResp.GetHTTPStatus(), errResp)
+}
+
+// protocolError 按接口协议的格式返回错误，并记录错误指标
+func protocolError(c *gin.Context, protocol chatProtocol, errResp *model.ErrorResponse) {
+	recordError(c, errResp)
+	protocol.writeError(c, errResp)
+}
+
+// recordError 记录错误指标，并保存错误供审计记录使用
+func recordError(c *gin.Context, errResp *model.ErrorResponse) {
+	metrics.ObserveError(c.GetString(metricModelKey), errResp.Error.Type)
+	c.Set(errorKey, errResp)
+}
+
+// adapterError 将适配器返回的错误转换为OpenAI格式错误
+//   - 请求超过期限（server.write_timeout / max_stream_duration）：timeout_error（408）
+//   - 模型未注册：not_found_error（404），指标中的模型记为unknown
+//   - 流式请求等待上游响应超过 stream_idle_timeout：timeout_error（408），code为 upstream_idle_timeout
+//   - 模型对应的适配器在健康探测中不可用：service_unavailable_error（503）
+//   - 模型不支持该接口（如用聊天模型请求向量化）：invalid_request_error（400）
+//   - response_format 中的Schema无法编译：invalid_request_error（400）
+//   - 模型输出重试后仍不符合 response_format：api_error（500），code为 invalid_model_output
+//   - 其他错误：api_error（500）
+func adapterError(c *gin.Context, err error) *model.ErrorResponse {
+	if errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		return model.NewTimeoutError("request").WithCode("request_timeout")
+	}
+	if errors.Is(err, adapter.ErrModelNotFound) {
+		c.Set(metricModelKey, unknownModel)
+		return model.NewNotFoundError("model").WithCode("model_not_found")
+	}
+	if errors.Is(err, adapter.ErrAdapterUnavailable) {
+		return model.NewUnavailableError("上游暂时不可用: " + c.GetString(metricModelKey)).WithCode("upstream_unavailable")
+	}
+	if errors.Is(err, upstream.ErrStreamIdle) {
+		return model.NewTimeoutError("upstream stream idle").WithCode("upstream_idle_timeout")
+	}
+	if errors.Is(err, adapter.ErrEmbeddingsNotSupported) {
+		return model.NewInvalidRequestError("该模型不支持向量化: "+c.GetString(metricModelKey), "model").WithCode("model_not_supported")
+	}
+	var schemaErr *structured.SchemaError
+	if errors.As(err, &schemaErr) {
+		return model.NewInvalidRequestError(schemaErr.Error(), "response_format").WithCode("invalid_json_schema")
+	}
+	var validationErr *structured.ValidationError
+	if errors.As(err, &validationErr) {
+		return model.NewAPIError(validationErr.Error()).WithCode("invalid_model_output")
+	}
+	return model.NewAPIError("模型调用失败: " + err.Error())
+}
+
+// upstreamStreamError 将上游流读取错误转换为SSE错误事件
+//   - 数据块空闲超时（adapters.*.stream_idle_timeout）：timeout_error，code为 upstream_idle_timeout
+//   - 其他读取错误：api_error，code为 upstream_stream_error
+func upstreamStreamError(err error) *model.ErrorResponse {
+	if errors.Is(err, upstream.ErrStreamIdle) {
+		return model.NewTimeoutError("upstream stream idle").WithCode("upstream_idle_timeout")
+	}
+	return model.NewAPIError("上游流式响应中断: " + err.Error()).WithCode("upstream_stream_error")
+}
+
+// cacheLookup 根据 Cache-Control 请求头创建缓存控制
+//   - no-cache：跳过缓存查询，但仍保存新的响应
+//   - no-store：不保存本次响应
+func cacheLookup(cacheControl string) *cache.Lookup {
+	lookup := &cache.Lookup{}
+	for _, directive := range strings.Split(cacheControl, ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-cache":
+			lookup.NoCache = true
+		case "no-store":
+			lookup.NoStore = true
+		}
+	}
+	return lookup
+}
+
+// reportTruncation 通过响应头和请求级日志报告对话截断结果
+// 未发生截断时不做任何处理
+func (h *ChatHandler) reportTruncation(c *gin.Context, result truncate.Result) {
+	if result.Strategy == "" {
+		return
+	}
+
+	if result.Dropped > 0 {
+		c.Header(HeaderMessagesDropped, strconv.Itoa(result.Dropped))
+	}
+	if result.Summarized > 0 {
+		c.Header(HeaderMessagesSummarized, strconv.Itoa(result.Summarized))
+	}
+	logger.AddFields(c.Request.Context(),
+		zap.String("truncation", result.Strategy),
+		zap.Int("messages_dropped", result.Dropped),
+		zap.Int("messages_summarized", result.Summarized),
+	)
+}
+
+// reportCache 通过响应头、请求级日志和指标报告缓存结果
+// 请求不可缓存（或未启用缓存）时不做任何处理
+func (h *ChatHandler) reportCache(c *gin.Context, lookup *cache.Lookup) {
+	if lookup.Result == "" {
+		return
+	}
+
+	c.Header(HeaderCache, lookup.Result)
+	logger.AddFields(c.Request.Context(), zap.String("cache", lookup.Result))
+	if lookup.Result == cache.ResultSemanticHit {
+		logger.AddFields(c.Request.Context(), zap.Float32("cache_similarity", lookup.Similarity))
+	}
+	metrics.ObserveCache(c.GetString(metricModelKey), lookup.Result)
+}
+
+// reportUpstream 设置上游调用结果响应头
+// 未调用上游（如缓存命中）时不设置
+// 参数：
+//   - upstreamModel: 上游响应中的模型名称（尚未得到时为空）
+func reportUpstream(c *gin.Context, upstreamModel string) {
+	meta := upstream.MetaFromContext(c.Request.Context())
+	if meta.Adapter() == "" {
+		return
+	}
+
+	c.Header(HeaderAdapter, meta.Adapter())
+	if upstreamModel != "" {
+		c.Header(HeaderUpstreamModel, upstreamModel)
+	}
+	if id := meta.RequestID(); id != "" {
+		c.Header(HeaderUpstreamRequestID, id)
+	}
+}
+
+// audit 提交一条审计记录
+// 参数：
+//   - req: 客户端发送的原始请求
+//   - resp: 最终响应（失败时为nil）
+//   - start: 请求开始时间
+func (h *ChatHandler) audit(c *gin.Context, req *model.ChatRequest, resp *model.ChatResponse, start time.Time) {
+	if h.auditor == nil {
+		return
+	}
+
+	finished := time.Now()
+	rec := &audit.Record{
+		RequestID:  middleware.GetRequestID(c),
+		KeyID:      audit.KeyID(c.GetHeader("Authorization")),
+		Model:      req.Model,
+		Stream:     req.Stream,
+		StartedAt:  start,
+		FinishedAt: finished,
+		LatencyMs:  finished.Sub(start).Milliseconds(),
+		Status:     c.Writer.Status(),
+		Request:    req,
+		Response:   resp,
+	}
+	if resp != nil {
+		usage := resp.Usage
+		rec.Usage = &usage
+	}
+	if v, ok := c.Get(errorKey); ok {
+		errResp := v.(*model.ErrorResponse)
+		rec.Error = &errResp.Error
+	}
+
+	h.auditor.Record(rec)
+}
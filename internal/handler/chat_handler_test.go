@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/metrics"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// stubChat 测试用聊天适配器：返回固定的响应或数据块，并记录收到的请求
+type stubChat struct {
+	resp   *model.ChatResponse
+	chunks []*model.StreamResponse
+	err    error
+
+	requests []*model.ChatRequest
+}
+
+func (s *stubChat) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
+	copied := *req
+	s.requests = append(s.requests, &copied)
+	if s.err != nil {
+		return nil, s.err
+	}
+	resp := *s.resp
+	return &resp, nil
+}
+
+func (s *stubChat) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
+	copied := *req
+	s.requests = append(s.requests, &copied)
+	if s.err != nil {
+		return nil, s.err
+	}
+	ch := make(chan *model.StreamResponse, len(s.chunks))
+	for _, chunk := range s.chunks {
+		ch <- chunk
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (s *stubChat) Name() string { return "stub" }
+
+func (s *stubChat) HealthCheck(ctx context.Context) error { return nil }
+
+// stubRegistry 测试用模型注册表
+type stubRegistry map[string]bool
+
+func (r stubRegistry) GetAdapter(modelName string) (adapter.ModelAdapter, error) {
+	if !r[modelName] {
+		return nil, adapter.ErrModelNotFound
+	}
+	return nil, nil
+}
+
+// serveJSON 用 handler 处理一个 JSON POST 请求
+func serveJSON(handler gin.HandlerFunc, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", handler)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMetricModel(t *testing.T) {
+	registry := stubRegistry{"glm-4": true}
+	tests := []struct {
+		name     string
+		registry ModelRegistry
+		model    string
+		want     string
+	}{
+		{"registered", registry, "glm-4", "glm-4"},
+		{"unregistered", registry, "made-up-model", unknownModel},
+		{"no registry", nil, "glm-4", unknownModel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := metricModel(tt.registry, tt.model); got != tt.want {
+				t.Errorf("metricModel(%q) = %q, want %q", tt.model, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatCompletionUnregisteredModelLabel(t *testing.T) {
+	// 上游失败时错误指标只能使用已注册的模型名，调用方传入的任意模型名记为 unknown
+	chat := &stubChat{err: context.DeadlineExceeded}
+	h := NewChatHandler(chat, WithModels(stubRegistry{"glm-4": true}))
+
+	const rawModel = "attacker-controlled-model-name"
+	before := testutil.ToFloat64(metrics.ErrorsTotal.WithLabelValues(unknownModel, model.ErrorTypeAPIError))
+	w := serveJSON(h.HandleChatCompletion, `{"model":"`+rawModel+`","messages":[{"role":"user","content":"hi"}]}`)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	if got := testutil.ToFloat64(metrics.ErrorsTotal.WithLabelValues(unknownModel, model.ErrorTypeAPIError)); got != before+1 {
+		t.Errorf("errors_total{model=unknown} = %v, want %v", got, before+1)
+	}
+	// DeleteLabelValues 返回 true 说明存在以原始模型名为标签的序列
+	if metrics.RequestsTotal.DeleteLabelValues(rawModel, "false", "500") {
+		t.Error("requests_total carries the raw model label")
+	}
+	if metrics.ErrorsTotal.DeleteLabelValues(rawModel, model.ErrorTypeAPIError) {
+		t.Error("errors_total carries the raw model label")
+	}
+}
+
+// sseData 返回SSE响应中所有 data 行的内容
+func sseData(body string) []string {
+	var data []string
+	for _, line := range strings.Split(body, "\n") {
+		if after, ok := strings.CutPrefix(line, "data: "); ok {
+			data = append(data, after)
+		}
+	}
+	return data
+}
+
+func TestChatCompletionStreamIncludeUsage(t *testing.T) {
+	stop := "stop"
+	chat := &stubChat{chunks: []*model.StreamResponse{
+		model.NewStreamResponse("chatcmpl-1", "glm-4", "", true),
+		model.NewStreamResponse("chatcmpl-1", "glm-4", "Hello", false),
+		{ID: "chatcmpl-1", Model: "glm-4", Choices: []model.StreamChoice{{FinishReason: &stop}}},
+		model.NewStreamUsageResponse("chatcmpl-1", "glm-4", 0, model.Usage{PromptTokens: 5, CompletionTokens: 1, TotalTokens: 6}),
+	}}
+	h := NewChatHandler(chat)
+
+	tests := []struct {
+		name      string
+		body      string
+		wantUsage bool
+	}{
+		{"usage requested", `{"model":"glm-4","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hi"}]}`, true},
+		{"usage not requested", `{"model":"glm-4","stream":true,"messages":[{"role":"user","content":"hi"}]}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveJSON(h.HandleChatCompletion, tt.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+			data := sseData(w.Body.String())
+			if data[len(data)-1] != "[DONE]" {
+				t.Fatalf("stream does not end with [DONE]: %v", data)
+			}
+			hasUsage := strings.Contains(data[len(data)-2], `"usage":{"prompt_tokens":5`)
+			if hasUsage != tt.wantUsage {
+				t.Errorf("usage chunk sent = %v, want %v (last event %s)", hasUsage, tt.wantUsage, data[len(data)-2])
+			}
+		})
+	}
+
+	w := serveJSON(h.HandleChatCompletion, `{"model":"glm-4","stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hi"}]}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "stream_options") {
+		t.Errorf("stream_options without stream: status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/metrics"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// suffixInstruction 设置 suffix 时的系统提示词
+// 聊天模型没有中间插入（FIM）能力，只能要求模型输出 prompt 和 suffix 之间的内容
+const suffixInstruction = "You are a text insertion engine. The user message contains a <prefix> and a <suffix>. " +
+	"Output only the text that belongs between them, without repeating the prefix or the suffix and without any explanation."
+
+// HandleCompletions 处理旧版文本补全请求
+// 路由：POST /v1/completions
+// 每个 prompt 包装为一条 user 消息，依次经过与 /v1/chat/completions 相同的处理流程和模型路由，
+// 结果按 text_completion 格式返回；prompt 数组的补全按 prompt 序号 * n + 候选序号 编号
+//
+// 请求示例：
+//
+//	{
+//	  "model": "glm-4-flash",
+//	  "prompt": ["从前有座山，", "床前明月光，"],
+//	  "max_tokens": 64,
+//	  "echo": true
+//	}
+func (h *ChatHandler) HandleCompletions(c *gin.Context) {
+	start := time.Now()
+
+	// 1. 解析请求body
+	var req model.CompletionRequest
+	c.Set(metricModelKey, unknownModel)
+	defer func() {
+		metrics.ObserveRequest(c.GetString(metricModelKey), req.Stream, c.Writer.Status(), time.Since(start))
+	}()
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, model.NewInvalidRequestError("无效的请求格式: "+err.Error(), "body"))
+		return
+	}
+
+	// 2. 依次处理每个 prompt，任意一个失败后不再继续
+	// 每次处理结束时请求上下文的期限会被释放，下一个 prompt 从原始请求重新开始
+	protocol := &textCompletion{
+		prompts:      req.Prompt,
+		echo:         req.Echo,
+		n:            req.GetN(),
+		includeUsage: req.IncludeUsage(),
+	}
+	original := c.Request
+	for i, prompt := range req.Prompt {
+		protocol.prompt = i
+		c.Request = original
+		h.serve(c, completionChatRequest(&req, prompt), protocol, start)
+		if protocol.failed {
+			return
+		}
+	}
+}
+
+// completionChatRequest 将一个 prompt 转换为聊天请求
+// 设置 suffix 时以 <prefix>/<suffix> 标记包装，并通过系统提示词要求模型只输出中间的内容
+func completionChatRequest(req *model.CompletionRequest, prompt string) *model.ChatRequest {
+	chatReq := &model.ChatRequest{
+		Model:            req.Model,
+		MaxTokens:        req.MaxTokens,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		N:                req.N,
+		Stream:           req.Stream,
+		Stop:             req.Stop,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+		User:             req.User,
+	}
+
+	if req.Suffix == "" {
+		chatReq.Messages = []model.Message{{Role: "user", Content: model.NewTextContent(prompt)}}
+		return chatReq
+	}
+	chatReq.Messages = []model.Message{
+		{Role: "system", Content: model.NewTextContent(suffixInstruction)},
+		{Role: "user", Content: model.NewTextContent("<prefix>" + prompt + "</prefix>\n<suffix>" + req.Suffix + "</suffix>")},
+	}
+	return chatReq
+}
+
+// textCompletion OpenAI旧版文本补全格式（/v1/completions）
+// 一个请求的所有 prompt 共用一个实例：非流式时收集各 prompt 的补全，处理完最后一个后一起返回；
+// 流式时各 prompt 的数据块依次发送，最后一个结束后发送 [DONE]
+type textCompletion struct {
+	prompts      []string
+	echo         bool
+	n            int
+	includeUsage bool
+
+	// prompt 当前处理的 prompt 序号
+	prompt int
+
+	// failed 已返回错误，不再处理后续 prompt
+	failed bool
+
+	// 第一个 prompt 的响应ID、模型和时间戳，作为整个响应的值
+	id      string
+	model   string
+	created int64
+
+	choices []model.CompletionChoice
+	usage   model.Usage
+}
+
+func (p *textCompletion) writeError(c *gin.Context, errResp *model.ErrorResponse) {
+	p.failed = true
+	c.JSON(errResp.GetHTTPStatus(), errResp)
+}
+
+func (p *textCompletion) writeResponse(c *gin.Context, resp *model.ChatResponse) {
+	p.begin(resp.ID, resp.Model, resp.Created)
+	for _, choice := range resp.Choices {
+		text := ""
+		if choice.Message != nil {
+			text = choice.Message.Content.Text()
+		}
+		if p.echo {
+			text = p.prompts[p.prompt] + text
+		}
+		finishReason := choice.FinishReason
+		p.choices = append(p.choices, model.CompletionChoice{
+			Text:         text,
+			Index:        p.index(choice.Index),
+			FinishReason: &finishReason,
+		})
+	}
+	p.addUsage(resp.Usage)
+
+	if !p.last() {
+		return
+	}
+	usage := p.usage
+	c.JSON(http.StatusOK, &model.CompletionResponse{
+		ID:      p.id,
+		Object:  "text_completion",
+		Created: p.created,
+		Model:   p.model,
+		Choices: p.choices,
+		Usage:   &usage,
+	})
+}
+
+func (p *textCompletion) newStream() chatStream {
+	return &textCompletionStream{protocol: p}
+}
+
+// begin 记录第一个 prompt 的响应ID、模型和时间戳
+func (p *textCompletion) begin(id, modelName string, created int64) {
+	if p.id != "" {
+		return
+	}
+	p.id = model.IDPrefixCompletion + strings.TrimPrefix(id, model.IDPrefixChatCompletion)
+	p.model = modelName
+	p.created = created
+}
+
+// index 补全的全局序号
+func (p *textCompletion) index(choiceIndex int) int {
+	return p.prompt*p.n + choiceIndex
+}
+
+// last 当前是否为最后一个 prompt
+func (p *textCompletion) last() bool {
+	return p.prompt == len(p.prompts)-1
+}
+
+// addUsage 累加用量
+func (p *textCompletion) addUsage(usage model.Usage) {
+	p.usage.PromptTokens += usage.PromptTokens
+	p.usage.CompletionTokens += usage.CompletionTokens
+	p.usage.TotalTokens += usage.TotalTokens
+}
+
+// textCompletionStream 一个 prompt 的流式输出
+// 以 text_completion 数据块发送增量文本；echo 时在第一个数据块前先发送 prompt
+type textCompletionStream struct {
+	protocol *textCompletion
+	echoed   bool
+}
+
+func (s *textCompletionStream) chunk(c *gin.Context, chunk *model.StreamResponse) {
+	p := s.protocol
+	p.begin(chunk.ID, chunk.Model, chunk.Created)
+	s.sendEcho(c)
+
+	// 用量在 finish 时按完整响应累加
+	if chunk.IsUsage() {
+		return
+	}
+	var choices []model.CompletionChoice
+	for _, choice := range chunk.Choices {
+		if choice.Delta.Content == "" && choice.FinishReason == nil {
+			continue
+		}
+		choices = append(choices, model.CompletionChoice{
+			Text:         choice.Delta.Content,
+			Index:        p.index(choice.Index),
+			FinishReason: choice.FinishReason,
+		})
+	}
+	if len(choices) > 0 {
+		s.write(c, choices, nil)
+	}
+}
+
+func (s *textCompletionStream) fail(c *gin.Context, errResp *model.ErrorResponse) {
+	s.protocol.failed = true
+	// 尚未开始输出时以HTTP错误返回，与非流式请求一致
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.JSON(errResp.GetHTTPStatus(), errResp)
+		return
+	}
+	data, _ := json.Marshal(errResp)
+	writeSSE(c, data)
+}
+
+func (s *textCompletionStream) finish(c *gin.Context, resp *model.ChatResponse) {
+	p := s.protocol
+	p.begin(resp.ID, resp.Model, resp.Created)
+	s.sendEcho(c)
+	p.addUsage(resp.Usage)
+
+	if !p.last() {
+		return
+	}
+	if p.includeUsage {
+		usage := p.usage
+		s.write(c, []model.CompletionChoice{}, &usage)
+	}
+	writeSSE(c, []byte("[DONE]"))
+}
+
+// sendEcho echo=true 时为当前 prompt 的每个补全发送一次 prompt
+func (s *textCompletionStream) sendEcho(c *gin.Context) {
+	p := s.protocol
+	if !p.echo || s.echoed {
+		return
+	}
+	s.echoed = true
+	choices := make([]model.CompletionChoice, 0, p.n)
+	for i := 0; i < p.n; i++ {
+		choices = append(choices, model.CompletionChoice{Text: p.prompts[p.prompt], Index: p.index(i)})
+	}
+	s.write(c, choices, nil)
+}
+
+// write 发送一个 text_completion 数据块
+func (s *textCompletionStream) write(c *gin.Context, choices []model.CompletionChoice, usage *model.Usage) {
+	p := s.protocol
+	data, _ := json.Marshal(&model.CompletionResponse{
+		ID:      p.id,
+		Object:  "text_completion",
+		Created: p.created,
+		Model:   p.model,
+		Choices: choices,
+		Usage:   usage,
+	})
+	writeSSE(c, data)
+}
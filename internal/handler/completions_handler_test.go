@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+func TestCompletionChatRequest(t *testing.T) {
+	maxTokens := 16
+	tests := []struct {
+		name string
+		req  model.CompletionRequest
+		want []string
+	}{
+		{
+			name: "plain prompt",
+			req:  model.CompletionRequest{Model: "glm-4", MaxTokens: &maxTokens, Stop: model.CompletionStop{"\n"}},
+			want: []string{"user:Once upon a time"},
+		},
+		{
+			name: "suffix",
+			req:  model.CompletionRequest{Model: "glm-4", Suffix: " happily ever after."},
+			want: []string{"system:" + suffixInstruction, "user:<prefix>Once upon a time</prefix>\n<suffix> happily ever after.</suffix>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chatReq := completionChatRequest(&tt.req, "Once upon a time")
+			got := make([]string, len(chatReq.Messages))
+			for i, msg := range chatReq.Messages {
+				got[i] = msg.Role + ":" + msg.Content.Text()
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("messages = %q, want %q", got, tt.want)
+			}
+			if chatReq.Model != tt.req.Model || chatReq.MaxTokens != tt.req.MaxTokens || strings.Join(chatReq.Stop, ",") != strings.Join(tt.req.Stop, ",") {
+				t.Errorf("parameters not carried over: %+v", chatReq)
+			}
+		})
+	}
+}
+
+func TestHandleCompletions(t *testing.T) {
+	chat := &stubChat{resp: &model.ChatResponse{
+		ID:    "chatcmpl-abc",
+		Model: "glm-4",
+		Choices: []model.Choice{
+			{Index: 0, Message: &model.Message{Role: "assistant", Content: model.NewTextContent(" one")}, FinishReason: "stop"},
+			{Index: 1, Message: &model.Message{Role: "assistant", Content: model.NewTextContent(" two")}, FinishReason: "length"},
+		},
+		Usage: model.Usage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7},
+	}}
+	h := NewChatHandler(chat)
+
+	w := serveJSON(h.HandleCompletions, `{"model":"glm-4","prompt":["A","B"],"n":2,"echo":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var resp model.CompletionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != "cmpl-abc" || resp.Object != "text_completion" || resp.Model != "glm-4" {
+		t.Errorf("id/object/model = %s/%s/%s", resp.ID, resp.Object, resp.Model)
+	}
+
+	// 补全按 prompt序号 * n + 候选序号 编号，echo 时以 prompt 开头
+	want := []string{"0:A one:stop", "1:A two:length", "2:B one:stop", "3:B two:length"}
+	got := make([]string, len(resp.Choices))
+	for i, choice := range resp.Choices {
+		got[i] = strings.Join([]string{strconv.Itoa(choice.Index), choice.Text, *choice.FinishReason}, ":")
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("choices = %q, want %q", got, want)
+	}
+	if resp.Usage == nil || *resp.Usage != (model.Usage{PromptTokens: 6, CompletionTokens: 8, TotalTokens: 14}) {
+		t.Errorf("usage = %+v, want sum of both prompts", resp.Usage)
+	}
+
+	if len(chat.requests) != 2 {
+		t.Fatalf("adapter received %d requests, want 2", len(chat.requests))
+	}
+	for i, prompt := range []string{"A", "B"} {
+		if text := chat.requests[i].Messages[0].Content.Text(); text != prompt {
+			t.Errorf("request %d prompt = %q, want %q", i, text, prompt)
+		}
+	}
+}
+
+func TestHandleCompletionsStream(t *testing.T) {
+	stop := "stop"
+	chat := &stubChat{chunks: []*model.StreamResponse{
+		model.NewStreamResponse("chatcmpl-abc", "glm-4", "", true),
+		model.NewStreamResponse("chatcmpl-abc", "glm-4", " one", false),
+		{ID: "chatcmpl-abc", Model: "glm-4", Choices: []model.StreamChoice{{FinishReason: &stop}}},
+		model.NewStreamUsageResponse("chatcmpl-abc", "glm-4", 0, model.Usage{PromptTokens: 3, CompletionTokens: 1, TotalTokens: 4}),
+	}}
+	h := NewChatHandler(chat)
+
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "two prompts with echo and usage",
+			body: `{"model":"glm-4","prompt":["A","B"],"echo":true,"stream":true,"stream_options":{"include_usage":true}}`,
+			want: []string{
+				`[{"text":"A","index":0,"logprobs":null,"finish_reason":null}]`,
+				`[{"text":" one","index":0,"logprobs":null,"finish_reason":null}]`,
+				`[{"text":"","index":0,"logprobs":null,"finish_reason":"stop"}]`,
+				`[{"text":"B","index":1,"logprobs":null,"finish_reason":null}]`,
+				`[{"text":" one","index":1,"logprobs":null,"finish_reason":null}]`,
+				`[{"text":"","index":1,"logprobs":null,"finish_reason":"stop"}]`,
+				`[] usage={"prompt_tokens":6,"completion_tokens":2,"total_tokens":8}`,
+				`[DONE]`,
+			},
+		},
+		{
+			name: "single prompt",
+			body: `{"model":"glm-4","prompt":"A","stream":true}`,
+			want: []string{
+				`[{"text":" one","index":0,"logprobs":null,"finish_reason":null}]`,
+				`[{"text":"","index":0,"logprobs":null,"finish_reason":"stop"}]`,
+				`[DONE]`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveJSON(h.HandleCompletions, tt.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+
+			var got []string
+			for _, data := range sseData(w.Body.String()) {
+				if data == "[DONE]" {
+					got = append(got, data)
+					continue
+				}
+				var chunk struct {
+					ID      string          `json:"id"`
+					Object  string          `json:"object"`
+					Choices json.RawMessage `json:"choices"`
+					Usage   json.RawMessage `json:"usage"`
+				}
+				if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+					t.Fatalf("decode chunk %s: %v", data, err)
+				}
+				if chunk.ID != "cmpl-abc" || chunk.Object != "text_completion" {
+					t.Errorf("chunk id/object = %s/%s", chunk.ID, chunk.Object)
+				}
+				line := string(chunk.Choices)
+				if chunk.Usage != nil {
+					line += " usage=" + string(chunk.Usage)
+				}
+				got = append(got, line)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("chunks =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestHandleCompletionsErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+		wantCalls  int
+	}{
+		{"missing prompt", `{"model":"glm-4"}`, nil, http.StatusBadRequest, 0},
+		{"token array prompt", `{"model":"glm-4","prompt":[1,2]}`, nil, http.StatusBadRequest, 0},
+		// 第一个 prompt 失败后不再处理后续 prompt
+		{"adapter error stops remaining prompts", `{"model":"glm-4","prompt":["A","B"]}`, errors.New("boom"), http.StatusInternalServerError, 1},
+		{"stream adapter error", `{"model":"glm-4","prompt":["A","B"],"stream":true}`, errors.New("boom"), http.StatusInternalServerError, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := &stubChat{err: tt.err}
+			w := serveJSON(NewChatHandler(chat).HandleCompletions, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var errResp model.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+				t.Fatalf("body is not a single error response: %s", w.Body.String())
+			}
+			if len(chat.requests) != tt.wantCalls {
+				t.Errorf("adapter called %d times, want %d", len(chat.requests), tt.wantCalls)
+			}
+		})
+	}
+}
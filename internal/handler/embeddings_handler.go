@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"strings"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/adapter/upstream"
+	"github.com/AtSunset1/prism/internal/metrics"
+	"github.com/AtSunset1/prism/internal/middleware"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/tracing"
+	"github.com/AtSunset1/prism/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// EmbeddingsHandler 处理向量化相关的HTTP请求
+// 路由、鉴权、指标与聊天接口共用：
+//   - 模型通过 AdapterManager 路由到适配器（适配器需实现 EmbeddingAdapter）
+//   - 调用方只能访问其API Key允许的模型
+//   - 网关层和上游指标使用相同的指标名
+type EmbeddingsHandler struct {
+	adapter adapter.EmbeddingAdapter // 向量化适配器（通常是 AdapterManager）
+}
+
+// NewEmbeddingsHandler 创建一个新的EmbeddingsHandler
+// 参数：
+//   - adapter: 向量化适配器（实现了EmbeddingAdapter接口）
+func NewEmbeddingsHandler(adapter adapter.EmbeddingAdapter) *EmbeddingsHandler {
+	return &EmbeddingsHandler{
+		adapter: adapter,
+	}
+}
+
+// HandleEmbeddings 处理向量化请求
+// 路由：POST /v1/embeddings
+//
+// 请求示例：
+//
+//	{
+//	  "model": "embedding-3",
+//	  "input": ["第一段文本", "第二段文本"],
+//	  "encoding_format": "base64"
+//	}
+func (h *EmbeddingsHandler) HandleEmbeddings(c *gin.Context) {
+	start := time.Now()
+
+	// 1. 解析请求body为EmbeddingRequest
+	var req model.EmbeddingRequest
+	c.Set(metricModelKey, unknownModel)
+	defer func() {
+		metrics.ObserveRequest(c.GetString(metricModelKey), false, c.Writer.Status(), time.Since(start))
+	}()
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, model.NewInvalidRequestError("无效的请求格式: "+err.Error(), "body"))
+		return
+	}
+	for _, input := range req.Input {
+		if strings.TrimSpace(input) == "" {
+			writeError(c, model.NewInvalidRequestError("input cannot contain empty strings", "input"))
+			return
+		}
+	}
+
+	// 2. 检查调用方是否有权访问该模型
+	if !middleware.GetPrincipal(c).CanAccess(req.Model) {
+		writeError(c, model.NewNotFoundError("model").WithCode("model_not_found"))
+		return
+	}
+	// AdapterManager 同时是模型注册表；其他适配器无法判断模型是否已注册，指标中记为 unknown
+	registry, _ := h.adapter.(ModelRegistry)
+	c.Set(metricModelKey, metricModel(registry, req.Model))
+
+	logger.AddFields(c.Request.Context(),
+		zap.String("model", req.Model),
+		zap.Int("inputs", len(req.Input)),
+	)
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(tracing.EmbeddingRequestAttributes(&req)...)
+
+	// 3. 调用适配器，并在响应头中返回上游调用结果
+	ctx, _ := upstream.NewContext(c.Request.Context())
+	c.Request = c.Request.WithContext(ctx)
+	resp, err := h.adapter.Embed(ctx, &req)
+	if err != nil {
+		logger.FromContext(ctx).Warn("向量模型调用失败", zap.Error(err))
+		reportUpstream(c, "")
+		writeError(c, adapterError(c, err))
+		return
+	}
+	reportUpstream(c, resp.Model)
+
+	logger.AddFields(c.Request.Context(),
+		zap.Int("prompt_tokens", resp.Usage.PromptTokens),
+		zap.Int("total_tokens", resp.Usage.TotalTokens),
+	)
+
+	// 4. 按请求的格式返回
+	if req.EncodingFormat == model.EncodingFormatBase64 {
+		resp.EncodeBase64()
+	}
+	c.JSON(200, resp)
+}
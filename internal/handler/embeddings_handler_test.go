@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// stubEmbedder 测试用向量化适配器：每个输入返回一个二维向量
+type stubEmbedder struct {
+	stubChat
+}
+
+func (s *stubEmbedder) Embed(ctx context.Context, req *model.EmbeddingRequest) (*model.EmbeddingResponse, error) {
+	resp := &model.EmbeddingResponse{Object: "list", Model: req.Model}
+	for i := range req.Input {
+		resp.Data = append(resp.Data, model.Embedding{
+			Object:    "embedding",
+			Index:     i,
+			Embedding: model.EmbeddingVector{Float: []float32{float32(i), 1}},
+		})
+	}
+	resp.Usage = model.EmbeddingUsage{PromptTokens: len(req.Input), TotalTokens: len(req.Input)}
+	return resp, nil
+}
+
+func TestHandleEmbeddings(t *testing.T) {
+	manager := adapter.NewAdapterManager()
+	err := manager.Reload(map[string]adapter.ModelAdapter{
+		"embedding-3": &stubEmbedder{},
+		"glm-4":       &stubChat{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewEmbeddingsHandler(manager)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantData   int
+		base64     bool
+	}{
+		{"single input", `{"model":"embedding-3","input":"hi"}`, http.StatusOK, "", 1, false},
+		{"batch base64", `{"model":"embedding-3","input":["a","b"],"encoding_format":"base64"}`, http.StatusOK, "", 2, true},
+		{"empty string input", `{"model":"embedding-3","input":["a",""]}`, http.StatusBadRequest, "", 0, false},
+		{"unknown encoding", `{"model":"embedding-3","input":"hi","encoding_format":"hex"}`, http.StatusBadRequest, "", 0, false},
+		{"chat-only model", `{"model":"glm-4","input":"hi"}`, http.StatusBadRequest, "model_not_supported", 0, false},
+		{"unregistered model", `{"model":"missing","input":"hi"}`, http.StatusNotFound, "model_not_found", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveJSON(h.HandleEmbeddings, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				var errResp model.ErrorResponse
+				json.Unmarshal(w.Body.Bytes(), &errResp)
+				if tt.wantCode != "" && errResp.Error.Code != tt.wantCode {
+					t.Errorf("error code = %q, want %q", errResp.Error.Code, tt.wantCode)
+				}
+				return
+			}
+
+			var raw struct {
+				Data []struct {
+					Embedding json.RawMessage `json:"embedding"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+				t.Fatal(err)
+			}
+			if len(raw.Data) != tt.wantData {
+				t.Fatalf("data = %d, want %d", len(raw.Data), tt.wantData)
+			}
+			if isString := raw.Data[0].Embedding[0] == '"'; isString != tt.base64 {
+				t.Errorf("embedding = %s, base64 = %v", raw.Data[0].Embedding, tt.base64)
+			}
+		})
+	}
+}
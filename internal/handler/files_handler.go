@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/AtSunset1/prism/internal/audit"
+	"github.com/AtSunset1/prism/internal/batch"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// FilesHandler 处理 OpenAI Files API 请求（批处理的输入和输出文件）
+// 文件按上传时的API Key隔离，只有同一个Key可以读取和删除
+type FilesHandler struct {
+	store *batch.FileStore
+}
+
+// NewFilesHandler 创建一个新的FilesHandler
+// 参数：
+//   - store: 文件存储
+func NewFilesHandler(store *batch.FileStore) *FilesHandler {
+	return &FilesHandler{store: store}
+}
+
+// HandleUpload 上传文件
+// 路由：POST /v1/files（multipart/form-data）
+// 目前只支持 purpose=batch（每行一个聊天请求的JSONL文件）
+//
+// 请求示例：
+//
+//	curl http://localhost:8080/v1/files -F purpose=batch -F file=@requests.jsonl
+func (h *FilesHandler) HandleUpload(c *gin.Context) {
+	purpose := c.PostForm("purpose")
+	if purpose != batch.PurposeBatch {
+		writeError(c, model.NewInvalidRequestError(
+			fmt.Sprintf("purpose must be %s", batch.PurposeBatch), "purpose",
+		))
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		writeError(c, model.NewInvalidRequestError("file is required", "file"))
+		return
+	}
+	src, err := header.Open()
+	if err != nil {
+		writeError(c, model.NewServerError("读取上传文件失败: "+err.Error()))
+		return
+	}
+	defer src.Close()
+
+	file, err := h.store.Create(src, header.Filename, purpose, audit.KeyID(c.GetHeader("Authorization")))
+	if err != nil {
+		if errors.Is(err, batch.ErrFileTooLarge) {
+			writeError(c, model.NewInvalidRequestError("file exceeds the maximum allowed size", "file").WithCode("file_too_large"))
+			return
+		}
+		writeError(c, model.NewServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, file)
+}
+
+// HandleList 列出文件
+// 路由：GET /v1/files?purpose=batch
+func (h *FilesHandler) HandleList(c *gin.Context) {
+	files, err := h.store.List(audit.KeyID(c.GetHeader("Authorization")), c.Query("purpose"))
+	if err != nil {
+		writeError(c, model.NewServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, batch.ListResponse[batch.File]{Object: "list", Data: files})
+}
+
+// HandleGet 读取文件信息
+// 路由：GET /v1/files/:id
+func (h *FilesHandler) HandleGet(c *gin.Context) {
+	file, err := h.store.Get(c.Param("id"), audit.KeyID(c.GetHeader("Authorization")))
+	if err != nil {
+		writeError(c, model.NewNotFoundError("file"))
+		return
+	}
+	c.JSON(http.StatusOK, file)
+}
+
+// HandleContent 下载文件内容
+// 路由：GET /v1/files/:id/content
+func (h *FilesHandler) HandleContent(c *gin.Context) {
+	f, file, err := h.store.Open(c.Param("id"), audit.KeyID(c.GetHeader("Authorization")))
+	if err != nil {
+		writeError(c, model.NewNotFoundError("file"))
+		return
+	}
+	defer f.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Filename))
+	c.DataFromReader(http.StatusOK, file.Bytes, "application/jsonl", f, nil)
+}
+
+// HandleDelete 删除文件
+// 路由：DELETE /v1/files/:id
+// 批处理任务结束前删除其输入文件，任务会在读取文件时失败
+func (h *FilesHandler) HandleDelete(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.store.Delete(id, audit.KeyID(c.GetHeader("Authorization"))); err != nil {
+		if errors.Is(err, batch.ErrNotFound) {
+			writeError(c, model.NewNotFoundError("file"))
+			return
+		}
+		writeError(c, model.NewServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, batch.DeletedFile{ID: id, Object: "file", Deleted: true})
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/AtSunset1/prism/internal/health"
+	"github.com/AtSunset1/prism/internal/lifecycle"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler 处理健康检查请求
+//   - /livez：进程存活（用于存活探针，不依赖上游）
+//   - /readyz：是否可以接收流量（正在关闭或所有上游都不可用时返回503）
+//   - /health：兼容旧接口，只反映是否正在关闭
+type HealthHandler struct {
+	drainer *lifecycle.Drainer
+	prober  *health.Prober
+}
+
+// NewHealthHandler 创建一个新的HealthHandler
+// 参数：
+//   - drainer: 排空状态（可为nil，表示从不关闭）
+//   - prober: 上游健康探测器（可为nil，表示不检查上游）
+func NewHealthHandler(drainer *lifecycle.Drainer, prober *health.Prober) *HealthHandler {
+	return &HealthHandler{
+		drainer: drainer,
+		prober:  prober,
+	}
+}
+
+// HandleHealth 健康检查
+// 路由：GET /health
+func (h *HealthHandler) HandleHealth(c *gin.Context) {
+	if h.drainer.Draining() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "draining",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "healthy",
+	})
+}
+
+// HandleLivez 存活检查
+// 路由：GET /livez
+// 只要进程能处理请求就返回200（包括正在关闭时），上游故障不应导致网关被重启
+func (h *HealthHandler) HandleLivez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "alive",
+	})
+}
+
+// HandleReadyz 就绪检查
+// 路由：GET /readyz
+// 返回后台探测器缓存的各适配器状态，本身不发起探测
+//
+// 响应示例：
+//
+//	{
+//	  "status": "ready",
+//	  "adapters": [
+//	    {"adapter": "glm", "healthy": true, "latency_ms": 83, "checked_at": "2025-01-01T00:00:00Z"}
+//	  ]
+//	}
+func (h *HealthHandler) HandleReadyz(c *gin.Context) {
+	status, code := "ready", http.StatusOK
+	switch {
+	case h.drainer.Draining():
+		status, code = "draining", http.StatusServiceUnavailable
+	case h.prober != nil && !h.prober.Ready():
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+
+	adapters := h.prober.Statuses()
+	if adapters == nil {
+		adapters = []health.AdapterStatus{}
+	}
+	c.JSON(code, gin.H{
+		"status":   status,
+		"adapters": adapters,
+	})
+}
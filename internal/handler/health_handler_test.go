@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/lifecycle"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// blockingChat 流式调用返回一个数据块后一直不结束，直到请求被取消
+type blockingChat struct {
+	stubChat
+}
+
+func (b *blockingChat) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
+	ch := make(chan *model.StreamResponse, 1)
+	ch <- model.NewStreamResponse("chatcmpl-1", req.Model, "", true)
+	go func() {
+		<-ctx.Done()
+		close(ch)
+	}()
+	return ch, nil
+}
+
+func TestHealthDraining(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	drainer := lifecycle.NewDrainer()
+	h := NewHealthHandler(drainer, nil)
+	r := gin.New()
+	r.GET("/health", h.HandleHealth)
+	r.GET("/livez", h.HandleLivez)
+	r.GET("/readyz", h.HandleReadyz)
+
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	for _, path := range []string{"/health", "/livez", "/readyz"} {
+		if code := get(path); code != http.StatusOK {
+			t.Errorf("%s = %d before draining", path, code)
+		}
+	}
+
+	// 排空开始后只有存活检查仍返回200
+	drainer.Start()
+	want := map[string]int{"/health": http.StatusServiceUnavailable, "/livez": http.StatusOK, "/readyz": http.StatusServiceUnavailable}
+	for path, code := range want {
+		if got := get(path); got != code {
+			t.Errorf("%s = %d while draining, want %d", path, got, code)
+		}
+	}
+}
+
+func TestStreamEndsWhenDrainExpires(t *testing.T) {
+	drainer := lifecycle.NewDrainer()
+	drainer.Expire()
+	h := NewChatHandler(&blockingChat{}, WithDrainer(drainer))
+
+	w := serveJSON(h.HandleChatCompletion, `{"model":"glm-4","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	body := w.Body.String()
+	if !strings.Contains(body, "server_shutting_down") {
+		t.Errorf("body = %s, want server_shutting_down error event", body)
+	}
+	if strings.Contains(body, "[DONE]") {
+		t.Error("interrupted stream sent [DONE]")
+	}
+}
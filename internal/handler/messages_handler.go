@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/anthropic"
+	"github.com/AtSunset1/prism/internal/metrics"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/tokenizer"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleMessages 处理 Anthropic Messages API 请求
+// 路由：POST /v1/messages
+// 请求转换为 ChatRequest 后与 /v1/chat/completions 使用同一套处理流程和模型路由，
+// 可以由任意上游处理；响应（包括完整的流式事件序列）和错误都转换回 Anthropic 格式
+//
+// 请求示例：
+//
+//	{
+//	  "model": "glm-4-flash",
+//	  "max_tokens": 1024,
+//	  "system": "你是一个助手",
+//	  "messages": [{"role": "user", "content": "你好"}]
+//	}
+func (h *ChatHandler) HandleMessages(c *gin.Context) {
+	start := time.Now()
+
+	// 1. 解析请求body为 Messages API 请求
+	var req anthropic.MessagesRequest
+	c.Set(metricModelKey, unknownModel)
+	defer func() {
+		metrics.ObserveRequest(c.GetString(metricModelKey), req.Stream, c.Writer.Status(), time.Since(start))
+	}()
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		protocolError(c, anthropicMessages{}, model.NewInvalidRequestError("无效的请求格式: "+err.Error(), "body"))
+		return
+	}
+
+	// 2. 转换为聊天请求
+	chatReq, err := anthropic.ToChatRequest(&req)
+	if err != nil {
+		param := "messages"
+		var paramErr *model.ParamError
+		if errors.As(err, &paramErr) {
+			param = paramErr.Param
+		}
+		protocolError(c, anthropicMessages{}, model.NewInvalidRequestError(err.Error(), param))
+		return
+	}
+
+	// 3. 经过公共处理流程，按 Anthropic 格式返回
+	h.serve(c, chatReq, anthropicMessages{req: chatReq}, start)
+}
+
+// anthropicMessages Anthropic Messages API 格式（/v1/messages）
+type anthropicMessages struct {
+	// req 转换后的聊天请求（估算 message_start 中的输入token数）
+	req *model.ChatRequest
+}
+
+func (anthropicMessages) writeError(c *gin.Context, errResp *model.ErrorResponse) {
+	c.JSON(errResp.GetHTTPStatus(), anthropic.FromError(errResp))
+}
+
+func (anthropicMessages) writeResponse(c *gin.Context, resp *model.ChatResponse) {
+	c.JSON(http.StatusOK, anthropic.FromChatResponse(resp))
+}
+
+func (p anthropicMessages) newStream() chatStream {
+	tok, _ := tokenizer.ForModel(p.req.Model)
+	return &anthropicStream{events: anthropic.NewStream(tokenizer.CountRequest(tok, p.req))}
+}
+
+// anthropicStream 以 message_start、content_block_* 等事件输出
+type anthropicStream struct {
+	events *anthropic.Stream
+}
+
+func (s *anthropicStream) chunk(c *gin.Context, chunk *model.StreamResponse) {
+	writeAnthropicEvents(c, s.events.Chunk(chunk))
+}
+
+func (s *anthropicStream) fail(c *gin.Context, errResp *model.ErrorResponse) {
+	// 尚未开始输出时与 Anthropic 一致，以HTTP错误返回
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.JSON(errResp.GetHTTPStatus(), anthropic.FromError(errResp))
+		return
+	}
+	writeAnthropicEvents(c, []anthropic.Event{anthropic.ErrorEvent(errResp)})
+}
+
+func (s *anthropicStream) finish(c *gin.Context, resp *model.ChatResponse) {
+	writeAnthropicEvents(c, s.events.Finish(resp))
+}
+
+// writeAnthropicEvents 依次发送流式事件
+func writeAnthropicEvents(c *gin.Context, events []anthropic.Event) {
+	for _, ev := range events {
+		data, _ := json.Marshal(ev.Data)
+		writeEvent(c, ev.Type, data)
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// sseEvents 返回SSE响应中所有 event 行的事件类型
+func sseEvents(body string) []string {
+	var events []string
+	for _, line := range strings.Split(body, "\n") {
+		if after, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, after)
+		}
+	}
+	return events
+}
+
+func TestHandleMessages(t *testing.T) {
+	stop := "stop"
+	chat := &stubChat{
+		resp: &model.ChatResponse{
+			ID:      "chatcmpl-1",
+			Model:   "glm-4",
+			Choices: []model.Choice{{Message: &model.Message{Role: "assistant", Content: model.NewTextContent("Hello")}, FinishReason: "stop"}},
+			Usage:   model.Usage{PromptTokens: 5, CompletionTokens: 1, TotalTokens: 6},
+		},
+		chunks: []*model.StreamResponse{
+			model.NewStreamResponse("chatcmpl-1", "glm-4", "", true),
+			model.NewStreamResponse("chatcmpl-1", "glm-4", "Hello", false),
+			{ID: "chatcmpl-1", Model: "glm-4", Choices: []model.StreamChoice{{FinishReason: &stop}}},
+			model.NewStreamUsageResponse("chatcmpl-1", "glm-4", 0, model.Usage{PromptTokens: 5, CompletionTokens: 1, TotalTokens: 6}),
+		},
+	}
+	h := NewChatHandler(chat)
+
+	t.Run("non-stream", func(t *testing.T) {
+		w := serveJSON(h.HandleMessages, `{"model":"glm-4","max_tokens":16,"system":"be brief","messages":[{"role":"user","content":"hi"}]}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+		}
+		want := `{"id":"msg_1","type":"message","role":"assistant","model":"glm-4","content":[{"type":"text","text":"Hello"}],"stop_reason":"end_turn","stop_sequence":null,"usage":{"input_tokens":5,"output_tokens":1}}`
+		if got := w.Body.String(); got != want {
+			t.Errorf("body =\n%s\nwant\n%s", got, want)
+		}
+
+		sent := chat.requests[len(chat.requests)-1]
+		if len(sent.Messages) != 2 || sent.Messages[0].Role != "system" || sent.GetMaxTokens() != 16 {
+			t.Errorf("adapter received %+v", sent)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		w := serveJSON(h.HandleMessages, `{"model":"glm-4","max_tokens":16,"stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+		}
+		wantEvents := []string{"message_start", "ping", "content_block_start", "content_block_delta", "content_block_stop", "message_delta", "message_stop"}
+		events := sseEvents(w.Body.String())
+		if strings.Join(events, ",") != strings.Join(wantEvents, ",") {
+			t.Fatalf("events = %v, want %v", events, wantEvents)
+		}
+
+		// message_start 与 message_delta 报告相同的输入token数
+		var start struct {
+			Message struct {
+				Usage struct {
+					InputTokens int `json:"input_tokens"`
+				} `json:"usage"`
+			} `json:"message"`
+		}
+		var delta struct {
+			Usage struct {
+				InputTokens  int `json:"input_tokens"`
+				OutputTokens int `json:"output_tokens"`
+			} `json:"usage"`
+		}
+		data := sseData(w.Body.String())
+		if err := json.Unmarshal([]byte(data[0]), &start); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(data[len(data)-2]), &delta); err != nil {
+			t.Fatal(err)
+		}
+		if start.Message.Usage.InputTokens == 0 || delta.Usage.InputTokens != start.Message.Usage.InputTokens {
+			t.Errorf("input_tokens: message_start %d, message_delta %d", start.Message.Usage.InputTokens, delta.Usage.InputTokens)
+		}
+		if delta.Usage.OutputTokens != 1 {
+			t.Errorf("message_delta output_tokens = %d, want 1", delta.Usage.OutputTokens)
+		}
+	})
+}
+
+func TestHandleMessagesErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		chat       *stubChat
+		body       string
+		wantStatus int
+		wantType   string
+	}{
+		{"missing max_tokens", &stubChat{}, `{"model":"glm-4","messages":[{"role":"user","content":"hi"}]}`, http.StatusBadRequest, model.ErrorTypeInvalidRequest},
+		{"unsupported block", &stubChat{}, `{"model":"glm-4","max_tokens":1,"messages":[{"role":"user","content":[{"type":"server_tool_use"}]}]}`, http.StatusBadRequest, model.ErrorTypeInvalidRequest},
+		{"unknown model", &stubChat{err: adapter.ErrModelNotFound}, `{"model":"glm-4","max_tokens":1,"messages":[{"role":"user","content":"hi"}]}`, http.StatusNotFound, model.ErrorTypeNotFound},
+		{"stream unknown model", &stubChat{err: adapter.ErrModelNotFound}, `{"model":"glm-4","max_tokens":1,"stream":true,"messages":[{"role":"user","content":"hi"}]}`, http.StatusNotFound, model.ErrorTypeNotFound},
+		{"upstream failure", &stubChat{err: errors.New("connection reset")}, `{"model":"glm-4","max_tokens":1,"messages":[{"role":"user","content":"hi"}]}`, http.StatusInternalServerError, model.ErrorTypeAPIError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveJSON(NewChatHandler(tt.chat).HandleMessages, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var resp struct {
+				Type  string `json:"type"`
+				Error struct {
+					Type string `json:"type"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("body is not JSON: %s", w.Body.String())
+			}
+			if resp.Type != "error" || resp.Error.Type != tt.wantType {
+				t.Errorf("body = %s, want error type %s", w.Body.String(), tt.wantType)
+			}
+		})
+	}
+}
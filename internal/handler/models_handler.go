@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/middleware"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/config"
+	"github.com/gin-gonic/gin"
+)
+
+// ModelsHandler 处理模型列表相关的HTTP请求
+// 模型来自 AdapterManager 的注册关系，扩展元数据来自配置文件的 models 段，
+// 两者在热加载后都会自动生效
+type ModelsHandler struct {
+	manager *adapter.AdapterManager
+
+	// created 模型的创建时间（网关没有模型上线时间，统一使用启动时间）
+	created int64
+}
+
+// NewModelsHandler 创建一个新的ModelsHandler
+// 参数：
+//   - manager: 适配器管理器
+func NewModelsHandler(manager *adapter.AdapterManager) *ModelsHandler {
+	return &ModelsHandler{
+		manager: manager,
+		created: time.Now().Unix(),
+	}
+}
+
+// HandleListModels 列出调用方可以访问的模型
+// 路由：GET /v1/models
+func (h *ModelsHandler) HandleListModels(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+	cfg := config.GetConfig()
+
+	names := h.manager.ListModels()
+	sort.Strings(names)
+
+	data := make([]model.ModelObject, 0, len(names))
+	for _, name := range names {
+		if !principal.CanAccess(name) {
+			continue
+		}
+		if obj, ok := h.modelObject(cfg, name); ok {
+			data = append(data, obj)
+		}
+	}
+
+	c.JSON(http.StatusOK, model.ModelList{
+		Object: "list",
+		Data:   data,
+	})
+}
+
+// HandleGetModel 获取单个模型
+// 路由：GET /v1/models/:id
+// 调用方无权访问的模型与不存在的模型一样返回404
+func (h *ModelsHandler) HandleGetModel(c *gin.Context) {
+	// 模型名称可能包含"/"（如 org/model），使用通配路由参数
+	id := strings.TrimPrefix(c.Param("id"), "/")
+
+	if !middleware.GetPrincipal(c).CanAccess(id) {
+		h.notFound(c)
+		return
+	}
+
+	obj, ok := h.modelObject(config.GetConfig(), id)
+	if !ok {
+		h.notFound(c)
+		return
+	}
+	c.JSON(http.StatusOK, obj)
+}
+
+// modelObject 组装单个模型信息
+// 模型未注册时返回false
+func (h *ModelsHandler) modelObject(cfg *config.Config, id string) (model.ModelObject, bool) {
+	adp, err := h.manager.GetAdapter(id)
+	if err != nil {
+		return model.ModelObject{}, false
+	}
+
+	obj := model.ModelObject{
+		ID:      id,
+		Object:  "model",
+		Created: h.created,
+		OwnedBy: adp.Name(),
+		Adapter: adp.Name(),
+	}
+
+	if cfg == nil {
+		return obj, true
+	}
+	meta, ok := cfg.GetModel(id)
+	if !ok {
+		return obj, true
+	}
+
+	if meta.OwnedBy != "" {
+		obj.OwnedBy = meta.OwnedBy
+	}
+	obj.ContextWindow = meta.ContextWindow
+	obj.MaxOutputTokens = meta.MaxOutputTokens
+	obj.Capabilities = meta.Capabilities
+	if meta.Pricing != (config.ModelPricing{}) {
+		obj.Pricing = &model.ModelPricing{
+			Input:    meta.Pricing.Input,
+			Output:   meta.Pricing.Output,
+			Currency: meta.Pricing.Currency,
+		}
+	}
+	return obj, true
+}
+
+// notFound 返回模型不存在错误
+func (h *ModelsHandler) notFound(c *gin.Context) {
+	errResp := model.NewNotFoundError("model").WithCode("model_not_found")
+	c.JSON(errResp.GetHTTPStatus(), errResp)
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/auth"
+	"github.com/AtSunset1/prism/internal/middleware"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/config"
+	"github.com/gin-gonic/gin"
+)
+
+// baseConfig 不含模型元数据的最小配置
+const baseConfig = `
+adapters:
+  glm:
+    api_key: "test-key"
+    base_url: "https://example.com/v1"
+    models: ["glm-4"]
+`
+
+// loadConfig 写入并加载配置文件（更新全局配置）
+// 测试结束后恢复为 baseConfig，避免模型元数据影响其他测试
+func loadConfig(t *testing.T, content string) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cfg, err := config.Load(write("config.yaml", content))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	base := write("base.yaml", baseConfig)
+	t.Cleanup(func() {
+		if _, err := config.Load(base); err != nil {
+			t.Errorf("restore config: %v", err)
+		}
+	})
+	return cfg
+}
+
+const modelsConfig = `
+adapters:
+  glm:
+    api_key: "test-key"
+    base_url: "https://example.com/v1"
+    models: ["glm-4", "glm-4-flash", "org/custom"]
+auth:
+  enabled: true
+  keys:
+    - name: full
+      key: sk-full
+    - name: limited
+      key: sk-limited
+      models: ["glm-4-flash"]
+models:
+  glm-4:
+    owned_by: zhipuai
+    context_window: 128000
+    pricing:
+      input: 100
+      output: 100
+      currency: CNY
+    capabilities: ["chat", "stream"]
+`
+
+// modelsRouter 组装带鉴权的模型路由
+func modelsRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	cfg := loadConfig(t, modelsConfig)
+
+	stub := &stubChat{}
+	manager := adapter.NewAdapterManager()
+	if err := manager.Reload(map[string]adapter.ModelAdapter{"glm-4": stub, "glm-4-flash": stub, "org/custom": stub}); err != nil {
+		t.Fatal(err)
+	}
+	h := NewModelsHandler(manager)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Auth(auth.New(cfg.Auth)))
+	r.GET("/models", h.HandleListModels)
+	r.GET("/models/*id", h.HandleGetModel)
+	return r
+}
+
+func getWithKey(r *gin.Engine, path, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestListModels(t *testing.T) {
+	r := modelsRouter(t)
+
+	tests := []struct {
+		name string
+		key  string
+		want []string
+	}{
+		{"all models", "sk-full", []string{"glm-4", "glm-4-flash", "org/custom"}},
+		{"restricted key", "sk-limited", []string{"glm-4-flash"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := getWithKey(r, "/models", tt.key)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+			var list model.ModelList
+			if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, m := range list.Data {
+				ids = append(ids, m.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("models = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetModel(t *testing.T) {
+	r := modelsRouter(t)
+
+	tests := []struct {
+		name       string
+		path       string
+		key        string
+		wantStatus int
+	}{
+		{"configured model", "/models/glm-4", "sk-full", http.StatusOK},
+		{"model name with slash", "/models/org/custom", "sk-full", http.StatusOK},
+		{"unregistered model", "/models/gpt-4o", "sk-full", http.StatusNotFound},
+		{"model outside key scope", "/models/glm-4", "sk-limited", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := getWithKey(r, tt.path, tt.key)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	var obj model.ModelObject
+	json.Unmarshal(getWithKey(r, "/models/glm-4", "sk-full").Body.Bytes(), &obj)
+	if obj.OwnedBy != "zhipuai" || obj.Adapter != "stub" || obj.ContextWindow != 128000 {
+		t.Errorf("model = %+v", obj)
+	}
+	if obj.Pricing == nil || obj.Pricing.Input != 100 || obj.Pricing.Currency != "CNY" {
+		t.Errorf("pricing = %+v", obj.Pricing)
+	}
+
+	// 没有元数据的模型使用适配器名称作为所属组织
+	var bare model.ModelObject
+	json.Unmarshal(getWithKey(r, "/models/glm-4-flash", "sk-full").Body.Bytes(), &bare)
+	if bare.OwnedBy != "stub" || bare.Pricing != nil || bare.ContextWindow != 0 {
+		t.Errorf("model without metadata = %+v", bare)
+	}
+}
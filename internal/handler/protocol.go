@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// chatProtocol 聊天类接口的响应格式
+// 处理流程（校验、截断、多模态、缓存、期限、审计）与接口协议无关，见 ChatHandler.serve；
+// 各协议只负责把网关内部的 ChatResponse / StreamResponse 转换为自己的格式
+// 协议实例按请求创建，可以保存转换需要的请求信息
+type chatProtocol interface {
+	// writeError 返回错误响应（指标和审计由调用方记录）
+	writeError(c *gin.Context, errResp *model.ErrorResponse)
+
+	// writeResponse 返回非流式响应
+	writeResponse(c *gin.Context, resp *model.ChatResponse)
+
+	// newStream 开始一次流式响应（SSE响应头已设置，尚未写入）
+	newStream() chatStream
+}
+
+// chatStream 一次流式响应的输出
+// 可以保存事件序号、已开始的内容块等转换状态
+type chatStream interface {
+	// chunk 发送一个数据块（用量数据块是否转发由协议决定）
+	chunk(c *gin.Context, chunk *model.StreamResponse)
+
+	// fail 发送错误并结束流
+	// 上游调用初始化失败时也会调用，此时尚未写入任何数据
+	fail(c *gin.Context, errResp *model.ErrorResponse)
+
+	// finish 正常结束流
+	// 参数：
+	//   - resp: 拼装后的完整响应
+	finish(c *gin.Context, resp *model.ChatResponse)
+}
+
+// openAIChat OpenAI Chat Completions 格式（/v1/chat/completions）
+type openAIChat struct {
+	// includeUsage 是否转发流末尾的用量数据块（stream_options.include_usage）
+	includeUsage bool
+}
+
+func (openAIChat) writeError(c *gin.Context, errResp *model.ErrorResponse) {
+	c.JSON(errResp.GetHTTPStatus(), errResp)
+}
+
+func (openAIChat) writeResponse(c *gin.Context, resp *model.ChatResponse) {
+	c.JSON(http.StatusOK, resp)
+}
+
+func (p openAIChat) newStream() chatStream {
+	return &openAIChatStream{includeUsage: p.includeUsage}
+}
+
+// openAIChatStream 以 chat.completion.chunk 数据块输出，最后发送 [DONE]
+type openAIChatStream struct {
+	includeUsage bool
+}
+
+func (s *openAIChatStream) chunk(c *gin.Context, chunk *model.StreamResponse) {
+	// 流末尾的用量数据块只在调用方设置 stream_options.include_usage 时转发
+	if chunk.IsUsage() && !s.includeUsage {
+		return
+	}
+
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		// JSON序列化失败（理论上不应该发生），跳过该数据块
+		errResp := model.NewServerError("数据序列化失败: " + err.Error())
+		recordError(c, errResp)
+		s.fail(c, errResp)
+		return
+	}
+	writeSSE(c, data)
+}
+
+func (s *openAIChatStream) fail(c *gin.Context, errResp *model.ErrorResponse) {
+	data, _ := json.Marshal(errResp)
+	writeSSE(c, data)
+}
+
+func (s *openAIChatStream) finish(c *gin.Context, resp *model.ChatResponse) {
+	writeSSE(c, []byte("[DONE]"))
+}
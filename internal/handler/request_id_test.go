@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/adapter/glm"
+	"github.com/AtSunset1/prism/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestChatCompletionUpstreamHeaders(t *testing.T) {
+	var sentID string
+	upstreamSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sentID = r.Header.Get(middleware.HeaderRequestID)
+		w.Header().Set("X-Request-Id", "upstream-1")
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte(`data: {"id":"u1","model":"glm-4-0520","choices":[{"index":0,"delta":{"role":"assistant","content":"hi"}}]}` + "\n\n"))
+			w.Write([]byte("data: [DONE]\n\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"u1","object":"chat.completion","model":"glm-4-0520","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`))
+	}))
+	defer upstreamSrv.Close()
+
+	manager := adapter.NewAdapterManager()
+	if err := manager.Register("glm-4", glm.NewGLMAdapterWithConfig("", "key", upstreamSrv.URL, 0)); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Logger(zap.NewNop()))
+	r.POST("/v1/chat/completions", NewChatHandler(manager).HandleChatCompletion)
+
+	tests := []struct {
+		name     string
+		stream   string
+		clientID string
+	}{
+		{"non-stream with client id", "false", "client-req-1"},
+		{"stream with client id", "true", "client-req-2"},
+		{"generated id", "false", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"model":"glm-4","stream":` + tt.stream + `,"messages":[{"role":"user","content":"hi"}]}`
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.clientID != "" {
+				req.Header.Set(middleware.HeaderRequestID, tt.clientID)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+
+			id := w.Header().Get(middleware.HeaderRequestID)
+			if tt.clientID != "" && id != tt.clientID {
+				t.Errorf("%s = %q, want %q", middleware.HeaderRequestID, id, tt.clientID)
+			}
+			if id == "" || sentID != id {
+				t.Errorf("upstream received request id %q, response carries %q", sentID, id)
+			}
+
+			wantHeaders := map[string]string{
+				HeaderAdapter:           glm.GLMName,
+				HeaderUpstreamModel:     "glm-4-0520",
+				HeaderUpstreamRequestID: "upstream-1",
+			}
+			for header, want := range wantHeaders {
+				if got := w.Header().Get(header); got != want {
+					t.Errorf("%s = %q, want %q", header, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/audit"
+	"github.com/AtSunset1/prism/internal/metrics"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/responses"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ResponsesHandler 处理 OpenAI Responses API 请求
+// 创建响应时转换为 ChatRequest，经过 ChatHandler 的公共处理流程；
+// store=true 的响应保存在本地，供 previous_response_id 重建对话和 GET 读取
+type ResponsesHandler struct {
+	chat  *ChatHandler
+	store *responses.Store
+}
+
+// NewResponsesHandler 创建一个新的ResponsesHandler
+// 参数：
+//   - chat: 聊天处理器（复用其处理流程）
+//   - store: 响应存储
+func NewResponsesHandler(chat *ChatHandler, store *responses.Store) *ResponsesHandler {
+	return &ResponsesHandler{
+		chat:  chat,
+		store: store,
+	}
+}
+
+// HandleCreate 创建响应
+// 路由：POST /v1/responses
+// 设置 previous_response_id 时从本地存储取出之前的对话，与本次输入拼接后请求上游
+//
+// 请求示例：
+//
+//	{
+//	  "model": "glm-4-flash",
+//	  "instructions": "你是一个助手",
+//	  "input": "你好",
+//	  "previous_response_id": "resp_Xk3fP9qLw2ZbT7nRc0VdYs1a"
+//	}
+func (h *ResponsesHandler) HandleCreate(c *gin.Context) {
+	start := time.Now()
+
+	// 1. 解析请求body
+	var req responses.Request
+	c.Set(metricModelKey, unknownModel)
+	defer func() {
+		metrics.ObserveRequest(c.GetString(metricModelKey), req.Stream, c.Writer.Status(), time.Since(start))
+	}()
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, model.NewInvalidRequestError("无效的请求格式: "+err.Error(), "body"))
+		return
+	}
+
+	// 2. 取出之前的对话（只能继续同一个API Key创建的响应）
+	keyID := audit.KeyID(c.GetHeader("Authorization"))
+	var history []model.Message
+	if req.PreviousResponseID != "" {
+		rec, err := h.store.Get(req.PreviousResponseID, keyID)
+		if err != nil {
+			writeError(c, model.NewInvalidRequestError(
+				"previous response with id '"+req.PreviousResponseID+"' not found", "previous_response_id",
+			).WithCode("previous_response_not_found"))
+			return
+		}
+		history = rec.Messages
+	}
+
+	// 3. 转换为聊天请求
+	chatReq, conversation, err := responses.ToChatRequest(&req, history)
+	if err != nil {
+		param := "input"
+		var paramErr *model.ParamError
+		if errors.As(err, &paramErr) {
+			param = paramErr.Param
+		}
+		writeError(c, model.NewInvalidRequestError(err.Error(), param))
+		return
+	}
+
+	// 4. 经过公共处理流程，按 Responses API 格式返回
+	protocol := &openAIResponses{
+		store:        h.store,
+		keyID:        keyID,
+		tmpl:         responses.NewResponse(&req),
+		conversation: conversation,
+	}
+	h.chat.serve(c, chatReq, protocol, start)
+}
+
+// HandleGet 读取保存的响应
+// 路由：GET /v1/responses/:id
+func (h *ResponsesHandler) HandleGet(c *gin.Context) {
+	rec, err := h.store.Get(c.Param("id"), audit.KeyID(c.GetHeader("Authorization")))
+	if err != nil {
+		writeError(c, model.NewNotFoundError("response"))
+		return
+	}
+	c.JSON(http.StatusOK, rec.Response)
+}
+
+// HandleDelete 删除保存的响应
+// 路由：DELETE /v1/responses/:id
+func (h *ResponsesHandler) HandleDelete(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.store.Delete(id, audit.KeyID(c.GetHeader("Authorization"))); err != nil {
+		if errors.Is(err, responses.ErrNotFound) {
+			writeError(c, model.NewNotFoundError("response"))
+			return
+		}
+		writeError(c, model.NewServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, responses.DeletedResponse{ID: id, Object: "response", Deleted: true})
+}
+
+// openAIResponses OpenAI Responses API 格式（/v1/responses）
+// 错误格式与 Chat Completions 相同；完成的响应按 store 参数保存
+type openAIResponses struct {
+	store *responses.Store
+	keyID string
+
+	// tmpl 响应对象模板（ID在请求上游之前生成）
+	tmpl *responses.Response
+
+	// conversation 本次请求的对话（之前的对话 + 本次输入），保存时追加模型的回复
+	conversation []model.Message
+}
+
+func (p *openAIResponses) writeError(c *gin.Context, errResp *model.ErrorResponse) {
+	c.JSON(errResp.GetHTTPStatus(), errResp)
+}
+
+func (p *openAIResponses) writeResponse(c *gin.Context, resp *model.ChatResponse) {
+	out := responses.FromChatResponse(p.tmpl, resp)
+	p.save(out, resp)
+	c.JSON(http.StatusOK, out)
+}
+
+func (p *openAIResponses) newStream() chatStream {
+	return &responsesStream{protocol: p, events: responses.NewStream(p.tmpl)}
+}
+
+// save 保存完成的响应（store=false 时不保存）
+// 保存失败只记录日志，不影响本次响应
+func (p *openAIResponses) save(out *responses.Response, resp *model.ChatResponse) {
+	if !out.Store {
+		return
+	}
+
+	messages := append([]model.Message(nil), p.conversation...)
+	if choice := resp.GetFirstChoice(); choice != nil && choice.Message != nil {
+		messages = append(messages, *choice.Message)
+	}
+	rec := &responses.Record{Response: out, KeyID: p.keyID, Messages: messages}
+	if err := p.store.Save(rec); err != nil {
+		zap.L().Warn("保存响应失败", zap.String("id", out.ID), zap.Error(err))
+	}
+}
+
+// responsesStream 以 response.* 事件输出
+type responsesStream struct {
+	protocol *openAIResponses
+	events   *responses.Stream
+}
+
+func (s *responsesStream) chunk(c *gin.Context, chunk *model.StreamResponse) {
+	writeResponsesEvents(c, s.events.Chunk(chunk))
+}
+
+func (s *responsesStream) fail(c *gin.Context, errResp *model.ErrorResponse) {
+	// 尚未开始输出时以HTTP错误返回，与非流式请求一致
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.JSON(errResp.GetHTTPStatus(), errResp)
+		return
+	}
+	writeResponsesEvents(c, s.events.Fail(errResp))
+}
+
+func (s *responsesStream) finish(c *gin.Context, resp *model.ChatResponse) {
+	events, out := s.events.Finish(resp)
+	s.protocol.save(out, resp)
+	writeResponsesEvents(c, events)
+}
+
+// writeResponsesEvents 依次发送流式事件
+func writeResponsesEvents(c *gin.Context, events []responses.Event) {
+	for _, ev := range events {
+		data, _ := json.Marshal(ev.Data)
+		writeEvent(c, ev.Type, data)
+	}
+}
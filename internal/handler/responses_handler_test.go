@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/responses"
+	"github.com/gin-gonic/gin"
+)
+
+// responsesRouter 注册 Responses API 路由
+func responsesRouter(t *testing.T, chat *stubChat) *gin.Engine {
+	t.Helper()
+	store, err := responses.NewStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	h := NewResponsesHandler(NewChatHandler(chat), store)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/v1/responses", h.HandleCreate)
+	r.GET("/v1/responses/:id", h.HandleGet)
+	r.DELETE("/v1/responses/:id", h.HandleDelete)
+	return r
+}
+
+// serveResponses 以指定API Key发送请求
+func serveResponses(r *gin.Engine, method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// decodeResponse 解析响应对象
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder) *responses.Response {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var resp responses.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return &resp
+}
+
+// replyChat 返回固定文本回复的适配器
+func replyChat(text string) *stubChat {
+	stop := "stop"
+	return &stubChat{
+		resp: &model.ChatResponse{
+			ID:      "chatcmpl-1",
+			Model:   "glm-4",
+			Choices: []model.Choice{{Message: &model.Message{Role: "assistant", Content: model.NewTextContent(text)}, FinishReason: "stop"}},
+			Usage:   model.Usage{PromptTokens: 5, CompletionTokens: 1, TotalTokens: 6},
+		},
+		chunks: []*model.StreamResponse{
+			model.NewStreamResponse("chatcmpl-1", "glm-4", "", true),
+			model.NewStreamResponse("chatcmpl-1", "glm-4", text, false),
+			{ID: "chatcmpl-1", Model: "glm-4", Choices: []model.StreamChoice{{FinishReason: &stop}}},
+			model.NewStreamUsageResponse("chatcmpl-1", "glm-4", 0, model.Usage{PromptTokens: 5, CompletionTokens: 1, TotalTokens: 6}),
+		},
+	}
+}
+
+func TestResponsesConversation(t *testing.T) {
+	chat := replyChat("Hello")
+	r := responsesRouter(t, chat)
+
+	first := decodeResponse(t, serveResponses(r, http.MethodPost, "/v1/responses", "key-a",
+		`{"model":"glm-4","instructions":"be brief","input":"hi"}`))
+	if first.Status != responses.StatusCompleted || len(first.Output) != 1 || first.Output[0].Content[0].Text != "Hello" {
+		t.Fatalf("first response = %+v", first)
+	}
+
+	// 继续对话：上游收到之前的对话和本次输入，instructions 不沿用
+	second := decodeResponse(t, serveResponses(r, http.MethodPost, "/v1/responses", "key-a",
+		`{"model":"glm-4","input":"again","previous_response_id":"`+first.ID+`"}`))
+	sent := chat.requests[len(chat.requests)-1]
+	var got []string
+	for _, msg := range sent.Messages {
+		got = append(got, msg.Role+":"+msg.Content.Text())
+	}
+	want := []string{"user:hi", "assistant:Hello", "user:again"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("adapter received %q, want %q", got, want)
+	}
+	if second.PreviousResponseID == nil || *second.PreviousResponseID != first.ID {
+		t.Errorf("previous_response_id = %v, want %s", second.PreviousResponseID, first.ID)
+	}
+
+	// 读取保存的响应
+	stored := decodeResponse(t, serveResponses(r, http.MethodGet, "/v1/responses/"+first.ID, "key-a", ""))
+	if stored.ID != first.ID || stored.Output[0].Content[0].Text != "Hello" {
+		t.Errorf("stored response = %+v", stored)
+	}
+}
+
+func TestResponsesKeyScope(t *testing.T) {
+	r := responsesRouter(t, replyChat("Hello"))
+	created := decodeResponse(t, serveResponses(r, http.MethodPost, "/v1/responses", "key-a", `{"model":"glm-4","input":"hi"}`))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"get", http.MethodGet, "/v1/responses/" + created.ID, "", http.StatusNotFound, ""},
+		{"delete", http.MethodDelete, "/v1/responses/" + created.ID, "", http.StatusNotFound, ""},
+		{"continue", http.MethodPost, "/v1/responses", `{"model":"glm-4","input":"x","previous_response_id":"` + created.ID + `"}`, http.StatusBadRequest, "previous_response_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveResponses(r, tt.method, tt.path, "key-b", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" && !strings.Contains(w.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want code %s", w.Body.String(), tt.wantCode)
+			}
+		})
+	}
+
+	// 其他Key的操作不影响原响应
+	decodeResponse(t, serveResponses(r, http.MethodGet, "/v1/responses/"+created.ID, "key-a", ""))
+}
+
+func TestResponsesDelete(t *testing.T) {
+	r := responsesRouter(t, replyChat("Hello"))
+	created := decodeResponse(t, serveResponses(r, http.MethodPost, "/v1/responses", "key-a", `{"model":"glm-4","input":"hi"}`))
+
+	w := serveResponses(r, http.MethodDelete, "/v1/responses/"+created.ID, "key-a", "")
+	want := `{"id":"` + created.ID + `","object":"response","deleted":true}`
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Fatalf("delete = %d %s, want %s", w.Code, w.Body.String(), want)
+	}
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		if w := serveResponses(r, method, "/v1/responses/"+created.ID, "key-a", ""); w.Code != http.StatusNotFound {
+			t.Errorf("%s after delete = %d, want 404", method, w.Code)
+		}
+	}
+}
+
+func TestResponsesStore(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantSaved bool
+	}{
+		{"default", `{"model":"glm-4","input":"hi"}`, true},
+		{"store false", `{"model":"glm-4","input":"hi","store":false}`, false},
+		{"stream", `{"model":"glm-4","input":"hi","stream":true}`, true},
+		{"stream store false", `{"model":"glm-4","input":"hi","stream":true,"store":false}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := responsesRouter(t, replyChat("Hello"))
+			w := serveResponses(r, http.MethodPost, "/v1/responses", "key-a", tt.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+
+			var id string
+			if strings.Contains(tt.body, `"stream":true`) {
+				// 流式响应以 response.completed 结束，其中的响应对象与保存的一致
+				events := sseEvents(w.Body.String())
+				if len(events) == 0 || events[len(events)-1] != responses.EventCompleted {
+					t.Fatalf("events = %v", events)
+				}
+				data := sseData(w.Body.String())
+				var completed struct {
+					Response responses.Response `json:"response"`
+				}
+				if err := json.Unmarshal([]byte(data[len(data)-1]), &completed); err != nil {
+					t.Fatal(err)
+				}
+				id = completed.Response.ID
+			} else {
+				id = decodeResponse(t, w).ID
+			}
+
+			w = serveResponses(r, http.MethodGet, "/v1/responses/"+id, "key-a", "")
+			if saved := w.Code == http.StatusOK; saved != tt.wantSaved {
+				t.Fatalf("saved = %v, want %v (status %d)", saved, tt.wantSaved, w.Code)
+			}
+			if tt.wantSaved {
+				if stored := decodeResponse(t, w); len(stored.Output) != 1 || stored.Output[0].Content[0].Text != "Hello" {
+					t.Errorf("stored response = %+v", stored)
+				}
+			}
+		})
+	}
+}
+
+func TestResponsesCreateErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantParam  string
+	}{
+		{"invalid json", `{`, http.StatusBadRequest, "body"},
+		{"missing previous response", `{"model":"glm-4","input":"hi","previous_response_id":"resp_missing"}`, http.StatusBadRequest, "previous_response_id"},
+		{"built-in tool", `{"model":"glm-4","input":"hi","tools":[{"type":"web_search"}]}`, http.StatusBadRequest, "tools"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := replyChat("Hello")
+			r := responsesRouter(t, chat)
+			w := serveResponses(r, http.MethodPost, "/v1/responses", "key-a", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var errResp model.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+				t.Fatal(err)
+			}
+			if errResp.Error.Param != tt.wantParam {
+				t.Errorf("param = %q, want %q", errResp.Error.Param, tt.wantParam)
+			}
+			if len(chat.requests) != 0 {
+				t.Error("invalid request was sent to the adapter")
+			}
+		})
+	}
+}
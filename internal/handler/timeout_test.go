@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+const timeoutConfig = `
+adapters:
+  glm:
+    api_key: "test-key"
+    base_url: "https://example.com/v1"
+    models: ["glm-4"]
+server:
+  read_timeout: 50ms
+  write_timeout: 50ms
+  stream_write_timeout: 1s
+  max_stream_duration: 300ms
+`
+
+// slowChat 普通调用一直等到请求被取消；流式调用每隔 interval 发送一个数据块，共 chunks 个
+type slowChat struct {
+	stubChat
+	interval time.Duration
+	chunks   int
+}
+
+func (s *slowChat) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *slowChat) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
+	ch := make(chan *model.StreamResponse)
+	go func() {
+		defer close(ch)
+		for i := range s.chunks {
+			select {
+			case <-time.After(s.interval):
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case ch <- model.NewStreamResponse("chatcmpl-1", req.Model, "x", i == 0):
+			case <-ctx.Done():
+				return
+			}
+		}
+		stop := "stop"
+		select {
+		case ch <- &model.StreamResponse{ID: "chatcmpl-1", Model: req.Model, Choices: []model.StreamChoice{{FinishReason: &stop}}}:
+		case <-ctx.Done():
+		}
+	}()
+	return ch, nil
+}
+
+// postStream 通过真实的HTTP服务器发送请求（连接的读写超时只有真实连接才生效）
+func postStream(t *testing.T, h *ChatHandler, readTimeout time.Duration, body string) (int, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", h.HandleChatCompletion)
+
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.ReadTimeout = readTimeout
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestStreamOutlivesReadTimeout(t *testing.T) {
+	loadConfig(t, timeoutConfig)
+	// 流持续约200ms，超过 read_timeout 和 write_timeout，但在 max_stream_duration 之内
+	h := NewChatHandler(&slowChat{interval: 20 * time.Millisecond, chunks: 10})
+
+	code, body := postStream(t, h, 50*time.Millisecond, `{"model":"glm-4","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if code != http.StatusOK || !strings.HasSuffix(strings.TrimSpace(body), "data: [DONE]") {
+		t.Errorf("status = %d, body = %s", code, body)
+	}
+}
+
+func TestStreamMaxDuration(t *testing.T) {
+	loadConfig(t, timeoutConfig)
+	h := NewChatHandler(&slowChat{interval: 50 * time.Millisecond, chunks: 100})
+
+	start := time.Now()
+	_, body := postStream(t, h, 0, `{"model":"glm-4","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if !strings.Contains(body, "stream_duration_exceeded") || strings.Contains(body, "[DONE]") {
+		t.Errorf("body = %s, want stream_duration_exceeded without [DONE]", body)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("stream lasted %v", elapsed)
+	}
+}
+
+func TestNonStreamWriteTimeout(t *testing.T) {
+	loadConfig(t, timeoutConfig)
+	h := NewChatHandler(&slowChat{})
+
+	code, body := postStream(t, h, 0, `{"model":"glm-4","messages":[{"role":"user","content":"hi"}]}`)
+	if code != http.StatusRequestTimeout || !strings.Contains(body, "request_timeout") {
+		t.Errorf("status = %d, body = %s", code, body)
+	}
+}
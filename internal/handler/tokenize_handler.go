@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/middleware"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/tokenizer"
+	"github.com/AtSunset1/prism/pkg/config"
+	"github.com/gin-gonic/gin"
+)
+
+// TokenizeHandler 处理token计数请求
+// 使用与上下文窗口校验相同的分词器，调用方可以在发送请求前确认是否会超出窗口
+type TokenizeHandler struct {
+	manager *adapter.AdapterManager
+}
+
+// NewTokenizeHandler 创建一个新的TokenizeHandler
+// 参数：
+//   - manager: 适配器管理器（用于确认模型已注册）
+func NewTokenizeHandler(manager *adapter.AdapterManager) *TokenizeHandler {
+	return &TokenizeHandler{
+		manager: manager,
+	}
+}
+
+// HandleTokenize 计算文本或对话的token数
+// 路由：POST /v1/tokenize
+//
+// 请求示例：
+//
+//	{
+//	  "model": "glm-4",
+//	  "messages": [{"role": "user", "content": "你好"}]
+//	}
+func (h *TokenizeHandler) HandleTokenize(c *gin.Context) {
+	var req model.TokenizeRequest
+	c.Set(metricModelKey, unknownModel)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, model.NewInvalidRequestError("无效的请求格式: "+err.Error(), "body"))
+		return
+	}
+	if (req.Input == "") == (len(req.Messages) == 0) {
+		writeError(c, model.NewInvalidRequestError("exactly one of input or messages is required", "input"))
+		return
+	}
+
+	// 调用方无权访问的模型与未注册的模型一样返回404
+	if !middleware.GetPrincipal(c).CanAccess(req.Model) {
+		writeError(c, model.NewNotFoundError("model").WithCode("model_not_found"))
+		return
+	}
+	if _, err := h.manager.GetAdapter(req.Model); err != nil {
+		writeError(c, model.NewNotFoundError("model").WithCode("model_not_found"))
+		return
+	}
+	c.Set(metricModelKey, req.Model)
+
+	tok, name := tokenizer.ForModel(req.Model)
+	resp := model.TokenizeResponse{
+		Object:    "tokenize",
+		Model:     req.Model,
+		Tokenizer: name,
+	}
+	if req.Input != "" {
+		resp.Tokens = tok.Count(req.Input)
+	} else {
+		resp.Tokens = tokenizer.CountRequest(tok, &model.ChatRequest{Messages: req.Messages, Tools: req.Tools})
+	}
+	if cfg := config.GetConfig(); cfg != nil {
+		if meta, ok := cfg.GetModel(req.Model); ok {
+			resp.ContextWindow = meta.ContextWindow
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
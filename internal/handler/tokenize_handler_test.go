@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/tokenizer"
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+const contextWindowConfig = `
+adapters:
+  glm:
+    api_key: "test-key"
+    base_url: "https://example.com/v1"
+    models: ["glm-4", "glm-4-flash"]
+models:
+  glm-4:
+    context_window: 20
+`
+
+func TestHandleTokenize(t *testing.T) {
+	loadConfig(t, contextWindowConfig)
+	manager := adapter.NewAdapterManager()
+	if err := manager.Reload(map[string]adapter.ModelAdapter{"glm-4": &stubChat{}}); err != nil {
+		t.Fatal(err)
+	}
+	h := NewTokenizeHandler(manager)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantTokens int
+	}{
+		// 未配置词表时按字符估算
+		{"input", `{"model":"glm-4","input":"abcdabcd"}`, http.StatusOK, 2},
+		{"messages", `{"model":"glm-4","messages":[{"role":"user","content":"abcd"}]}`, http.StatusOK, 3 + 3 + 1 + 1},
+		{"both input and messages", `{"model":"glm-4","input":"a","messages":[{"role":"user","content":"a"}]}`, http.StatusBadRequest, 0},
+		{"neither input nor messages", `{"model":"glm-4"}`, http.StatusBadRequest, 0},
+		{"unregistered model", `{"model":"missing","input":"a"}`, http.StatusNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveJSON(h.HandleTokenize, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp model.TokenizeResponse
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if resp.Tokens != tt.wantTokens || resp.Tokenizer != "estimate" || resp.ContextWindow != 20 {
+				t.Errorf("response = %+v, want %d tokens", resp, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestChatCompletionContextLength(t *testing.T) {
+	loadConfig(t, contextWindowConfig)
+	chat := &stubChat{resp: &model.ChatResponse{
+		ID:      "chatcmpl-1",
+		Choices: []model.Choice{{Message: &model.Message{Role: "assistant", Content: model.NewTextContent("ok")}, FinishReason: "stop"}},
+	}}
+	h := NewChatHandler(chat)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"fits", `{"model":"glm-4","messages":[{"role":"user","content":"hi"}]}`, http.StatusOK},
+		{"prompt too long", `{"model":"glm-4","messages":[{"role":"user","content":"` + strings.Repeat("word ", 100) + `"}]}`, http.StatusBadRequest},
+		{"max_tokens too large", `{"model":"glm-4","max_tokens":100,"messages":[{"role":"user","content":"hi"}]}`, http.StatusBadRequest},
+		// 未配置词表时按估算值的下限校验：估算22个token（超出窗口但在误差范围内）不拒绝
+		{"estimate within error margin", `{"model":"glm-4","messages":[{"role":"user","content":"` + strings.Repeat("abcd", 15) + `"}]}`, http.StatusOK},
+		// 没有配置 context_window 的模型不校验
+		{"no context window", `{"model":"glm-4-flash","messages":[{"role":"user","content":"` + strings.Repeat("word ", 100) + `"}]}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveJSON(h.HandleChatCompletion, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusBadRequest && !strings.Contains(w.Body.String(), "context_length_exceeded") {
+				t.Errorf("body = %s, want context_length_exceeded", w.Body.String())
+			}
+			if tt.wantStatus == http.StatusBadRequest && !strings.Contains(w.Body.String(), "estimated without the model's tokenizer") {
+				t.Errorf("body = %s, want the count marked as an estimate", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestChatCompletionContextLengthBPE(t *testing.T) {
+	loadConfig(t, contextWindowConfig)
+
+	// 单字节词表：每个字节一个token
+	var vocab strings.Builder
+	for b := 0; b < 256; b++ {
+		fmt.Fprintf(&vocab, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(b)}), b)
+	}
+	path := filepath.Join(t.TempDir(), "bytes.tiktoken")
+	if err := os.WriteFile(path, []byte(vocab.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := tokenizer.NewRegistry(config.TokenizerConfig{
+		Default:      "bytes",
+		Vocabularies: map[string]config.VocabularyConfig{"bytes": {Path: path}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenizer.SetGlobal(registry)
+	t.Cleanup(func() { tokenizer.SetGlobal(nil) })
+
+	// 使用词表时不预留误差余量：4+3+3+12=22个token超出窗口即拒绝
+	h := NewChatHandler(&stubChat{})
+	w := serveJSON(h.HandleChatCompletion, `{"model":"glm-4","messages":[{"role":"user","content":"abcdabcdabcd"}]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); !strings.Contains(body, "you requested 22 tokens (22 in the messages, 0 in the completion)") {
+		t.Errorf("body = %s", body)
+	}
+}
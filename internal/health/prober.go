@@ -0,0 +1,240 @@
+// Package health 在后台定期探测上游适配器的健康状态
+// 探测结果缓存在内存中，供 /readyz 报告和路由决策使用，请求路径上不会发起探测
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/metrics"
+	"github.com/AtSunset1/prism/pkg/config"
+	"go.uber.org/zap"
+)
+
+// AdapterStatus 单个适配器的最近一次探测结果
+type AdapterStatus struct {
+	// Adapter 适配器名称
+	Adapter string `json:"adapter"`
+
+	// Healthy 是否可用
+	Healthy bool `json:"healthy"`
+
+	// Error 探测失败的原因（可用时省略）
+	Error string `json:"error,omitempty"`
+
+	// LatencyMS 探测耗时（毫秒）
+	LatencyMS int64 `json:"latency_ms"`
+
+	// CheckedAt 探测时间
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Prober 上游健康探测器
+// 每隔 interval 并发探测每个适配器（多个模型共用的适配器只探测一次），
+// 适配器列表每轮从 AdapterManager 读取，热加载后自动生效
+type Prober struct {
+	// manager 适配器管理器
+	manager *adapter.AdapterManager
+
+	// interval 探测间隔（0表示不探测）
+	interval time.Duration
+
+	// timeout 单个适配器的探测超时
+	timeout time.Duration
+
+	// statuses 适配器名称 -> 最近一次探测结果
+	statuses map[string]AdapterStatus
+
+	// probed 是否已完成第一轮探测
+	probed atomic.Bool
+
+	// mu 保护 statuses
+	mu sync.RWMutex
+}
+
+// NewProber 创建健康探测器
+// 参数：
+//   - manager: 适配器管理器
+//   - cfg: 健康探测配置
+//
+// 示例：
+//
+//	prober := health.NewProber(manager, cfg.Health)
+//	stop := prober.Start()
+//	defer stop()
+func NewProber(manager *adapter.AdapterManager, cfg config.HealthConfig) *Prober {
+	return &Prober{
+		manager:  manager,
+		interval: cfg.Interval,
+		timeout:  cfg.Timeout,
+		statuses: make(map[string]AdapterStatus),
+	}
+}
+
+// Enabled 返回是否启用了后台探测
+func (p *Prober) Enabled() bool {
+	return p != nil && p.interval > 0
+}
+
+// Start 在后台开始探测：立即探测一轮，之后每隔 interval 探测一次
+// 未启用时不做任何事
+//
+// 返回：停止探测的函数（等待进行中的探测结束）
+func (p *Prober) Start() (stop func()) {
+	if !p.Enabled() {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			p.Probe(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Probe 并发探测所有适配器一次并更新结果
+// 已被热加载移除的适配器的结果会被删除
+func (p *Prober) Probe(ctx context.Context) {
+	adapters := p.manager.Adapters()
+	results := make([]AdapterStatus, len(adapters))
+
+	var wg sync.WaitGroup
+	for i, adp := range adapters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = p.check(ctx, adp)
+		}()
+	}
+	wg.Wait()
+
+	// 关闭过程中被取消的探测不更新结果
+	if ctx.Err() != nil {
+		return
+	}
+
+	statuses := make(map[string]AdapterStatus, len(results))
+	for _, status := range results {
+		statuses[status.Adapter] = status
+	}
+
+	p.mu.Lock()
+	previous := p.statuses
+	p.statuses = statuses
+	p.mu.Unlock()
+	p.probed.Store(true)
+
+	for name, status := range statuses {
+		metrics.SetUpstreamHealth(name, status.Healthy)
+
+		// 只在状态变化时输出日志
+		old, ok := previous[name]
+		switch {
+		case !status.Healthy && (!ok || old.Healthy):
+			zap.L().Warn("上游适配器不可用", zap.String("adapter", name), zap.String("error", status.Error))
+		case status.Healthy && ok && !old.Healthy:
+			zap.L().Info("上游适配器已恢复", zap.String("adapter", name))
+		}
+	}
+	for name := range previous {
+		if _, ok := statuses[name]; !ok {
+			metrics.DeleteUpstreamHealth(name)
+		}
+	}
+}
+
+// check 探测单个适配器
+func (p *Prober) check(ctx context.Context, adp adapter.ModelAdapter) AdapterStatus {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := adp.HealthCheck(ctx)
+	status := AdapterStatus{
+		Adapter:   adp.Name(),
+		Healthy:   err == nil,
+		LatencyMS: time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// Healthy 返回适配器最近一次探测是否可用
+// 未启用探测或尚未探测过的适配器视为可用
+// 实现 adapter.HealthReporter 接口
+func (p *Prober) Healthy(adapterName string) bool {
+	if !p.Enabled() {
+		return true
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	status, ok := p.statuses[adapterName]
+	return !ok || status.Healthy
+}
+
+// Ready 返回网关是否可以接收流量
+//   - 未启用探测：总是就绪
+//   - 第一轮探测完成前：未就绪
+//   - 至少一个适配器可用时就绪（单个供应商故障不影响其他模型）
+func (p *Prober) Ready() bool {
+	if !p.Enabled() {
+		return true
+	}
+	if !p.probed.Load() {
+		return false
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, status := range p.statuses {
+		if status.Healthy {
+			return true
+		}
+	}
+	return false
+}
+
+// Statuses 返回所有适配器的最近一次探测结果，按适配器名称排序
+func (p *Prober) Statuses() []AdapterStatus {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	statuses := make([]AdapterStatus, 0, len(p.statuses))
+	for _, status := range p.statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Adapter < statuses[j].Adapter
+	})
+	return statuses
+}
@@ -0,0 +1,258 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// stubAdapter 测试用适配器：HealthCheck 返回 err，记录探测次数
+type stubAdapter struct {
+	name   string
+	err    atomic.Pointer[error]
+	checks atomic.Int32
+
+	// block 为true时 HealthCheck 一直等到ctx结束
+	block bool
+}
+
+func newStub(name string, err error) *stubAdapter {
+	a := &stubAdapter{name: name}
+	a.setErr(err)
+	return a
+}
+
+func (a *stubAdapter) setErr(err error) {
+	a.err.Store(&err)
+}
+
+func (a *stubAdapter) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
+	return &model.ChatResponse{}, nil
+}
+
+func (a *stubAdapter) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
+	ch := make(chan *model.StreamResponse)
+	close(ch)
+	return ch, nil
+}
+
+func (a *stubAdapter) Name() string { return a.name }
+
+func (a *stubAdapter) HealthCheck(ctx context.Context) error {
+	a.checks.Add(1)
+	if a.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return *a.err.Load()
+}
+
+// newManager 创建注册了指定适配器的管理器（每个适配器一个同名模型）
+func newManager(t *testing.T, adapters ...adapter.ModelAdapter) *adapter.AdapterManager {
+	t.Helper()
+	m := adapter.NewAdapterManager()
+	reload(t, m, adapters...)
+	return m
+}
+
+// reload 替换管理器的注册关系
+func reload(t *testing.T, m *adapter.AdapterManager, adapters ...adapter.ModelAdapter) {
+	t.Helper()
+	registry := make(map[string]adapter.ModelAdapter, len(adapters))
+	for _, a := range adapters {
+		registry[a.Name()+"-model"] = a
+	}
+	if err := m.Reload(registry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProberDisabled(t *testing.T) {
+	down := newStub("down", errors.New("boom"))
+	p := NewProber(newManager(t, down), config.HealthConfig{})
+
+	if p.Enabled() {
+		t.Fatal("Enabled = true with zero interval")
+	}
+	p.Start()()
+	if down.checks.Load() != 0 {
+		t.Error("disabled prober ran a health check")
+	}
+	if !p.Ready() || !p.Healthy("down") {
+		t.Error("disabled prober should report ready and healthy")
+	}
+
+	var nilProber *Prober
+	if nilProber.Enabled() || nilProber.Statuses() != nil {
+		t.Error("nil prober should be disabled with no statuses")
+	}
+}
+
+func TestProbe(t *testing.T) {
+	up := newStub("up", nil)
+	down := newStub("down", errors.New("status 503"))
+	// 同一个适配器注册了两个模型，只探测一次
+	m := adapter.NewAdapterManager()
+	if err := m.Reload(map[string]adapter.ModelAdapter{"a": up, "b": up, "c": down}); err != nil {
+		t.Fatal(err)
+	}
+	p := NewProber(m, config.HealthConfig{Interval: time.Hour, Timeout: time.Second})
+
+	// 第一轮探测完成前未就绪，但不拒绝请求
+	if p.Ready() {
+		t.Error("Ready before the first probe")
+	}
+	if !p.Healthy("down") {
+		t.Error("adapter not probed yet should be healthy")
+	}
+
+	p.Probe(context.Background())
+	if up.checks.Load() != 1 || down.checks.Load() != 1 {
+		t.Errorf("checks = %d/%d, want 1/1", up.checks.Load(), down.checks.Load())
+	}
+	if !p.Ready() {
+		t.Error("not ready with one healthy adapter")
+	}
+	if !p.Healthy("up") || p.Healthy("down") {
+		t.Errorf("Healthy(up) = %v, Healthy(down) = %v", p.Healthy("up"), p.Healthy("down"))
+	}
+	if !p.Healthy("unknown") {
+		t.Error("unknown adapter should be healthy")
+	}
+
+	// 按适配器名称排序，JSON格式与 /readyz 一致
+	data, err := json.Marshal(p.Statuses())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statuses []map[string]any
+	json.Unmarshal(data, &statuses)
+	if len(statuses) != 2 || statuses[0]["adapter"] != "down" || statuses[1]["adapter"] != "up" {
+		t.Fatalf("statuses = %s", data)
+	}
+	if statuses[0]["healthy"] != false || statuses[0]["error"] != "status 503" {
+		t.Errorf("down status = %v", statuses[0])
+	}
+	if _, ok := statuses[1]["error"]; ok || statuses[1]["healthy"] != true {
+		t.Errorf("up status = %v", statuses[1])
+	}
+	for _, status := range statuses {
+		if _, ok := status["latency_ms"]; !ok {
+			t.Errorf("latency_ms missing: %v", status)
+		}
+		if _, ok := status["checked_at"]; !ok {
+			t.Errorf("checked_at missing: %v", status)
+		}
+	}
+
+	// 所有适配器都不可用时未就绪
+	up.setErr(errors.New("timeout"))
+	p.Probe(context.Background())
+	if p.Ready() {
+		t.Error("ready with no healthy adapter")
+	}
+
+	// 恢复后重新可用
+	down.setErr(nil)
+	p.Probe(context.Background())
+	if !p.Ready() || !p.Healthy("down") {
+		t.Error("recovered adapter still unhealthy")
+	}
+}
+
+func TestProbeAfterReload(t *testing.T) {
+	old := newStub("old", nil)
+	m := newManager(t, old)
+	p := NewProber(m, config.HealthConfig{Interval: time.Hour, Timeout: time.Second})
+	p.Probe(context.Background())
+
+	added := newStub("added", errors.New("boom"))
+	reload(t, m, added)
+	p.Probe(context.Background())
+
+	statuses := p.Statuses()
+	if len(statuses) != 1 || statuses[0].Adapter != "added" || statuses[0].Healthy {
+		t.Errorf("statuses = %+v, want only the unhealthy added adapter", statuses)
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	slow := &stubAdapter{name: "slow", block: true}
+	p := NewProber(newManager(t, slow), config.HealthConfig{Interval: time.Hour, Timeout: 20 * time.Millisecond})
+
+	p.Probe(context.Background())
+	if p.Healthy("slow") {
+		t.Error("adapter exceeding the probe timeout is healthy")
+	}
+	if statuses := p.Statuses(); len(statuses) != 1 || statuses[0].Error == "" {
+		t.Errorf("statuses = %+v", statuses)
+	}
+}
+
+func TestProbeCanceled(t *testing.T) {
+	down := newStub("down", errors.New("boom"))
+	p := NewProber(newManager(t, down), config.HealthConfig{Interval: time.Hour, Timeout: time.Second})
+
+	// 关闭过程中被取消的探测不更新结果
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.Probe(ctx)
+	if p.Ready() || len(p.Statuses()) != 0 {
+		t.Error("canceled probe updated the results")
+	}
+}
+
+func TestProberStart(t *testing.T) {
+	up := newStub("up", nil)
+	p := NewProber(newManager(t, up), config.HealthConfig{Interval: 10 * time.Millisecond, Timeout: time.Second})
+
+	stop := p.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for up.checks.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop()
+
+	if n := up.checks.Load(); n < 3 {
+		t.Fatalf("checks = %d, want at least 3 with a 10ms interval", n)
+	}
+	if !p.Ready() {
+		t.Error("not ready after probing")
+	}
+	// stop 返回后不再探测
+	n := up.checks.Load()
+	time.Sleep(30 * time.Millisecond)
+	if up.checks.Load() != n {
+		t.Error("prober kept running after stop")
+	}
+}
+
+func TestRejectUnhealthy(t *testing.T) {
+	up := newStub("up", nil)
+	down := newStub("down", errors.New("boom"))
+	m := newManager(t, up, down)
+	p := NewProber(m, config.HealthConfig{Interval: time.Hour, Timeout: time.Second})
+	m.SetHealthReporter(p)
+	p.Probe(context.Background())
+
+	req := &model.ChatRequest{Model: "down-model"}
+	if _, err := m.Chat(context.Background(), req); !errors.Is(err, adapter.ErrAdapterUnavailable) {
+		t.Errorf("Chat to unhealthy adapter: err = %v, want ErrAdapterUnavailable", err)
+	}
+	if _, err := m.Chat(context.Background(), &model.ChatRequest{Model: "up-model"}); err != nil {
+		t.Errorf("Chat to healthy adapter: %v", err)
+	}
+
+	// 取消健康状态来源后不再拒绝
+	m.SetHealthReporter(nil)
+	if _, err := m.Chat(context.Background(), req); err != nil {
+		t.Errorf("Chat without reporter: %v", err)
+	}
+}
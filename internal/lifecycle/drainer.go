@@ -0,0 +1,61 @@
+// Package lifecycle 保存网关的运行状态，供健康检查和长连接请求感知服务关闭
+package lifecycle
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Drainer 服务关闭时的排空状态
+// 关闭分为两个阶段：
+//  1. Start：开始排空，健康检查返回未就绪，不再接受新连接，进行中的请求继续执行
+//  2. Expire：排空期限已到，进行中的流式响应应向客户端发送错误事件后立即结束
+type Drainer struct {
+	// draining 是否已开始排空
+	draining atomic.Bool
+
+	// expired 排空期限到达时关闭
+	expired chan struct{}
+
+	// once 保证 expired 只关闭一次
+	once sync.Once
+}
+
+// NewDrainer 创建排空状态
+func NewDrainer() *Drainer {
+	return &Drainer{
+		expired: make(chan struct{}),
+	}
+}
+
+// Start 开始排空（可重复调用）
+func (d *Drainer) Start() {
+	d.draining.Store(true)
+}
+
+// Draining 返回是否已开始排空；nil 表示从不排空
+func (d *Drainer) Draining() bool {
+	return d != nil && d.draining.Load()
+}
+
+// Expire 标记排空期限已到（可重复调用），同时视为已开始排空
+func (d *Drainer) Expire() {
+	d.Start()
+	d.once.Do(func() { close(d.expired) })
+}
+
+// Expired 返回排空期限到达时关闭的channel；nil 时返回永不关闭的nil channel
+// 示例：
+//
+//	select {
+//	case chunk, ok := <-streamChan:
+//	    ...
+//	case <-drainer.Expired():
+//	    // 发送错误事件并结束响应
+//	}
+func (d *Drainer) Expired() <-chan struct{} {
+	if d == nil {
+		return nil
+	}
+	return d.expired
+}
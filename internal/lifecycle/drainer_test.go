@@ -0,0 +1,47 @@
+package lifecycle
+
+import "testing"
+
+func TestDrainer(t *testing.T) {
+	d := NewDrainer()
+	if d.Draining() {
+		t.Fatal("new drainer is draining")
+	}
+
+	d.Start()
+	if !d.Draining() {
+		t.Error("Start did not begin draining")
+	}
+	select {
+	case <-d.Expired():
+		t.Fatal("Expired closed before Expire")
+	default:
+	}
+
+	// 可重复调用
+	d.Expire()
+	d.Expire()
+	select {
+	case <-d.Expired():
+	default:
+		t.Error("Expired not closed after Expire")
+	}
+
+	expiredOnly := NewDrainer()
+	expiredOnly.Expire()
+	if !expiredOnly.Draining() {
+		t.Error("Expire did not imply draining")
+	}
+}
+
+func TestNilDrainer(t *testing.T) {
+	var d *Drainer
+	if d.Draining() {
+		t.Error("nil drainer is draining")
+	}
+	select {
+	case <-d.Expired():
+		t.Error("nil drainer expired")
+	default:
+	}
+}
@@ -0,0 +1,252 @@
+package media
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// defaultFetchTimeout 下载远程图片的默认超时时间
+const defaultFetchTimeout = 10 * time.Second
+
+// megabyte 配置中的大小单位
+const megabyte = 1 << 20
+
+// Processor 多模态内容处理器
+// 在请求发送给上游之前：
+//   - 校验图片、音频的大小和单个请求的片段数
+//   - 可选地下载远程图片并内联为 data URL（上游无法访问图片地址时使用）
+//
+// 返回的错误都是调用方输入的问题，可以直接作为 invalid_request_error 返回
+type Processor struct {
+	current atomic.Pointer[settings]
+}
+
+// settings 当前生效的限制和下载图片使用的HTTP客户端
+// 热加载时整体替换，处理中的请求继续使用开始时的设置
+type settings struct {
+	cfg    config.MediaConfig
+	client *http.Client
+}
+
+// New 创建多模态内容处理器
+// 参数：
+//   - cfg: 多模态内容配置
+func New(cfg config.MediaConfig) *Processor {
+	p := &Processor{}
+	p.Reload(cfg)
+	return p
+}
+
+// Reload 替换多模态内容配置（大小限制、片段数、是否下载远程图片）
+// 之后开始处理的请求使用新配置
+func (p *Processor) Reload(cfg config.MediaConfig) {
+	timeout := cfg.FetchTimeout
+	if timeout == 0 {
+		timeout = defaultFetchTimeout
+	}
+
+	// 下载地址来自调用方，禁止访问内网地址，避免被用来探测内部服务
+	dialer := &net.Dialer{Timeout: timeout, Control: denyPrivateNetworks}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	old := p.current.Swap(&settings{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+		},
+	})
+	if old != nil {
+		old.client.CloseIdleConnections()
+	}
+}
+
+// Process 校验并处理请求中的多模态内容
+// 需要改写内容时会替换 req.Messages 为新的切片，不修改调用方持有的原始消息
+func (p *Processor) Process(ctx context.Context, req *model.ChatRequest) error {
+	s := p.current.Load()
+	var messages []model.Message
+	mediaParts := 0
+
+	for i, msg := range req.Messages {
+		if !msg.Content.IsMultipart() {
+			continue
+		}
+
+		parts := make([]model.ContentPart, len(msg.Content.Parts()))
+		for j, part := range msg.Content.Parts() {
+			if part.Type != model.PartTypeText {
+				mediaParts++
+				if s.cfg.MaxParts > 0 && mediaParts > s.cfg.MaxParts {
+					return fmt.Errorf("too many media parts in request (max %d)", s.cfg.MaxParts)
+				}
+			}
+
+			processed, err := s.processPart(ctx, part)
+			if err != nil {
+				return fmt.Errorf("messages[%d].content[%d]: %w", i, j, err)
+			}
+			parts[j] = processed
+		}
+
+		if messages == nil {
+			messages = make([]model.Message, len(req.Messages))
+			copy(messages, req.Messages)
+		}
+		messages[i].Content = model.NewMultipartContent(parts)
+	}
+
+	if messages != nil {
+		req.Messages = messages
+	}
+	return nil
+}
+
+// processPart 处理单个内容片段
+func (s *settings) processPart(ctx context.Context, part model.ContentPart) (model.ContentPart, error) {
+	switch part.Type {
+	case model.PartTypeImageURL:
+		image := *part.ImageURL
+		url, err := s.processImage(ctx, image.URL)
+		if err != nil {
+			return part, err
+		}
+		image.URL = url
+		part.ImageURL = &image
+
+	case model.PartTypeInputAudio:
+		switch part.InputAudio.Format {
+		case "wav", "mp3":
+		default:
+			return part, fmt.Errorf("unsupported input_audio format: %q (must be 'wav' or 'mp3')", part.InputAudio.Format)
+		}
+		if err := checkSize("audio", base64Size(part.InputAudio.Data), s.cfg.MaxAudioSize); err != nil {
+			return part, err
+		}
+	}
+	return part, nil
+}
+
+// processImage 校验图片地址，必要时下载并内联
+// 返回：处理后的图片地址
+func (s *settings) processImage(ctx context.Context, url string) (string, error) {
+	switch {
+	case strings.HasPrefix(url, "data:"):
+		mediaType, data, err := ParseDataURL(url)
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(mediaType, "image/") {
+			return "", fmt.Errorf("image data URL has non-image media type %q", mediaType)
+		}
+		if err := checkSize("image", base64Size(data), s.cfg.MaxImageSize); err != nil {
+			return "", err
+		}
+		return url, nil
+
+	case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "https://"):
+		if !s.cfg.FetchRemoteImages {
+			return url, nil
+		}
+		return s.fetchImage(ctx, url)
+
+	default:
+		return "", errors.New("image_url.url must be an http(s) URL or a data URL")
+	}
+}
+
+// fetchImage 下载远程图片并转换为 data URL
+func (s *settings) fetchImage(ctx context.Context, url string) (string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid image url: %w", err)
+	}
+
+	httpResp, err := s.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("fetch image failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetch image failed: status %d", httpResp.StatusCode)
+	}
+
+	// 多读1个字节，用来判断是否超出限制
+	limit := int64(s.cfg.MaxImageSize) * megabyte
+	reader := io.Reader(httpResp.Body)
+	if limit > 0 {
+		reader = io.LimitReader(httpResp.Body, limit+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("fetch image failed: %w", err)
+	}
+	if err := checkSize("image", int64(len(data)), s.cfg.MaxImageSize); err != nil {
+		return "", err
+	}
+
+	mediaType := strings.TrimSpace(strings.Split(httpResp.Header.Get("Content-Type"), ";")[0])
+	if !strings.HasPrefix(mediaType, "image/") {
+		mediaType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("fetched url is not an image (content type %q)", mediaType)
+	}
+
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ParseDataURL 解析base64编码的 data URL
+// 返回：媒体类型（如 image/png）、base64数据
+func ParseDataURL(url string) (string, string, error) {
+	header, data, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return "", "", errors.New("data URL must be base64 encoded (data:<media type>;base64,<data>)")
+	}
+	return strings.TrimSuffix(header, ";base64"), data, nil
+}
+
+// base64Size 估算base64数据解码后的字节数
+func base64Size(data string) int64 {
+	return int64(base64.RawStdEncoding.DecodedLen(len(strings.TrimRight(data, "="))))
+}
+
+// checkSize 检查大小是否超出限制（limitMB<=0表示不限制）
+func checkSize(kind string, size int64, limitMB int) error {
+	if limitMB > 0 && size > int64(limitMB)*megabyte {
+		return fmt.Errorf("%s exceeds size limit of %d MB", kind, limitMB)
+	}
+	return nil
+}
+
+// denyPrivateNetworks 拒绝连接环回、内网、链路本地等地址
+// 在建立连接时检查（DNS解析之后），可以防止域名解析到内网地址绕过检查
+func denyPrivateNetworks(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("invalid address %q", address)
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("address %s is not allowed", ip)
+	}
+	return nil
+}
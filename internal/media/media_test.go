@@ -0,0 +1,137 @@
+package media
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// pngHeader PNG文件头（http.DetectContentType 识别为 image/png）
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+
+// imageRequest 构造带一张图片的请求
+func imageRequest(url string) *model.ChatRequest {
+	return &model.ChatRequest{Messages: []model.Message{{Role: "user", Content: model.NewMultipartContent([]model.ContentPart{
+		{Type: model.PartTypeText, Text: "看这张图"},
+		{Type: model.PartTypeImageURL, ImageURL: &model.ImageURL{URL: url}},
+	})}}}
+}
+
+func TestProcessValidation(t *testing.T) {
+	small := "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngHeader)
+	large := "data:image/png;base64," + base64.StdEncoding.EncodeToString(make([]byte, megabyte+1))
+	audio := func(format string, size int) model.ContentPart {
+		return model.ContentPart{Type: model.PartTypeInputAudio, InputAudio: &model.InputAudio{
+			Data:   base64.StdEncoding.EncodeToString(make([]byte, size)),
+			Format: format,
+		}}
+	}
+
+	tests := []struct {
+		name   string
+		parts  []model.ContentPart
+		errMsg string
+	}{
+		{"small data URL", []model.ContentPart{{Type: model.PartTypeImageURL, ImageURL: &model.ImageURL{URL: small}}}, ""},
+		{"remote url passes through", []model.ContentPart{{Type: model.PartTypeImageURL, ImageURL: &model.ImageURL{URL: "https://example.com/a.png"}}}, ""},
+		{"image too large", []model.ContentPart{{Type: model.PartTypeImageURL, ImageURL: &model.ImageURL{URL: large}}}, "image exceeds size limit"},
+		{"non-image data URL", []model.ContentPart{{Type: model.PartTypeImageURL, ImageURL: &model.ImageURL{URL: "data:text/plain;base64,aGk="}}}, "non-image media type"},
+		{"not base64", []model.ContentPart{{Type: model.PartTypeImageURL, ImageURL: &model.ImageURL{URL: "data:image/png,raw"}}}, "must be base64"},
+		{"unsupported scheme", []model.ContentPart{{Type: model.PartTypeImageURL, ImageURL: &model.ImageURL{URL: "file:///etc/passwd"}}}, "http(s) URL or a data URL"},
+		{"audio", []model.ContentPart{audio("mp3", 16)}, ""},
+		{"audio format", []model.ContentPart{audio("ogg", 16)}, "unsupported input_audio format"},
+		{"audio too large", []model.ContentPart{audio("wav", megabyte+1)}, "audio exceeds size limit"},
+		{"too many parts", []model.ContentPart{audio("wav", 1), audio("wav", 1), audio("wav", 1)}, "too many media parts"},
+	}
+	p := New(config.MediaConfig{MaxImageSize: 1, MaxAudioSize: 1, MaxParts: 2})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &model.ChatRequest{Messages: []model.Message{{Role: "user", Content: model.NewMultipartContent(tt.parts)}}}
+			err := p.Process(context.Background(), req)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("Process: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("err = %v, want %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestProcessInlinesRemoteImage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a.png":
+			// 不带 Content-Type 时按内容识别
+			w.Header()["Content-Type"] = nil
+			w.Write(pngHeader)
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := New(config.MediaConfig{FetchRemoteImages: true, MaxImageSize: 1})
+	// 测试服务器在环回地址上，使用不限制地址的客户端
+	p.current.Load().client = srv.Client()
+
+	req := imageRequest(srv.URL + "/a.png")
+	original := req.Messages
+	if err := p.Process(context.Background(), req); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	got := req.Messages[0].Content.Parts()[1].ImageURL.URL
+	if want := "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngHeader); got != want {
+		t.Errorf("url = %q, want %q", got, want)
+	}
+	// 不修改调用方持有的原始消息
+	if original[0].Content.Parts()[1].ImageURL.URL != srv.URL+"/a.png" {
+		t.Error("original message was modified")
+	}
+
+	for path, errMsg := range map[string]string{"/page.html": "not an image", "/missing.png": "status 404"} {
+		err := p.Process(context.Background(), imageRequest(srv.URL+path))
+		if err == nil || !strings.Contains(err.Error(), errMsg) {
+			t.Errorf("%s: err = %v, want %q", path, err, errMsg)
+		}
+	}
+}
+
+func TestFetchDeniesPrivateNetworks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(pngHeader)
+	}))
+	defer srv.Close()
+
+	p := New(config.MediaConfig{FetchRemoteImages: true})
+	err := p.Process(context.Background(), imageRequest(srv.URL+"/a.png"))
+	if err == nil || !strings.Contains(err.Error(), "is not allowed") {
+		t.Errorf("err = %v, want loopback address rejected", err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	large := "data:image/png;base64," + base64.StdEncoding.EncodeToString(make([]byte, megabyte+1))
+
+	p := New(config.MediaConfig{MaxImageSize: 1})
+	if err := p.Process(context.Background(), imageRequest(large)); err == nil {
+		t.Fatal("expected size limit error before reload")
+	}
+
+	p.Reload(config.MediaConfig{MaxImageSize: 2})
+	if err := p.Process(context.Background(), imageRequest(large)); err != nil {
+		t.Errorf("Process after reload: %v", err)
+	}
+}
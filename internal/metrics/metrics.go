@@ -0,0 +1,206 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 所有指标的前缀
+const namespace = "prism"
+
+// 上游调用结果标签值
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+// latencyBuckets 延迟分桶（秒）
+// LLM调用耗时跨度很大：短回复几百毫秒，长回复可达数分钟
+var latencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+
+// ttftBuckets 首token延迟分桶（秒）
+var ttftBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 15, 30}
+
+// ===== 网关层指标（ChatHandler记录） =====
+
+var (
+	// RequestsTotal 网关收到的请求数
+	RequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "Total number of chat requests handled by the gateway.",
+	}, []string{"model", "stream", "status"})
+
+	// RequestDuration 网关请求总耗时（包含流式传输时间）
+	RequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "End-to-end latency of chat requests handled by the gateway.",
+		Buckets:   latencyBuckets,
+	}, []string{"model", "stream", "status"})
+
+	// ErrorsTotal 返回给客户端的错误数（按 ErrorDetail.Type 分类）
+	ErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "errors_total",
+		Help:      "Total number of errors returned to clients, by error type.",
+	}, []string{"model", "type"})
+)
+
+// ===== 上游层指标（AdapterManager记录） =====
+
+var (
+	// UpstreamRequestsTotal 上游调用次数
+	UpstreamRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_requests_total",
+		Help:      "Total number of upstream calls, by model, adapter and result.",
+	}, []string{"model", "adapter", "status"})
+
+	// UpstreamLatency 上游调用耗时
+	// 非流式：请求发出到完整响应返回；流式：请求发出到流结束
+	UpstreamLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_latency_seconds",
+		Help:      "Latency of upstream calls, by model, adapter and result.",
+		Buckets:   latencyBuckets,
+	}, []string{"model", "adapter", "status"})
+
+	// TimeToFirstToken 流式请求的首token延迟
+	TimeToFirstToken = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "time_to_first_token_seconds",
+		Help:      "Time from upstream call start to the first streamed content chunk.",
+		Buckets:   ttftBuckets,
+	}, []string{"model", "adapter"})
+
+	// TokensTotal token用量
+	// direction: input（prompt_tokens）, output（completion_tokens）
+	TokensTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tokens_total",
+		Help:      "Total number of tokens consumed, by model, adapter and direction.",
+	}, []string{"model", "adapter", "direction"})
+
+	// ActiveStreams 进行中的流式请求数
+	ActiveStreams = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_streams",
+		Help:      "Number of in-flight streaming upstream calls.",
+	}, []string{"model", "adapter"})
+
+	// RetriesTotal 上游重试次数（调用方通过 adapter.WithRetry 标记的重新调用）
+	RetriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_retries_total",
+		Help:      "Total number of upstream retries.",
+	}, []string{"model", "adapter"})
+
+	// UpstreamHealthy 后台探测得到的适配器健康状态：1=健康, 0=不健康
+	UpstreamHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "upstream_healthy",
+		Help:      "Upstream health per adapter from the background prober: 1=healthy, 0=unhealthy.",
+	}, []string{"adapter"})
+)
+
+// ===== 响应缓存指标 =====
+
+var (
+	// CacheRequestsTotal 缓存查询次数
+	CacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Total number of response cache lookups by result.",
+	}, []string{"model", "result"})
+)
+
+// ===== 结构化输出指标 =====
+
+var (
+	// StructuredOutputTotal 结构化输出校验次数（每次尝试计一次）
+	StructuredOutputTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "structured_output_validations_total",
+		Help:      "Total number of structured output validations by result.",
+	}, []string{"model", "result"})
+)
+
+// Handler 返回 /metrics 的HTTP处理器
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveRequest 记录一次网关请求
+// 参数：
+//   - model: 请求的模型名称
+//   - stream: 是否为流式请求
+//   - status: HTTP状态码
+//   - elapsed: 请求总耗时
+func ObserveRequest(model string, stream bool, status int, elapsed time.Duration) {
+	streamLabel := "false"
+	if stream {
+		streamLabel = "true"
+	}
+	statusLabel := strconv.Itoa(status)
+
+	RequestsTotal.WithLabelValues(model, streamLabel, statusLabel).Inc()
+	RequestDuration.WithLabelValues(model, streamLabel, statusLabel).Observe(elapsed.Seconds())
+}
+
+// ObserveError 记录一次返回给客户端的错误
+func ObserveError(model, errType string) {
+	ErrorsTotal.WithLabelValues(model, errType).Inc()
+}
+
+// ObserveUpstream 记录一次上游调用结果
+func ObserveUpstream(model, adapter, status string, elapsed time.Duration) {
+	UpstreamRequestsTotal.WithLabelValues(model, adapter, status).Inc()
+	UpstreamLatency.WithLabelValues(model, adapter, status).Observe(elapsed.Seconds())
+}
+
+// ObserveTokens 记录token用量
+func ObserveTokens(model, adapter string, promptTokens, completionTokens int) {
+	if promptTokens > 0 {
+		TokensTotal.WithLabelValues(model, adapter, "input").Add(float64(promptTokens))
+	}
+	if completionTokens > 0 {
+		TokensTotal.WithLabelValues(model, adapter, "output").Add(float64(completionTokens))
+	}
+}
+
+// ObserveRetry 记录一次上游重试
+func ObserveRetry(model, adapter string) {
+	RetriesTotal.WithLabelValues(model, adapter).Inc()
+}
+
+// SetUpstreamHealth 更新适配器的探测结果
+func SetUpstreamHealth(adapter string, healthy bool) {
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	UpstreamHealthy.WithLabelValues(adapter).Set(value)
+}
+
+// DeleteUpstreamHealth 删除已移除适配器的探测结果（热加载后调用）
+func DeleteUpstreamHealth(adapter string) {
+	UpstreamHealthy.DeleteLabelValues(adapter)
+}
+
+// ObserveCache 记录一次缓存查询
+// result 取值：hit, miss
+func ObserveCache(model, result string) {
+	CacheRequestsTotal.WithLabelValues(model, result).Inc()
+}
+
+// ObserveStructuredOutput 记录一次结构化输出校验
+// result 取值：valid, invalid
+func ObserveStructuredOutput(model, result string) {
+	StructuredOutputTotal.WithLabelValues(model, result).Inc()
+}
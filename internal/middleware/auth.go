@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"github.com/AtSunset1/prism/internal/auth"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// principalKey gin.Context中保存调用方的key
+const principalKey = "prism.principal"
+
+// HeaderAPIKey Anthropic 风格的API Key请求头
+const HeaderAPIKey = "X-Api-Key"
+
+// Auth API Key鉴权中间件
+// 未启用鉴权时直接放行；启用时要求 Authorization: Bearer <key>，
+// 缺少或无效的Key返回401，通过后调用方信息可以用 GetPrincipal 获取
+// Anthropic SDK 使用 x-api-key 请求头传递Key，没有 Authorization 时按 Bearer 处理
+//
+// 参数：
+//   - authenticator: 鉴权器
+func Auth(authenticator *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 统一为 Authorization 请求头，审计、缓存按它计算调用方标识
+		if key := c.GetHeader(HeaderAPIKey); key != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+key)
+		}
+
+		if !authenticator.Enabled() {
+			c.Next()
+			return
+		}
+
+		authorization := c.GetHeader("Authorization")
+		if auth.BearerToken(authorization) == "" {
+			c.AbortWithStatusJSON(model.ErrMissingAPIKey.GetHTTPStatus(), model.ErrMissingAPIKey)
+			return
+		}
+
+		principal, ok := authenticator.Authenticate(authorization)
+		if !ok {
+			errResp := model.NewAuthenticationError("Invalid API key").WithCode("invalid_api_key")
+			c.AbortWithStatusJSON(errResp.GetHTTPStatus(), errResp)
+			return
+		}
+
+		c.Set(principalKey, principal)
+		logger.AddFields(c.Request.Context(), zap.String("key_name", principal.Name))
+
+		c.Next()
+	}
+}
+
+// GetPrincipal 获取当前请求的调用方
+// 未启用鉴权时返回nil（nil Principal 可以访问全部模型）
+func GetPrincipal(c *gin.Context) *auth.Principal {
+	if v, ok := c.Get(principalKey); ok {
+		return v.(*auth.Principal)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/AtSunset1/prism/pkg/logger"
+	"github.com/AtSunset1/prism/pkg/requestid"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// HeaderRequestID 请求ID请求头/响应头
+const HeaderRequestID = requestid.Header
+
+// requestIDKey gin.Context中保存请求ID的key
+const requestIDKey = "prism.request_id"
+
+// Logger 请求日志中间件（替代gin默认的Logger）
+// 职责：
+//   - 沿用调用方传入的 X-Request-ID（格式不合法时重新生成），写入响应头和 c.Request.Context()，
+//     适配器发往上游的请求会带上同一个请求ID
+//   - 为每个请求创建请求级Logger，放入 c.Request.Context()
+//   - 请求结束后输出一条访问日志，包含请求ID、状态码、耗时，
+//     以及处理过程中由handler/adapter补充的模型、适配器、token用量等字段
+//
+// 参数：
+//   - base: 基础Logger
+func Logger(base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(HeaderRequestID)
+		if !requestid.Valid(requestID) {
+			requestID = requestid.New()
+		}
+
+		c.Set(requestIDKey, requestID)
+		c.Header(HeaderRequestID, requestID)
+
+		reqLogger := base.With(zap.String("request_id", requestID))
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+			reqLogger = reqLogger.With(zap.String("trace_id", sc.TraceID().String()))
+		}
+		ctx := requestid.NewContext(c.Request.Context(), requestID)
+		c.Request = c.Request.WithContext(logger.NewContext(ctx, reqLogger))
+
+		c.Next()
+
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			fields = append(fields, zap.String("errors", c.Errors.String()))
+		}
+
+		l := logger.FromContext(c.Request.Context())
+		switch status := c.Writer.Status(); {
+		case status >= 500:
+			// 5xx 中有预期内的响应（如上游不可用时 /readyz 返回503），堆栈对定位没有帮助
+			l.WithOptions(zap.AddStacktrace(zap.FatalLevel)).Error("请求处理完成", fields...)
+		case status >= 400:
+			l.Warn("请求处理完成", fields...)
+		default:
+			l.Info("请求处理完成", fields...)
+		}
+	}
+}
+
+// GetRequestID 获取当前请求的请求ID
+// 未经过Logger中间件时返回空字符串
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
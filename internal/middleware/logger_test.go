@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggerAccessLevels(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		status int
+		level  zapcore.Level
+	}{
+		{http.StatusOK, zapcore.InfoLevel},
+		{http.StatusNotFound, zapcore.WarnLevel},
+		{http.StatusServiceUnavailable, zapcore.ErrorLevel},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			// 与 logger.New 一致：Error 级别默认附带堆栈
+			base := zap.New(core, zap.AddStacktrace(zap.ErrorLevel))
+
+			r := gin.New()
+			r.Use(Logger(base))
+			r.GET("/", func(c *gin.Context) { c.Status(tt.status) })
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("got %d log entries, want 1", len(entries))
+			}
+			entry := entries[0]
+			if entry.Level != tt.level {
+				t.Errorf("level = %v, want %v", entry.Level, tt.level)
+			}
+			if entry.Stack != "" {
+				t.Errorf("access log carries a stack trace:\n%s", entry.Stack)
+			}
+			if got := entry.ContextMap()["status"]; got != int64(tt.status) {
+				t.Errorf("status field = %v, want %d", got, tt.status)
+			}
+		})
+	}
+}
+
+func TestLoggerRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Logger(zap.NewNop()))
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, GetRequestID(c)) })
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"valid id is propagated", "abc-123_DEF", true},
+		{"missing id is generated", "", false},
+		{"invalid id is replaced", "bad id\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(HeaderRequestID, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			got := w.Header().Get(HeaderRequestID)
+			if got == "" || got != w.Body.String() {
+				t.Fatalf("response header %q does not match context id %q", got, w.Body.String())
+			}
+			if tt.keep && got != tt.incoming {
+				t.Errorf("request id = %q, want %q", got, tt.incoming)
+			}
+			if !tt.keep && got == tt.incoming {
+				t.Errorf("request id %q was not replaced", got)
+			}
+		})
+	}
+}
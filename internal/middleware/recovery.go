@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Recovery panic恢复中间件（替代gin默认的Recovery）
+// 捕获handler中的panic，记录带堆栈的错误日志，并返回OpenAI格式的500错误
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				// 客户端已断开连接（写入时panic），无需再返回响应体
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+
+				logger.FromContext(c.Request.Context()).Error("请求处理发生panic",
+					zap.Any("panic", r),
+					zap.Stack("stack"),
+				)
+
+				errResp := model.ErrInternalServer
+				c.AbortWithStatusJSON(errResp.GetHTTPStatus(), errResp)
+			}
+		}()
+
+		c.Next()
+	}
+}
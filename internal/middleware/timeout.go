@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/AtSunset1/prism/pkg/config"
+	"github.com/gin-gonic/gin"
+)
+
+// writeGrace 上下文到期后，留给处理器写出超时错误的时间
+// 连接写超时比上下文期限晚这么久，否则超时错误无法送达客户端
+const writeGrace = time.Second
+
+// Timeout 非流式接口的总期限中间件
+// 期限为 server.write_timeout：到期后取消请求上下文（上游调用随之取消），
+// 并设置连接的写超时，避免慢客户端长期占用连接
+//
+// http.Server 不设置全局 WriteTimeout（会切断流式响应），
+// 可能返回流式响应的接口不使用该中间件，而是由处理器按请求类型调用 SetDeadline 或 ExtendWriteDeadline
+func Timeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var timeout time.Duration
+		if cfg := config.GetConfig(); cfg != nil {
+			timeout = cfg.Server.WriteTimeout
+		}
+		cancel := SetDeadline(c, timeout)
+		defer cancel()
+
+		c.Next()
+	}
+}
+
+// SetDeadline 为请求设置总期限：请求上下文超时和连接写超时（多留 writeGrace）
+// 参数：
+//   - c: 请求上下文
+//   - timeout: 期限（0表示不限制）
+//
+// 返回：释放上下文的函数（请求结束时调用）
+func SetDeadline(c *gin.Context, timeout time.Duration) context.CancelFunc {
+	if timeout <= 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	c.Request = c.Request.WithContext(ctx)
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(timeout + writeGrace))
+	return cancel
+}
+
+// ExtendWriteDeadline 将连接的写超时顺延到 timeout 之后
+// 流式响应每次写入前调用：只要数据持续发送，连接就不会因写超时断开
+// 参数：
+//   - c: 请求上下文
+//   - timeout: 单次写入的期限（0表示不限制）
+func ExtendWriteDeadline(c *gin.Context, timeout time.Duration) {
+	deadline := time.Time{}
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	http.NewResponseController(c.Writer).SetWriteDeadline(deadline)
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+// StreamAccumulator 流式响应拼装器
+// 将一系列 StreamResponse 数据块还原为完整的 ChatResponse
+// 用于审计日志、缓存等需要"流结束后的完整结果"的场景
+//
+// 示例：
+//
+//	acc := model.NewStreamAccumulator()
+//	for chunk := range streamChan {
+//	    acc.Add(chunk)
+//	}
+//	resp := acc.Response()
+type StreamAccumulator struct {
+	id                string
+	model             string
+	created           int64
+	systemFingerprint string
+	usage             Usage
+
+	// choices 按index保存每个候选回复的累计内容
+	choices map[int]*accumulatedChoice
+}
+
+// accumulatedChoice 单个候选回复的累计状态
+type accumulatedChoice struct {
+	role         string
+	content      strings.Builder
+	finishReason string
+
+	// toolCalls 按调用序号保存工具调用，参数片段依次拼接
+	toolCalls     map[int]*ToolCall
+	toolCallOrder []int
+}
+
+// NewStreamAccumulator 创建流式响应拼装器
+func NewStreamAccumulator() *StreamAccumulator {
+	return &StreamAccumulator{
+		choices: make(map[int]*accumulatedChoice),
+	}
+}
+
+// Add 追加一个数据块
+func (a *StreamAccumulator) Add(chunk *StreamResponse) {
+	if chunk == nil || chunk.IsError() {
+		return
+	}
+
+	if a.id == "" {
+		a.id = chunk.ID
+		a.created = chunk.Created
+	}
+	if chunk.Model != "" {
+		a.model = chunk.Model
+	}
+	if chunk.SystemFingerprint != "" {
+		a.systemFingerprint = chunk.SystemFingerprint
+	}
+	if chunk.Usage != nil {
+		a.usage = *chunk.Usage
+	}
+
+	for _, sc := range chunk.Choices {
+		choice, ok := a.choices[sc.Index]
+		if !ok {
+			choice = &accumulatedChoice{}
+			a.choices[sc.Index] = choice
+		}
+		if sc.Delta.Role != "" {
+			choice.role = sc.Delta.Role
+		}
+		choice.content.WriteString(sc.Delta.Content)
+		for i, delta := range sc.Delta.ToolCalls {
+			choice.addToolCall(i, delta)
+		}
+		if sc.FinishReason != nil {
+			choice.finishReason = *sc.FinishReason
+		}
+	}
+}
+
+// Response 返回拼装后的完整响应
+// 流未正常结束时，对应choice的 FinishReason 为空；没有收到用量数据块时 Usage 为零值
+func (a *StreamAccumulator) Response() *ChatResponse {
+	indexes := make([]int, 0, len(a.choices))
+	for index := range a.choices {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	choices := make([]Choice, 0, len(indexes))
+	for _, index := range indexes {
+		acc := a.choices[index]
+		role := acc.role
+		if role == "" {
+			role = "assistant"
+		}
+		choices = append(choices, Choice{
+			Index: index,
+			Message: &Message{
+				Role:      role,
+				Content:   NewTextContent(acc.content.String()),
+				ToolCalls: acc.completedToolCalls(),
+			},
+			FinishReason: acc.finishReason,
+		})
+	}
+
+	return &ChatResponse{
+		ID:                a.id,
+		Object:            "chat.completion",
+		Created:           a.created,
+		Model:             a.model,
+		Choices:           choices,
+		Usage:             a.usage,
+		SystemFingerprint: a.systemFingerprint,
+	}
+}
+
+// addToolCall 合并一个工具调用增量
+// 参数：
+//   - position: 增量在当前数据块中的位置（上游没有提供Index时作为序号）
+//   - delta: 工具调用增量
+func (c *accumulatedChoice) addToolCall(position int, delta ToolCall) {
+	index := position
+	if delta.Index != nil {
+		index = *delta.Index
+	}
+
+	if c.toolCalls == nil {
+		c.toolCalls = make(map[int]*ToolCall)
+	}
+	call, ok := c.toolCalls[index]
+	if !ok {
+		call = &ToolCall{}
+		c.toolCalls[index] = call
+		c.toolCallOrder = append(c.toolCallOrder, index)
+	}
+
+	if delta.ID != "" {
+		call.ID = delta.ID
+	}
+	if delta.Type != "" {
+		call.Type = delta.Type
+	}
+	if delta.Function.Name != "" {
+		call.Function.Name = delta.Function.Name
+	}
+	call.Function.Arguments += delta.Function.Arguments
+}
+
+// completedToolCalls 返回拼装后的工具调用（按首次出现的顺序，不含流式序号）
+func (c *accumulatedChoice) completedToolCalls() []ToolCall {
+	if len(c.toolCallOrder) == 0 {
+		return nil
+	}
+
+	calls := make([]ToolCall, 0, len(c.toolCallOrder))
+	for _, index := range c.toolCallOrder {
+		call := *c.toolCalls[index]
+		if call.Type == "" {
+			call.Type = ToolTypeFunction
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
@@ -0,0 +1,147 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// CompletionRequest 文本补全请求（OpenAI旧版 /v1/completions 格式）
+// 网关没有单独的补全上游：每个 prompt 包装为一条 user 消息，按聊天请求发送给同一套适配器
+type CompletionRequest struct {
+	// Model 模型名称（与 /v1/chat/completions 使用同一套模型路由）
+	Model string `json:"model" binding:"required"`
+
+	// Prompt 提示文本
+	// 支持单个字符串或字符串数组（数组中每个元素各自生成补全，不支持token数组）
+	Prompt CompletionPrompt `json:"prompt" binding:"required,min=1"`
+
+	// Suffix 插入位置之后的文本（可选）
+	// 聊天模型不支持真正的中间插入，网关通过提示词要求模型只输出 prompt 和 suffix 之间的内容
+	Suffix string `json:"suffix,omitempty"`
+
+	// Echo 是否在补全文本前附上 prompt
+	Echo bool `json:"echo,omitempty"`
+
+	// MaxTokens 最大生成token数
+	MaxTokens *int `json:"max_tokens,omitempty"`
+
+	// Temperature 温度
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// TopP 核采样
+	TopP *float64 `json:"top_p,omitempty"`
+
+	// N 每个 prompt 生成几个补全
+	N *int `json:"n,omitempty"`
+
+	// Stream 是否流式返回
+	Stream bool `json:"stream,omitempty"`
+
+	// StreamOptions 流式响应选项（仅 stream=true 时有效）
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+
+	// Stop 停止词（字符串或字符串数组）
+	Stop CompletionStop `json:"stop,omitempty"`
+
+	// PresencePenalty 存在惩罚
+	PresencePenalty *float64 `json:"presence_penalty,omitempty"`
+
+	// FrequencyPenalty 频率惩罚
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+
+	// User 用户标识符
+	User string `json:"user,omitempty"`
+
+	// BestOf、LogProbs 聊天上游无法支持，接受但忽略（响应中 logprobs 总是null）
+	BestOf   *int `json:"best_of,omitempty"`
+	LogProbs *int `json:"logprobs,omitempty"`
+}
+
+// GetN 获取每个 prompt 的补全数，未设置时为1
+func (r *CompletionRequest) GetN() int {
+	if r.N == nil || *r.N < 1 {
+		return 1
+	}
+	return *r.N
+}
+
+// IncludeUsage 流式响应是否需要在末尾发送用量数据块
+func (r *CompletionRequest) IncludeUsage() bool {
+	return r.Stream && r.StreamOptions != nil && r.StreamOptions.IncludeUsage
+}
+
+// CompletionPrompt 补全提示文本
+// JSON中可以是单个字符串，也可以是字符串数组
+type CompletionPrompt []string
+
+// UnmarshalJSON 同时接受字符串和字符串数组
+func (p *CompletionPrompt) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*p = CompletionPrompt{single}
+		return nil
+	}
+
+	var batch []string
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return errors.New("prompt must be a string or an array of strings (token arrays are not supported)")
+	}
+	*p = batch
+	return nil
+}
+
+// CompletionStop 停止词
+// JSON中可以是单个字符串，也可以是字符串数组
+type CompletionStop []string
+
+// UnmarshalJSON 同时接受字符串和字符串数组
+func (s *CompletionStop) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = CompletionStop{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("stop must be a string or an array of strings")
+	}
+	*s = list
+	return nil
+}
+
+// CompletionResponse 文本补全响应（流式数据块使用同样的结构）
+type CompletionResponse struct {
+	// ID 响应ID，格式：cmpl-{随机字符串}
+	ID string `json:"id"`
+
+	// Object 对象类型，固定值："text_completion"（流式数据块也是该值）
+	Object string `json:"object"`
+
+	// Created 创建时间戳（Unix时间戳，秒）
+	Created int64 `json:"created"`
+
+	// Model 实际使用的模型名称
+	Model string `json:"model"`
+
+	// Choices 补全列表，Index = prompt序号 * n + 候选序号
+	Choices []CompletionChoice `json:"choices"`
+
+	// Usage Token使用情况（所有 prompt 合计；流式响应中只出现在末尾的用量数据块）
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// CompletionChoice 单个补全
+type CompletionChoice struct {
+	// Text 补全文本（echo=true 时以 prompt 开头）
+	Text string `json:"text"`
+
+	// Index 补全的索引
+	Index int `json:"index"`
+
+	// LogProbs 对数概率（不支持，总是null）
+	LogProbs interface{} `json:"logprobs"`
+
+	// FinishReason 结束原因：stop, length, content_filter（流式中间数据块为null）
+	FinishReason *string `json:"finish_reason"`
+}
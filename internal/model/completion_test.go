@@ -0,0 +1,67 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCompletionRequestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantPrompt []string
+		wantStop   []string
+		wantErr    bool
+	}{
+		{"single prompt", `{"model":"m","prompt":"hello"}`, []string{"hello"}, nil, false},
+		{"prompt array", `{"model":"m","prompt":["a","b"],"stop":["x","y"]}`, []string{"a", "b"}, []string{"x", "y"}, false},
+		{"single stop", `{"model":"m","prompt":"a","stop":"\n"}`, []string{"a"}, []string{"\n"}, false},
+		{"token array prompt", `{"model":"m","prompt":[1,2,3]}`, nil, nil, true},
+		{"numeric stop", `{"model":"m","prompt":"a","stop":1}`, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req CompletionRequest
+			err := json.Unmarshal([]byte(tt.data), &req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if strings.Join(req.Prompt, ",") != strings.Join(tt.wantPrompt, ",") {
+				t.Errorf("prompt = %q, want %q", req.Prompt, tt.wantPrompt)
+			}
+			if strings.Join(req.Stop, ",") != strings.Join(tt.wantStop, ",") {
+				t.Errorf("stop = %q, want %q", req.Stop, tt.wantStop)
+			}
+		})
+	}
+}
+
+func TestCompletionRequestOptions(t *testing.T) {
+	zero, three := 0, 3
+	tests := []struct {
+		name      string
+		req       CompletionRequest
+		wantN     int
+		wantUsage bool
+	}{
+		{"defaults", CompletionRequest{}, 1, false},
+		{"n zero", CompletionRequest{N: &zero}, 1, false},
+		{"n three", CompletionRequest{N: &three}, 3, false},
+		{"usage without stream", CompletionRequest{StreamOptions: &StreamOptions{IncludeUsage: true}}, 1, false},
+		{"stream with usage", CompletionRequest{Stream: true, StreamOptions: &StreamOptions{IncludeUsage: true}}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.req.GetN(); got != tt.wantN {
+				t.Errorf("GetN() = %d, want %d", got, tt.wantN)
+			}
+			if got := tt.req.IncludeUsage(); got != tt.wantUsage {
+				t.Errorf("IncludeUsage() = %v, want %v", got, tt.wantUsage)
+			}
+		})
+	}
+}
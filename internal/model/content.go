@@ -0,0 +1,200 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 内容片段类型（ContentPart.Type 的取值）
+const (
+	PartTypeText       = "text"
+	PartTypeImageURL   = "image_url"
+	PartTypeInputAudio = "input_audio"
+	PartTypeFile       = "file"
+)
+
+// Content 消息内容
+// JSON中可以是字符串，也可以是内容片段数组（多模态）：
+//
+//	"content": "你好"
+//	"content": [
+//	  {"type": "text", "text": "这张图里有什么？"},
+//	  {"type": "image_url", "image_url": {"url": "https://example.com/cat.png"}}
+//	]
+//
+// 序列化时保持原来的格式，纯文本模型的上游不会收到数组
+type Content struct {
+	text  string
+	parts []ContentPart // 非nil表示数组格式
+}
+
+// NewTextContent 创建纯文本内容
+func NewTextContent(text string) Content {
+	return Content{text: text}
+}
+
+// NewMultipartContent 创建多模态内容
+func NewMultipartContent(parts []ContentPart) Content {
+	if parts == nil {
+		parts = []ContentPart{}
+	}
+	return Content{parts: parts}
+}
+
+// IsMultipart 是否为内容片段数组格式
+func (c Content) IsMultipart() bool {
+	return c.parts != nil
+}
+
+// Parts 返回内容片段（纯文本内容返回nil）
+// 返回的切片与 Content 共享底层数组，修改前请复制
+func (c Content) Parts() []ContentPart {
+	return c.parts
+}
+
+// Text 返回文本内容
+// 数组格式时按顺序拼接所有文本片段（以换行分隔），忽略图片、音频等片段
+func (c Content) Text() string {
+	if c.parts == nil {
+		return c.text
+	}
+
+	texts := make([]string, 0, len(c.parts))
+	for _, part := range c.parts {
+		if part.Type == PartTypeText {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// IsEmpty 是否没有任何内容
+func (c Content) IsEmpty() bool {
+	if c.parts == nil {
+		return c.text == ""
+	}
+	return len(c.parts) == 0
+}
+
+// HasMedia 是否包含文本以外的片段（图片、音频、文件）
+func (c Content) HasMedia() bool {
+	for _, part := range c.parts {
+		if part.Type != PartTypeText {
+			return true
+		}
+	}
+	return false
+}
+
+// String 实现 fmt.Stringer，返回文本内容
+func (c Content) String() string {
+	return c.Text()
+}
+
+// MarshalJSON 按原格式输出：字符串或片段数组
+func (c Content) MarshalJSON() ([]byte, error) {
+	if c.parts != nil {
+		return json.Marshal(c.parts)
+	}
+	return json.Marshal(c.text)
+}
+
+// UnmarshalJSON 接受字符串、片段数组或null
+func (c *Content) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*c = Content{}
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*c = NewTextContent(text)
+		return nil
+	case len(data) > 0 && data[0] == '[':
+		var parts []ContentPart
+		if err := json.Unmarshal(data, &parts); err != nil {
+			return err
+		}
+		*c = NewMultipartContent(parts)
+		return nil
+	default:
+		return errors.New("content must be a string or an array of content parts")
+	}
+}
+
+// ContentPart 内容片段
+type ContentPart struct {
+	// Type 片段类型：text, image_url, input_audio, file
+	Type string `json:"type"`
+
+	// Text 文本（type=text）
+	Text string `json:"text,omitempty"`
+
+	// ImageURL 图片（type=image_url）
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+
+	// InputAudio 音频（type=input_audio）
+	InputAudio *InputAudio `json:"input_audio,omitempty"`
+
+	// File 文件（type=file），原样透传给上游
+	File *FilePart `json:"file,omitempty"`
+}
+
+// ImageURL 图片地址
+type ImageURL struct {
+	// URL 远程地址（http/https）或 data URL（data:image/png;base64,...）
+	URL string `json:"url"`
+
+	// Detail 图片精度：auto, low, high（可选）
+	Detail string `json:"detail,omitempty"`
+}
+
+// InputAudio 音频输入
+type InputAudio struct {
+	// Data base64编码的音频数据
+	Data string `json:"data"`
+
+	// Format 音频格式：wav, mp3
+	Format string `json:"format"`
+}
+
+// FilePart 文件输入
+type FilePart struct {
+	// FileID 已上传文件的ID
+	FileID string `json:"file_id,omitempty"`
+
+	// FileData base64编码的文件内容（data URL）
+	FileData string `json:"file_data,omitempty"`
+
+	// Filename 文件名
+	Filename string `json:"filename,omitempty"`
+}
+
+// Validate 校验片段的必填字段
+func (p *ContentPart) Validate() error {
+	switch p.Type {
+	case PartTypeText:
+		return nil
+	case PartTypeImageURL:
+		if p.ImageURL == nil || p.ImageURL.URL == "" {
+			return errors.New("image_url part requires image_url.url")
+		}
+	case PartTypeInputAudio:
+		if p.InputAudio == nil || p.InputAudio.Data == "" || p.InputAudio.Format == "" {
+			return errors.New("input_audio part requires input_audio.data and input_audio.format")
+		}
+	case PartTypeFile:
+		if p.File == nil || (p.File.FileID == "" && p.File.FileData == "") {
+			return errors.New("file part requires file.file_id or file.file_data")
+		}
+	default:
+		return fmt.Errorf("unsupported content part type: %q", p.Type)
+	}
+	return nil
+}
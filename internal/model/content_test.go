@@ -0,0 +1,73 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestContentJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		text      string
+		multipart bool
+		media     bool
+		wantErr   bool
+	}{
+		{"string", `"hello"`, "hello", false, false, false},
+		{"null", `null`, "", false, false, false},
+		{"text parts", `[{"type":"text","text":"a"},{"type":"text","text":"b"}]`, "a\nb", true, false, false},
+		{"image part", `[{"type":"text","text":"看图"},{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]`, "看图", true, true, false},
+		{"object", `{"type":"text"}`, "", false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Content
+			err := json.Unmarshal([]byte(tt.data), &c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if c.Text() != tt.text || c.IsMultipart() != tt.multipart || c.HasMedia() != tt.media {
+				t.Errorf("content = text %q, multipart %v, media %v", c.Text(), c.IsMultipart(), c.HasMedia())
+			}
+
+			// 按原格式输出
+			if tt.data == "null" {
+				return
+			}
+			out, _ := json.Marshal(c)
+			var roundTrip Content
+			json.Unmarshal(out, &roundTrip)
+			if roundTrip.IsMultipart() != tt.multipart || roundTrip.Text() != tt.text {
+				t.Errorf("round trip = %s", out)
+			}
+		})
+	}
+}
+
+func TestContentPartValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		part    ContentPart
+		wantErr bool
+	}{
+		{"text", ContentPart{Type: PartTypeText, Text: "hi"}, false},
+		{"image", ContentPart{Type: PartTypeImageURL, ImageURL: &ImageURL{URL: "https://example.com/a.png"}}, false},
+		{"image without url", ContentPart{Type: PartTypeImageURL, ImageURL: &ImageURL{}}, true},
+		{"audio", ContentPart{Type: PartTypeInputAudio, InputAudio: &InputAudio{Data: "AAAA", Format: "wav"}}, false},
+		{"audio without format", ContentPart{Type: PartTypeInputAudio, InputAudio: &InputAudio{Data: "AAAA"}}, true},
+		{"file id", ContentPart{Type: PartTypeFile, File: &FilePart{FileID: "file-1"}}, false},
+		{"empty file", ContentPart{Type: PartTypeFile, File: &FilePart{Filename: "a.pdf"}}, true},
+		{"unknown type", ContentPart{Type: "video"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.part.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
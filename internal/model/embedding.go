@@ -0,0 +1,144 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+)
+
+// 向量编码格式（EmbeddingRequest.EncodingFormat 的取值）
+const (
+	EncodingFormatFloat  = "float"
+	EncodingFormatBase64 = "base64"
+)
+
+// EmbeddingRequest 向量化请求（OpenAI兼容格式）
+type EmbeddingRequest struct {
+	// Model 向量模型名称
+	// 示例：
+	//   - "embedding-3" - 智谱向量模型
+	//   - "text-embedding-3-small" - OpenAI向量模型
+	Model string `json:"model" binding:"required"`
+
+	// Input 需要向量化的文本
+	// 支持单个字符串或字符串数组（批量）
+	Input EmbeddingInput `json:"input" binding:"required"`
+
+	// EncodingFormat 返回的向量格式
+	//   - "float"：浮点数数组（默认）
+	//   - "base64"：float32小端序字节的base64编码，体积更小
+	EncodingFormat string `json:"encoding_format,omitempty" binding:"omitempty,oneof=float base64"`
+
+	// Dimensions 输出向量维度（可选，只有部分模型支持）
+	Dimensions *int `json:"dimensions,omitempty"`
+
+	// User 用户标识符（可选）
+	User string `json:"user,omitempty"`
+}
+
+// EmbeddingInput 向量化输入
+// JSON中可以是单个字符串，也可以是字符串数组
+type EmbeddingInput []string
+
+// UnmarshalJSON 同时接受字符串和字符串数组
+func (in *EmbeddingInput) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*in = EmbeddingInput{single}
+		return nil
+	}
+
+	var batch []string
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return errors.New("input must be a string or an array of strings")
+	}
+	*in = batch
+	return nil
+}
+
+// EmbeddingResponse 向量化响应（OpenAI兼容格式）
+type EmbeddingResponse struct {
+	// Object 对象类型，固定值："list"
+	Object string `json:"object"`
+
+	// Data 向量列表，顺序与输入一致（以Index为准）
+	Data []Embedding `json:"data"`
+
+	// Model 实际使用的模型名称
+	Model string `json:"model"`
+
+	// Usage Token使用统计（向量化只有输入token）
+	Usage EmbeddingUsage `json:"usage"`
+}
+
+// Embedding 单个输入的向量
+type Embedding struct {
+	// Object 对象类型，固定值："embedding"
+	Object string `json:"object"`
+
+	// Index 对应输入的索引
+	Index int `json:"index"`
+
+	// Embedding 向量
+	Embedding EmbeddingVector `json:"embedding"`
+}
+
+// EmbeddingUsage 向量化Token使用统计
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// EmbeddingVector 向量
+// 序列化时：设置了 Base64 则输出base64字符串，否则输出浮点数数组
+// 反序列化时：两种格式都接受，统一解码到 Float
+type EmbeddingVector struct {
+	Float  []float32
+	Base64 string
+}
+
+// MarshalJSON 按编码格式输出向量
+func (v EmbeddingVector) MarshalJSON() ([]byte, error) {
+	if v.Base64 != "" {
+		return json.Marshal(v.Base64)
+	}
+	if v.Float == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(v.Float)
+}
+
+// UnmarshalJSON 接受浮点数数组或base64字符串
+func (v *EmbeddingVector) UnmarshalJSON(data []byte) error {
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err == nil {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return err
+		}
+		if len(raw)%4 != 0 {
+			return errors.New("invalid base64 embedding length")
+		}
+		v.Float = make([]float32, len(raw)/4)
+		for i := range v.Float {
+			v.Float[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:]))
+		}
+		v.Base64 = encoded
+		return nil
+	}
+	return json.Unmarshal(data, &v.Float)
+}
+
+// EncodeBase64 将所有向量转换为base64格式（float32小端序，与OpenAI一致）
+func (r *EmbeddingResponse) EncodeBase64() {
+	for i := range r.Data {
+		vec := r.Data[i].Embedding.Float
+		raw := make([]byte, len(vec)*4)
+		for j, f := range vec {
+			binary.LittleEndian.PutUint32(raw[j*4:], math.Float32bits(f))
+		}
+		r.Data[i].Embedding.Base64 = base64.StdEncoding.EncodeToString(raw)
+	}
+}
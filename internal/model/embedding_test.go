@@ -0,0 +1,87 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddingInputUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []string
+		wantErr bool
+	}{
+		{"single string", `"hello"`, []string{"hello"}, false},
+		{"batch", `["a","b"]`, []string{"a", "b"}, false},
+		{"number", `42`, nil, true},
+		{"mixed array", `["a",1]`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var in EmbeddingInput
+			err := json.Unmarshal([]byte(tt.data), &in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && strings.Join(in, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("input = %v, want %v", in, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddingVectorBase64RoundTrip(t *testing.T) {
+	resp := &EmbeddingResponse{Data: []Embedding{{Embedding: EmbeddingVector{Float: []float32{0.5, -1.25, 3}}}}}
+	resp.EncodeBase64()
+
+	data, err := json.Marshal(resp.Data[0].Embedding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), `"`) {
+		t.Fatalf("base64 vector marshalled as %s", data)
+	}
+
+	var decoded EmbeddingVector
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	want := []float32{0.5, -1.25, 3}
+	if len(decoded.Float) != len(want) {
+		t.Fatalf("decoded = %v", decoded.Float)
+	}
+	for i := range want {
+		if decoded.Float[i] != want[i] {
+			t.Errorf("decoded[%d] = %v, want %v", i, decoded.Float[i], want[i])
+		}
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{"float array", `[1,2]`, "[1,2]", false},
+		{"empty vector", `[]`, "[]", false},
+		{"truncated base64", `"AAA="`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v EmbeddingVector
+			err := json.Unmarshal([]byte(tt.data), &v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			out, _ := json.Marshal(v)
+			if string(out) != tt.want {
+				t.Errorf("marshal = %s, want %s", out, tt.want)
+			}
+		})
+	}
+}
@@ -48,6 +48,7 @@ const (
 	ErrorTypeAPIError        = "api_error"
 	ErrorTypeTimeout         = "timeout_error"
 	ErrorTypeServerError     = "server_error"
+	ErrorTypeUnavailable     = "service_unavailable_error"
 )
 
 // ===== 构造函数 =====
@@ -135,6 +136,16 @@ func NewServerError(message string) *ErrorResponse {
 	}
 }
 
+// NewUnavailableError 创建服务不可用错误（如网关正在关闭）
+func NewUnavailableError(message string) *ErrorResponse {
+	return &ErrorResponse{
+		Error: ErrorDetail{
+			Type:    ErrorTypeUnavailable,
+			Message: message,
+		},
+	}
+}
+
 // ===== 辅助方法 =====
 
 // WithParam 添加参数名
@@ -166,11 +177,30 @@ func (e *ErrorResponse) GetHTTPStatus() int {
 		return http.StatusRequestTimeout // 408
 	case ErrorTypeServerError, ErrorTypeAPIError:
 		return http.StatusInternalServerError // 500
+	case ErrorTypeUnavailable:
+		return http.StatusServiceUnavailable // 503
 	default:
 		return http.StatusInternalServerError // 500
 	}
 }
 
+// ParamError 请求参数校验错误
+// Param 对应错误响应中的 param 字段
+type ParamError struct {
+	Param string
+	Err   error
+}
+
+// Error 实现 error 接口
+func (e *ParamError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
 // ===== 常用错误消息 =====
 
 var (
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"crypto/rand"
+	"time"
+)
+
+// ID前缀（与OpenAI保持一致）
+const (
+	// IDPrefixChatCompletion 聊天响应ID前缀
+	IDPrefixChatCompletion = "chatcmpl-"
+
+	// IDPrefixCompletion 文本补全响应ID前缀（/v1/completions）
+	IDPrefixCompletion = "cmpl-"
+
+	// IDPrefixResponse Responses API 响应ID前缀
+	IDPrefixResponse = "resp_"
+
+	// IDPrefixMessage Responses API 消息输出项ID前缀
+	IDPrefixMessage = "msg_"
+
+	// IDPrefixFunctionCall Responses API 函数调用输出项ID前缀
+	IDPrefixFunctionCall = "fc_"
+
+	// IDPrefixFile 上传文件ID前缀（/v1/files）
+	IDPrefixFile = "file-"
+
+	// IDPrefixBatch 批处理任务ID前缀（/v1/batches）
+	IDPrefixBatch = "batch_"
+
+	// IDPrefixBatchRequest 批处理中单个请求结果的ID前缀
+	IDPrefixBatchRequest = "batch_req_"
+)
+
+// idAlphabet 随机部分使用的字符（大小写字母和数字）
+const idAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// idLength 随机部分长度（约143位随机性，可忽略碰撞）
+const idLength = 24
+
+// NewID 生成带前缀的唯一ID
+// 随机部分来自 crypto/rand，按字母表均匀取值
+//
+// 示例：
+//
+//	model.NewID(model.IDPrefixChatCompletion) // chatcmpl-Xk3fP9qLw2ZbT7nRc0VdYs1a
+func NewID(prefix string) string {
+	b := make([]byte, 0, idLength)
+	buf := make([]byte, idLength*2)
+	for len(b) < idLength {
+		if _, err := rand.Read(buf); err != nil {
+			// 系统随机源不可用（极少见），退化为时间戳
+			return prefix + time.Now().Format("20060102150405.000000000")
+		}
+		for _, v := range buf {
+			// 丢弃 248 及以上的值，保证每个字符出现的概率相同
+			if v >= 248 {
+				continue
+			}
+			b = append(b, idAlphabet[int(v)%len(idAlphabet)])
+			if len(b) == idLength {
+				break
+			}
+		}
+	}
+	return prefix + string(b)
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := NewID(IDPrefixChatCompletion)
+		random, ok := strings.CutPrefix(id, IDPrefixChatCompletion)
+		if !ok || len(random) != idLength {
+			t.Fatalf("NewID() = %q, want %s followed by %d characters", id, IDPrefixChatCompletion, idLength)
+		}
+		if strings.Trim(random, idAlphabet) != "" {
+			t.Fatalf("NewID() = %q contains characters outside the alphabet", id)
+		}
+		if seen[id] {
+			t.Fatalf("NewID() returned duplicate id %q", id)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+// ModelObject 模型信息（OpenAI兼容格式）
+// GET /v1/models 和 GET /v1/models/{id} 返回的单个模型
+// 在OpenAI格式的基础上扩展了适配器、上下文窗口、价格、能力等字段
+type ModelObject struct {
+	// ID 模型名称，即请求中的 model 字段
+	ID string `json:"id"`
+
+	// Object 对象类型，固定值："model"
+	Object string `json:"object"`
+
+	// Created 创建时间戳（Unix时间戳，秒）
+	Created int64 `json:"created"`
+
+	// OwnedBy 模型所属组织
+	OwnedBy string `json:"owned_by"`
+
+	// ===== 扩展字段 =====
+
+	// Adapter 处理该模型的适配器名称，如 "glm"
+	Adapter string `json:"adapter"`
+
+	// ContextWindow 上下文窗口（token），未配置时省略
+	ContextWindow int `json:"context_window,omitempty"`
+
+	// MaxOutputTokens 单次最大输出（token），未配置时省略
+	MaxOutputTokens int `json:"max_output_tokens,omitempty"`
+
+	// Pricing 价格（每百万token），未配置时省略
+	Pricing *ModelPricing `json:"pricing,omitempty"`
+
+	// Capabilities 能力列表，如 ["chat", "stream"]
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// ModelPricing 模型价格（每百万token）
+type ModelPricing struct {
+	Input    float64 `json:"input"`
+	Output   float64 `json:"output"`
+	Currency string  `json:"currency,omitempty"`
+}
+
+// ModelList 模型列表（OpenAI兼容格式）
+type ModelList struct {
+	// Object 对象类型，固定值："list"
+	Object string `json:"object"`
+
+	// Data 模型列表
+	Data []ModelObject `json:"data"`
+}
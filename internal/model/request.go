@@ -1,5 +1,10 @@
 package model
 
+import (
+	"errors"
+	"fmt"
+)
+
 // ChatRequest 聊天请求（OpenAI兼容格式）
 // 用户发送给网关的请求，网关会转发给不同的AI模型
 type ChatRequest struct {
@@ -14,8 +19,8 @@ type ChatRequest struct {
 
 	// Messages 对话消息列表
 	// 包含整个对话历史，按时间顺序排列
-	// 至少需要1条消息
-	Messages []Message `json:"messages" binding:"required,min=1"`
+	// 至少需要1条消息，每条消息按 Message 上的规则逐条校验
+	Messages []Message `json:"messages" binding:"required,min=1,dive"`
 
 	// ===== 可选字段 =====
 
@@ -47,6 +52,9 @@ type ChatRequest struct {
 	// 默认：false
 	Stream bool `json:"stream,omitempty"`
 
+	// StreamOptions 流式响应选项（仅 stream=true 时有效）
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+
 	// TopP 核采样参数
 	// 范围：0.0 - 1.0
 	// 与Temperature二选一使用（不建议同时设置）
@@ -84,6 +92,36 @@ type ChatRequest struct {
 	// 用于追踪和分析，可选
 	// 示例："user-12345"
 	User string `json:"user,omitempty"`
+
+	// ===== 工具调用 =====
+
+	// Tools 可供模型调用的工具列表
+	Tools []Tool `json:"tools,omitempty" binding:"omitempty,dive"`
+
+	// ToolChoice 工具选择策略（可选）
+	//   - "none"：不调用工具
+	//   - "auto"：由模型决定（有tools时的默认值）
+	//   - "required"：必须调用工具
+	//   - {"type": "function", "function": {"name": "xxx"}}：必须调用指定函数
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+
+	// ParallelToolCalls 是否允许一次回复中调用多个工具（可选）
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
+
+	// ===== 结构化输出 =====
+
+	// ResponseFormat 输出格式（可选）
+	//   - {"type": "text"}：普通文本（默认）
+	//   - {"type": "json_object"}：输出合法的JSON对象
+	//   - {"type": "json_schema", "json_schema": {...}}：输出满足指定Schema的JSON
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// StreamOptions 流式响应选项
+type StreamOptions struct {
+	// IncludeUsage 是否在流结束前额外发送一个用量数据块
+	// 该数据块的 choices 为空数组，usage 为整个请求的token用量
+	IncludeUsage bool `json:"include_usage,omitempty"`
 }
 
 // Message 单条消息
@@ -93,16 +131,24 @@ type Message struct {
 	//   - "system"：系统提示，设定AI的行为规则
 	//   - "user"：用户输入
 	//   - "assistant"：AI回复（用于多轮对话）
-	Role string `json:"role" binding:"required,oneof=system user assistant"`
+	//   - "tool"：工具执行结果（需要携带 tool_call_id）
+	Role string `json:"role" binding:"required,oneof=system user assistant tool"`
 
 	// Content 消息内容
-	// 实际的文本内容
-	Content string `json:"content" binding:"required"`
+	// 字符串，或多模态内容片段数组（文本、图片、音频、文件）
+	// 调用工具的assistant消息可以没有内容（校验见 ChatRequest.Validate）
+	Content Content `json:"content"`
 
 	// Name 消息发送者的名称（可选）
 	// 用于多用户场景，区分不同的用户
 	// 示例："张三"、"user1"
 	Name string `json:"name,omitempty"`
+
+	// ToolCalls assistant消息中模型发起的工具调用
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// ToolCallID tool消息对应的工具调用ID
+	ToolCallID string `json:"tool_call_id,omitempty" binding:"required_if=Role tool"`
 }
 
 // ===== 辅助方法 =====
@@ -139,6 +185,11 @@ func (r *ChatRequest) GetN() int {
 	return *r.N
 }
 
+// IncludeUsage 流式响应是否需要发送用量数据块
+func (r *ChatRequest) IncludeUsage() bool {
+	return r.StreamOptions != nil && r.StreamOptions.IncludeUsage
+}
+
 // HasSystemMessage 检查是否包含system消息
 func (r *ChatRequest) HasSystemMessage() bool {
 	for _, msg := range r.Messages {
@@ -158,3 +209,33 @@ func (r *ChatRequest) GetLastUserMessage() *Message {
 	}
 	return nil
 }
+
+// Validate 校验binding标签无法表达的规则
+//   - 除调用工具的assistant消息外，每条消息都必须有内容
+//   - 多模态内容片段的类型和必填字段
+//   - response_format 的类型和Schema定义
+//   - stream_options 只能在 stream=true 时设置
+//
+// 返回：不合法时返回 *ParamError（错误信息可直接返回给调用方）
+func (r *ChatRequest) Validate() error {
+	for i := range r.Messages {
+		msg := &r.Messages[i]
+		if msg.Content.IsEmpty() && !(msg.Role == "assistant" && len(msg.ToolCalls) > 0) {
+			return &ParamError{Param: "messages", Err: fmt.Errorf("messages[%d].content is required", i)}
+		}
+		for j := range msg.Content.Parts() {
+			if err := msg.Content.Parts()[j].Validate(); err != nil {
+				return &ParamError{Param: "messages", Err: fmt.Errorf("messages[%d].content[%d]: %w", i, j, err)}
+			}
+		}
+	}
+	if r.ResponseFormat != nil {
+		if err := r.ResponseFormat.Validate(); err != nil {
+			return &ParamError{Param: "response_format", Err: err}
+		}
+	}
+	if r.StreamOptions != nil && !r.Stream {
+		return &ParamError{Param: "stream_options", Err: errors.New("stream_options is only allowed when stream is true")}
+	}
+	return nil
+}
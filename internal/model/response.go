@@ -50,7 +50,7 @@ type Choice struct {
 	//   - "stop"：正常结束（AI认为回复完整）
 	//   - "length"：达到max_tokens限制
 	//   - "content_filter"：内容被过滤（违规）
-	//   - "function_call"：调用了函数（高级功能）
+	//   - "tool_calls"：调用了工具（Message.ToolCalls 中是调用详情）
 	//   - null：流式响应中，未结束时为null
 	FinishReason string `json:"finish_reason"`
 
@@ -81,7 +81,7 @@ func NewChatResponse(model string, content string) *ChatResponse {
 	now := time.Now().Unix()
 
 	return &ChatResponse{
-		ID:      generateID(),
+		ID:      NewID(IDPrefixChatCompletion),
 		Object:  "chat.completion",
 		Created: now,
 		Model:   model,
@@ -90,7 +90,7 @@ func NewChatResponse(model string, content string) *ChatResponse {
 				Index: 0,
 				Message: &Message{
 					Role:    "assistant",
-					Content: content,
+					Content: NewTextContent(content),
 				},
 				FinishReason: "stop",
 			},
@@ -115,7 +115,7 @@ func (r *ChatResponse) GetFirstChoice() *Choice {
 func (r *ChatResponse) GetContent() string {
 	choice := r.GetFirstChoice()
 	if choice != nil && choice.Message != nil {
-		return choice.Message.Content
+		return choice.Message.Content.Text()
 	}
 	return ""
 }
@@ -125,12 +125,3 @@ func (r *ChatResponse) IsComplete() bool {
 	choice := r.GetFirstChoice()
 	return choice != nil && choice.FinishReason != ""
 }
-
-// generateID 生成响应ID
-// 格式：chatcmpl-{时间戳}{随机数}
-func generateID() string {
-	// 使用时间戳 + 随机数生成ID
-	// 实际项目中可以用更复杂的ID生成策略
-	now := time.Now().UnixNano()
-	return "chatcmpl-" + time.Now().Format("20060102150405") + "-" + string(rune(now%1000000))
-}
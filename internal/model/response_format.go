@@ -0,0 +1,103 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 响应格式类型（ResponseFormat.Type 的取值）
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// schemaNamePattern json_schema.name 的合法格式（与OpenAI一致）
+var schemaNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ResponseFormat 结构化输出格式
+//
+//	{"type": "json_object"}
+//	{"type": "json_schema", "json_schema": {"name": "weather", "strict": true, "schema": {...}}}
+type ResponseFormat struct {
+	// Type 格式类型：text, json_object, json_schema
+	Type string `json:"type"`
+
+	// JSONSchema 输出需要满足的JSON Schema（type=json_schema时必填）
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat JSON Schema格式定义
+type JSONSchemaFormat struct {
+	// Name 格式名称，只能包含字母、数字、下划线和连字符，最长64个字符
+	Name string `json:"name"`
+
+	// Description 格式说明，帮助模型理解输出的用途（可选）
+	Description string `json:"description,omitempty"`
+
+	// Schema JSON Schema对象，原样透传给上游
+	Schema json.RawMessage `json:"schema,omitempty"`
+
+	// Strict 是否严格遵循Schema，原样透传给支持的上游
+	// 网关不论是否strict都按Schema校验最终输出
+	Strict *bool `json:"strict,omitempty"`
+}
+
+// IsJSON 是否要求输出JSON（json_object 或 json_schema）
+func (f *ResponseFormat) IsJSON() bool {
+	return f != nil && (f.Type == ResponseFormatJSONObject || f.Type == ResponseFormatJSONSchema)
+}
+
+// Validate 校验格式类型和Schema定义
+func (f *ResponseFormat) Validate() error {
+	switch f.Type {
+	case ResponseFormatText, ResponseFormatJSONObject:
+		return nil
+	case ResponseFormatJSONSchema:
+	default:
+		return fmt.Errorf("unsupported response_format type: %q", f.Type)
+	}
+
+	s := f.JSONSchema
+	if s == nil {
+		return errors.New("json_schema is required when type is json_schema")
+	}
+	if !schemaNamePattern.MatchString(s.Name) {
+		return errors.New("json_schema.name must match ^[a-zA-Z0-9_-]{1,64}$")
+	}
+	if len(s.Schema) > 0 {
+		var schema map[string]any
+		if err := json.Unmarshal(s.Schema, &schema); err != nil {
+			return errors.New("json_schema.schema must be a JSON object")
+		}
+	}
+	return nil
+}
+
+// Instruction 返回要求模型按格式输出的提示词
+// 用于不支持 response_format 的上游：将其作为system消息加入对话
+// text格式返回空字符串
+func (f *ResponseFormat) Instruction() string {
+	if !f.IsJSON() {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("You must respond with a single valid JSON object only. ")
+	b.WriteString("Do not wrap it in markdown code fences and do not add any text before or after it.")
+
+	if s := f.JSONSchema; f.Type == ResponseFormatJSONSchema && s != nil {
+		if s.Description != "" {
+			b.WriteString("\nPurpose: ")
+			b.WriteString(s.Description)
+		}
+		if len(s.Schema) > 0 {
+			b.WriteString("\nThe JSON object must conform to this JSON Schema:\n")
+			b.Write(s.Schema)
+		}
+	}
+	return b.String()
+}
@@ -1,6 +1,12 @@
 package model
 
-import "time"
+import (
+	"context"
+	"time"
+)
+
+// replayChunkRunes 回放完整响应时每个数据块包含的字符数
+const replayChunkRunes = 16
 
 // StreamResponse 流式响应（OpenAI兼容格式）
 // SSE（Server-Sent Events）格式的单个数据块
@@ -25,6 +31,14 @@ type StreamResponse struct {
 
 	// SystemFingerprint 系统指纹（可选）
 	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
+	// Usage token用量
+	// 只出现在流结束前的用量数据块中（此时 Choices 为空数组），其他数据块为nil
+	Usage *Usage `json:"usage,omitempty"`
+
+	// Err 上游流读取失败（如数据块空闲超时）
+	// 只出现在流的最后一个数据块中，不发送给客户端，由网关转换为SSE错误事件
+	Err error `json:"-"`
 }
 
 // StreamChoice 流式回复选项
@@ -65,6 +79,10 @@ type StreamDelta struct {
 	//   chunk5: "是"
 	//   chunk6: "AI"
 	Content string `json:"content,omitempty"`
+
+	// ToolCalls 工具调用增量
+	// 同一个调用可能分成多个数据块，按 Index 拼接 Arguments
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 }
 
 // ===== 辅助方法 =====
@@ -116,6 +134,107 @@ func NewStreamEndResponse(id string, model string, finishReason string) *StreamR
 	}
 }
 
+// NewStreamUsageResponse 创建用量数据块（choices为空数组）
+func NewStreamUsageResponse(id string, model string, created int64, usage Usage) *StreamResponse {
+	return &StreamResponse{
+		ID:      id,
+		Object:  "chat.completion.chunk",
+		Created: created,
+		Model:   model,
+		Choices: []StreamChoice{},
+		Usage:   &usage,
+	}
+}
+
+// NewStreamErrorResponse 创建流式错误数据块
+// 适配器读取上游流失败时作为最后一个数据块发送
+func NewStreamErrorResponse(err error) *StreamResponse {
+	return &StreamResponse{Err: err}
+}
+
+// IsError 判断是否是错误数据块
+func (s *StreamResponse) IsError() bool {
+	return s.Err != nil
+}
+
+// IsUsage 判断是否是用量数据块（不含任何choice）
+func (s *StreamResponse) IsUsage() bool {
+	return s.Usage != nil && len(s.Choices) == 0
+}
+
+// ReplayStream 将完整响应回放为流式数据块
+// 用于缓存命中、结构化输出校验等拿到完整响应后仍需以SSE返回的场景
+// 每个choice依次发送：role数据块、若干内容数据块、工具调用数据块（如有）、带结束原因的结束数据块；
+// 最后发送用量数据块
+func ReplayStream(ctx context.Context, resp *ChatResponse) <-chan *StreamResponse {
+	out := make(chan *StreamResponse, 10)
+
+	go func() {
+		defer close(out)
+
+		send := func(choice StreamChoice) bool {
+			chunk := &StreamResponse{
+				ID:                resp.ID,
+				Object:            "chat.completion.chunk",
+				Created:           resp.Created,
+				Model:             resp.Model,
+				Choices:           []StreamChoice{choice},
+				SystemFingerprint: resp.SystemFingerprint,
+			}
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, choice := range resp.Choices {
+			role, content := "assistant", ""
+			var toolCalls []ToolCall
+			if choice.Message != nil {
+				role, content, toolCalls = choice.Message.Role, choice.Message.Content.Text(), choice.Message.ToolCalls
+			}
+
+			if !send(StreamChoice{Index: choice.Index, Delta: StreamDelta{Role: role}}) {
+				return
+			}
+
+			runes := []rune(content)
+			for i := 0; i < len(runes); i += replayChunkRunes {
+				end := min(i+replayChunkRunes, len(runes))
+				delta := StreamDelta{Content: string(runes[i:end])}
+				if !send(StreamChoice{Index: choice.Index, Delta: delta}) {
+					return
+				}
+			}
+
+			if len(toolCalls) > 0 {
+				deltas := make([]ToolCall, len(toolCalls))
+				for i, call := range toolCalls {
+					call.Index = &i
+					deltas[i] = call
+				}
+				if !send(StreamChoice{Index: choice.Index, Delta: StreamDelta{ToolCalls: deltas}}) {
+					return
+				}
+			}
+
+			reason := choice.FinishReason
+			if !send(StreamChoice{Index: choice.Index, FinishReason: &reason}) {
+				return
+			}
+		}
+
+		select {
+		case out <- NewStreamUsageResponse(resp.ID, resp.Model, resp.Created, resp.Usage):
+		case <-ctx.Done():
+		}
+	}()
+
+	return out
+}
+
 // IsFirst 判断是否是第一个chunk（包含role）
 func (s *StreamResponse) IsFirst() bool {
 	if len(s.Choices) > 0 {
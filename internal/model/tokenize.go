@@ -0,0 +1,38 @@
+package model
+
+// TokenizeRequest token计数请求（网关扩展接口 /v1/tokenize）
+// input 和 messages 二选一：
+//
+//	{"model": "glm-4", "input": "你好"}
+//	{"model": "glm-4", "messages": [{"role": "user", "content": "你好"}]}
+type TokenizeRequest struct {
+	// Model 模型名称（决定使用的分词器和上下文窗口）
+	Model string `json:"model" binding:"required"`
+
+	// Input 纯文本
+	Input string `json:"input,omitempty"`
+
+	// Messages 对话消息，按聊天请求的格式计数（包含每条消息的格式开销）
+	Messages []Message `json:"messages,omitempty" binding:"omitempty,dive"`
+
+	// Tools 工具定义（与 messages 一起计数，可选）
+	Tools []Tool `json:"tools,omitempty" binding:"omitempty,dive"`
+}
+
+// TokenizeResponse token计数响应
+type TokenizeResponse struct {
+	// Object 对象类型，固定为 "tokenize"
+	Object string `json:"object"`
+
+	// Model 模型名称
+	Model string `json:"model"`
+
+	// Tokenizer 实际使用的分词器（词表名称，或 "estimate" 表示按字符估算）
+	Tokenizer string `json:"tokenizer"`
+
+	// Tokens token数
+	Tokens int `json:"tokens"`
+
+	// ContextWindow 模型的上下文窗口（未配置时省略）
+	ContextWindow int `json:"context_window,omitempty"`
+}
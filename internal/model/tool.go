@@ -0,0 +1,133 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// 工具选择模式（ToolChoice.Mode 的取值）
+const (
+	ToolChoiceNone     = "none"     // 不调用工具，只生成文本
+	ToolChoiceAuto     = "auto"     // 由模型决定是否调用工具（默认）
+	ToolChoiceRequired = "required" // 必须调用至少一个工具
+)
+
+// ToolTypeFunction 工具类型：函数（目前唯一的工具类型）
+const ToolTypeFunction = "function"
+
+// FinishReasonToolCalls 模型调用了工具时的结束原因
+const FinishReasonToolCalls = "tool_calls"
+
+// Tool 可供模型调用的工具
+//
+// 示例：
+//
+//	{
+//	  "type": "function",
+//	  "function": {
+//	    "name": "get_weather",
+//	    "description": "查询城市天气",
+//	    "parameters": {"type": "object", "properties": {"city": {"type": "string"}}}
+//	  }
+//	}
+type Tool struct {
+	// Type 工具类型，固定值："function"
+	Type string `json:"type" binding:"required,eq=function"`
+
+	// Function 函数定义
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition 函数定义
+type FunctionDefinition struct {
+	// Name 函数名称
+	Name string `json:"name" binding:"required"`
+
+	// Description 函数说明（模型据此决定何时调用）
+	Description string `json:"description,omitempty"`
+
+	// Parameters 参数的JSON Schema，原样透传给上游
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+
+	// Strict 是否严格按照Schema生成参数（部分供应商支持）
+	Strict *bool `json:"strict,omitempty"`
+}
+
+// ToolCall 模型发起的一次工具调用
+// 非流式响应中是完整的调用；流式响应中是增量：
+//   - 第一个数据块包含 Index、ID、Type 和函数名
+//   - 后续数据块只包含 Index 和参数片段（Arguments需要按顺序拼接）
+type ToolCall struct {
+	// Index 调用的序号（仅流式增量中使用，用于拼接同一个调用的多个片段）
+	Index *int `json:"index,omitempty"`
+
+	// ID 调用ID，回传工具结果时作为 tool_call_id
+	ID string `json:"id,omitempty"`
+
+	// Type 工具类型，固定值："function"
+	Type string `json:"type,omitempty"`
+
+	// Function 被调用的函数
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall 被调用的函数及参数
+type FunctionCall struct {
+	// Name 函数名称
+	Name string `json:"name,omitempty"`
+
+	// Arguments 参数（JSON字符串，由模型生成，不保证合法）
+	Arguments string `json:"arguments"`
+}
+
+// ToolChoice 工具选择策略
+// JSON中可以是字符串（"none" / "auto" / "required"），
+// 也可以是指定函数的对象：{"type": "function", "function": {"name": "get_weather"}}
+type ToolChoice struct {
+	// Mode 选择模式（指定函数时为空）
+	Mode string
+
+	// Function 指定必须调用的函数名（Mode为空时有效）
+	Function string
+}
+
+// toolChoiceObject 指定函数时的JSON结构
+type toolChoiceObject struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+// MarshalJSON 按OpenAI格式输出
+func (tc ToolChoice) MarshalJSON() ([]byte, error) {
+	if tc.Function == "" {
+		return json.Marshal(tc.Mode)
+	}
+	obj := toolChoiceObject{Type: ToolTypeFunction}
+	obj.Function.Name = tc.Function
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON 同时接受字符串和对象两种格式
+func (tc *ToolChoice) UnmarshalJSON(data []byte) error {
+	var mode string
+	if err := json.Unmarshal(data, &mode); err == nil {
+		switch mode {
+		case ToolChoiceNone, ToolChoiceAuto, ToolChoiceRequired:
+			*tc = ToolChoice{Mode: mode}
+			return nil
+		}
+		return errors.New("tool_choice must be 'none', 'auto', 'required' or a function object")
+	}
+
+	var obj toolChoiceObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return errors.New("tool_choice must be 'none', 'auto', 'required' or a function object")
+	}
+	if obj.Type != ToolTypeFunction || obj.Function.Name == "" {
+		return errors.New("tool_choice object must specify a function name")
+	}
+	*tc = ToolChoice{Function: obj.Function.Name}
+	return nil
+}
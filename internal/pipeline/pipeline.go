@@ -0,0 +1,125 @@
+// Package pipeline 聊天请求发送给上游之前的公共处理步骤
+// 在线接口（handler.ChatHandler）和批处理（batch.Processor）共用同一个 Pipeline，
+// 同一个请求无论从哪条路径进入，截断、上下文窗口校验和多模态处理的结果都一致
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AtSunset1/prism/internal/media"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/tokenizer"
+	"github.com/AtSunset1/prism/internal/truncate"
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// Pipeline 上游调用前的请求处理
+// 处理顺序：截断对话历史 → 校验上下文窗口 → 处理多模态内容
+type Pipeline struct {
+	// truncator 对话截断器（可选，nil表示超出上下文窗口时直接拒绝）
+	truncator *truncate.Truncator
+
+	// media 多模态内容处理器（可选，nil表示不校验）
+	media *media.Processor
+}
+
+// Option Pipeline的可选配置
+type Option func(*Pipeline)
+
+// WithTruncator 启用对话截断
+// 请求超出上下文窗口时按模型配置的策略裁剪对话历史
+func WithTruncator(truncator *truncate.Truncator) Option {
+	return func(p *Pipeline) {
+		p.truncator = truncator
+	}
+}
+
+// WithMedia 启用多模态内容处理
+// 校验图片、音频大小，并按配置内联远程图片
+func WithMedia(processor *media.Processor) Option {
+	return func(p *Pipeline) {
+		p.media = processor
+	}
+}
+
+// New 创建请求处理流程
+// 不传入任何选项时只校验上下文窗口
+// 参数：
+//   - opts: 可选配置，如 WithTruncator、WithMedia
+//
+// 示例：
+//
+//	p := pipeline.New(pipeline.WithTruncator(truncate.New(manager)), pipeline.WithMedia(media.New(cfg.Media)))
+func New(opts ...Option) *Pipeline {
+	p := &Pipeline{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Prepare 在请求发送给上游之前处理请求
+//  1. 超出上下文窗口时按模型的截断策略裁剪对话历史
+//  2. 仍然超出时拒绝请求，不再请求上游
+//  3. 校验多模态内容大小，按配置内联远程图片
+//
+// 需要改写时替换 req.Messages，不修改调用方持有的原始消息（审计中保留原始请求）
+// 参数：
+//   - req: 已通过 Validate 的聊天请求
+//
+// 返回：
+//   - truncate.Result: 截断结果（未截断时为零值）
+//   - *model.ErrorResponse: 请求无法发送给上游时的错误响应，否则为nil
+func (p *Pipeline) Prepare(ctx context.Context, req *model.ChatRequest) (truncate.Result, *model.ErrorResponse) {
+	var result truncate.Result
+	if p.truncator != nil {
+		result = p.truncator.Fit(ctx, req)
+	}
+	if errResp := contextLengthError(req); errResp != nil {
+		return result, errResp
+	}
+
+	if p.media != nil {
+		if err := p.media.Process(ctx, req); err != nil {
+			return result, model.NewInvalidRequestError(err.Error(), "messages")
+		}
+	}
+	return result, nil
+}
+
+// contextLengthError 校验请求是否超出模型的上下文窗口
+// 输入token数使用模型配置的分词器计算；模型未配置 context_window 时不校验
+// 没有配置词表时只能按字符估算，估算值按 tokenizer.EstimateError 打折后仍然超出才拒绝，
+// 错误信息中注明是估算值
+// 返回：超出时返回 context_length_exceeded 错误，否则返回nil
+func contextLengthError(req *model.ChatRequest) *model.ErrorResponse {
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return nil
+	}
+	meta, ok := cfg.GetModel(req.Model)
+	if !ok || meta.ContextWindow <= 0 {
+		return nil
+	}
+
+	tok, name := tokenizer.ForModel(req.Model)
+	promptTokens := tokenizer.CountRequest(tok, req)
+	completionTokens := req.GetMaxTokens()
+	estimated := name == tokenizer.NameEstimate
+	checked := promptTokens
+	if estimated {
+		checked = int(float64(promptTokens) * (1 - tokenizer.EstimateError))
+	}
+	if checked+completionTokens <= meta.ContextWindow {
+		return nil
+	}
+
+	message := fmt.Sprintf("This model's maximum context length is %d tokens. However, you requested %d tokens (%d in the messages, %d in the completion). Please reduce the length of the messages or completion.",
+		meta.ContextWindow, promptTokens+completionTokens, promptTokens, completionTokens)
+	if estimated {
+		message = fmt.Sprintf("This model's maximum context length is %d tokens. However, you requested about %d tokens (about %d in the messages, estimated without the model's tokenizer, %d in the completion). Please reduce the length of the messages or completion.",
+			meta.ContextWindow, promptTokens+completionTokens, promptTokens, completionTokens)
+	}
+	return model.NewInvalidRequestError(message, "messages").WithCode("context_length_exceeded")
+}
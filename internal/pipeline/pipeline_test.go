@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/media"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/truncate"
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// pipelineConfig drop 超出窗口时删除最早的对话，strict 只校验窗口
+const pipelineConfig = `
+adapters:
+  glm:
+    api_key: "test-key"
+    base_url: "https://example.com/v1"
+    models: ["drop", "strict", "glm-4-flash"]
+models:
+  drop:
+    context_window: 40
+    truncation:
+      strategy: drop_oldest
+  strict:
+    context_window: 40
+`
+
+func loadConfig(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(pipelineConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Load(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// stubSummarizer 截断器需要的适配器（drop_oldest 不会调用）
+type stubSummarizer struct{}
+
+func (stubSummarizer) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (stubSummarizer) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (stubSummarizer) Name() string { return "stub" }
+
+func (stubSummarizer) HealthCheck(ctx context.Context) error { return nil }
+
+// conversation 构造 turns 轮对话，每条消息约10个token
+func conversation(modelName string, turns int) *model.ChatRequest {
+	req := &model.ChatRequest{Model: modelName}
+	for i := 0; i < turns; i++ {
+		req.Messages = append(req.Messages,
+			model.Message{Role: "user", Content: model.NewTextContent(strings.Repeat("q", 24))},
+			model.Message{Role: "assistant", Content: model.NewTextContent(strings.Repeat("a", 24))},
+		)
+	}
+	return req
+}
+
+func TestPrepare(t *testing.T) {
+	loadConfig(t)
+	large := "data:image/png;base64," + strings.Repeat("A", 2<<20)
+	image := &model.ChatRequest{Model: "glm-4-flash", Messages: []model.Message{{Role: "user", Content: model.NewMultipartContent([]model.ContentPart{
+		{Type: model.PartTypeImageURL, ImageURL: &model.ImageURL{URL: large}},
+	})}}}
+
+	tests := []struct {
+		name     string
+		pipeline *Pipeline
+		req      *model.ChatRequest
+		strategy string
+		errCode  string
+		errMsg   string
+	}{
+		{"fits", New(), conversation("strict", 1), "", "", ""},
+		{"too long", New(), conversation("strict", 4), "", "context_length_exceeded", "maximum context length is 40"},
+		{"truncated", New(WithTruncator(truncate.New(stubSummarizer{}))), conversation("drop", 4), truncate.StrategyDropOldest, "", ""},
+		{"no truncator", New(), conversation("drop", 4), "", "context_length_exceeded", ""},
+		{"media checked", New(WithMedia(media.New(config.MediaConfig{MaxImageSize: 1}))), image, "", "", "image exceeds size limit"},
+		{"no media processor", New(), image, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, errResp := tt.pipeline.Prepare(context.Background(), tt.req)
+			if result.Strategy != tt.strategy {
+				t.Errorf("strategy = %q, want %q", result.Strategy, tt.strategy)
+			}
+			if tt.errCode == "" && tt.errMsg == "" {
+				if errResp != nil {
+					t.Fatalf("Prepare: %+v", errResp.Error)
+				}
+				return
+			}
+			if errResp == nil {
+				t.Fatal("Prepare succeeded, want error")
+			}
+			if errResp.GetHTTPStatus() != 400 {
+				t.Errorf("status = %d, want 400", errResp.GetHTTPStatus())
+			}
+			if tt.errCode != "" && errResp.Error.Code != tt.errCode {
+				t.Errorf("code = %v, want %s", errResp.Error.Code, tt.errCode)
+			}
+			if !strings.Contains(errResp.Error.Message, tt.errMsg) {
+				t.Errorf("message = %q, want %q", errResp.Error.Message, tt.errMsg)
+			}
+		})
+	}
+}
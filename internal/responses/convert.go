@@ -0,0 +1,378 @@
+package responses
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// ===== Responses API 与聊天格式的转换 =====
+// 两种格式的主要差异：
+//   - 对话由输入项组成：message、function_call（模型的工具调用）、function_call_output（工具结果），
+//     分别对应 assistant 消息的 tool_calls 和独立的 tool 消息
+//   - instructions 是请求的顶层字段，developer 角色等价于 system
+//   - 工具定义和 json_schema 输出格式是扁平的，不嵌套在 function / json_schema 字段中
+//   - 输出是类型化的输出项列表，而不是 choices
+
+// ToChatRequest 将 Responses API 请求转换为网关内部的聊天请求
+// 参数：
+//   - req: Responses API 请求
+//   - history: previous_response_id 对应的对话历史（没有时为nil）
+//
+// 返回：
+//   - *model.ChatRequest: 聊天请求（instructions 作为第一条 system 消息）
+//   - []model.Message: 本次请求之后需要保存的对话（历史 + 本次输入，不含 instructions）
+//   - error: 请求中包含无法转换的内容（如内置工具），返回 *model.ParamError
+func ToChatRequest(req *Request, history []model.Message) (*model.ChatRequest, []model.Message, error) {
+	chatReq := &model.ChatRequest{
+		Model:             req.Model,
+		MaxTokens:         req.MaxOutputTokens,
+		Temperature:       req.Temperature,
+		TopP:              req.TopP,
+		Stream:            req.Stream,
+		User:              req.User,
+		ParallelToolCalls: req.ParallelToolCalls,
+	}
+
+	// 1. 之前的对话 + 本次输入项
+	conversation := append([]model.Message(nil), history...)
+	for i, item := range req.Input {
+		var err error
+		conversation, err = appendItem(conversation, item)
+		if err != nil {
+			return nil, nil, &model.ParamError{Param: "input", Err: fmt.Errorf("input[%d]: %w", i, err)}
+		}
+	}
+
+	// 2. instructions 只作用于本次请求，放在最前面
+	if req.Instructions != "" {
+		chatReq.Messages = append(chatReq.Messages, model.Message{
+			Role:    "system",
+			Content: model.NewTextContent(req.Instructions),
+		})
+	}
+	chatReq.Messages = append(chatReq.Messages, conversation...)
+
+	// 3. 工具定义和选择策略
+	for i, tool := range req.Tools {
+		if tool.Type != "function" {
+			return nil, nil, &model.ParamError{Param: "tools", Err: fmt.Errorf("tools[%d]: tool type %q is not supported", i, tool.Type)}
+		}
+		chatReq.Tools = append(chatReq.Tools, model.Tool{
+			Type: model.ToolTypeFunction,
+			Function: model.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+				Strict:      tool.Strict,
+			},
+		})
+	}
+	if tc := req.ToolChoice; tc != nil {
+		chatReq.ToolChoice = &model.ToolChoice{Mode: tc.Mode, Function: tc.Name}
+	}
+
+	// 4. 输出格式
+	if req.Text != nil && req.Text.Format != nil {
+		format, err := toResponseFormat(req.Text.Format)
+		if err != nil {
+			return nil, nil, &model.ParamError{Param: "text.format", Err: err}
+		}
+		chatReq.ResponseFormat = format
+	}
+
+	return chatReq, conversation, nil
+}
+
+// appendItem 将一个输入项追加到对话中
+//   - message：按角色转换，developer 视为 system
+//   - function_call：合并到前一条 assistant 消息的 tool_calls（没有时新建一条）
+//   - function_call_output：转换为 tool 消息
+//   - reasoning：上游不接受推理内容，丢弃
+func appendItem(messages []model.Message, item Item) ([]model.Message, error) {
+	itemType := item.Type
+	if itemType == "" && item.Role != "" {
+		itemType = ItemTypeMessage
+	}
+
+	switch itemType {
+	case ItemTypeMessage:
+		msg, err := toMessage(item)
+		if err != nil {
+			return nil, err
+		}
+		return append(messages, msg), nil
+	case ItemTypeFunctionCall:
+		if item.CallID == "" || item.Name == "" {
+			return nil, fmt.Errorf("function_call requires call_id and name")
+		}
+		args := item.Arguments
+		if args == "" {
+			args = "{}"
+		}
+		call := model.ToolCall{
+			ID:       item.CallID,
+			Type:     model.ToolTypeFunction,
+			Function: model.FunctionCall{Name: item.Name, Arguments: args},
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == "assistant" {
+			messages[n-1].ToolCalls = append(messages[n-1].ToolCalls, call)
+			return messages, nil
+		}
+		return append(messages, model.Message{
+			Role:      "assistant",
+			Content:   model.NewTextContent(""),
+			ToolCalls: []model.ToolCall{call},
+		}), nil
+	case ItemTypeFunctionCallOutput:
+		if item.CallID == "" {
+			return nil, fmt.Errorf("function_call_output requires call_id")
+		}
+		return append(messages, model.Message{
+			Role:       "tool",
+			ToolCallID: item.CallID,
+			Content:    model.NewTextContent(item.Output),
+		}), nil
+	case ItemTypeReasoning:
+		return messages, nil
+	default:
+		return nil, fmt.Errorf("input item type %q is not supported", item.Type)
+	}
+}
+
+// toMessage 转换 message 输入项
+// assistant 消息只保留文本；其他角色的图片、文件转换为多模态内容片段
+func toMessage(item Item) (model.Message, error) {
+	role := item.Role
+	switch role {
+	case "user", "system", "assistant":
+	case "developer":
+		role = "system"
+	default:
+		return model.Message{}, fmt.Errorf("message role must be 'user', 'assistant', 'system' or 'developer'")
+	}
+
+	if role == "assistant" {
+		var text strings.Builder
+		for _, part := range item.Content {
+			switch part.Type {
+			case PartTypeOutputText, PartTypeInputText:
+				text.WriteString(part.Text)
+			case PartTypeRefusal:
+				text.WriteString(part.Refusal)
+			default:
+				return model.Message{}, fmt.Errorf("content part type %q is not supported in assistant messages", part.Type)
+			}
+		}
+		return model.Message{Role: role, Content: model.NewTextContent(text.String())}, nil
+	}
+
+	var parts []model.ContentPart
+	for _, part := range item.Content {
+		converted, err := toContentPart(part)
+		if err != nil {
+			return model.Message{}, err
+		}
+		parts = append(parts, converted)
+	}
+
+	// 纯文本以字符串发送，兼容不接受内容数组的上游
+	if len(parts) == 1 && parts[0].Type == model.PartTypeText {
+		return model.Message{Role: role, Content: model.NewTextContent(parts[0].Text)}, nil
+	}
+	return model.Message{Role: role, Content: model.NewMultipartContent(parts)}, nil
+}
+
+// toContentPart 转换输入内容片段
+//   - input_text / output_text → text
+//   - input_image → image_url（不支持 file_id 引用的图片）
+//   - input_file → file（file_data 或 file_id 原样透传）
+func toContentPart(part ContentPart) (model.ContentPart, error) {
+	switch part.Type {
+	case PartTypeInputText, PartTypeOutputText:
+		return model.ContentPart{Type: model.PartTypeText, Text: part.Text}, nil
+	case PartTypeInputImage:
+		if part.ImageURL == "" {
+			return model.ContentPart{}, fmt.Errorf("input_image requires image_url")
+		}
+		return model.ContentPart{
+			Type:     model.PartTypeImageURL,
+			ImageURL: &model.ImageURL{URL: part.ImageURL, Detail: part.Detail},
+		}, nil
+	case PartTypeInputFile:
+		if part.FileData == "" && part.FileID == "" {
+			return model.ContentPart{}, fmt.Errorf("input_file requires file_data or file_id")
+		}
+		return model.ContentPart{
+			Type: model.PartTypeFile,
+			File: &model.FilePart{FileID: part.FileID, FileData: part.FileData, Filename: part.Filename},
+		}, nil
+	default:
+		return model.ContentPart{}, fmt.Errorf("content part type %q is not supported", part.Type)
+	}
+}
+
+// toResponseFormat 将 text.format 转换为 response_format
+func toResponseFormat(format *TextFormat) (*model.ResponseFormat, error) {
+	switch format.Type {
+	case model.ResponseFormatText, model.ResponseFormatJSONObject:
+		return &model.ResponseFormat{Type: format.Type}, nil
+	case model.ResponseFormatJSONSchema:
+		if format.Name == "" || len(format.Schema) == 0 {
+			return nil, fmt.Errorf("json_schema format requires name and schema")
+		}
+		return &model.ResponseFormat{
+			Type: format.Type,
+			JSONSchema: &model.JSONSchemaFormat{
+				Name:        format.Name,
+				Description: format.Description,
+				Schema:      format.Schema,
+				Strict:      format.Strict,
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("text.format type must be 'text', 'json_object' or 'json_schema'")
+	}
+}
+
+// NewResponse 根据请求创建响应对象（状态为 in_progress，没有输出）
+// 响应ID在请求上游之前生成，流式的 response.created 事件和最终保存的响应使用同一个ID
+func NewResponse(req *Request) *Response {
+	resp := &Response{
+		ID:                model.NewID(model.IDPrefixResponse),
+		Object:            "response",
+		CreatedAt:         time.Now().Unix(),
+		Status:            StatusInProgress,
+		MaxOutputTokens:   req.MaxOutputTokens,
+		Model:             req.Model,
+		Output:            []Item{},
+		ParallelToolCalls: req.ParallelToolCalls == nil || *req.ParallelToolCalls,
+		Store:             req.ShouldStore(),
+		Temperature:       req.Temperature,
+		TopP:              req.TopP,
+		Text:              TextConfig{Format: &TextFormat{Type: model.ResponseFormatText}},
+		ToolChoice:        ToolChoice{Mode: "auto"},
+		Tools:             req.Tools,
+		User:              req.User,
+		Metadata:          req.Metadata,
+	}
+	if req.Instructions != "" {
+		instructions := req.Instructions
+		resp.Instructions = &instructions
+	}
+	if req.PreviousResponseID != "" {
+		previous := req.PreviousResponseID
+		resp.PreviousResponseID = &previous
+	}
+	if req.Text != nil && req.Text.Format != nil {
+		resp.Text = *req.Text
+	}
+	if req.ToolChoice != nil {
+		resp.ToolChoice = *req.ToolChoice
+	}
+	if resp.Tools == nil {
+		resp.Tools = []Tool{}
+	}
+	if resp.Metadata == nil {
+		resp.Metadata = map[string]string{}
+	}
+	return resp
+}
+
+// FromChatResponse 根据聊天响应生成完成的响应对象
+// 只使用第一个choice：文本生成一个 message 输出项，每个工具调用生成一个 function_call 输出项
+// 参数：
+//   - tmpl: NewResponse 创建的响应对象（不会被修改）
+//   - resp: 聊天响应
+func FromChatResponse(tmpl *Response, resp *model.ChatResponse) *Response {
+	var output []Item
+	if choice := resp.GetFirstChoice(); choice != nil && choice.Message != nil {
+		if text := choice.Message.Content.Text(); text != "" {
+			output = append(output, messageItem(model.NewID(model.IDPrefixMessage), text, StatusCompleted))
+		}
+		for _, call := range choice.Message.ToolCalls {
+			output = append(output, functionCallItem(model.NewID(model.IDPrefixFunctionCall), call, StatusCompleted))
+		}
+	}
+	return Complete(tmpl, resp, output)
+}
+
+// Complete 用聊天响应的结束原因和用量完成响应对象
+//   - length → incomplete（max_output_tokens）
+//   - content_filter → incomplete（content_filter）
+//   - 其他 → completed
+//
+// 参数：
+//   - tmpl: NewResponse 创建的响应对象（不会被修改）
+//   - resp: 聊天响应
+//   - output: 输出项
+func Complete(tmpl *Response, resp *model.ChatResponse, output []Item) *Response {
+	out := *tmpl
+	if resp.Model != "" {
+		out.Model = resp.Model
+	}
+	out.Output = output
+	if out.Output == nil {
+		out.Output = []Item{}
+	}
+	out.Usage = &Usage{
+		InputTokens:  resp.Usage.PromptTokens,
+		OutputTokens: resp.Usage.CompletionTokens,
+		TotalTokens:  resp.Usage.TotalTokens,
+	}
+
+	out.Status = StatusCompleted
+	if choice := resp.GetFirstChoice(); choice != nil {
+		switch choice.FinishReason {
+		case "length":
+			out.Status = StatusIncomplete
+			out.IncompleteDetails = &IncompleteDetails{Reason: "max_output_tokens"}
+		case "content_filter":
+			out.Status = StatusIncomplete
+			out.IncompleteDetails = &IncompleteDetails{Reason: "content_filter"}
+		}
+	}
+	if out.Status == StatusIncomplete {
+		for i := range out.Output {
+			out.Output[i].Status = StatusIncomplete
+		}
+	}
+	return &out
+}
+
+// Failed 生成失败的响应对象（流式响应开始后出错时在 response.failed 事件中发送）
+func Failed(tmpl *Response, errResp *model.ErrorResponse) *Response {
+	out := *tmpl
+	out.Status = StatusFailed
+	code := errResp.Error.Code
+	if code == "" {
+		code = errResp.Error.Type
+	}
+	out.Error = &ResponseError{Code: code, Message: errResp.Error.Message}
+	return &out
+}
+
+// messageItem 生成 message 输出项
+func messageItem(id, text, status string) Item {
+	return Item{
+		Type:    ItemTypeMessage,
+		ID:      id,
+		Status:  status,
+		Role:    "assistant",
+		Content: MessageContent{{Type: PartTypeOutputText, Text: text}},
+	}
+}
+
+// functionCallItem 生成 function_call 输出项
+func functionCallItem(id string, call model.ToolCall, status string) Item {
+	return Item{
+		Type:      ItemTypeFunctionCall,
+		ID:        id,
+		Status:    status,
+		CallID:    call.ID,
+		Name:      call.Function.Name,
+		Arguments: call.Function.Arguments,
+	}
+}
@@ -0,0 +1,318 @@
+package responses
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// parseRequest 解析 Responses API 请求JSON
+func parseRequest(t *testing.T, body string) *Request {
+	t.Helper()
+	var req Request
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	return &req
+}
+
+// roles 返回消息的角色序列，以逗号分隔
+func roles(messages []model.Message) string {
+	out := make([]string, len(messages))
+	for i, msg := range messages {
+		out[i] = msg.Role
+	}
+	return strings.Join(out, ",")
+}
+
+func TestToChatRequest(t *testing.T) {
+	history := []model.Message{
+		{Role: "user", Content: model.NewTextContent("earlier")},
+		{Role: "assistant", Content: model.NewTextContent("reply")},
+	}
+
+	tests := []struct {
+		name             string
+		body             string
+		history          []model.Message
+		wantRoles        string
+		wantConversation string
+		check            func(t *testing.T, req *model.ChatRequest, conversation []model.Message)
+	}{
+		{
+			name:             "string input with instructions",
+			body:             `{"model":"glm-4","instructions":"be brief","input":"hi","max_output_tokens":32,"user":"u-1"}`,
+			wantRoles:        "system,user",
+			wantConversation: "user",
+			check: func(t *testing.T, req *model.ChatRequest, conversation []model.Message) {
+				if req.Messages[0].Content.Text() != "be brief" || req.Messages[1].Content.Text() != "hi" {
+					t.Errorf("messages = %+v", req.Messages)
+				}
+				if req.GetMaxTokens() != 32 || req.User != "u-1" {
+					t.Errorf("parameters not carried over: %+v", req)
+				}
+			},
+		},
+		{
+			name:             "history before input, instructions not saved",
+			body:             `{"model":"glm-4","instructions":"be brief","input":"next"}`,
+			history:          history,
+			wantRoles:        "system,user,assistant,user",
+			wantConversation: "user,assistant,user",
+		},
+		{
+			name:             "developer role is system",
+			body:             `{"model":"glm-4","input":[{"role":"developer","content":"rules"},{"role":"user","content":[{"type":"input_text","text":"hi"}]}]}`,
+			wantRoles:        "system,user",
+			wantConversation: "system,user",
+		},
+		{
+			name: "function calls merged into assistant message",
+			body: `{"model":"glm-4","input":[
+				{"role":"user","content":"weather?"},
+				{"type":"reasoning","id":"rs_1"},
+				{"type":"message","role":"assistant","content":[{"type":"output_text","text":"checking"}]},
+				{"type":"function_call","call_id":"call_a","name":"get_weather","arguments":"{\"city\":\"Paris\"}"},
+				{"type":"function_call","call_id":"call_b","name":"get_time"},
+				{"type":"function_call_output","call_id":"call_a","output":"sunny"},
+				{"type":"function_call_output","call_id":"call_b","output":"noon"}
+			]}`,
+			wantRoles:        "user,assistant,tool,tool",
+			wantConversation: "user,assistant,tool,tool",
+			check: func(t *testing.T, req *model.ChatRequest, conversation []model.Message) {
+				assistant := req.Messages[1]
+				if assistant.Content.Text() != "checking" || len(assistant.ToolCalls) != 2 {
+					t.Fatalf("assistant = %+v", assistant)
+				}
+				if got := assistant.ToolCalls[1].Function.Arguments; got != "{}" {
+					t.Errorf("empty arguments = %q, want {}", got)
+				}
+				if req.Messages[2].ToolCallID != "call_a" || req.Messages[3].Content.Text() != "noon" {
+					t.Errorf("tool messages = %+v", req.Messages[2:])
+				}
+			},
+		},
+		{
+			name:             "function call without preceding assistant",
+			body:             `{"model":"glm-4","input":[{"type":"function_call","call_id":"call_a","name":"f"},{"type":"function_call_output","call_id":"call_a","output":"ok"}]}`,
+			wantRoles:        "assistant,tool",
+			wantConversation: "assistant,tool",
+		},
+		{
+			name:             "image and file parts",
+			body:             `{"model":"glm-4v","input":[{"role":"user","content":[{"type":"input_text","text":"look"},{"type":"input_image","image_url":"https://example.com/a.png","detail":"low"},{"type":"input_file","file_data":"data:application/pdf;base64,AAAA","filename":"a.pdf"}]}]}`,
+			wantRoles:        "user",
+			wantConversation: "user",
+			check: func(t *testing.T, req *model.ChatRequest, conversation []model.Message) {
+				parts := req.Messages[0].Content.Parts()
+				if len(parts) != 3 {
+					t.Fatalf("got %d parts, want 3", len(parts))
+				}
+				if parts[1].ImageURL == nil || parts[1].ImageURL.URL != "https://example.com/a.png" || parts[1].ImageURL.Detail != "low" {
+					t.Errorf("image = %+v", parts[1])
+				}
+				if parts[2].File == nil || parts[2].File.Filename != "a.pdf" {
+					t.Errorf("file = %+v", parts[2])
+				}
+			},
+		},
+		{
+			name:             "tools, tool choice and json schema",
+			body:             `{"model":"glm-4","input":"hi","tools":[{"type":"function","name":"get_weather","parameters":{"type":"object"}}],"tool_choice":{"type":"function","name":"get_weather"},"parallel_tool_calls":false,"text":{"format":{"type":"json_schema","name":"answer","schema":{"type":"object"}}}}`,
+			wantRoles:        "user",
+			wantConversation: "user",
+			check: func(t *testing.T, req *model.ChatRequest, conversation []model.Message) {
+				if len(req.Tools) != 1 || req.Tools[0].Function.Name != "get_weather" {
+					t.Errorf("tools = %+v", req.Tools)
+				}
+				if req.ToolChoice == nil || req.ToolChoice.Function != "get_weather" {
+					t.Errorf("tool_choice = %+v", req.ToolChoice)
+				}
+				if req.ParallelToolCalls == nil || *req.ParallelToolCalls {
+					t.Error("parallel_tool_calls not carried over")
+				}
+				format := req.ResponseFormat
+				if format == nil || format.Type != model.ResponseFormatJSONSchema || format.JSONSchema.Name != "answer" {
+					t.Errorf("response_format = %+v", format)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, conversation, err := ToChatRequest(parseRequest(t, tt.body), tt.history)
+			if err != nil {
+				t.Fatalf("ToChatRequest: %v", err)
+			}
+			if got := roles(req.Messages); got != tt.wantRoles {
+				t.Fatalf("request roles = %s, want %s", got, tt.wantRoles)
+			}
+			if got := roles(conversation); got != tt.wantConversation {
+				t.Errorf("conversation roles = %s, want %s", got, tt.wantConversation)
+			}
+			if tt.check != nil {
+				tt.check(t, req, conversation)
+			}
+		})
+	}
+}
+
+func TestToChatRequestDoesNotModifyHistory(t *testing.T) {
+	history := []model.Message{{Role: "assistant", Content: model.NewTextContent("reply")}}
+	req := parseRequest(t, `{"model":"glm-4","input":[{"type":"function_call","call_id":"c","name":"f"}]}`)
+	if _, _, err := ToChatRequest(req, history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history[0].ToolCalls) != 0 {
+		t.Error("function_call was appended to the stored history")
+	}
+}
+
+func TestToChatRequestErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantParam string
+	}{
+		{"built-in tool", `{"model":"m","input":"hi","tools":[{"type":"web_search"}]}`, "tools"},
+		{"unknown item type", `{"model":"m","input":[{"type":"computer_call"}]}`, "input"},
+		{"unknown role", `{"model":"m","input":[{"role":"tool","content":"x"}]}`, "input"},
+		{"function call without call_id", `{"model":"m","input":[{"type":"function_call","name":"f"}]}`, "input"},
+		{"function call output without call_id", `{"model":"m","input":[{"type":"function_call_output","output":"x"}]}`, "input"},
+		{"image without url", `{"model":"m","input":[{"role":"user","content":[{"type":"input_image","file_id":"file-1"}]}]}`, "input"},
+		{"file without data", `{"model":"m","input":[{"role":"user","content":[{"type":"input_file"}]}]}`, "input"},
+		{"image in assistant message", `{"model":"m","input":[{"role":"assistant","content":[{"type":"input_image","image_url":"u"}]}]}`, "input"},
+		{"json schema without schema", `{"model":"m","input":"hi","text":{"format":{"type":"json_schema","name":"x"}}}`, "text.format"},
+		{"unknown format", `{"model":"m","input":"hi","text":{"format":{"type":"yaml"}}}`, "text.format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ToChatRequest(parseRequest(t, tt.body), nil)
+			var paramErr *model.ParamError
+			if !errors.As(err, &paramErr) {
+				t.Fatalf("error = %v, want *model.ParamError", err)
+			}
+			if paramErr.Param != tt.wantParam {
+				t.Errorf("param = %q, want %q", paramErr.Param, tt.wantParam)
+			}
+		})
+	}
+}
+
+func TestToolChoiceJSON(t *testing.T) {
+	tests := []struct {
+		body    string
+		want    ToolChoice
+		wantErr bool
+	}{
+		{`"auto"`, ToolChoice{Mode: "auto"}, false},
+		{`"required"`, ToolChoice{Mode: "required"}, false},
+		{`{"type":"function","name":"f"}`, ToolChoice{Name: "f"}, false},
+		{`"sometimes"`, ToolChoice{}, true},
+		{`{"type":"function"}`, ToolChoice{}, true},
+		{`{"type":"web_search"}`, ToolChoice{}, true},
+	}
+	for _, tt := range tests {
+		var tc ToolChoice
+		err := json.Unmarshal([]byte(tt.body), &tc)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.body, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if tc != tt.want {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.body, tc, tt.want)
+		}
+		if data, _ := json.Marshal(tc); string(data) != tt.body {
+			t.Errorf("Marshal(%+v) = %s, want %s", tc, data, tt.body)
+		}
+	}
+}
+
+func TestNewResponse(t *testing.T) {
+	resp := NewResponse(parseRequest(t, `{"model":"glm-4","input":"hi","instructions":"be brief","previous_response_id":"resp_prev","store":false,"metadata":{"k":"v"}}`))
+	if !strings.HasPrefix(resp.ID, model.IDPrefixResponse) || resp.Status != StatusInProgress {
+		t.Errorf("id/status = %s/%s", resp.ID, resp.Status)
+	}
+	if *resp.Instructions != "be brief" || *resp.PreviousResponseID != "resp_prev" || resp.Store || resp.Metadata["k"] != "v" {
+		t.Errorf("request fields not echoed: %+v", resp)
+	}
+	if !resp.ParallelToolCalls || resp.ToolChoice.Mode != "auto" || resp.Text.Format.Type != model.ResponseFormatText {
+		t.Errorf("defaults = %+v", resp)
+	}
+}
+
+func TestFromChatResponse(t *testing.T) {
+	tmpl := NewResponse(parseRequest(t, `{"model":"glm-4","input":"hi"}`))
+
+	tests := []struct {
+		name           string
+		finishReason   string
+		wantStatus     string
+		wantIncomplete string
+	}{
+		{"stop", "stop", StatusCompleted, ""},
+		{"tool calls", model.FinishReasonToolCalls, StatusCompleted, ""},
+		{"length", "length", StatusIncomplete, "max_output_tokens"},
+		{"content filter", "content_filter", StatusIncomplete, "content_filter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &model.ChatResponse{
+				Model: "glm-4-0520",
+				Choices: []model.Choice{{
+					Message: &model.Message{
+						Role:      "assistant",
+						Content:   model.NewTextContent("answer"),
+						ToolCalls: []model.ToolCall{{ID: "call_a", Function: model.FunctionCall{Name: "f", Arguments: "{}"}}},
+					},
+					FinishReason: tt.finishReason,
+				}},
+				Usage: model.Usage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7},
+			}
+			out := FromChatResponse(tmpl, resp)
+
+			if out.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", out.Status, tt.wantStatus)
+			}
+			if tt.wantIncomplete != "" && (out.IncompleteDetails == nil || out.IncompleteDetails.Reason != tt.wantIncomplete) {
+				t.Errorf("incomplete_details = %+v, want %s", out.IncompleteDetails, tt.wantIncomplete)
+			}
+			if out.ID != tmpl.ID || out.Model != "glm-4-0520" || tmpl.Status != StatusInProgress {
+				t.Errorf("id/model = %s/%s, template status %s", out.ID, out.Model, tmpl.Status)
+			}
+			if len(out.Output) != 2 || out.Output[0].Type != ItemTypeMessage || out.Output[1].Type != ItemTypeFunctionCall {
+				t.Fatalf("output = %+v", out.Output)
+			}
+			if out.Output[0].Content[0].Text != "answer" || out.Output[1].CallID != "call_a" || out.Output[0].Status != tt.wantStatus {
+				t.Errorf("output = %+v", out.Output)
+			}
+			if out.Usage == nil || out.Usage.InputTokens != 3 || out.Usage.OutputTokens != 4 || out.Usage.TotalTokens != 7 {
+				t.Errorf("usage = %+v", out.Usage)
+			}
+		})
+	}
+}
+
+func TestFailed(t *testing.T) {
+	tmpl := NewResponse(parseRequest(t, `{"model":"glm-4","input":"hi"}`))
+	tests := []struct {
+		errResp  *model.ErrorResponse
+		wantCode string
+	}{
+		{model.NewTimeoutError("upstream stream idle").WithCode("upstream_idle_timeout"), "upstream_idle_timeout"},
+		{model.NewServerError("boom"), model.ErrorTypeServerError},
+	}
+	for _, tt := range tests {
+		out := Failed(tmpl, tt.errResp)
+		if out.Status != StatusFailed || out.Error == nil || out.Error.Code != tt.wantCode {
+			t.Errorf("Failed() = %+v, want code %s", out, tt.wantCode)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package responses
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"go.uber.org/zap"
+)
+
+// storeFileExt 响应文件扩展名
+const storeFileExt = ".json"
+
+// ErrNotFound 响应不存在（未保存、已删除、已过期，或属于其他API Key）
+var ErrNotFound = errors.New("response not found")
+
+// Record 保存的响应
+type Record struct {
+	// Response 返回给调用方的响应对象（GET /v1/responses/{id}）
+	Response *Response `json:"response"`
+
+	// KeyID 创建该响应的调用方API Key指纹，只有同一个Key可以读取、删除或继续对话
+	KeyID string `json:"key_id,omitempty"`
+
+	// Messages 截至该响应的完整对话（含模型的回复，不含 instructions），
+	// 作为 previous_response_id 的对话历史
+	Messages []model.Message `json:"messages"`
+}
+
+// Store 本地响应存储
+// 每个响应保存为一个JSON文件：{dir}/{响应ID}.json
+// 过期时间按文件修改时间 + TTL 计算，启动时清理过期文件和残留的临时文件
+type Store struct {
+	dir string
+	ttl time.Duration
+
+	mu sync.Mutex
+}
+
+// NewStore 创建响应存储，并清理已过期的响应
+// 参数：
+//   - dir: 存储目录（不存在时自动创建）
+//   - ttl: 响应保留时间（0表示永久保留）
+func NewStore(dir string, ttl time.Duration) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create responses dir failed: %w", err)
+	}
+
+	s := &Store{dir: dir, ttl: ttl}
+	if err := s.cleanup(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get 读取保存的响应
+// 返回：
+//   - *Record: 保存的响应
+//   - error: 不存在、已过期或属于其他调用方时返回 ErrNotFound
+func (s *Store) Get(id, keyID string) (*Record, error) {
+	path, ok := s.path(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	if s.expired(info.ModTime(), time.Now()) {
+		os.Remove(path)
+		return nil, ErrNotFound
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		// 文件损坏，删除后视为不存在
+		zap.L().Warn("响应文件损坏，已删除", zap.String("id", id), zap.Error(err))
+		os.Remove(path)
+		return nil, ErrNotFound
+	}
+	if rec.KeyID != keyID {
+		return nil, ErrNotFound
+	}
+	return &rec, nil
+}
+
+// Save 保存响应（先写临时文件再重命名，避免读到写了一半的文件）
+func (s *Store) Save(rec *Record) error {
+	path, ok := s.path(rec.Response.ID)
+	if !ok {
+		return fmt.Errorf("invalid response id: %s", rec.Response.ID)
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal response failed: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(s.dir, rec.Response.ID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write response file failed: %w", err)
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write response file failed: %w", errors.Join(writeErr, closeErr))
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write response file failed: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除保存的响应
+// 返回：不存在或属于其他调用方时返回 ErrNotFound
+func (s *Store) Delete(id, keyID string) error {
+	if _, err := s.Get(id, keyID); err != nil {
+		return err
+	}
+	path, _ := s.path(id)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("delete response file failed: %w", err)
+	}
+	return nil
+}
+
+// path 响应对应的文件路径
+// 只接受网关生成的ID（resp_ 加字母数字），避免路径穿越
+func (s *Store) path(id string) (string, bool) {
+	suffix, ok := strings.CutPrefix(id, model.IDPrefixResponse)
+	if !ok || suffix == "" {
+		return "", false
+	}
+	for _, r := range suffix {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return "", false
+		}
+	}
+	return filepath.Join(s.dir, id+storeFileExt), true
+}
+
+// expired 按修改时间判断响应是否已过期
+func (s *Store) expired(modTime, now time.Time) bool {
+	return s.ttl > 0 && now.After(modTime.Add(s.ttl))
+}
+
+// cleanup 删除已过期的响应和上次进程退出时残留的临时文件
+func (s *Store) cleanup() error {
+	now := time.Now()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("scan responses dir failed: %w", err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		path := filepath.Join(s.dir, name)
+		if strings.HasSuffix(name, ".tmp") {
+			os.Remove(path)
+			continue
+		}
+		if !strings.HasSuffix(name, storeFileExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if s.expired(info.ModTime(), now) {
+			os.Remove(path)
+			removed++
+		}
+	}
+	if removed > 0 {
+		zap.L().Info("已清理过期的响应", zap.Int("count", removed))
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package responses
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// newRecord 构造一条保存的响应
+func newRecord(keyID string) *Record {
+	return &Record{
+		Response: NewResponse(&Request{Model: "glm-4"}),
+		KeyID:    keyID,
+		Messages: []model.Message{
+			{Role: "user", Content: model.NewTextContent("hi")},
+			{Role: "assistant", Content: model.NewTextContent("hello")},
+		},
+	}
+}
+
+func TestStore(t *testing.T) {
+	store, err := NewStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	rec := newRecord("key-a")
+	if err := store.Save(rec); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := store.Get(rec.Response.ID, "key-a")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Response.ID != rec.Response.ID || len(got.Messages) != 2 || got.Messages[1].Content.Text() != "hello" {
+		t.Errorf("Get = %+v", got)
+	}
+
+	// 其他API Key不能读取或删除
+	if _, err := store.Get(rec.Response.ID, "key-b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get with other key: %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(rec.Response.ID, "key-b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete with other key: %v, want ErrNotFound", err)
+	}
+
+	if err := store.Delete(rec.Response.ID, "key-a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(rec.Response.ID, "key-a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(rec.Response.ID, "key-a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete: %v, want ErrNotFound", err)
+	}
+}
+
+func TestStoreRejectsInvalidID(t *testing.T) {
+	store, err := NewStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	for _, id := range []string{"", "resp_", "resp_../../etc/passwd", "msg_abc", "resp_a/b"} {
+		if _, err := store.Get(id, ""); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) = %v, want ErrNotFound", id, err)
+		}
+		rec := newRecord("")
+		rec.Response.ID = id
+		if err := store.Save(rec); err == nil {
+			t.Errorf("Save(%q) succeeded, want error", id)
+		}
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	fresh, stale := newRecord(""), newRecord("")
+	for _, rec := range []*Record{fresh, stale} {
+		if err := store.Save(rec); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	stalePath := filepath.Join(dir, stale.Response.ID+storeFileExt)
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(stalePath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Get(stale.Response.ID, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get expired: %v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+		t.Error("expired response file not removed on Get")
+	}
+	if _, err := store.Get(fresh.Response.ID, ""); err != nil {
+		t.Errorf("Get fresh: %v", err)
+	}
+}
+
+func TestNewStoreCleanup(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	fresh, stale := newRecord(""), newRecord("")
+	for _, rec := range []*Record{fresh, stale} {
+		if err := store.Save(rec); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, stale.Response.ID+storeFileExt), old, old); err != nil {
+		t.Fatal(err)
+	}
+	tmp := filepath.Join(dir, "resp_x.123.tmp")
+	corrupt := newRecord("")
+	for path, data := range map[string]string{
+		tmp: "partial",
+		filepath.Join(dir, corrupt.Response.ID+storeFileExt): "{not json",
+	} {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err = NewStore(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Error("leftover temp file not removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, stale.Response.ID+storeFileExt)); !os.IsNotExist(err) {
+		t.Error("expired response not removed at startup")
+	}
+	if _, err := store.Get(fresh.Response.ID, ""); err != nil {
+		t.Errorf("Get fresh: %v", err)
+	}
+	// 损坏的文件视为不存在并删除
+	if _, err := store.Get(corrupt.Response.ID, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get corrupt: %v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, corrupt.Response.ID+storeFileExt)); !os.IsNotExist(err) {
+		t.Error("corrupt response file not removed")
+	}
+}
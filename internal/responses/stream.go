@@ -0,0 +1,334 @@
+package responses
+
+import (
+	"strings"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// 流式事件类型（SSE的 event 字段，同时是事件数据的 type 字段）
+const (
+	EventCreated                    = "response.created"
+	EventInProgress                 = "response.in_progress"
+	EventCompleted                  = "response.completed"
+	EventIncomplete                 = "response.incomplete"
+	EventFailed                     = "response.failed"
+	EventOutputItemAdded            = "response.output_item.added"
+	EventOutputItemDone             = "response.output_item.done"
+	EventContentPartAdded           = "response.content_part.added"
+	EventContentPartDone            = "response.content_part.done"
+	EventOutputTextDelta            = "response.output_text.delta"
+	EventOutputTextDone             = "response.output_text.done"
+	EventFunctionCallArgumentsDelta = "response.function_call_arguments.delta"
+	EventFunctionCallArgumentsDone  = "response.function_call_arguments.done"
+)
+
+// Event 一个流式事件
+type Event struct {
+	// Type 事件类型，同时作为SSE的 event 字段
+	Type string
+
+	// Data 事件数据（序列化为SSE的 data 字段）
+	Data any
+}
+
+// eventHeader 所有事件共有的字段
+type eventHeader struct {
+	Type           string `json:"type"`
+	SequenceNumber int    `json:"sequence_number"`
+}
+
+// responseEvent response.created / in_progress / completed / incomplete / failed 事件数据
+type responseEvent struct {
+	eventHeader
+	Response *Response `json:"response"`
+}
+
+// itemEvent response.output_item.added / done 事件数据
+type itemEvent struct {
+	eventHeader
+	OutputIndex int  `json:"output_index"`
+	Item        Item `json:"item"`
+}
+
+// partEvent response.content_part.added / done 事件数据
+type partEvent struct {
+	eventHeader
+	ItemID       string      `json:"item_id"`
+	OutputIndex  int         `json:"output_index"`
+	ContentIndex int         `json:"content_index"`
+	Part         ContentPart `json:"part"`
+}
+
+// textDeltaEvent response.output_text.delta 事件数据
+type textDeltaEvent struct {
+	eventHeader
+	ItemID       string `json:"item_id"`
+	OutputIndex  int    `json:"output_index"`
+	ContentIndex int    `json:"content_index"`
+	Delta        string `json:"delta"`
+	Logprobs     []any  `json:"logprobs"`
+}
+
+// textDoneEvent response.output_text.done 事件数据
+type textDoneEvent struct {
+	eventHeader
+	ItemID       string `json:"item_id"`
+	OutputIndex  int    `json:"output_index"`
+	ContentIndex int    `json:"content_index"`
+	Text         string `json:"text"`
+	Logprobs     []any  `json:"logprobs"`
+}
+
+// argumentsDeltaEvent response.function_call_arguments.delta 事件数据
+type argumentsDeltaEvent struct {
+	eventHeader
+	ItemID      string `json:"item_id"`
+	OutputIndex int    `json:"output_index"`
+	Delta       string `json:"delta"`
+}
+
+// argumentsDoneEvent response.function_call_arguments.done 事件数据
+type argumentsDoneEvent struct {
+	eventHeader
+	ItemID      string `json:"item_id"`
+	OutputIndex int    `json:"output_index"`
+	Name        string `json:"name"`
+	Arguments   string `json:"arguments"`
+}
+
+// streamItem 流式输出中的一个输出项
+type streamItem struct {
+	item Item
+	done bool
+
+	// buf 累计的文本（message）或参数（function_call）
+	buf strings.Builder
+}
+
+// Stream 将 chat.completion.chunk 数据块序列转换为 Responses API 的流式事件序列：
+//
+//	response.created → response.in_progress →
+//	  message：output_item.added → content_part.added → output_text.delta... →
+//	           output_text.done → content_part.done → output_item.done
+//	  function_call：output_item.added → function_call_arguments.delta... →
+//	           function_call_arguments.done → output_item.done
+//	response.completed（或 response.incomplete）
+//
+// 每个事件带递增的 sequence_number；非并发安全，每个流式响应使用一个实例
+type Stream struct {
+	// tmpl NewResponse 创建的响应对象（ID、回显的请求参数）
+	tmpl *Response
+	seq  int
+
+	started bool
+
+	// items 按输出顺序保存的输出项
+	items []*streamItem
+
+	// message 当前打开的 message 输出项（nil表示没有）
+	message *streamItem
+
+	// toolItems 工具调用序号（ToolCall.Index）→ 输出项
+	toolItems map[int]*streamItem
+}
+
+// NewStream 创建流式事件转换器
+// 参数：
+//   - tmpl: NewResponse 创建的响应对象（不会被修改）
+func NewStream(tmpl *Response) *Stream {
+	return &Stream{
+		tmpl:      tmpl,
+		toolItems: make(map[int]*streamItem),
+	}
+}
+
+// Chunk 转换一个数据块
+// 第一个数据块前发送 response.created 和 response.in_progress；用量在 Finish 时随完整响应发送
+func (s *Stream) Chunk(chunk *model.StreamResponse) []Event {
+	var events []Event
+	if !s.started {
+		events = append(events, s.start()...)
+	}
+	if len(chunk.Choices) == 0 {
+		return events
+	}
+
+	// 只转换第一个choice
+	choice := chunk.Choices[0]
+	if text := choice.Delta.Content; text != "" {
+		if s.message == nil {
+			events = append(events, s.openMessage()...)
+		}
+		s.message.buf.WriteString(text)
+		events = append(events, s.event(EventOutputTextDelta, &textDeltaEvent{
+			ItemID:      s.message.item.ID,
+			OutputIndex: s.index(s.message),
+			Delta:       text,
+			Logprobs:    []any{},
+		}))
+	}
+
+	for i, call := range choice.Delta.ToolCalls {
+		toolIndex := i
+		if call.Index != nil {
+			toolIndex = *call.Index
+		}
+		it, ok := s.toolItems[toolIndex]
+		if !ok {
+			// 工具调用之后的文本属于新的 message 输出项
+			events = append(events, s.closeMessage()...)
+			it = &streamItem{item: functionCallItem(model.NewID(model.IDPrefixFunctionCall), call, StatusInProgress)}
+			it.item.Arguments = ""
+			s.items = append(s.items, it)
+			s.toolItems[toolIndex] = it
+			events = append(events, s.event(EventOutputItemAdded, &itemEvent{OutputIndex: s.index(it), Item: it.item}))
+		}
+		if args := call.Function.Arguments; args != "" {
+			it.buf.WriteString(args)
+			events = append(events, s.event(EventFunctionCallArgumentsDelta, &argumentsDeltaEvent{
+				ItemID:      it.item.ID,
+				OutputIndex: s.index(it),
+				Delta:       args,
+			}))
+		}
+	}
+	return events
+}
+
+// Finish 结束流：关闭所有输出项，发送 response.completed（或 response.incomplete）
+// 参数：
+//   - resp: 拼装后的完整响应（提供结束原因和用量）
+//
+// 返回：
+//   - []Event: 事件序列
+//   - *Response: 最终的响应对象（输出项ID与流中发送的一致）
+func (s *Stream) Finish(resp *model.ChatResponse) ([]Event, *Response) {
+	var events []Event
+	if !s.started {
+		events = append(events, s.start()...)
+	}
+	for _, it := range s.items {
+		events = append(events, s.close(it)...)
+	}
+
+	output := make([]Item, 0, len(s.items))
+	for _, it := range s.items {
+		output = append(output, it.item)
+	}
+	final := Complete(s.tmpl, resp, output)
+
+	eventType := EventCompleted
+	if final.Status == StatusIncomplete {
+		eventType = EventIncomplete
+	}
+	return append(events, s.event(eventType, &responseEvent{Response: final})), final
+}
+
+// Fail 发送 response.failed 事件结束流
+func (s *Stream) Fail(errResp *model.ErrorResponse) []Event {
+	var events []Event
+	if !s.started {
+		events = append(events, s.start()...)
+	}
+	return append(events, s.event(EventFailed, &responseEvent{Response: Failed(s.tmpl, errResp)}))
+}
+
+// start 发送 response.created 和 response.in_progress
+func (s *Stream) start() []Event {
+	s.started = true
+	return []Event{
+		s.event(EventCreated, &responseEvent{Response: s.tmpl}),
+		s.event(EventInProgress, &responseEvent{Response: s.tmpl}),
+	}
+}
+
+// openMessage 打开新的 message 输出项
+func (s *Stream) openMessage() []Event {
+	it := &streamItem{item: messageItem(model.NewID(model.IDPrefixMessage), "", StatusInProgress)}
+	it.item.Content = MessageContent{}
+	s.items = append(s.items, it)
+	s.message = it
+
+	index := s.index(it)
+	return []Event{
+		s.event(EventOutputItemAdded, &itemEvent{OutputIndex: index, Item: it.item}),
+		s.event(EventContentPartAdded, &partEvent{
+			ItemID:      it.item.ID,
+			OutputIndex: index,
+			Part:        ContentPart{Type: PartTypeOutputText},
+		}),
+	}
+}
+
+// closeMessage 关闭当前的 message 输出项（没有时不做任何处理）
+func (s *Stream) closeMessage() []Event {
+	if s.message == nil {
+		return nil
+	}
+	events := s.close(s.message)
+	s.message = nil
+	return events
+}
+
+// close 关闭输出项，发送完成事件（已关闭的不再重复发送）
+func (s *Stream) close(it *streamItem) []Event {
+	if it.done {
+		return nil
+	}
+	it.done = true
+	it.item.Status = StatusCompleted
+	index := s.index(it)
+
+	if it.item.Type == ItemTypeFunctionCall {
+		it.item.Arguments = it.buf.String()
+		return []Event{
+			s.event(EventFunctionCallArgumentsDone, &argumentsDoneEvent{
+				ItemID:      it.item.ID,
+				OutputIndex: index,
+				Name:        it.item.Name,
+				Arguments:   it.item.Arguments,
+			}),
+			s.event(EventOutputItemDone, &itemEvent{OutputIndex: index, Item: it.item}),
+		}
+	}
+
+	if s.message == it {
+		s.message = nil
+	}
+	text := it.buf.String()
+	part := ContentPart{Type: PartTypeOutputText, Text: text}
+	it.item.Content = MessageContent{part}
+	return []Event{
+		s.event(EventOutputTextDone, &textDoneEvent{
+			ItemID:      it.item.ID,
+			OutputIndex: index,
+			Text:        text,
+			Logprobs:    []any{},
+		}),
+		s.event(EventContentPartDone, &partEvent{ItemID: it.item.ID, OutputIndex: index, Part: part}),
+		s.event(EventOutputItemDone, &itemEvent{OutputIndex: index, Item: it.item}),
+	}
+}
+
+// index 输出项在输出列表中的序号
+func (s *Stream) index(it *streamItem) int {
+	for i, item := range s.items {
+		if item == it {
+			return i
+		}
+	}
+	return -1
+}
+
+// event 生成事件，设置事件类型和递增的序号
+func (s *Stream) event(eventType string, data interface{ setHeader(eventHeader) }) Event {
+	data.setHeader(eventHeader{Type: eventType, SequenceNumber: s.seq})
+	s.seq++
+	return Event{Type: eventType, Data: data}
+}
+
+// setHeader 设置事件共有字段（嵌入 eventHeader 的事件数据都实现了该方法）
+func (h *eventHeader) setHeader(header eventHeader) {
+	*h = header
+}
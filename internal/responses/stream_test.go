@@ -0,0 +1,220 @@
+package responses
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// toolChunk 构造只含一个工具调用增量的数据块
+func toolChunk(index int, id, name, args string) *model.StreamResponse {
+	chunk := model.NewStreamResponse("chatcmpl-1", "glm-4", "", false)
+	chunk.Choices[0].Delta.ToolCalls = []model.ToolCall{
+		{Index: &index, ID: id, Function: model.FunctionCall{Name: name, Arguments: args}},
+	}
+	return chunk
+}
+
+// textChunk 构造文本增量数据块
+func textChunk(text string) *model.StreamResponse {
+	return model.NewStreamResponse("chatcmpl-1", "glm-4", text, false)
+}
+
+// endResponse 构造 Finish 使用的完整响应
+func endResponse(finishReason string) *model.ChatResponse {
+	return &model.ChatResponse{
+		Model:   "glm-4",
+		Choices: []model.Choice{{FinishReason: finishReason}},
+		Usage:   model.Usage{PromptTokens: 5, CompletionTokens: 9, TotalTokens: 14},
+	}
+}
+
+// eventSummary 事件类型和输出序号，便于比较事件顺序
+func eventSummary(ev Event) string {
+	index := -1
+	switch data := ev.Data.(type) {
+	case *itemEvent:
+		index = data.OutputIndex
+	case *partEvent:
+		index = data.OutputIndex
+	case *textDeltaEvent:
+		index = data.OutputIndex
+	case *textDoneEvent:
+		index = data.OutputIndex
+	case *argumentsDeltaEvent:
+		index = data.OutputIndex
+	case *argumentsDoneEvent:
+		index = data.OutputIndex
+	}
+	name := strings.TrimPrefix(ev.Type, "response.")
+	if index < 0 {
+		return name
+	}
+	return name + "@" + strconv.Itoa(index)
+}
+
+func TestStreamEvents(t *testing.T) {
+	tests := []struct {
+		name         string
+		chunks       []*model.StreamResponse
+		finishReason string
+		want         []string
+		wantStatus   string
+		wantOutput   []string
+	}{
+		{
+			name: "text, tool calls, then text",
+			chunks: []*model.StreamResponse{
+				model.NewStreamResponse("chatcmpl-1", "glm-4", "", true),
+				textChunk("Let me "),
+				textChunk("check."),
+				toolChunk(0, "call_a", "get_weather", `{"city":`),
+				toolChunk(0, "", "", `"Paris"}`),
+				toolChunk(1, "call_b", "get_time", ``),
+				textChunk("Done."),
+			},
+			finishReason: model.FinishReasonToolCalls,
+			want: []string{
+				"created", "in_progress",
+				"output_item.added@0", "content_part.added@0",
+				"output_text.delta@0", "output_text.delta@0",
+				// 工具调用开始时关闭当前的 message
+				"output_text.done@0", "content_part.done@0", "output_item.done@0",
+				"output_item.added@1",
+				"function_call_arguments.delta@1", "function_call_arguments.delta@1",
+				"output_item.added@2",
+				// 工具调用之后的文本是新的 message
+				"output_item.added@3", "content_part.added@3", "output_text.delta@3",
+				"function_call_arguments.done@1", "output_item.done@1",
+				"function_call_arguments.done@2", "output_item.done@2",
+				"output_text.done@3", "content_part.done@3", "output_item.done@3",
+				"completed",
+			},
+			wantStatus: StatusCompleted,
+			wantOutput: []string{"message:Let me check.", `function_call:{"city":"Paris"}`, "function_call:", "message:Done."},
+		},
+		{
+			name:         "truncated by length",
+			chunks:       []*model.StreamResponse{textChunk("partial")},
+			finishReason: "length",
+			want: []string{
+				"created", "in_progress",
+				"output_item.added@0", "content_part.added@0", "output_text.delta@0",
+				"output_text.done@0", "content_part.done@0", "output_item.done@0",
+				"incomplete",
+			},
+			wantStatus: StatusIncomplete,
+			wantOutput: []string{"message:partial"},
+		},
+		{
+			name:         "no chunks",
+			finishReason: "stop",
+			want:         []string{"created", "in_progress", "completed"},
+			wantStatus:   StatusCompleted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := NewResponse(&Request{Model: "glm-4"})
+			s := NewStream(tmpl)
+			var events []Event
+			for _, chunk := range tt.chunks {
+				events = append(events, s.Chunk(chunk)...)
+			}
+			finishEvents, final := s.Finish(endResponse(tt.finishReason))
+			events = append(events, finishEvents...)
+
+			got := make([]string, len(events))
+			for i, ev := range events {
+				got[i] = eventSummary(ev)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("events =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+
+			// sequence_number 从0开始连续递增
+			for i, ev := range events {
+				var header eventHeader
+				data, _ := json.Marshal(ev.Data)
+				if err := json.Unmarshal(data, &header); err != nil {
+					t.Fatal(err)
+				}
+				if header.SequenceNumber != i || header.Type != ev.Type {
+					t.Errorf("event %d header = %+v", i, header)
+				}
+			}
+
+			if final.Status != tt.wantStatus || final.Usage.TotalTokens != 14 || tmpl.Status != StatusInProgress {
+				t.Errorf("final status/usage = %s/%+v, template status %s", final.Status, final.Usage, tmpl.Status)
+			}
+			last := events[len(events)-1].Data.(*responseEvent).Response
+			if last != final {
+				t.Error("final event does not carry the returned response")
+			}
+
+			// 最终输出项与流中发送的ID一致
+			ids := make(map[string]bool)
+			for _, ev := range events {
+				if data, ok := ev.Data.(*itemEvent); ok {
+					ids[data.Item.ID] = true
+				}
+			}
+			output := make([]string, len(final.Output))
+			for i, item := range final.Output {
+				if !ids[item.ID] {
+					t.Errorf("output item %s was not sent in the stream", item.ID)
+				}
+				if item.Status != tt.wantStatus {
+					t.Errorf("output item %d status = %s, want %s", i, item.Status, tt.wantStatus)
+				}
+				if item.Type == ItemTypeMessage {
+					output[i] = item.Type + ":" + item.Content[0].Text
+				} else {
+					output[i] = item.Type + ":" + item.Arguments
+				}
+			}
+			if strings.Join(output, "|") != strings.Join(tt.wantOutput, "|") {
+				t.Errorf("output = %q, want %q", output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestStreamFail(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []*model.StreamResponse
+		want   []string
+	}{
+		{"before any chunk", nil, []string{EventCreated, EventInProgress, EventFailed}},
+		{"after text", []*model.StreamResponse{textChunk("hi")}, []string{
+			EventCreated, EventInProgress, EventOutputItemAdded, EventContentPartAdded, EventOutputTextDelta, EventFailed,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStream(NewResponse(&Request{Model: "glm-4"}))
+			var events []Event
+			for _, chunk := range tt.chunks {
+				events = append(events, s.Chunk(chunk)...)
+			}
+			events = append(events, s.Fail(model.NewServerError("boom"))...)
+
+			got := make([]string, len(events))
+			for i, ev := range events {
+				got[i] = ev.Type
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("events = %v, want %v", got, tt.want)
+			}
+			resp := events[len(events)-1].Data.(*responseEvent).Response
+			if resp.Status != StatusFailed || resp.Error == nil || resp.Error.Message != "boom" {
+				t.Errorf("failed response = %+v", resp)
+			}
+		})
+	}
+}
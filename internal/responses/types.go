@@ -0,0 +1,434 @@
+// Package responses 实现 OpenAI Responses API（/v1/responses）与网关内部聊天格式之间的转换
+// 请求中的输入项转换为 ChatRequest 后走与 /v1/chat/completions 相同的处理流程，
+// 响应转换为类型化的输出项（message、function_call），流式响应转换为 response.* 事件序列
+// store=true 的响应保存在本地，previous_response_id 据此重建之前的对话
+package responses
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// 输入/输出项类型（Item.Type 的取值）
+const (
+	ItemTypeMessage            = "message"
+	ItemTypeFunctionCall       = "function_call"
+	ItemTypeFunctionCallOutput = "function_call_output"
+	ItemTypeReasoning          = "reasoning"
+)
+
+// 内容片段类型（ContentPart.Type 的取值）
+const (
+	PartTypeInputText  = "input_text"
+	PartTypeInputImage = "input_image"
+	PartTypeInputFile  = "input_file"
+	PartTypeOutputText = "output_text"
+	PartTypeRefusal    = "refusal"
+)
+
+// 响应状态（Response.Status 的取值）
+const (
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+	StatusIncomplete = "incomplete"
+	StatusFailed     = "failed"
+)
+
+// Request Responses API 请求（POST /v1/responses）
+type Request struct {
+	// Model 模型名称（与 /v1/chat/completions 使用同一套模型路由）
+	Model string `json:"model" binding:"required"`
+
+	// Input 输入（字符串或输入项数组）
+	Input Input `json:"input" binding:"required"`
+
+	// Instructions 系统提示词（只作用于本次请求，不会随 previous_response_id 带入后续请求）
+	Instructions string `json:"instructions,omitempty"`
+
+	// PreviousResponseID 上一次响应的ID，网关据此从本地存储重建之前的对话
+	PreviousResponseID string `json:"previous_response_id,omitempty"`
+
+	// Store 是否保存本次响应（默认true），保存后才能作为 previous_response_id 或通过 GET 读取
+	Store *bool `json:"store,omitempty"`
+
+	// Stream 是否流式返回
+	Stream bool `json:"stream,omitempty"`
+
+	// MaxOutputTokens 最大生成token数
+	MaxOutputTokens *int `json:"max_output_tokens,omitempty"`
+
+	// Temperature 温度
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// TopP 核采样
+	TopP *float64 `json:"top_p,omitempty"`
+
+	// Tools 可用工具（只支持 function）
+	Tools []Tool `json:"tools,omitempty"`
+
+	// ToolChoice 工具选择策略
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+
+	// ParallelToolCalls 是否允许一次回复中调用多个工具
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
+
+	// Text 文本输出格式（text.format 对应 response_format）
+	Text *TextConfig `json:"text,omitempty"`
+
+	// Metadata 调用方自定义的键值对，原样保存在响应中
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// User 终端用户标识
+	User string `json:"user,omitempty"`
+}
+
+// ShouldStore 是否保存本次响应（未设置时默认保存）
+func (r *Request) ShouldStore() bool {
+	return r.Store == nil || *r.Store
+}
+
+// Input 请求输入
+// JSON中可以是字符串（等价于一条 user 消息），也可以是输入项数组
+type Input []Item
+
+// UnmarshalJSON 同时接受字符串和输入项数组两种格式
+func (in *Input) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*in = Input{{Type: ItemTypeMessage, Role: "user", Content: MessageContent{{Type: PartTypeInputText, Text: text}}}}
+		return nil
+	}
+
+	var items []Item
+	if err := json.Unmarshal(data, &items); err != nil {
+		return errors.New("input must be a string or an array of input items")
+	}
+	*in = items
+	return nil
+}
+
+// Item 输入项或输出项
+// 不同类型使用不同的字段
+type Item struct {
+	// Type 类型：message, function_call, function_call_output, reasoning
+	// 输入中的消息可以省略（有 role 时视为 message）
+	Type string `json:"type,omitempty"`
+
+	// ID 输出项ID（msg_xxx、fc_xxx），输入中可以省略
+	ID string `json:"id,omitempty"`
+
+	// Status 输出项状态：in_progress, completed, incomplete
+	Status string `json:"status,omitempty"`
+
+	// Role 消息角色：user, assistant, system, developer（type=message）
+	Role string `json:"role,omitempty"`
+
+	// Content 消息内容（type=message），字符串或内容片段数组
+	Content MessageContent `json:"content,omitempty"`
+
+	// CallID 工具调用ID（type=function_call / function_call_output）
+	CallID string `json:"call_id,omitempty"`
+
+	// Name 函数名称（type=function_call）
+	Name string `json:"name,omitempty"`
+
+	// Arguments 函数参数，JSON字符串（type=function_call）
+	Arguments string `json:"arguments,omitempty"`
+
+	// Output 工具执行结果（type=function_call_output）
+	Output string `json:"output,omitempty"`
+}
+
+// MarshalJSON 按输出项类型输出
+// message 总是带 content 数组、function_call 总是带 arguments（流式的 output_item.added 中二者为空）
+func (it Item) MarshalJSON() ([]byte, error) {
+	switch it.Type {
+	case ItemTypeMessage:
+		content := it.Content
+		if content == nil {
+			content = MessageContent{}
+		}
+		return json.Marshal(struct {
+			Type    string         `json:"type"`
+			ID      string         `json:"id"`
+			Status  string         `json:"status"`
+			Role    string         `json:"role"`
+			Content MessageContent `json:"content"`
+		}{it.Type, it.ID, it.Status, it.Role, content})
+	case ItemTypeFunctionCall:
+		return json.Marshal(struct {
+			Type      string `json:"type"`
+			ID        string `json:"id"`
+			Status    string `json:"status"`
+			CallID    string `json:"call_id"`
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+		}{it.Type, it.ID, it.Status, it.CallID, it.Name, it.Arguments})
+	default:
+		type plain Item
+		return json.Marshal(plain(it))
+	}
+}
+
+// MessageContent 消息内容
+// JSON中可以是字符串（等价于一个 input_text 片段），也可以是内容片段数组
+type MessageContent []ContentPart
+
+// UnmarshalJSON 同时接受字符串和内容片段数组两种格式
+func (mc *MessageContent) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*mc = MessageContent{{Type: PartTypeInputText, Text: text}}
+		return nil
+	}
+
+	var parts []ContentPart
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return errors.New("content must be a string or an array of content parts")
+	}
+	*mc = parts
+	return nil
+}
+
+// ContentPart 内容片段
+type ContentPart struct {
+	// Type 片段类型：input_text, input_image, input_file, output_text, refusal
+	Type string `json:"type"`
+
+	// Text 文本（type=input_text / output_text）
+	Text string `json:"text,omitempty"`
+
+	// Refusal 拒绝回答的说明（type=refusal）
+	Refusal string `json:"refusal,omitempty"`
+
+	// ImageURL 图片地址或 data URL（type=input_image）
+	ImageURL string `json:"image_url,omitempty"`
+
+	// Detail 图片精度：auto, low, high（type=input_image）
+	Detail string `json:"detail,omitempty"`
+
+	// FileID 已上传文件的ID（type=input_image / input_file）
+	FileID string `json:"file_id,omitempty"`
+
+	// FileData 文件内容的 data URL（type=input_file）
+	FileData string `json:"file_data,omitempty"`
+
+	// Filename 文件名（type=input_file）
+	Filename string `json:"filename,omitempty"`
+}
+
+// MarshalJSON output_text 片段总是带 text 和 annotations 字段（SDK按此解析）
+func (p ContentPart) MarshalJSON() ([]byte, error) {
+	if p.Type == PartTypeOutputText {
+		return json.Marshal(struct {
+			Type        string `json:"type"`
+			Text        string `json:"text"`
+			Annotations []any  `json:"annotations"`
+		}{p.Type, p.Text, []any{}})
+	}
+	type plain ContentPart
+	return json.Marshal(plain(p))
+}
+
+// Tool 工具定义（Responses API 中函数定义是扁平的，不嵌套在 function 字段中）
+type Tool struct {
+	// Type 工具类型（只支持 function；web_search 等内置工具无法转发给上游）
+	Type string `json:"type"`
+
+	// Name 函数名称
+	Name string `json:"name"`
+
+	// Description 函数说明
+	Description string `json:"description,omitempty"`
+
+	// Parameters 参数的JSON Schema
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+
+	// Strict 是否严格按照Schema生成参数
+	Strict *bool `json:"strict,omitempty"`
+}
+
+// ToolChoice 工具选择策略
+// JSON中可以是字符串（"none" / "auto" / "required"），也可以是 {"type": "function", "name": "xxx"}
+type ToolChoice struct {
+	// Mode 选择模式（指定函数时为空）
+	Mode string
+
+	// Name 指定必须调用的函数名（Mode为空时有效）
+	Name string
+}
+
+// toolChoiceObject 指定函数时的JSON结构
+type toolChoiceObject struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// MarshalJSON 按 Responses API 格式输出
+func (tc ToolChoice) MarshalJSON() ([]byte, error) {
+	if tc.Name == "" {
+		return json.Marshal(tc.Mode)
+	}
+	return json.Marshal(toolChoiceObject{Type: "function", Name: tc.Name})
+}
+
+// UnmarshalJSON 同时接受字符串和对象两种格式
+func (tc *ToolChoice) UnmarshalJSON(data []byte) error {
+	var mode string
+	if err := json.Unmarshal(data, &mode); err == nil {
+		switch mode {
+		case "none", "auto", "required":
+			*tc = ToolChoice{Mode: mode}
+			return nil
+		}
+		return errors.New("tool_choice must be 'none', 'auto', 'required' or a function object")
+	}
+
+	var obj toolChoiceObject
+	if err := json.Unmarshal(data, &obj); err != nil || obj.Type != "function" || obj.Name == "" {
+		return errors.New("tool_choice object must be {\"type\": \"function\", \"name\": \"...\"}")
+	}
+	*tc = ToolChoice{Name: obj.Name}
+	return nil
+}
+
+// TextConfig 文本输出配置
+type TextConfig struct {
+	// Format 输出格式
+	Format *TextFormat `json:"format,omitempty"`
+}
+
+// TextFormat 输出格式（对应 Chat Completions 的 response_format，json_schema 的字段是扁平的）
+type TextFormat struct {
+	// Type 格式类型：text, json_object, json_schema
+	Type string `json:"type"`
+
+	// Name Schema名称（type=json_schema）
+	Name string `json:"name,omitempty"`
+
+	// Description Schema说明（type=json_schema）
+	Description string `json:"description,omitempty"`
+
+	// Schema JSON Schema（type=json_schema）
+	Schema json.RawMessage `json:"schema,omitempty"`
+
+	// Strict 是否严格遵循Schema（type=json_schema）
+	Strict *bool `json:"strict,omitempty"`
+}
+
+// Response Responses API 响应对象
+// 非流式请求直接返回；流式请求在 response.created、response.completed 等事件中返回
+type Response struct {
+	// ID 响应ID，格式：resp_{随机字符串}
+	ID string `json:"id"`
+
+	// Object 固定值："response"
+	Object string `json:"object"`
+
+	// CreatedAt 创建时间戳（Unix时间戳，秒）
+	CreatedAt int64 `json:"created_at"`
+
+	// Status 状态：in_progress, completed, incomplete, failed
+	Status string `json:"status"`
+
+	// Error 失败原因（status=failed）
+	Error *ResponseError `json:"error"`
+
+	// IncompleteDetails 未完成原因（status=incomplete）
+	IncompleteDetails *IncompleteDetails `json:"incomplete_details"`
+
+	// Instructions 本次请求的系统提示词
+	Instructions *string `json:"instructions"`
+
+	// MaxOutputTokens 最大生成token数
+	MaxOutputTokens *int `json:"max_output_tokens"`
+
+	// Model 模型名称
+	Model string `json:"model"`
+
+	// Output 输出项（消息、函数调用）
+	Output []Item `json:"output"`
+
+	// ParallelToolCalls 是否允许一次回复中调用多个工具
+	ParallelToolCalls bool `json:"parallel_tool_calls"`
+
+	// PreviousResponseID 上一次响应的ID
+	PreviousResponseID *string `json:"previous_response_id"`
+
+	// Store 是否已保存
+	Store bool `json:"store"`
+
+	// Temperature 温度
+	Temperature *float64 `json:"temperature"`
+
+	// TopP 核采样
+	TopP *float64 `json:"top_p"`
+
+	// Text 文本输出配置
+	Text TextConfig `json:"text"`
+
+	// ToolChoice 工具选择策略
+	ToolChoice ToolChoice `json:"tool_choice"`
+
+	// Tools 可用工具
+	Tools []Tool `json:"tools"`
+
+	// Usage token用量（in_progress 时为null）
+	Usage *Usage `json:"usage"`
+
+	// User 终端用户标识
+	User string `json:"user,omitempty"`
+
+	// Metadata 调用方自定义的键值对
+	Metadata map[string]string `json:"metadata"`
+}
+
+// ResponseError 响应失败原因
+type ResponseError struct {
+	// Code 错误代码，如 server_error、rate_limit_exceeded
+	Code string `json:"code"`
+
+	// Message 错误消息
+	Message string `json:"message"`
+}
+
+// IncompleteDetails 响应未完成的原因
+type IncompleteDetails struct {
+	// Reason 原因：max_output_tokens, content_filter
+	Reason string `json:"reason"`
+}
+
+// Usage token用量
+type Usage struct {
+	InputTokens         int                 `json:"input_tokens"`
+	InputTokensDetails  InputTokensDetails  `json:"input_tokens_details"`
+	OutputTokens        int                 `json:"output_tokens"`
+	OutputTokensDetails OutputTokensDetails `json:"output_tokens_details"`
+	TotalTokens         int                 `json:"total_tokens"`
+}
+
+// InputTokensDetails 输入token明细（上游不报告缓存命中，总是0）
+type InputTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// OutputTokensDetails 输出token明细（上游不报告推理token，总是0）
+type OutputTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
+
+// DeletedResponse 删除响应的结果（DELETE /v1/responses/{id}）
+type DeletedResponse struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Deleted bool   `json:"deleted"`
+}
@@ -3,42 +3,123 @@ package router
 import (
 	"net/http"
 
+	"github.com/AtSunset1/prism/internal/auth"
 	"github.com/AtSunset1/prism/internal/handler"
+	"github.com/AtSunset1/prism/internal/metrics"
+	"github.com/AtSunset1/prism/internal/middleware"
+	"github.com/AtSunset1/prism/pkg/config"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
+// Handlers 路由使用的所有处理器
+type Handlers struct {
+	Chat       *handler.ChatHandler       // 聊天补全
+	Responses  *handler.ResponsesHandler  // Responses API
+	Models     *handler.ModelsHandler     // 模型列表
+	Embeddings *handler.EmbeddingsHandler // 向量化
+	Tokenize   *handler.TokenizeHandler   // token计数
+	Health     *handler.HealthHandler     // 健康检查
+	Files      *handler.FilesHandler      // 文件（Batch API 的输入/输出）
+	Batches    *handler.BatchesHandler    // 批处理任务
+}
+
 // SetupRouter 配置并返回Gin路由器
 // 参数：
-//   - chatHandler: 聊天处理器
+//   - cfg: 配置实例
+//   - handlers: 各接口的处理器
+//   - authenticator: API Key鉴权器（作用于 /v1 下的所有接口）
+//   - log: 基础Logger（请求日志、路由注册日志都基于它输出）
 // 返回：
 //   - *gin.Engine: 配置好的Gin路由器
-func SetupRouter(chatHandler *handler.ChatHandler) *gin.Engine {
-	// 创建Gin路由器（包含Logger和Recovery中间件）
-	r := gin.Default()
+func SetupRouter(cfg *config.Config, handlers Handlers, authenticator *auth.Authenticator, log *zap.Logger) *gin.Engine {
+	// gin的路由注册信息改为输出到zap（仅debug模式下打印）
+	gin.DebugPrintRouteFunc = func(httpMethod, absolutePath, handlerName string, nuHandlers int) {
+		log.Debug("注册路由",
+			zap.String("method", httpMethod),
+			zap.String("path", absolutePath),
+			zap.String("handler", handlerName),
+		)
+	}
+
+	// 创建Gin路由器，使用基于zap的Logger和Recovery中间件替代默认中间件
+	// Tracing放在最前面：请求日志需要带上trace_id
+	r := gin.New()
+	r.Use(middleware.Tracing(), middleware.Logger(log), middleware.Recovery())
 
 	// 注册路由
-	registerRoutes(r, chatHandler)
+	registerRoutes(r, cfg, handlers, authenticator)
 
 	return r
 }
 
 // registerRoutes 注册所有路由
-func registerRoutes(r *gin.Engine, chatHandler *handler.ChatHandler) {
+func registerRoutes(r *gin.Engine, cfg *config.Config, handlers Handlers, authenticator *auth.Authenticator) {
 	// ========== 基础路由 ==========
 
 	// 欢迎页面
 	r.GET("/", handleWelcome)
 
 	// 健康检查
-	r.GET("/health", handleHealthCheck)
+	r.GET("/health", handlers.Health.HandleHealth)
+	r.GET("/livez", handlers.Health.HandleLivez)
+	r.GET("/readyz", handlers.Health.HandleReadyz)
+
+	// Prometheus指标
+	if cfg.Metrics.Enabled {
+		r.GET(cfg.Metrics.Path, gin.WrapH(metrics.Handler()))
+	}
 
 	// ========== OpenAI兼容API路由 ==========
 
 	// v1版本API组
-	v1 := r.Group("/v1")
+	v1 := r.Group("/v1", middleware.Auth(authenticator))
 	{
 		// 聊天补全接口（核心功能）
-		v1.POST("/chat/completions", chatHandler.HandleChatCompletion)
+		// 可能返回流式响应，由处理器按请求类型自行设置期限
+		v1.POST("/chat/completions", handlers.Chat.HandleChatCompletion)
+
+		// 旧版文本补全接口（prompt 包装为聊天请求）
+		v1.POST("/completions", handlers.Chat.HandleCompletions)
+
+		// Anthropic Messages API 兼容接口（与聊天补全共用模型路由）
+		v1.POST("/messages", handlers.Chat.HandleMessages)
+
+		// OpenAI Responses API（与聊天补全共用模型路由）
+		v1.POST("/responses", handlers.Responses.HandleCreate)
+
+		// 上传、下载文件（Batch API），大文件传输时间不受总期限限制
+		v1.POST("/files", handlers.Files.HandleUpload)
+		v1.GET("/files/:id/content", handlers.Files.HandleContent)
+	}
+
+	// 非流式接口使用统一的总期限（server.write_timeout）
+	timed := v1.Group("", middleware.Timeout())
+	{
+		// 向量化接口
+		timed.POST("/embeddings", handlers.Embeddings.HandleEmbeddings)
+
+		// 模型列表（按调用方可访问的模型过滤）
+		timed.GET("/models", handlers.Models.HandleListModels)
+		timed.GET("/models/*id", handlers.Models.HandleGetModel)
+
+		// token计数（网关扩展接口）
+		timed.POST("/tokenize", handlers.Tokenize.HandleTokenize)
+
+		// 读取、删除保存的响应（Responses API）
+		timed.GET("/responses/:id", handlers.Responses.HandleGet)
+		timed.DELETE("/responses/:id", handlers.Responses.HandleDelete)
+
+		// 文件管理（Batch API）
+		timed.GET("/files", handlers.Files.HandleList)
+		timed.GET("/files/:id", handlers.Files.HandleGet)
+		timed.DELETE("/files/:id", handlers.Files.HandleDelete)
+
+		// 批处理任务：创建后在后台执行，通过查询接口获取状态和进度
+		timed.POST("/batches", handlers.Batches.HandleCreate)
+		timed.GET("/batches", handlers.Batches.HandleList)
+		timed.GET("/batches/:id", handlers.Batches.HandleGet)
+		timed.POST("/batches/:id/cancel", handlers.Batches.HandleCancel)
 	}
 }
 
@@ -51,14 +132,17 @@ func handleWelcome(c *gin.Context) {
 		"description": "OpenAI-compatible AI Gateway for multiple LLM providers",
 		"endpoints": gin.H{
 			"health": "GET /health",
+			"livez":  "GET /livez",
+			"readyz": "GET /readyz",
 			"chat":   "POST /v1/chat/completions",
+			"completions": "POST /v1/completions",
+			"messages": "POST /v1/messages",
+			"responses": "POST /v1/responses",
+			"models": "GET /v1/models",
+			"embeddings": "POST /v1/embeddings",
+			"tokenize": "POST /v1/tokenize",
+			"files": "POST /v1/files",
+			"batches": "POST /v1/batches",
 		},
 	})
 }
-
-// handleHealthCheck 健康检查处理器
-func handleHealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status": "healthy",
-	})
-}
@@ -0,0 +1,298 @@
+// Package server 运行网关的HTTP服务器
+// 负责监听端口（TCP或unix socket，可选TLS、HTTP/2、HTTP/3），并在收到退出信号后优雅关闭：
+// 先报告未就绪、停止接受新连接，再等待进行中的请求（包括长时间的流式响应）在排空期限内结束
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/lifecycle"
+	"github.com/AtSunset1/prism/pkg/config"
+	"github.com/quic-go/quic-go/http3"
+	"go.uber.org/zap"
+)
+
+// closeGrace 排空期限到达后，留给流式响应发送错误事件的时间
+// 超过后强制关闭所有连接
+const closeGrace = 5 * time.Second
+
+// shutdownPollInterval 关闭时检查进行中的HTTP/3请求的间隔
+const shutdownPollInterval = 100 * time.Millisecond
+
+// Server 网关HTTP服务器
+type Server struct {
+	// httpServer HTTP/1.1 和 HTTP/2 服务器
+	httpServer *http.Server
+
+	// h3Server HTTP/3 服务器（未启用时为nil）
+	h3Server *http3.Server
+
+	// h3Active 进行中的HTTP/3请求数
+	h3Active atomic.Int64
+
+	// certs TLS证书加载器（未启用TLS时为nil）
+	certs *certReloader
+
+	// network 监听的网络类型：tcp 或 unix
+	network string
+
+	// addr 监听地址：host:port 或 unix socket 路径
+	addr string
+
+	// drainer 排空状态（健康检查和流式响应据此感知关闭）
+	drainer *lifecycle.Drainer
+
+	// shutdownDelay 停止接受新连接前的等待时间
+	shutdownDelay time.Duration
+
+	// shutdownTimeout 排空期限
+	shutdownTimeout time.Duration
+}
+
+// New 创建网关HTTP服务器
+// 参数：
+//   - handler: 路由器
+//   - cfg: 服务器配置
+//   - drainer: 排空状态（与健康检查、ChatHandler 共用）
+//
+// 返回：
+//   - *Server: 服务器
+//   - error: TLS证书或CA证书无法加载时返回错误
+//
+// 示例：
+//
+//	srv, err := server.New(r, cfg.Server, drainer)
+//	if err != nil {
+//	    zap.L().Fatal("创建服务器失败", zap.Error(err))
+//	}
+//	if err := srv.Run(ctx); err != nil {
+//	    zap.L().Error("服务器异常退出", zap.Error(err))
+//	}
+func New(handler http.Handler, cfg config.ServerConfig, drainer *lifecycle.Drainer) (*Server, error) {
+	s := &Server{
+		network:         "tcp",
+		addr:            fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		drainer:         drainer,
+		shutdownDelay:   cfg.ShutdownDelay,
+		shutdownTimeout: cfg.ShutdownTimeout,
+	}
+	if cfg.UnixSocket != "" {
+		s.network, s.addr = "unix", cfg.UnixSocket
+	}
+
+	// 启用TLS时通过ALPN协商HTTP/2，否则接受明文HTTP/2（h2c，需要客户端直接以HTTP/2连接）
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if cfg.HTTP2 {
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(!cfg.TLS.Enabled)
+	}
+
+	s.httpServer = &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		Protocols:         protocols,
+		// 不设置 WriteTimeout：它对整个响应生效，会切断长时间的流式响应
+		// 写超时按接口设置，见 middleware.Timeout 和 ChatHandler
+	}
+
+	if !cfg.TLS.Enabled {
+		return s, nil
+	}
+
+	certs, err := newCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := newTLSConfig(cfg.TLS, certs)
+	if err != nil {
+		certs.Close()
+		return nil, err
+	}
+	s.certs = certs
+	s.httpServer.TLSConfig = tlsConfig
+
+	if cfg.TLS.HTTP3 {
+		s.h3Server = &http3.Server{
+			Addr: s.addr,
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				s.h3Active.Add(1)
+				defer s.h3Active.Add(-1)
+				handler.ServeHTTP(w, r)
+			}),
+			TLSConfig:   http3.ConfigureTLSConfig(tlsConfig),
+			IdleTimeout: cfg.IdleTimeout,
+		}
+		// 在TCP连接的响应中通过 Alt-Svc 通告HTTP/3
+		s.httpServer.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.h3Server.SetQUICHeaders(w.Header())
+			handler.ServeHTTP(w, r)
+		})
+	}
+	return s, nil
+}
+
+// Addr 返回监听地址（host:port 或 unix socket 路径）
+func (s *Server) Addr() string {
+	return s.addr
+}
+
+// Protocols 返回启用的协议，用于启动日志
+func (s *Server) Protocols() []string {
+	var protocols []string
+	if s.httpServer.TLSConfig != nil {
+		protocols = append(protocols, "https")
+	} else {
+		protocols = append(protocols, "http")
+	}
+	if s.httpServer.Protocols.HTTP2() || s.httpServer.Protocols.UnencryptedHTTP2() {
+		protocols = append(protocols, "h2")
+	}
+	if s.h3Server != nil {
+		protocols = append(protocols, "h3")
+	}
+	return protocols
+}
+
+// Run 启动服务器并阻塞，直到 ctx 取消（收到退出信号）后完成优雅关闭
+// 返回：监听失败或强制关闭失败时返回错误，正常关闭返回nil
+func (s *Server) Run(ctx context.Context) error {
+	if s.certs != nil {
+		defer s.certs.Close()
+	}
+
+	ln, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		if s.httpServer.TLSConfig != nil {
+			errCh <- s.httpServer.ServeTLS(ln, "", "")
+		} else {
+			errCh <- s.httpServer.Serve(ln)
+		}
+	}()
+	if s.h3Server != nil {
+		go func() {
+			errCh <- s.h3Server.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-errCh:
+		s.httpServer.Close()
+		if s.h3Server != nil {
+			s.h3Server.Close()
+		}
+		return err
+	case <-ctx.Done():
+	}
+	return s.shutdown()
+}
+
+// listen 创建TCP或unix socket监听
+// unix socket 文件已存在时（上次异常退出遗留）先删除
+func (s *Server) listen() (net.Listener, error) {
+	if s.network == "unix" {
+		if err := os.Remove(s.addr); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("remove stale unix socket failed: %w", err)
+		}
+	}
+	ln, err := net.Listen(s.network, s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s %s failed: %w", s.network, s.addr, err)
+	}
+	return ln, nil
+}
+
+// shutdown 优雅关闭
+//  1. 开始排空：健康检查返回未就绪，等待 shutdownDelay 让负载均衡摘除实例
+//  2. 停止接受新连接，等待进行中的请求结束，最长 shutdownTimeout
+//  3. 超过期限：通知流式响应发送错误事件并结束，再等待 closeGrace 后强制关闭连接
+//
+// HTTP/3 服务器同时关闭，见 shutdownHTTP3；排空期限由两个服务器共用同一个计时器，
+// TCP连接上的请求已全部结束时，HTTP/3 上的流式响应同样能在期限到达时收到错误事件
+func (s *Server) shutdown() error {
+	s.drainer.Start()
+	zap.L().Info("开始优雅关闭",
+		zap.Duration("shutdown_delay", s.shutdownDelay),
+		zap.Duration("shutdown_timeout", s.shutdownTimeout),
+	)
+	time.Sleep(s.shutdownDelay)
+
+	expireTimer := time.AfterFunc(s.shutdownTimeout, func() {
+		zap.L().Warn("排空期限已到，中断仍在进行的请求")
+		s.drainer.Expire()
+	})
+	defer expireTimer.Stop()
+
+	var wg sync.WaitGroup
+	if s.h3Server != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.shutdownHTTP3()
+		}()
+	}
+	defer wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	err := s.httpServer.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	// 计时器与 Shutdown 的期限同时到达，这里确保强制关闭前已通知流式响应
+	s.drainer.Expire()
+
+	graceCtx, graceCancel := context.WithTimeout(context.Background(), closeGrace)
+	defer graceCancel()
+	if err := s.httpServer.Shutdown(graceCtx); err != nil {
+		zap.L().Warn("仍有请求未结束，强制关闭连接", zap.Error(err))
+		return s.httpServer.Close()
+	}
+	return nil
+}
+
+// shutdownHTTP3 关闭HTTP/3服务器
+// quic-go 发送GOAWAY后会等待客户端关闭连接，已经断开的客户端要等到QUIC空闲超时才会释放，
+// 因此这里自行统计进行中的请求：请求全部结束（最长 shutdownTimeout + closeGrace）后直接关闭所有连接
+func (s *Server) shutdownHTTP3() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout+closeGrace)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.h3Server.Shutdown(ctx)
+	}()
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for s.h3Active.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			zap.L().Warn("HTTP/3 请求未在期限内结束，强制关闭连接", zap.Int64("active", s.h3Active.Load()))
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	cancel()
+	<-done
+}
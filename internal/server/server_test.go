@@ -0,0 +1,252 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/lifecycle"
+	"github.com/AtSunset1/prism/pkg/config"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// okHandler 返回200和请求使用的协议
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Proto)
+	})
+}
+
+// startServer 启动服务器并等待开始监听
+// 返回：触发优雅关闭并等待 Run 返回的函数（测试结束时自动调用）
+func startServer(t *testing.T, cfg config.ServerConfig, handler http.Handler, drainer *lifecycle.Drainer) (stop func() error) {
+	t.Helper()
+	if drainer == nil {
+		drainer = lifecycle.NewDrainer()
+	}
+	srv, err := New(handler, cfg, drainer)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Run(ctx) }()
+
+	var runErr error
+	stopped := false
+	stop = func() error {
+		if !stopped {
+			stopped = true
+			cancel()
+			runErr = <-errCh
+		}
+		return runErr
+	}
+	t.Cleanup(func() { stop() })
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, err := net.Dial(srv.network, srv.Addr())
+		if err == nil {
+			conn.Close()
+			return stop
+		}
+		select {
+		case err := <-errCh:
+			stopped = true
+			t.Fatalf("Run: %v", err)
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server not listening on %s: %v", srv.Addr(), err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// unixClient 通过unix socket连接的HTTP客户端
+// 参数：
+//   - path: socket路径
+//   - tlsConfig: TLS配置（nil表示明文）
+//   - protocols: 允许的协议（nil表示默认）
+func unixClient(path string, tlsConfig *tls.Config, protocols *http.Protocols) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+			TLSClientConfig:   tlsConfig,
+			ForceAttemptHTTP2: true,
+			Protocols:         protocols,
+		},
+		Timeout: 5 * time.Second,
+	}
+}
+
+// freePort 返回一个当前未被占用的本地端口
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prism.sock")
+	// 上次异常退出遗留的socket文件
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := startServer(t, config.ServerConfig{UnixSocket: path}, okHandler(), nil)
+
+	resp, err := unixClient(path, nil, nil).Get("http://prism/")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "HTTP/1.1" {
+		t.Errorf("got %d %q, want 200 HTTP/1.1", resp.StatusCode, body)
+	}
+
+	if err := stop(); err != nil {
+		t.Errorf("Run returned %v after shutdown", err)
+	}
+}
+
+func TestHTTP2Negotiated(t *testing.T) {
+	ca := newTestCA(t, "test-ca")
+
+	h2c := new(http.Protocols)
+	h2c.SetUnencryptedHTTP2(true)
+
+	tests := []struct {
+		name      string
+		tls       bool
+		http2     bool
+		protocols *http.Protocols
+		want      string
+	}{
+		{"tls alpn h2", true, true, nil, "HTTP/2.0"},
+		{"tls http2 disabled", true, false, nil, "HTTP/1.1"},
+		{"cleartext h2c", false, true, h2c, "HTTP/2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := config.ServerConfig{UnixSocket: filepath.Join(dir, "prism.sock"), HTTP2: tt.http2}
+			url := "http://prism/"
+			var clientTLS *tls.Config
+			if tt.tls {
+				cfg.TLS = serverTLS(t, dir, ca)
+				clientTLS = &tls.Config{RootCAs: ca.pool(), ServerName: "localhost"}
+				url = "https://localhost/"
+			}
+			startServer(t, cfg, okHandler(), nil)
+
+			resp, err := unixClient(cfg.UnixSocket, clientTLS, tt.protocols).Get(url)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.Proto != tt.want || string(body) != tt.want {
+				t.Errorf("client proto %s, server proto %s, want %s", resp.Proto, body, tt.want)
+			}
+		})
+	}
+}
+
+// drainingHandler 模拟流式响应：先发送响应头，排空期限到达时发送结束事件
+func drainingHandler(drainer *lifecycle.Drainer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-drainer.Expired():
+			io.WriteString(w, "expired")
+		case <-r.Context().Done():
+		}
+	})
+}
+
+// streamUntilShutdown 发起请求，收到响应头后关闭服务器，返回响应体
+func streamUntilShutdown(t *testing.T, client *http.Client, url string, stop func() error) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	stopErr := make(chan error, 1)
+	go func() { stopErr <- stop() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	if err := <-stopErr; err != nil {
+		t.Errorf("Run returned %v after shutdown", err)
+	}
+	return string(body)
+}
+
+func TestShutdownExpiresStreams(t *testing.T) {
+	drainer := lifecycle.NewDrainer()
+	cfg := config.ServerConfig{
+		UnixSocket:      filepath.Join(t.TempDir(), "prism.sock"),
+		ShutdownTimeout: 200 * time.Millisecond,
+	}
+	stop := startServer(t, cfg, drainingHandler(drainer), drainer)
+
+	start := time.Now()
+	if body := streamUntilShutdown(t, unixClient(cfg.UnixSocket, nil, nil), "http://prism/", stop); body != "expired" {
+		t.Errorf("body = %q, want expired", body)
+	}
+	if elapsed := time.Since(start); elapsed >= closeGrace {
+		t.Errorf("shutdown took %v, stream was not ended at the drain deadline", elapsed)
+	}
+}
+
+// HTTP/3 上的流式响应也要在排空期限到达时收到结束事件，
+// 即使TCP连接上已经没有进行中的请求
+func TestShutdownExpiresHTTP3Streams(t *testing.T) {
+	ca := newTestCA(t, "test-ca")
+	drainer := lifecycle.NewDrainer()
+	cfg := config.ServerConfig{
+		Host:            "127.0.0.1",
+		Port:            freePort(t),
+		ShutdownTimeout: 200 * time.Millisecond,
+		TLS:             serverTLS(t, t.TempDir(), ca),
+	}
+	cfg.TLS.HTTP3 = true
+	stop := startServer(t, cfg, drainingHandler(drainer), drainer)
+
+	transport := &http3.Transport{TLSClientConfig: &tls.Config{RootCAs: ca.pool(), ServerName: "localhost"}}
+	defer transport.Close()
+	client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+	url := "https://127.0.0.1:" + strconv.Itoa(cfg.Port) + "/"
+
+	start := time.Now()
+	if body := streamUntilShutdown(t, client, url, stop); body != "expired" {
+		t.Errorf("body = %q, want expired", body)
+	}
+	if elapsed := time.Since(start); elapsed >= closeGrace {
+		t.Errorf("shutdown took %v, stream was not ended at the drain deadline", elapsed)
+	}
+}
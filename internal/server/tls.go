@@ -0,0 +1,169 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/AtSunset1/prism/pkg/config"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// certReloadDebounce 证书文件变更事件的合并窗口
+// 证书和私钥通常先后写入（Kubernetes Secret 则是替换符号链接），合并后只加载一次
+const certReloadDebounce = 500 * time.Millisecond
+
+// certReloader 服务器证书
+// 通过 tls.Config.GetCertificate 提供证书，文件变更后重新加载，新连接立即使用新证书
+type certReloader struct {
+	// certFile 证书文件
+	certFile string
+
+	// keyFile 私钥文件
+	keyFile string
+
+	// cert 当前证书
+	cert atomic.Pointer[tls.Certificate]
+
+	// watcher 文件监听器（监听证书和私钥所在目录）
+	watcher *fsnotify.Watcher
+}
+
+// newCertReloader 加载证书并开始监听文件变更
+// 参数：
+//   - certFile: 证书文件
+//   - keyFile: 私钥文件
+//
+// 返回：
+//   - *certReloader: 证书加载器（退出时调用 Close）
+//   - error: 证书无法加载或无法监听文件时返回错误
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create certificate watcher failed: %w", err)
+	}
+	// 监听目录而非文件：替换文件、替换符号链接都能捕获
+	for _, dir := range []string{filepath.Dir(certFile), filepath.Dir(keyFile)} {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("watch certificate dir failed: %w", err)
+		}
+	}
+	r.watcher = watcher
+
+	go r.watch()
+	return r, nil
+}
+
+// load 读取证书和私钥
+func (r *certReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load tls certificate failed: %w", err)
+	}
+	r.cert.Store(&cert)
+	return nil
+}
+
+// watch 处理文件变更事件
+// 新证书无法加载时（如证书已写入、私钥尚未写入）只记录日志，继续使用当前证书
+func (r *certReloader) watch() {
+	var debounce *time.Timer
+	reloadCh := make(chan struct{}, 1)
+
+	for {
+		select {
+		case _, ok := <-r.watcher.Events:
+			if !ok {
+				if debounce != nil {
+					debounce.Stop()
+				}
+				return
+			}
+			if debounce != nil {
+				debounce.Stop()
+			}
+			debounce = time.AfterFunc(certReloadDebounce, func() {
+				select {
+				case reloadCh <- struct{}{}:
+				default:
+				}
+			})
+
+		case <-reloadCh:
+			if err := r.load(); err != nil {
+				zap.L().Error("TLS证书重新加载失败，继续使用当前证书", zap.Error(err))
+				continue
+			}
+			zap.L().Info("TLS证书已重新加载", zap.String("cert_file", r.certFile))
+
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			zap.L().Warn("TLS证书文件监听出错", zap.Error(err))
+		}
+	}
+}
+
+// GetCertificate 返回当前证书
+// 用作 tls.Config.GetCertificate
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// Close 停止监听文件变更
+func (r *certReloader) Close() error {
+	return r.watcher.Close()
+}
+
+// newTLSConfig 根据配置创建TLS配置
+// 参数：
+//   - cfg: 服务器TLS配置
+//   - certs: 证书加载器
+//
+// 返回：
+//   - *tls.Config: TLS配置（NextProtos 由 http.Server / http3.Server 按启用的协议设置）
+//   - error: CA证书无法读取时返回错误
+func newTLSConfig(cfg config.ServerTLSConfig, certs *certReloader) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}
+	if cfg.MinVersion == "1.3" {
+		tlsConfig.MinVersion = tls.VersionTLS13
+	}
+
+	switch strings.ToLower(cfg.ClientAuth) {
+	case "optional":
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return tlsConfig, nil
+	}
+
+	pool := x509.NewCertPool()
+	for _, file := range cfg.ClientCAFiles {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read client ca file failed: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("client ca file %s contains no valid certificates", file)
+		}
+	}
+	tlsConfig.ClientCAs = pool
+	return tlsConfig, nil
+}
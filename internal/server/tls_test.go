@@ -0,0 +1,280 @@
+package server
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// testCA 测试时生成的自签名CA
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// newTestCA 生成自签名CA证书
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          randomSerial(t),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue 签发证书（服务器证书对 localhost 和 127.0.0.1 有效）
+// 返回：证书和私钥的PEM
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: randomSerial(t),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	if usage == x509.ExtKeyUsageServerAuth {
+		tmpl.DNSNames = []string{"localhost"}
+		tmpl.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1)}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// clientCert 签发客户端证书
+func (ca *testCA) clientCert(t *testing.T) tls.Certificate {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// pool 只包含该CA的证书池
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+func randomSerial(t *testing.T) *big.Int {
+	t.Helper()
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serial
+}
+
+// writeFile 写入文件，返回路径
+func writeFile(t *testing.T, path string, data []byte) string {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// serverTLS 在 dir 中写入由 ca 签发的服务器证书，返回启用TLS的配置
+func serverTLS(t *testing.T, dir string, ca *testCA) config.ServerTLSConfig {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, "localhost", x509.ExtKeyUsageServerAuth)
+	return config.ServerTLSConfig{
+		Enabled:  true,
+		CertFile: writeFile(t, filepath.Join(dir, "tls.crt"), certPEM),
+		KeyFile:  writeFile(t, filepath.Join(dir, "tls.key"), keyPEM),
+	}
+}
+
+func TestCertReloadOnFileChange(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "test-ca")
+	cfg := config.ServerConfig{
+		UnixSocket: filepath.Join(dir, "prism.sock"),
+		TLS:        serverTLS(t, dir, ca),
+	}
+	startServer(t, cfg, okHandler(), nil)
+
+	// peerCert 完成一次TLS握手，返回服务器证书
+	peerCert := func() []byte {
+		conn, err := tls.Dial("unix", cfg.UnixSocket, &tls.Config{RootCAs: ca.pool(), ServerName: "localhost"})
+		if err != nil {
+			t.Fatalf("tls dial: %v", err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Raw
+	}
+
+	before := peerCert()
+
+	// 先写私钥再写证书，期间的不匹配状态不应影响服务
+	certPEM, keyPEM := ca.issue(t, "localhost", x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.TLS.KeyFile, keyPEM)
+	writeFile(t, cfg.TLS.CertFile, certPEM)
+	block, _ := pem.Decode(certPEM)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got := peerCert()
+		if bytes.Equal(got, block.Bytes) {
+			return
+		}
+		if !bytes.Equal(got, before) {
+			t.Fatal("server presented an unexpected certificate")
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("certificate not reloaded after file change")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestCertReloadKeepsCertOnInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	tlsCfg := serverTLS(t, dir, newTestCA(t, "test-ca"))
+	certs, err := newCertReloader(tlsCfg.CertFile, tlsCfg.KeyFile)
+	if err != nil {
+		t.Fatalf("newCertReloader: %v", err)
+	}
+	defer certs.Close()
+
+	before, _ := certs.GetCertificate(nil)
+	writeFile(t, tlsCfg.CertFile, []byte("not a certificate"))
+	time.Sleep(certReloadDebounce + 200*time.Millisecond)
+
+	if after, _ := certs.GetCertificate(nil); after != before {
+		t.Error("invalid certificate file replaced the current certificate")
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	serverCA := newTestCA(t, "server-ca")
+	clientCA := newTestCA(t, "client-ca")
+	unknownCA := newTestCA(t, "unknown-ca")
+
+	tests := []struct {
+		name       string
+		clientAuth string
+		cert       *tls.Certificate
+		wantErr    bool
+	}{
+		{"require trusted cert", "require", ptr(clientCA.clientCert(t)), false},
+		{"require no cert", "require", nil, true},
+		{"require unknown ca", "require", ptr(unknownCA.clientCert(t)), true},
+		{"optional no cert", "optional", nil, false},
+		{"optional unknown ca", "optional", ptr(unknownCA.clientCert(t)), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tlsCfg := serverTLS(t, dir, serverCA)
+			tlsCfg.ClientAuth = tt.clientAuth
+			tlsCfg.ClientCAFiles = []string{writeFile(t, filepath.Join(dir, "client-ca.crt"), clientCA.pem)}
+			cfg := config.ServerConfig{UnixSocket: filepath.Join(dir, "prism.sock"), TLS: tlsCfg}
+			startServer(t, cfg, okHandler(), nil)
+
+			clientTLS := &tls.Config{RootCAs: serverCA.pool(), ServerName: "localhost"}
+			if tt.cert != nil {
+				// 无论服务器接受哪些CA都发送证书（Certificates 只会发送由可接受CA签发的证书）
+				clientTLS.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					return tt.cert, nil
+				}
+			}
+			resp, err := unixClient(cfg.UnixSocket, clientTLS, nil).Get("https://localhost/")
+			if tt.wantErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatalf("request succeeded with status %d, want tls error", resp.StatusCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, want 200", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfigClientCAErrors(t *testing.T) {
+	dir := t.TempDir()
+	tlsCfg := serverTLS(t, dir, newTestCA(t, "test-ca"))
+	certs, err := newCertReloader(tlsCfg.CertFile, tlsCfg.KeyFile)
+	if err != nil {
+		t.Fatalf("newCertReloader: %v", err)
+	}
+	defer certs.Close()
+
+	tests := []struct {
+		name  string
+		files []string
+	}{
+		{"missing file", []string{filepath.Join(dir, "missing.crt")}},
+		{"no certificates", []string{writeFile(t, filepath.Join(dir, "empty.crt"), []byte("not pem"))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.ServerTLSConfig{ClientAuth: "require", ClientCAFiles: tt.files}
+			if _, err := newTLSConfig(cfg, certs); err == nil {
+				t.Error("newTLSConfig succeeded, want error")
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,131 @@
+// Package structured 实现结构化输出（response_format）的校验与重试
+package structured
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/metrics"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 校验结果（指标标签）
+const (
+	resultValid   = "valid"
+	resultInvalid = "invalid"
+)
+
+// Adapter 结构化输出适配器
+// 包装另一个 ModelAdapter（通常是 AdapterManager），对要求JSON输出的请求：
+//   - 调用被包装的适配器（上游是否原生支持 response_format 由各适配器自行处理）
+//   - 校验最终输出：json_object 要求输出是JSON对象，json_schema 提供了Schema时按Schema校验（不论是否strict）
+//   - 不符合时将错误原因反馈给模型重试，超过重试次数返回 *ValidationError
+//
+// 流式请求需要拿到完整输出才能校验，因此改为非流式调用，校验通过后回放为流式数据块
+type Adapter struct {
+	next       adapter.ModelAdapter
+	maxRetries atomic.Int64
+}
+
+// NewAdapter 创建结构化输出适配器
+// 参数：
+//   - next: 被包装的适配器
+//   - maxRetries: 输出不符合格式时的最大重试次数（0表示不重试）
+//
+// 示例：
+//
+//	chatAdapter := structured.NewAdapter(manager, cfg.Structured.MaxRetries)
+func NewAdapter(next adapter.ModelAdapter, maxRetries int) *Adapter {
+	a := &Adapter{next: next}
+	a.SetMaxRetries(maxRetries)
+	return a
+}
+
+// SetMaxRetries 调整最大重试次数（配置热加载时调用，之后开始的请求生效）
+func (a *Adapter) SetMaxRetries(maxRetries int) {
+	a.maxRetries.Store(int64(max(maxRetries, 0)))
+}
+
+// Chat 普通调用（校验输出格式）
+// 重试产生的token用量会累加到最终响应的 Usage 中
+func (a *Adapter) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
+	if !req.ResponseFormat.IsJSON() {
+		return a.next.Chat(ctx, req)
+	}
+
+	v, err := newValidator(req.ResponseFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	attempt := *req
+	maxRetries := int(a.maxRetries.Load())
+	callCtx := ctx
+	var usage model.Usage
+	for i := 0; ; i++ {
+		resp, err := a.next.Chat(callCtx, &attempt)
+		if err != nil {
+			return nil, err
+		}
+		usage.PromptTokens += resp.Usage.PromptTokens
+		usage.CompletionTokens += resp.Usage.CompletionTokens
+		usage.TotalTokens += resp.Usage.TotalTokens
+
+		checkErr := v.check(resp)
+		if checkErr == nil {
+			metrics.ObserveStructuredOutput(req.Model, resultValid)
+			if i > 0 {
+				logger.AddFields(ctx, zap.Int("structured_retries", i))
+			}
+			resp.Usage = usage
+			return resp, nil
+		}
+		metrics.ObserveStructuredOutput(req.Model, resultInvalid)
+
+		if i >= maxRetries {
+			logger.AddFields(ctx, zap.Int("structured_retries", i))
+			return nil, &ValidationError{Attempts: i + 1, Err: checkErr}
+		}
+		logger.FromContext(ctx).Warn("输出不符合response_format，重试",
+			zap.Int("attempt", i+1),
+			zap.Error(checkErr),
+		)
+
+		// 在原始对话后追加本次的错误输出和纠正提示，之后的调用记为重试
+		callCtx = adapter.WithRetry(ctx)
+		attempt.Messages = append(append([]model.Message(nil), req.Messages...),
+			model.Message{Role: "assistant", Content: model.NewTextContent(resp.GetContent())},
+			model.Message{Role: "user", Content: model.NewTextContent(feedback(req.ResponseFormat, checkErr))},
+		)
+	}
+}
+
+// ChatStream 流式调用（校验输出格式）
+// 要求JSON输出时改为非流式调用，校验通过后回放；校验失败时直接返回错误
+func (a *Adapter) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
+	if !req.ResponseFormat.IsJSON() {
+		return a.next.ChatStream(ctx, req)
+	}
+
+	nonStream := *req
+	nonStream.Stream = false
+	nonStream.StreamOptions = nil
+	resp, err := a.Chat(ctx, &nonStream)
+	if err != nil {
+		return nil, err
+	}
+	return model.ReplayStream(ctx, resp), nil
+}
+
+// Name 返回被包装适配器的名称
+func (a *Adapter) Name() string {
+	return a.next.Name()
+}
+
+// HealthCheck 委托给被包装的适配器
+func (a *Adapter) HealthCheck(ctx context.Context) error {
+	return a.next.HealthCheck(ctx)
+}
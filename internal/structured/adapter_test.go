@@ -0,0 +1,271 @@
+package structured
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/metrics"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// scriptedChat 按顺序返回预设的回复内容，并记录收到的请求
+type scriptedChat struct {
+	replies  []string
+	requests []*model.ChatRequest
+}
+
+func (s *scriptedChat) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
+	copied := *req
+	s.requests = append(s.requests, &copied)
+	content := s.replies[min(len(s.requests), len(s.replies))-1]
+	return &model.ChatResponse{
+		ID:    "chatcmpl-1",
+		Model: req.Model,
+		Choices: []model.Choice{
+			{Message: &model.Message{Role: "assistant", Content: model.NewTextContent(content)}, FinishReason: "stop"},
+		},
+		Usage: model.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}, nil
+}
+
+func (s *scriptedChat) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
+	copied := *req
+	s.requests = append(s.requests, &copied)
+	ch := make(chan *model.StreamResponse)
+	close(ch)
+	return ch, nil
+}
+
+func (s *scriptedChat) Name() string { return "scripted" }
+
+func (s *scriptedChat) HealthCheck(ctx context.Context) error { return nil }
+
+// personSchema 要求 name 字段为字符串的严格Schema
+func personSchema() *model.ResponseFormat {
+	strict := true
+	return &model.ResponseFormat{
+		Type: model.ResponseFormatJSONSchema,
+		JSONSchema: &model.JSONSchemaFormat{
+			Name:   "person",
+			Schema: json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}`),
+			Strict: &strict,
+		},
+	}
+}
+
+// looseSchema 与 personSchema 相同但未设置strict
+func looseSchema() *model.ResponseFormat {
+	format := personSchema()
+	format.JSONSchema.Strict = nil
+	return format
+}
+
+func TestTrimCodeFence(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`  {"a":1}  `, `{"a":1}`},
+		{"```json\n{\"a\":1}\n```", `{"a":1}`},
+		{"```\n{\"a\":1}\n```", `{"a":1}`},
+		{"```{\"a\":1}```", `{"a":1}`},
+		{"```", "```"},
+	}
+	for _, tt := range tests {
+		if got := trimCodeFence(tt.in); got != tt.want {
+			t.Errorf("trimCodeFence(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  *model.ResponseFormat
+		content string
+		errMsg  string
+	}{
+		{"json object", &model.ResponseFormat{Type: model.ResponseFormatJSONObject}, `{"a":1}`, ""},
+		{"json array", &model.ResponseFormat{Type: model.ResponseFormatJSONObject}, `[1]`, "not a JSON object"},
+		{"broken json", &model.ResponseFormat{Type: model.ResponseFormatJSONObject}, `{"a":`, "not valid JSON"},
+		{"schema match", personSchema(), `{"name":"Ada"}`, ""},
+		{"schema mismatch", personSchema(), `{"name":1}`, "does not match schema"},
+		{"missing required", personSchema(), `{}`, "does not match schema"},
+		{"non-strict schema match", looseSchema(), `{"name":"Ada"}`, ""},
+		{"non-strict schema mismatch", looseSchema(), `{"name":1}`, "does not match schema"},
+		{"schema without schema body", &model.ResponseFormat{Type: model.ResponseFormatJSONSchema, JSONSchema: &model.JSONSchemaFormat{Name: "any"}}, `{"x":1}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := newValidator(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = v.validate(tt.content)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("err = %v, want %q", err, tt.errMsg)
+			}
+		})
+	}
+
+	format := personSchema()
+	format.JSONSchema.Schema = json.RawMessage(`{"type":"object","properties":{"name":{"$ref":"https://example.com/name.json"}}}`)
+	var schemaErr *SchemaError
+	if _, err := newValidator(format); !errors.As(err, &schemaErr) {
+		t.Errorf("external $ref: err = %v, want *SchemaError", err)
+	}
+}
+
+func TestAdapterRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxRetries   int
+		replies      []string
+		wantCalls    int
+		wantContent  string
+		wantAttempts int
+	}{
+		{"valid first time", 2, []string{"```json\n{\"name\":\"Ada\"}\n```"}, 1, `{"name":"Ada"}`, 0},
+		{"valid after retry", 2, []string{"not json", `{"name":"Ada"}`}, 2, `{"name":"Ada"}`, 0},
+		{"retries exhausted", 1, []string{`{"name":1}`}, 2, "", 2},
+		{"no retries", 0, []string{"not json"}, 1, "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &scriptedChat{replies: tt.replies}
+			a := NewAdapter(next, tt.maxRetries)
+			req := &model.ChatRequest{
+				Model:          "glm-4",
+				Messages:       []model.Message{{Role: "user", Content: model.NewTextContent("who?")}},
+				ResponseFormat: personSchema(),
+			}
+
+			resp, err := a.Chat(context.Background(), req)
+			if len(next.requests) != tt.wantCalls {
+				t.Errorf("upstream calls = %d, want %d", len(next.requests), tt.wantCalls)
+			}
+			if tt.wantAttempts > 0 {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Attempts != tt.wantAttempts {
+					t.Fatalf("err = %v, want ValidationError after %d attempts", err, tt.wantAttempts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.GetContent(); got != tt.wantContent {
+				t.Errorf("content = %q, want %q", got, tt.wantContent)
+			}
+			// 重试的用量累加到最终响应
+			if resp.Usage.TotalTokens != 15*tt.wantCalls {
+				t.Errorf("total tokens = %d, want %d", resp.Usage.TotalTokens, 15*tt.wantCalls)
+			}
+			if len(req.Messages) != 1 {
+				t.Error("caller messages were modified")
+			}
+		})
+	}
+}
+
+func TestAdapterRetryFeedback(t *testing.T) {
+	next := &scriptedChat{replies: []string{"not json", `{"name":"Ada"}`}}
+	a := NewAdapter(next, 1)
+	req := &model.ChatRequest{
+		Model:          "glm-4",
+		Messages:       []model.Message{{Role: "user", Content: model.NewTextContent("who?")}},
+		ResponseFormat: personSchema(),
+	}
+	if _, err := a.Chat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	retry := next.requests[1].Messages
+	if len(retry) != 3 || retry[1].Role != "assistant" || retry[1].Content.Text() != "not json" {
+		t.Fatalf("retry messages = %+v", retry)
+	}
+	if retry[2].Role != "user" || !strings.Contains(retry[2].Content.Text(), "rejected") {
+		t.Errorf("feedback = %q", retry[2].Content.Text())
+	}
+}
+
+func TestAdapterStream(t *testing.T) {
+	next := &scriptedChat{replies: []string{`{"name":"Ada"}`}}
+	a := NewAdapter(next, 0)
+
+	// 不要求JSON的请求直接透传
+	if _, err := a.ChatStream(context.Background(), &model.ChatRequest{Model: "glm-4", Stream: true}); err != nil {
+		t.Fatal(err)
+	}
+	if next.requests[0].Stream != true {
+		t.Error("plain stream request was not passed through")
+	}
+
+	req := &model.ChatRequest{
+		Model:          "glm-4",
+		Stream:         true,
+		StreamOptions:  &model.StreamOptions{IncludeUsage: true},
+		ResponseFormat: &model.ResponseFormat{Type: model.ResponseFormatJSONObject},
+	}
+	ch, err := a.ChatStream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := model.NewStreamAccumulator()
+	for chunk := range ch {
+		acc.Add(chunk)
+	}
+	if got := acc.Response().GetContent(); got != `{"name":"Ada"}` {
+		t.Errorf("replayed content = %q", got)
+	}
+	if upstream := next.requests[1]; upstream.Stream || upstream.StreamOptions != nil {
+		t.Error("JSON stream request was not converted to a non-stream call")
+	}
+}
+
+func TestAdapterSetMaxRetries(t *testing.T) {
+	next := &scriptedChat{replies: []string{"not json"}}
+	a := NewAdapter(next, 0)
+	a.SetMaxRetries(2)
+
+	req := &model.ChatRequest{Model: "glm-4", ResponseFormat: &model.ResponseFormat{Type: model.ResponseFormatJSONObject}}
+	var validationErr *ValidationError
+	if _, err := a.Chat(context.Background(), req); !errors.As(err, &validationErr) || validationErr.Attempts != 3 {
+		t.Fatalf("err = %v, want ValidationError after 3 attempts", err)
+	}
+
+	a.SetMaxRetries(-1)
+	next.requests = nil
+	if _, err := a.Chat(context.Background(), req); err == nil || len(next.requests) != 1 {
+		t.Errorf("negative max retries: calls = %d, want 1", len(next.requests))
+	}
+}
+
+func TestAdapterRetryMetric(t *testing.T) {
+	manager := adapter.NewAdapterManager()
+	if err := manager.Reload(map[string]adapter.ModelAdapter{"structured-model": &scriptedChat{replies: []string{"not json", `{"name":"Ada"}`}}}); err != nil {
+		t.Fatal(err)
+	}
+	retries := metrics.RetriesTotal.WithLabelValues("structured-model", "scripted")
+	before := testutil.ToFloat64(retries)
+
+	a := NewAdapter(manager, 2)
+	req := &model.ChatRequest{Model: "structured-model", ResponseFormat: personSchema()}
+	if _, err := a.Chat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(retries) - before; got != 1 {
+		t.Errorf("retries = %v, want 1", got)
+	}
+}
@@ -0,0 +1,143 @@
+package structured
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// schemaURL 编译Schema时使用的资源地址（只在错误信息中出现）
+const schemaURL = "urn:prism:response_format"
+
+// SchemaError response_format 中的JSON Schema无法编译
+// 属于请求错误，调用方应返回400
+type SchemaError struct {
+	Err error
+}
+
+// Error 实现 error 接口
+func (e *SchemaError) Error() string {
+	return "invalid json_schema.schema: " + e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError 模型输出在重试后仍不符合 response_format
+type ValidationError struct {
+	// Attempts 总共尝试的次数（含第一次调用）
+	Attempts int
+
+	// Err 最后一次校验失败的原因
+	Err error
+}
+
+// Error 实现 error 接口
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("model output does not match response_format after %d attempt(s): %v", e.Attempts, e.Err)
+}
+
+// Unwrap 返回最后一次校验失败的原因
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// validator 校验模型输出是否符合 response_format
+type validator struct {
+	// schema json_schema 中编译好的Schema，为nil时只校验输出是JSON对象
+	schema *jsonschema.Schema
+}
+
+// newValidator 根据 response_format 创建校验器
+// json_schema 提供了Schema时都按Schema校验，与 strict 无关：
+// strict 只原样传给支持的上游，上游是否遵守都需要网关校验
+// 返回：Schema无法编译时返回 *SchemaError
+func newValidator(format *model.ResponseFormat) (*validator, error) {
+	if format.Type != model.ResponseFormatJSONSchema || format.JSONSchema == nil || len(format.JSONSchema.Schema) == 0 {
+		return &validator{}, nil
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(format.JSONSchema.Schema))
+	if err != nil {
+		return nil, &SchemaError{Err: err}
+	}
+
+	c := jsonschema.NewCompiler()
+	// 不加载任何外部引用（$ref 只能指向Schema内部），避免读取本地文件或发起网络请求
+	c.UseLoader(jsonschema.SchemeURLLoader{})
+	if err := c.AddResource(schemaURL, doc); err != nil {
+		return nil, &SchemaError{Err: err}
+	}
+	schema, err := c.Compile(schemaURL)
+	if err != nil {
+		return nil, &SchemaError{Err: err}
+	}
+	return &validator{schema: schema}, nil
+}
+
+// check 校验响应中每个候选回复的内容
+// 调用工具的回复不校验；通过校验的内容会去掉首尾空白和Markdown代码块标记
+// 返回：第一个不符合格式的回复的错误原因
+func (v *validator) check(resp *model.ChatResponse) error {
+	for i := range resp.Choices {
+		msg := resp.Choices[i].Message
+		if msg == nil || len(msg.ToolCalls) > 0 || resp.Choices[i].FinishReason == model.FinishReasonToolCalls {
+			continue
+		}
+
+		content := trimCodeFence(msg.Content.Text())
+		if err := v.validate(content); err != nil {
+			return err
+		}
+		msg.Content = model.NewTextContent(content)
+	}
+	return nil
+}
+
+// validate 校验单条输出
+func (v *validator) validate(content string) error {
+	if !strings.HasPrefix(content, "{") {
+		return errors.New("output is not a JSON object")
+	}
+	inst, err := jsonschema.UnmarshalJSON(strings.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("output is not valid JSON: %w", err)
+	}
+	if _, ok := inst.(map[string]any); !ok {
+		return errors.New("output is not a JSON object")
+	}
+
+	if v.schema != nil {
+		if err := v.schema.Validate(inst); err != nil {
+			return fmt.Errorf("output does not match schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// trimCodeFence 去掉首尾空白和包裹输出的Markdown代码块（```json ... ```）
+// 不支持 response_format 的上游即使收到提示词，也经常这样包裹JSON
+func trimCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") || !strings.HasSuffix(content, "```") || len(content) < 6 {
+		return content
+	}
+
+	inner := strings.TrimSuffix(content[3:], "```")
+	// 去掉语言标记所在的第一行（如 json）
+	if nl := strings.IndexByte(inner, '\n'); nl >= 0 && !strings.HasPrefix(strings.TrimSpace(inner[:nl]), "{") {
+		inner = inner[nl+1:]
+	}
+	return strings.TrimSpace(inner)
+}
+
+// feedback 生成重试时追加给模型的纠正提示
+func feedback(format *model.ResponseFormat, err error) string {
+	return fmt.Sprintf("Your previous reply was rejected: %v.\n%s", err, format.Instruction())
+}
@@ -0,0 +1,192 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+)
+
+// BPE 基于词表的字节级BPE分词器（与tiktoken的算法一致）
+// 文本先按预分词规则切分，再对每个片段的UTF-8字节反复合并序号最小的相邻字节对
+type BPE struct {
+	// ranks token字节序列 -> 序号（序号越小越先合并）
+	ranks map[string]int
+
+	// split 预分词规则
+	split func(text string) []string
+}
+
+// LoadBPE 从tiktoken格式的词表文件加载分词器
+// 文件每行为 "base64编码的token 序号"，如 cl100k_base.tiktoken；
+// 智谱GLM-4等使用tiktoken格式词表的模型可以直接使用其词表文件
+// 参数：
+//   - path: 词表文件路径
+//   - pattern: 预分词规则（目前只支持 cl100k，为空时使用 cl100k）
+func LoadBPE(path, pattern string) (*BPE, error) {
+	if pattern != "" && pattern != PatternCL100K {
+		return nil, fmt.Errorf("unsupported tokenizer pattern: %s", pattern)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open vocabulary failed: %w", err)
+	}
+	defer f.Close()
+
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("vocabulary %s line %d: expected 2 fields, got %d", path, line, len(fields))
+		}
+
+		token, err := base64.StdEncoding.DecodeString(string(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("vocabulary %s line %d: %w", path, line, err)
+		}
+		rank, err := strconv.Atoi(string(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("vocabulary %s line %d: %w", path, line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read vocabulary failed: %w", err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("vocabulary %s is empty", path)
+	}
+
+	return &BPE{ranks: ranks, split: splitCL100K}, nil
+}
+
+// Count 返回文本的token数
+func (b *BPE) Count(text string) int {
+	n := 0
+	for _, piece := range b.split(text) {
+		n += b.countPiece([]byte(piece))
+	}
+	return n
+}
+
+// Size 词表大小
+func (b *BPE) Size() int {
+	return len(b.ranks)
+}
+
+// countPiece 计算单个预分词片段合并后的token数
+// 词表不完整导致无法合并到已知token时，剩余片段按字节计数
+//
+// 片段保存为双向链表，候选合并放在按（序号, 位置）排序的堆中，
+// 每次合并只更新相邻两处，复杂度为 O(n log n)：
+// 预分词不限制连续字母的长度，逐轮扫描的 O(n²) 算法会被超长片段拖垮
+func (b *BPE) countPiece(piece []byte) int {
+	if len(piece) <= 1 {
+		return len(piece)
+	}
+	if _, ok := b.ranks[string(piece)]; ok {
+		return 1
+	}
+
+	// 第i个片段从 piece[i] 开始，到 next[i] 结束；合并后被并入的片段标记为已删除
+	n := len(piece)
+	next := make([]int, n)
+	prev := make([]int, n)
+	removed := make([]bool, n)
+	// pairRank[i] 第i个片段与下一片段合并后的序号（堆中序号与之不同的候选已失效）
+	pairRank := make([]int, n)
+	for i := range n {
+		next[i] = i + 1
+		prev[i] = i - 1
+	}
+
+	// rankAt 第i个片段与下一片段合并后的序号，没有下一片段时返回 math.MaxInt
+	rankAt := func(i int) int {
+		if next[i] >= n {
+			return math.MaxInt
+		}
+		return b.rank(piece[i:next[next[i]]])
+	}
+	h := make(mergeHeap, 0, n)
+	for i := 0; i < n-1; i++ {
+		pairRank[i] = b.rank(piece[i : i+2])
+		if pairRank[i] != math.MaxInt {
+			h = append(h, merge{rank: pairRank[i], start: i})
+		}
+	}
+	heap.Init(&h)
+
+	count := n
+	for h.Len() > 0 {
+		m := heap.Pop(&h).(merge)
+		i := m.start
+		if removed[i] || pairRank[i] != m.rank {
+			continue
+		}
+
+		// 合并第i个片段和下一片段
+		j := next[i]
+		removed[j] = true
+		next[i] = next[j]
+		if next[j] < n {
+			prev[next[j]] = i
+		}
+		count--
+
+		// 更新受影响的两个候选：前一片段与合并结果、合并结果与下一片段
+		for _, k := range []int{prev[i], i} {
+			if k < 0 {
+				continue
+			}
+			pairRank[k] = rankAt(k)
+			if pairRank[k] != math.MaxInt {
+				heap.Push(&h, merge{rank: pairRank[k], start: k})
+			}
+		}
+	}
+	return count
+}
+
+// merge 一个候选合并：从 start 开始的片段与下一片段合并后的序号
+type merge struct {
+	rank  int
+	start int
+}
+
+// mergeHeap 候选合并的最小堆，序号相同时先合并靠左的片段（与tiktoken一致）
+type mergeHeap []merge
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].rank != h[j].rank {
+		return h[i].rank < h[j].rank
+	}
+	return h[i].start < h[j].start
+}
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)   { *h = append(*h, x.(merge)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	m := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return m
+}
+
+// rank 查询字节序列的序号，不在词表中时返回 math.MaxInt
+func (b *BPE) rank(token []byte) int {
+	if r, ok := b.ranks[string(token)]; ok {
+		return r
+	}
+	return math.MaxInt
+}
@@ -0,0 +1,135 @@
+package tokenizer
+
+import (
+	"unicode"
+)
+
+// PatternCL100K cl100k预分词规则
+// 等价于正则（Go的regexp不支持其中的 (?!\S) 断言，因此手写实现）：
+//
+//	(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+const PatternCL100K = "cl100k"
+
+// splitCL100K 按cl100k规则切分文本，各分支按正则的优先顺序尝试
+func splitCL100K(text string) []string {
+	runes := []rune(text)
+	pieces := make([]string, 0, len(runes)/3+1)
+
+	for i := 0; i < len(runes); {
+		end := matchContraction(runes, i)
+		if end == 0 {
+			end = matchLetters(runes, i)
+		}
+		if end == 0 {
+			end = matchNumbers(runes, i)
+		}
+		if end == 0 {
+			end = matchPunctuation(runes, i)
+		}
+		if end == 0 {
+			end = matchWhitespace(runes, i)
+		}
+		if end == 0 {
+			end = i + 1
+		}
+
+		pieces = append(pieces, string(runes[i:end]))
+		i = end
+	}
+	return pieces
+}
+
+// matchContraction 英文缩写：'s 't 're 've 'm 'll 'd（不区分大小写）
+func matchContraction(runes []rune, i int) int {
+	if runes[i] != '\'' || i+1 >= len(runes) {
+		return 0
+	}
+	switch unicode.ToLower(runes[i+1]) {
+	case 's', 't', 'm', 'd':
+		return i + 2
+	}
+	if i+2 < len(runes) {
+		pair := string([]rune{unicode.ToLower(runes[i+1]), unicode.ToLower(runes[i+2])})
+		if pair == "re" || pair == "ve" || pair == "ll" {
+			return i + 3
+		}
+	}
+	return 0
+}
+
+// matchLetters [^\r\n\p{L}\p{N}]?\p{L}+
+func matchLetters(runes []rune, i int) int {
+	j := i
+	if !unicode.IsLetter(runes[j]) {
+		if isNewline(runes[j]) || unicode.IsNumber(runes[j]) || j+1 >= len(runes) || !unicode.IsLetter(runes[j+1]) {
+			return 0
+		}
+		j++
+	}
+	for j < len(runes) && unicode.IsLetter(runes[j]) {
+		j++
+	}
+	return j
+}
+
+// matchNumbers \p{N}{1,3}
+func matchNumbers(runes []rune, i int) int {
+	j := i
+	for j < len(runes) && j-i < 3 && unicode.IsNumber(runes[j]) {
+		j++
+	}
+	if j == i {
+		return 0
+	}
+	return j
+}
+
+// matchPunctuation  ?[^\s\p{L}\p{N}]+[\r\n]*
+func matchPunctuation(runes []rune, i int) int {
+	j := i
+	if runes[j] == ' ' {
+		j++
+	}
+	start := j
+	for j < len(runes) && isPunctuation(runes[j]) {
+		j++
+	}
+	if j == start {
+		return 0
+	}
+	for j < len(runes) && isNewline(runes[j]) {
+		j++
+	}
+	return j
+}
+
+// matchWhitespace \s*[\r\n]+|\s+(?!\S)|\s+
+func matchWhitespace(runes []rune, i int) int {
+	end, lastNewline := i, -1
+	for end < len(runes) && unicode.IsSpace(runes[end]) {
+		if isNewline(runes[end]) {
+			lastNewline = end
+		}
+		end++
+	}
+	switch {
+	case end == i:
+		return 0
+	case lastNewline >= 0:
+		// \s*[\r\n]+：到最后一个换行符为止
+		return lastNewline + 1
+	case end < len(runes) && end-i > 1:
+		// \s+(?!\S)：留下最后一个空白，与后面的单词合并
+		return end - 1
+	default:
+		return end
+	}
+}
+
+func isNewline(r rune) bool {
+	return r == '\r' || r == '\n'
+}
+
+func isPunctuation(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
@@ -0,0 +1,97 @@
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/AtSunset1/prism/pkg/config"
+	"go.uber.org/zap"
+)
+
+// NameEstimate 按字符估算的分词器名称（没有配置词表时使用）
+const NameEstimate = "estimate"
+
+// Registry 分词器注册表
+// 保存启动时加载的所有词表；模型使用哪个词表由配置的 models.{id}.tokenizer 决定，
+// 每次查询时读取当前配置，热加载后模型与词表的对应关系立即生效
+type Registry struct {
+	// vocabularies 词表名称（小写）-> 分词器
+	vocabularies map[string]Tokenizer
+
+	// defaultName 模型未指定分词器时使用的词表名称（小写，为空表示估算）
+	defaultName string
+}
+
+// global 全局注册表（未初始化时所有模型都使用估算）
+var global atomic.Pointer[Registry]
+
+// NewRegistry 根据配置加载所有词表
+// 参数：
+//   - cfg: 分词器配置
+//
+// 返回：
+//   - *Registry: 注册表
+//   - error: 词表文件无法读取或格式错误时返回错误
+func NewRegistry(cfg config.TokenizerConfig) (*Registry, error) {
+	r := &Registry{
+		vocabularies: make(map[string]Tokenizer, len(cfg.Vocabularies)),
+		defaultName:  strings.ToLower(cfg.Default),
+	}
+
+	for name, vocab := range cfg.Vocabularies {
+		bpe, err := LoadBPE(vocab.Path, vocab.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("load tokenizer %s: %w", name, err)
+		}
+		r.vocabularies[strings.ToLower(name)] = bpe
+
+		zap.L().Info("分词器词表加载成功",
+			zap.String("tokenizer", name),
+			zap.String("path", vocab.Path),
+			zap.Int("size", bpe.Size()),
+		)
+	}
+	return r, nil
+}
+
+// Get 按名称获取分词器
+// 返回：分词器和实际使用的名称；名称为空或未加载时返回估算分词器
+func (r *Registry) Get(name string) (Tokenizer, string) {
+	name = strings.ToLower(name)
+	if r != nil && name != "" {
+		if t, ok := r.vocabularies[name]; ok {
+			return t, name
+		}
+	}
+	return Estimator{}, NameEstimate
+}
+
+// ForModel 获取模型使用的分词器
+// 优先使用 models.{id}.tokenizer，其次使用 tokenizer.default，都没有时使用估算分词器
+func (r *Registry) ForModel(modelName string) (Tokenizer, string) {
+	name := ""
+	if r != nil {
+		name = r.defaultName
+	}
+	if cfg := config.GetConfig(); cfg != nil {
+		if m, ok := cfg.GetModel(modelName); ok && m.Tokenizer != "" {
+			name = m.Tokenizer
+		}
+	}
+	return r.Get(name)
+}
+
+// SetGlobal 设置全局注册表
+func SetGlobal(r *Registry) {
+	global.Store(r)
+}
+
+// ForModel 从全局注册表获取模型使用的分词器
+// 示例：
+//
+//	tok, name := tokenizer.ForModel("glm-4")
+//	n := tokenizer.CountRequest(tok, req)
+func ForModel(modelName string) (Tokenizer, string) {
+	return global.Load().ForModel(modelName)
+}
@@ -0,0 +1,125 @@
+// Package tokenizer 在本地计算token数
+// 用于上下文窗口校验、/v1/tokenize 接口，以及上游没有返回用量时的兜底统计
+//
+// 支持两种分词器：
+//   - BPE：加载tiktoken格式的词表文件（cl100k_base或供应商提供的词表），结果与上游基本一致
+//   - Estimator：没有配置词表时按字符类别估算
+package tokenizer
+
+import (
+	"unicode"
+
+	"github.com/AtSunset1/prism/internal/model"
+)
+
+// 对话格式的额外开销（参考OpenAI的计算方式）
+const (
+	// tokensPerMessage 每条消息的格式标记（角色、分隔符等）
+	tokensPerMessage = 3
+
+	// tokensPerName 设置了 name 的消息额外消耗
+	tokensPerName = 1
+
+	// tokensPerReply 回复开头的固定标记
+	tokensPerReply = 3
+
+	// tokensPerMedia 每个图片/音频/文件片段的估算值（实际取决于分辨率和时长）
+	tokensPerMedia = 85
+)
+
+// Tokenizer 文本分词器
+type Tokenizer interface {
+	// Count 返回文本的token数
+	Count(text string) int
+}
+
+// Estimator 按字符类别估算token数，不依赖词表
+//   - 中日韩字符：约1.5个字符 = 1个token
+//   - 其他字符：约4个字符 = 1个token（空白不计）
+//
+// 误差通常在20%以内，只适合统计和兜底，不适合精确计费
+type Estimator struct{}
+
+// EstimateError Estimator 的相对误差上限
+// 按估算结果做硬性限制（如上下文窗口校验）时，应按此预留余量，避免误拒
+const EstimateError = 0.2
+
+// Count 估算文本的token数
+func (Estimator) Count(text string) int {
+	var cjk, other int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r), unicode.Is(unicode.Hiragana, r),
+			unicode.Is(unicode.Katakana, r), unicode.Is(unicode.Hangul, r):
+			cjk++
+		case unicode.IsSpace(r):
+		default:
+			other++
+		}
+	}
+	return (cjk*2+2)/3 + (other+3)/4
+}
+
+// CountRequest 计算请求的输入token数
+// 包括所有消息的内容、工具调用、工具定义和对话格式的开销
+func CountRequest(t Tokenizer, req *model.ChatRequest) int {
+	n := tokensPerReply
+	for i := range req.Messages {
+		n += CountMessage(t, &req.Messages[i])
+	}
+	for _, tool := range req.Tools {
+		n += t.Count(tool.Function.Name) + t.Count(tool.Function.Description) + t.Count(string(tool.Function.Parameters))
+	}
+	return n
+}
+
+// CountMessage 计算单条消息的token数
+func CountMessage(t Tokenizer, msg *model.Message) int {
+	n := tokensPerMessage + t.Count(msg.Role)
+	if msg.Name != "" {
+		n += tokensPerName + t.Count(msg.Name)
+	}
+
+	if msg.Content.IsMultipart() {
+		for _, part := range msg.Content.Parts() {
+			if part.Type == model.PartTypeText {
+				n += t.Count(part.Text)
+			} else {
+				n += tokensPerMedia
+			}
+		}
+	} else {
+		n += t.Count(msg.Content.Text())
+	}
+
+	for _, call := range msg.ToolCalls {
+		n += t.Count(call.Function.Name) + t.Count(call.Function.Arguments)
+	}
+	return n
+}
+
+// CountResponse 计算响应的输出token数（所有候选回复的内容和工具调用）
+func CountResponse(t Tokenizer, resp *model.ChatResponse) int {
+	var n int
+	for _, choice := range resp.Choices {
+		if choice.Message == nil {
+			continue
+		}
+		n += t.Count(choice.Message.Content.Text())
+		for _, call := range choice.Message.ToolCalls {
+			n += t.Count(call.Function.Name) + t.Count(call.Function.Arguments)
+		}
+	}
+	return n
+}
+
+// EstimateUsage 本地计算一次调用的token用量
+func EstimateUsage(t Tokenizer, req *model.ChatRequest, resp *model.ChatResponse) model.Usage {
+	prompt := CountRequest(t, req)
+	completion := CountResponse(t, resp)
+	return model.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+	}
+}
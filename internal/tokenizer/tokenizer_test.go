@@ -0,0 +1,259 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// writeVocabulary 写入tiktoken格式的词表文件
+func writeVocabulary(t *testing.T, tokens ...string) string {
+	t.Helper()
+	var b strings.Builder
+	for rank, token := range tokens {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+	path := filepath.Join(t.TempDir(), "vocab.tiktoken")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSplitCL100K(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello world", []string{"Hello", " world"}},
+		{"I'm here", []string{"I", "'m", " here"}},
+		{"12345", []string{"123", "45"}},
+		{"a  b", []string{"a", " ", " b"}},
+		{"hi!!\n\nok", []string{"hi", "!!\n\n", "ok"}},
+		{"你好，世界", []string{"你好", "，世界"}},
+		{"end  ", []string{"end", "  "}},
+	}
+	for _, tt := range tests {
+		got := splitCL100K(tt.text)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("splitCL100K(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBPECount(t *testing.T) {
+	bpe, err := LoadBPE(writeVocabulary(t, "a", "b", "c", "ab", "abc", " ", " ab"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bpe.Size() != 7 {
+		t.Errorf("Size = %d, want 7", bpe.Size())
+	}
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"abab", 2},
+		{"cab", 2},
+		{"ab ab", 2},
+		// 词表中没有的字节按字节计数
+		{"xyz", 3},
+	}
+	for _, tt := range tests {
+		if got := bpe.Count(tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBPECountLongPiece(t *testing.T) {
+	bpe, err := LoadBPE(writeVocabulary(t, "a", "b", "aa", "ab", "aaaa"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 预分词不限制连续字母的长度，10万个字母是一个片段
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"repeated letter", strings.Repeat("a", 100000), 25000},
+		{"alternating letters", strings.Repeat("ab", 50000), 50000},
+		{"unknown letters", strings.Repeat("字", 100000), 300000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			if got := bpe.Count(tt.text); got != tt.want {
+				t.Errorf("Count = %d, want %d", got, tt.want)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Count took %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestLoadBPEErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0o644)
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		pattern string
+		errMsg  string
+	}{
+		{"missing file", filepath.Join(dir, "missing"), "", "open vocabulary"},
+		{"unknown pattern", write("ok", "YQ== 0\n"), "o200k", "unsupported tokenizer pattern"},
+		{"bad line", write("fields", "YQ==\n"), "", "line 1: expected 2 fields"},
+		{"bad base64", write("base64", "!!! 0\n"), "", "line 1"},
+		{"bad rank", write("rank", "YQ== x\n"), "", "line 1"},
+		{"empty", write("empty", "\n"), "", "is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadBPE(tt.path, tt.pattern)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("err = %v, want %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestEstimatorCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"a b c d", 1},
+		{"你好世", 2},
+		{"你好 abcd", 3},
+	}
+	for _, tt := range tests {
+		if got := (Estimator{}).Count(tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+// charCounter 每个字节计为1个token，便于核对格式开销
+type charCounter struct{}
+
+func (charCounter) Count(text string) int { return len(text) }
+
+func TestCountRequest(t *testing.T) {
+	req := &model.ChatRequest{
+		Messages: []model.Message{
+			{Role: "user", Name: "bob", Content: model.NewTextContent("hi")},
+			{Role: "user", Content: model.NewMultipartContent([]model.ContentPart{
+				{Type: model.PartTypeText, Text: "see"},
+				{Type: model.PartTypeImageURL, ImageURL: &model.ImageURL{URL: "https://example.com/a.png"}},
+			})},
+			{Role: "assistant", ToolCalls: []model.ToolCall{{Function: model.FunctionCall{Name: "f", Arguments: "{}"}}}},
+		},
+		Tools: []model.Tool{{Type: "function", Function: model.FunctionDefinition{Name: "f", Description: "d"}}},
+	}
+
+	want := tokensPerReply +
+		(tokensPerMessage + 4 + tokensPerName + 3 + 2) +
+		(tokensPerMessage + 4 + 3 + tokensPerMedia) +
+		(tokensPerMessage + 9 + 1 + 2) +
+		(1 + 1)
+	if got := CountRequest(charCounter{}, req); got != want {
+		t.Errorf("CountRequest = %d, want %d", got, want)
+	}
+
+	resp := &model.ChatResponse{Choices: []model.Choice{
+		{Message: &model.Message{Content: model.NewTextContent("hello")}},
+		{Message: &model.Message{ToolCalls: []model.ToolCall{{Function: model.FunctionCall{Name: "f", Arguments: "{}"}}}}},
+	}}
+	usage := EstimateUsage(charCounter{}, req, resp)
+	if usage.PromptTokens != want || usage.CompletionTokens != 8 || usage.TotalTokens != want+8 {
+		t.Errorf("EstimateUsage = %+v", usage)
+	}
+}
+
+func TestRegistryForModel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte(`
+adapters:
+  glm:
+    api_key: "test-key"
+    base_url: "https://example.com/v1"
+    models: ["glm-4", "glm-4-flash"]
+tokenizer:
+  vocabularies:
+    small:
+      path: small.tiktoken
+models:
+  glm-4-flash:
+    tokenizer: Small
+`), 0o644)
+	if _, err := config.Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRegistry(config.TokenizerConfig{
+		Default: "CL100K",
+		Vocabularies: map[string]config.VocabularyConfig{
+			"cl100k": {Path: writeVocabulary(t, "a", "b")},
+			"small":  {Path: writeVocabulary(t, "a")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"glm-4", "cl100k"},
+		{"glm-4-flash", "small"},
+	}
+	for _, tt := range tests {
+		if _, name := r.ForModel(tt.model); name != tt.want {
+			t.Errorf("ForModel(%q) = %q, want %q", tt.model, name, tt.want)
+		}
+	}
+
+	// 没有默认词表时，未指定分词器的模型使用估算
+	noDefault, err := NewRegistry(config.TokenizerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, name := noDefault.ForModel("glm-4"); name != NameEstimate {
+		t.Errorf("ForModel without default = %q, want estimate", name)
+	}
+
+	// 未初始化的注册表使用估算
+	var empty *Registry
+	if tok, name := empty.ForModel("glm-4"); name != NameEstimate {
+		t.Errorf("nil registry = %q, want estimate", name)
+	} else if _, ok := tok.(Estimator); !ok {
+		t.Errorf("nil registry tokenizer = %T", tok)
+	}
+
+	if _, err := NewRegistry(config.TokenizerConfig{Vocabularies: map[string]config.VocabularyConfig{"x": {Path: "/missing"}}}); err == nil {
+		t.Error("NewRegistry accepted a missing vocabulary")
+	}
+}
@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"github.com/AtSunset1/prism/internal/model"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+// ===== GenAI 语义约定属性 =====
+// 参考：https://opentelemetry.io/docs/specs/semconv/gen-ai/
+
+// RequestAttributes 聊天请求的GenAI属性
+// 只记录调用方显式设置的采样参数，不记录消息内容
+func RequestAttributes(req *model.ChatRequest) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.GenAIOperationNameChat,
+		semconv.GenAIRequestModel(req.Model),
+	}
+
+	if req.MaxTokens != nil {
+		attrs = append(attrs, semconv.GenAIRequestMaxTokens(*req.MaxTokens))
+	}
+	if req.Temperature != nil {
+		attrs = append(attrs, semconv.GenAIRequestTemperature(*req.Temperature))
+	}
+	if req.TopP != nil {
+		attrs = append(attrs, semconv.GenAIRequestTopP(*req.TopP))
+	}
+	if req.N != nil {
+		attrs = append(attrs, semconv.GenAIRequestChoiceCount(*req.N))
+	}
+	if len(req.Stop) > 0 {
+		attrs = append(attrs, semconv.GenAIRequestStopSequences(req.Stop...))
+	}
+	if req.ResponseFormat.IsJSON() {
+		attrs = append(attrs, semconv.GenAIOutputTypeJSON)
+	}
+
+	return attrs
+}
+
+// ProviderAttribute 上游供应商属性（使用适配器名称）
+func ProviderAttribute(adapterName string) attribute.KeyValue {
+	return semconv.GenAIProviderNameKey.String(adapterName)
+}
+
+// ResponseAttributes 非流式响应的GenAI属性
+func ResponseAttributes(resp *model.ChatResponse) []attribute.KeyValue {
+	finishReasons := make([]string, 0, len(resp.Choices))
+	for _, choice := range resp.Choices {
+		if choice.FinishReason != "" {
+			finishReasons = append(finishReasons, choice.FinishReason)
+		}
+	}
+
+	return []attribute.KeyValue{
+		semconv.GenAIResponseID(resp.ID),
+		semconv.GenAIResponseModel(resp.Model),
+		semconv.GenAIResponseFinishReasons(finishReasons...),
+		semconv.GenAIUsageInputTokens(resp.Usage.PromptTokens),
+		semconv.GenAIUsageOutputTokens(resp.Usage.CompletionTokens),
+	}
+}
+
+// StreamAttributes 流式响应结束时的GenAI属性
+// 参数：
+//   - last: 最后收到的数据块（可能为nil）
+//   - finishReason: 结束原因（流未正常结束时为空）
+//   - usage: token用量（可能为nil）
+func StreamAttributes(last *model.StreamResponse, finishReason string, usage *model.Usage) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if last != nil {
+		attrs = append(attrs,
+			semconv.GenAIResponseID(last.ID),
+			semconv.GenAIResponseModel(last.Model),
+		)
+	}
+	if finishReason != "" {
+		attrs = append(attrs, semconv.GenAIResponseFinishReasons(finishReason))
+	}
+	if usage != nil {
+		attrs = append(attrs,
+			semconv.GenAIUsageInputTokens(usage.PromptTokens),
+			semconv.GenAIUsageOutputTokens(usage.CompletionTokens),
+		)
+	}
+	return attrs
+}
+
+// EmbeddingRequestAttributes 向量化请求的GenAI属性
+func EmbeddingRequestAttributes(req *model.EmbeddingRequest) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.GenAIOperationNameEmbeddings,
+		semconv.GenAIRequestModel(req.Model),
+	}
+	if req.EncodingFormat != "" {
+		attrs = append(attrs, semconv.GenAIRequestEncodingFormats(req.EncodingFormat))
+	}
+	return attrs
+}
+
+// EmbeddingResponseAttributes 向量化响应的GenAI属性
+func EmbeddingResponseAttributes(resp *model.EmbeddingResponse) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.GenAIResponseModel(resp.Model),
+		semconv.GenAIUsageInputTokens(resp.Usage.PromptTokens),
+	}
+	if len(resp.Data) > 0 {
+		attrs = append(attrs, semconv.GenAIEmbeddingsDimensionCount(len(resp.Data[0].Embedding.Float)))
+	}
+	return attrs
+}
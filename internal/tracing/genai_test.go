@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+func attrMap(attrs []attribute.KeyValue) map[attribute.Key]string {
+	m := make(map[attribute.Key]string, len(attrs))
+	for _, kv := range attrs {
+		m[kv.Key] = kv.Value.Emit()
+	}
+	return m
+}
+
+func TestRequestAttributes(t *testing.T) {
+	maxTokens, temperature := 256, 0.5
+	tests := []struct {
+		name    string
+		req     *model.ChatRequest
+		want    map[attribute.Key]string
+		missing []attribute.Key
+	}{
+		{
+			name: "only model",
+			req:  &model.ChatRequest{Model: "glm-4"},
+			want: map[attribute.Key]string{
+				semconv.GenAIOperationNameKey: "chat",
+				semconv.GenAIRequestModelKey:  "glm-4",
+			},
+			missing: []attribute.Key{semconv.GenAIRequestMaxTokensKey, semconv.GenAIRequestTemperatureKey},
+		},
+		{
+			name: "sampling parameters",
+			req:  &model.ChatRequest{Model: "glm-4", MaxTokens: &maxTokens, Temperature: &temperature},
+			want: map[attribute.Key]string{
+				semconv.GenAIRequestMaxTokensKey:   "256",
+				semconv.GenAIRequestTemperatureKey: "0.5",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := attrMap(RequestAttributes(tt.req))
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+			for _, k := range tt.missing {
+				if _, ok := got[k]; ok {
+					t.Errorf("unexpected attribute %s", k)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamAttributes(t *testing.T) {
+	last := &model.StreamResponse{ID: "chatcmpl-1", Model: "glm-4"}
+	usage := &model.Usage{PromptTokens: 5, CompletionTokens: 2}
+
+	got := attrMap(StreamAttributes(last, "stop", usage))
+	want := map[attribute.Key]string{
+		semconv.GenAIResponseIDKey:            "chatcmpl-1",
+		semconv.GenAIResponseModelKey:         "glm-4",
+		semconv.GenAIResponseFinishReasonsKey: `["stop"]`,
+		semconv.GenAIUsageInputTokensKey:      "5",
+		semconv.GenAIUsageOutputTokensKey:     "2",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+
+	// 流未正常结束时没有任何响应属性
+	if attrs := StreamAttributes(nil, "", nil); len(attrs) != 0 {
+		t.Errorf("StreamAttributes(nil) = %v, want empty", attrs)
+	}
+}
+
+func TestInitDisabledSetsPropagator(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	shutdown, err := Init(context.Background(), config.TracingConfig{Enabled: false})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+
+	// 未启用导出时仍然透传 traceparent
+	fields := otel.GetTextMapPropagator().Fields()
+	var traceparent bool
+	for _, f := range fields {
+		if f == "traceparent" {
+			traceparent = true
+		}
+	}
+	if !traceparent {
+		t.Errorf("propagator fields = %v, want traceparent", fields)
+	}
+}
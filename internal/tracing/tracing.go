@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AtSunset1/prism/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName Tracer名称
+const instrumentationName = "github.com/AtSunset1/prism"
+
+// ShutdownFunc 关闭TracerProvider，刷新尚未导出的span
+type ShutdownFunc func(ctx context.Context) error
+
+// Init 根据配置初始化全局TracerProvider和传播器
+// 参数：
+//   - ctx: 上下文（用于创建导出器）
+//   - cfg: 链路追踪配置
+//
+// 返回：
+//   - ShutdownFunc: 进程退出前调用，确保span全部导出
+//   - error: 导出器创建失败时返回错误
+//
+// 说明：
+//   - 无论是否启用导出，都会设置W3C TraceContext传播器，
+//     这样上游的 traceparent 能透传给下游供应商
+//   - 未启用时使用默认的noop TracerProvider，埋点代码零开销
+func Init(ctx context.Context, cfg config.TracingConfig) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create otlp exporter failed: %w", err)
+	}
+
+	tp := NewTracerProvider(cfg, sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+// NewTracerProvider 创建TracerProvider
+// 参数：
+//   - cfg: 链路追踪配置（服务名、采样率）
+//   - opts: 额外选项，例如导出器（sdktrace.WithBatcher / sdktrace.WithSyncer）
+//
+// 示例：
+//
+//	exporter := tracetest.NewInMemoryExporter()
+//	tp := tracing.NewTracerProvider(cfg, sdktrace.WithSyncer(exporter))
+//	otel.SetTracerProvider(tp)
+func NewTracerProvider(cfg config.TracingConfig, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	)
+
+	// 父span已采样时跟随父span；根span按比例采样
+	sampler := sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))
+
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sampler),
+	}, opts...)
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// Tracer 返回Prism使用的Tracer
+// 每次从全局TracerProvider获取，保证 Init 之后创建的span能被导出
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
@@ -0,0 +1,227 @@
+// Package truncate 在请求超出模型上下文窗口时按配置的策略裁剪对话历史
+package truncate
+
+import (
+	"context"
+	"strings"
+
+	"github.com/AtSunset1/prism/internal/adapter"
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/internal/tokenizer"
+	"github.com/AtSunset1/prism/pkg/config"
+	"github.com/AtSunset1/prism/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 截断策略（config.Truncation.Strategy 的取值）
+const (
+	StrategyNone          = "none"
+	StrategyDropOldest    = "drop_oldest"
+	StrategyKeepFirstLast = "keep_first_last"
+	StrategySummarize     = "summarize"
+)
+
+// defaultSummaryMaxTokens 摘要的默认最大token数
+const defaultSummaryMaxTokens = 512
+
+// summaryPrompt 生成摘要时的system提示词
+const summaryPrompt = "You summarize conversations. Write a concise summary of the conversation below, " +
+	"keeping facts, decisions, names, numbers and open questions that later messages may rely on. " +
+	"Write the summary in the same language as the conversation. Output only the summary."
+
+// summaryPrefix 摘要消息的前缀（摘要以system消息插入对话）
+const summaryPrefix = "Summary of the earlier conversation:\n"
+
+// Result 截断结果
+type Result struct {
+	// Strategy 使用的策略（未截断时为空）
+	Strategy string
+
+	// Dropped 被删除的消息数
+	Dropped int
+
+	// Summarized 被摘要替换的消息数
+	Summarized int
+}
+
+// Truncator 对话截断器
+// 截断只在请求超出上下文窗口时发生，以"轮"为单位删除消息：
+// 一轮从user消息开始，包含其后的assistant和tool消息，因此工具调用与其结果不会被拆开；
+// system消息总是保留
+type Truncator struct {
+	// summarizer 生成摘要使用的适配器（通常是 AdapterManager）
+	summarizer adapter.ModelAdapter
+}
+
+// New 创建对话截断器
+// 参数：
+//   - summarizer: 生成摘要使用的适配器（summarize策略调用 summary_model）
+//
+// 示例：
+//
+//	prep := pipeline.New(pipeline.WithTruncator(truncate.New(manager)))
+func New(summarizer adapter.ModelAdapter) *Truncator {
+	return &Truncator{summarizer: summarizer}
+}
+
+// Fit 按模型的截断策略裁剪请求，使输入加最大输出不超过上下文窗口
+// 模型未配置策略、未超出窗口时不做任何修改；裁剪时替换 req.Messages（不修改原切片）
+// 摘要生成失败时退化为直接删除
+//
+// 返回：截断结果（未截断时为零值）
+func (t *Truncator) Fit(ctx context.Context, req *model.ChatRequest) Result {
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return Result{}
+	}
+	meta, ok := cfg.GetModel(req.Model)
+	policy := meta.Truncation
+	if !ok || meta.ContextWindow <= 0 || policy.Strategy == "" || policy.Strategy == StrategyNone {
+		return Result{}
+	}
+
+	tok, _ := tokenizer.ForModel(req.Model)
+	budget := meta.ContextWindow - req.GetMaxTokens()
+	total := tokenizer.CountRequest(tok, req)
+	if total <= budget {
+		return Result{}
+	}
+
+	// 摘要策略需要为摘要消息预留空间
+	summaryMaxTokens := policy.SummaryMaxTokens
+	if summaryMaxTokens <= 0 {
+		summaryMaxTokens = defaultSummaryMaxTokens
+	}
+	keepFirst := policy.KeepFirst
+	switch policy.Strategy {
+	case StrategyDropOldest:
+		keepFirst = 0
+	case StrategySummarize:
+		keepFirst = 0
+		budget -= summaryMaxTokens + tokenizer.CountMessage(tok, &model.Message{Role: "system", Content: model.NewTextContent(summaryPrefix)})
+	}
+
+	conv := newConversation(req.Messages)
+	dropped := conv.selectDrop(tok, req.Messages, total, budget, keepFirst, max(policy.KeepLast, 1))
+	if len(dropped) == 0 {
+		return Result{}
+	}
+
+	var summary *model.Message
+	if policy.Strategy == StrategySummarize {
+		var err error
+		summary, err = t.summarize(ctx, policy.SummaryModel, summaryMaxTokens, req.Messages, dropped)
+		if err != nil {
+			logger.FromContext(ctx).Warn("生成对话摘要失败，改为直接删除", zap.String("summary_model", policy.SummaryModel), zap.Error(err))
+		}
+	}
+
+	req.Messages = conv.rebuild(req.Messages, dropped, summary)
+	result := Result{Strategy: policy.Strategy}
+	if summary != nil {
+		result.Summarized = len(dropped)
+	} else {
+		result.Dropped = len(dropped)
+	}
+	return result
+}
+
+// summarize 调用摘要模型总结被删除的消息
+// 返回：以system消息表示的摘要
+func (t *Truncator) summarize(ctx context.Context, summaryModel string, maxTokens int, messages []model.Message, dropped map[int]bool) (*model.Message, error) {
+	var transcript strings.Builder
+	for i, msg := range messages {
+		if !dropped[i] {
+			continue
+		}
+		text := msg.Content.Text()
+		for _, call := range msg.ToolCalls {
+			text += "\n[tool call] " + call.Function.Name + "(" + call.Function.Arguments + ")"
+		}
+		if text == "" {
+			continue
+		}
+		transcript.WriteString(msg.Role)
+		transcript.WriteString(": ")
+		transcript.WriteString(text)
+		transcript.WriteString("\n\n")
+	}
+
+	temperature := 0.0
+	resp, err := t.summarizer.Chat(ctx, &model.ChatRequest{
+		Model: summaryModel,
+		Messages: []model.Message{
+			{Role: "system", Content: model.NewTextContent(summaryPrompt)},
+			{Role: "user", Content: model.NewTextContent(transcript.String())},
+		},
+		MaxTokens:   &maxTokens,
+		Temperature: &temperature,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Message{
+		Role:    "system",
+		Content: model.NewTextContent(summaryPrefix + strings.TrimSpace(resp.GetContent())),
+	}, nil
+}
+
+// conversation 按轮划分的对话
+type conversation struct {
+	// turns 每一轮包含的消息下标（不含system消息）
+	turns [][]int
+}
+
+// newConversation 将消息划分为轮：每条user消息开始新的一轮
+// 第一条user消息之前的非system消息单独作为第一轮
+func newConversation(messages []model.Message) *conversation {
+	c := &conversation{}
+	for i, msg := range messages {
+		if msg.Role == "system" {
+			continue
+		}
+		if msg.Role == "user" || len(c.turns) == 0 {
+			c.turns = append(c.turns, nil)
+		}
+		last := len(c.turns) - 1
+		c.turns[last] = append(c.turns[last], i)
+	}
+	return c
+}
+
+// selectDrop 选择需要删除的消息
+// 保留最早 keepFirst 轮和最近 keepLast 轮，从中间最早的一轮开始删除，直到不超过预算
+// 即使全部可删除的轮都删除后仍然超出，也返回全部可删除的消息（由上下文窗口校验拒绝请求）
+//
+// 返回：需要删除的消息下标
+func (c *conversation) selectDrop(tok tokenizer.Tokenizer, messages []model.Message, total, budget, keepFirst, keepLast int) map[int]bool {
+	dropped := make(map[int]bool)
+	end := len(c.turns) - keepLast
+	for i := keepFirst; i < end && total > budget; i++ {
+		for _, index := range c.turns[i] {
+			dropped[index] = true
+			total -= tokenizer.CountMessage(tok, &messages[index])
+		}
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+	return dropped
+}
+
+// rebuild 生成裁剪后的消息列表，摘要（如有）插入到第一条被删除消息的位置
+func (c *conversation) rebuild(messages []model.Message, dropped map[int]bool, summary *model.Message) []model.Message {
+	out := make([]model.Message, 0, len(messages)-len(dropped)+1)
+	for i, msg := range messages {
+		if !dropped[i] {
+			out = append(out, msg)
+			continue
+		}
+		if summary != nil {
+			out = append(out, *summary)
+			summary = nil
+		}
+	}
+	return out
+}
@@ -0,0 +1,190 @@
+package truncate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/internal/model"
+	"github.com/AtSunset1/prism/pkg/config"
+)
+
+// stubSummarizer 测试用摘要适配器
+type stubSummarizer struct {
+	err      error
+	requests []*model.ChatRequest
+}
+
+func (s *stubSummarizer) Chat(ctx context.Context, req *model.ChatRequest) (*model.ChatResponse, error) {
+	s.requests = append(s.requests, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.ChatResponse{Choices: []model.Choice{
+		{Message: &model.Message{Role: "assistant", Content: model.NewTextContent(" earlier turns \n")}, FinishReason: "stop"},
+	}}, nil
+}
+
+func (s *stubSummarizer) ChatStream(ctx context.Context, req *model.ChatRequest) (<-chan *model.StreamResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *stubSummarizer) Name() string { return "stub" }
+
+func (s *stubSummarizer) HealthCheck(ctx context.Context) error { return nil }
+
+// truncationConfig 四个上下文窗口为80的模型，分别使用不同的截断策略
+const truncationConfig = `
+adapters:
+  glm:
+    api_key: "test-key"
+    base_url: "https://example.com/v1"
+    models: ["drop", "first-last", "summarize", "none", "glm-4-flash"]
+models:
+  drop:
+    context_window: 80
+    truncation:
+      strategy: drop_oldest
+  first-last:
+    context_window: 80
+    truncation:
+      strategy: keep_first_last
+      keep_first: 1
+      keep_last: 1
+  summarize:
+    context_window: 80
+    truncation:
+      strategy: summarize
+      summary_model: glm-4-flash
+      summary_max_tokens: 10
+  none:
+    context_window: 80
+    truncation:
+      strategy: none
+`
+
+func loadTruncationConfig(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(truncationConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Load(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// longConversation 一条system消息加 turns 轮对话，每条消息约10个token
+func longConversation(modelName string, turns int) *model.ChatRequest {
+	req := &model.ChatRequest{
+		Model:    modelName,
+		Messages: []model.Message{{Role: "system", Content: model.NewTextContent("be brief")}},
+	}
+	filler := strings.Repeat("x", 36)
+	for i := range turns {
+		req.Messages = append(req.Messages,
+			model.Message{Role: "user", Content: model.NewTextContent(fmt.Sprintf("q%d%s", i, filler))},
+			model.Message{Role: "assistant", Content: model.NewTextContent(fmt.Sprintf("a%d%s", i, filler))},
+		)
+	}
+	return req
+}
+
+// prefixes 返回每条消息内容的前两个字符（用于核对保留了哪些轮）
+func prefixes(messages []model.Message) string {
+	var out []string
+	for _, msg := range messages {
+		text := msg.Content.Text()
+		if msg.Role == "system" {
+			text = "sys"
+			if strings.HasPrefix(msg.Content.Text(), summaryPrefix) {
+				text = "summary"
+			}
+		}
+		out = append(out, text[:min(len(text), 2)])
+	}
+	return strings.Join(out, ",")
+}
+
+func TestFit(t *testing.T) {
+	loadTruncationConfig(t)
+
+	tests := []struct {
+		name   string
+		model  string
+		turns  int
+		want   Result
+		remain string
+	}{
+		{"fits", "drop", 2, Result{}, "sy,q0,a0,q1,a1"},
+		{"drop oldest", "drop", 4, Result{Strategy: StrategyDropOldest, Dropped: 4}, "sy,q2,a2,q3,a3"},
+		{"keep first and last", "first-last", 4, Result{Strategy: StrategyKeepFirstLast, Dropped: 4}, "sy,q0,a0,q3,a3"},
+		{"summarize", "summarize", 4, Result{Strategy: StrategySummarize, Summarized: 6}, "sy,su,q3,a3"},
+		{"no strategy", "none", 4, Result{}, "sy,q0,a0,q1,a1,q2,a2,q3,a3"},
+		{"unknown model", "missing", 4, Result{}, "sy,q0,a0,q1,a1,q2,a2,q3,a3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summarizer := &stubSummarizer{}
+			req := longConversation(tt.model, tt.turns)
+			original := req.Messages
+
+			got := New(summarizer).Fit(context.Background(), req)
+			if got != tt.want {
+				t.Errorf("result = %+v, want %+v", got, tt.want)
+			}
+			if p := prefixes(req.Messages); p != tt.remain {
+				t.Errorf("messages = %s, want %s", p, tt.remain)
+			}
+			if tt.want.Strategy != "" && len(original) != 1+2*tt.turns {
+				t.Error("original messages were modified")
+			}
+			if tt.want.Summarized > 0 {
+				if len(summarizer.requests) != 1 || summarizer.requests[0].Model != "glm-4-flash" {
+					t.Fatalf("summary requests = %+v", summarizer.requests)
+				}
+				if !strings.Contains(summarizer.requests[0].Messages[1].Content.Text(), "user: q0") {
+					t.Errorf("transcript = %q", summarizer.requests[0].Messages[1].Content.Text())
+				}
+				if req.Messages[1].Content.Text() != summaryPrefix+"earlier turns" {
+					t.Errorf("summary message = %q", req.Messages[1].Content.Text())
+				}
+			}
+		})
+	}
+}
+
+func TestFitSummaryFailureDrops(t *testing.T) {
+	loadTruncationConfig(t)
+
+	req := longConversation("summarize", 4)
+	got := New(&stubSummarizer{err: errors.New("upstream down")}).Fit(context.Background(), req)
+	if got != (Result{Strategy: StrategySummarize, Dropped: 6}) {
+		t.Errorf("result = %+v", got)
+	}
+	if p := prefixes(req.Messages); p != "sy,q3,a3" {
+		t.Errorf("messages = %s", p)
+	}
+}
+
+func TestNewConversation(t *testing.T) {
+	messages := []model.Message{
+		{Role: "system"},
+		{Role: "assistant"},
+		{Role: "user"},
+		{Role: "assistant", ToolCalls: []model.ToolCall{{ID: "call_1"}}},
+		{Role: "tool", ToolCallID: "call_1"},
+		{Role: "assistant"},
+		{Role: "system"},
+		{Role: "user"},
+	}
+	got := fmt.Sprint(newConversation(messages).turns)
+	// 工具调用与结果属于同一轮，system消息不属于任何一轮
+	if want := "[[1] [2 3 4 5] [7]]"; got != want {
+		t.Errorf("turns = %s, want %s", got, want)
+	}
+}
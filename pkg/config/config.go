@@ -1,30 +1,130 @@
 package config
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Config 全局配置结构
 type Config struct {
-	Server   ServerConfig            `mapstructure:"server"`
-	Adapters map[string]AdapterConfig `mapstructure:"adapters"`
-	Router   RouterConfig            `mapstructure:"router"`
-	Logging  LoggingConfig           `mapstructure:"logging"`
+	Server     ServerConfig             `mapstructure:"server"`
+	Adapters   map[string]AdapterConfig `mapstructure:"adapters"`
+	Router     RouterConfig             `mapstructure:"router"`
+	Logging    LoggingConfig            `mapstructure:"logging"`
+	Metrics    MetricsConfig            `mapstructure:"metrics"`
+	Tracing    TracingConfig            `mapstructure:"tracing"`
+	Audit      AuditConfig              `mapstructure:"audit"`
+	Cache      CacheConfig              `mapstructure:"cache"`
+	Auth       AuthConfig               `mapstructure:"auth"`
+	Media      MediaConfig              `mapstructure:"media"`
+	Structured StructuredConfig         `mapstructure:"structured_output"`
+	Tokenizer  TokenizerConfig          `mapstructure:"tokenizer"`
+	Health     HealthConfig             `mapstructure:"health"`
+	Responses  ResponsesConfig          `mapstructure:"responses"`
+	Batch      BatchConfig              `mapstructure:"batch"`
+	Models     map[string]ModelConfig   `mapstructure:"models"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Host         string        `mapstructure:"host"`
-	Port         int           `mapstructure:"port"`
-	Mode         string        `mapstructure:"mode"` // debug, release
-	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	Host              string        `mapstructure:"host"`
+	Port              int           `mapstructure:"port"`
+	Mode              string        `mapstructure:"mode"`                // debug, release
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"` // 读取请求头的超时
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`        // 读取整个请求（含请求体）的超时
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`       // 非流式请求的总期限（从读完请求头到写完响应）
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`        // keep-alive 连接的空闲超时
+
+	// StreamWriteTimeout 流式响应单次写入的期限，每发送一个数据块后顺延
+	// 客户端长时间不读取（网络中断、对端卡死）时断开连接
+	StreamWriteTimeout time.Duration `mapstructure:"stream_write_timeout"`
+
+	// MaxStreamDuration 单个流式响应的最长持续时间（0表示不限制）
+	MaxStreamDuration time.Duration `mapstructure:"max_stream_duration"`
+
+	// ShutdownDelay 收到退出信号后、停止接受新连接前的等待时间
+	// 期间健康检查返回未就绪，留给负载均衡摘除实例
+	ShutdownDelay time.Duration `mapstructure:"shutdown_delay"`
+
+	// ShutdownTimeout 排空期限：等待进行中的请求（包括流式响应）结束的最长时间
+	// 超过后向仍在进行的流式响应发送错误事件并关闭连接
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+
+	// UnixSocket 监听的unix socket路径（非空时代替 host:port，用于sidecar部署）
+	UnixSocket string `mapstructure:"unix_socket"`
+
+	// HTTP2 是否启用HTTP/2：启用TLS时通过ALPN协商，未启用TLS时接受明文HTTP/2（h2c）
+	HTTP2 bool `mapstructure:"http2"`
+
+	// TLS 在网关终止TLS
+	TLS ServerTLSConfig `mapstructure:"tls"`
+}
+
+// ServerTLSConfig 服务器TLS配置
+type ServerTLSConfig struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	CertFile      string   `mapstructure:"cert_file"`       // 证书文件（PEM，可包含中间证书），变更后自动重新加载
+	KeyFile       string   `mapstructure:"key_file"`        // 私钥文件（PEM）
+	MinVersion    string   `mapstructure:"min_version"`     // 最低TLS版本：1.2, 1.3
+	ClientAuth    string   `mapstructure:"client_auth"`     // 客户端证书校验（mTLS）：none, optional, require
+	ClientCAFiles []string `mapstructure:"client_ca_files"` // 校验客户端证书的CA证书文件（PEM，可包含多个证书）
+	HTTP3         bool     `mapstructure:"http3"`           // 同时在相同端口的UDP上提供HTTP/3
 }
 
 // AdapterConfig 适配器配置
 type AdapterConfig struct {
+	Type    string        `mapstructure:"type"` // 适配器类型：glm, openai（为空时使用适配器名称）
 	APIKey  string        `mapstructure:"api_key"`
 	BaseURL string        `mapstructure:"base_url"`
-	Timeout time.Duration `mapstructure:"timeout"`
+	Timeout time.Duration `mapstructure:"timeout"` // 非流式请求的总超时（含读取响应体）
 	Models  []string      `mapstructure:"models"`
+
+	// StreamIdleTimeout 流式响应两个数据块之间的最长间隔，超过即中断（流式请求不受 timeout 约束）
+	StreamIdleTimeout time.Duration   `mapstructure:"stream_idle_timeout"`
+	Transport         TransportConfig `mapstructure:"transport"` // 上游连接池配置
+}
+
+// TransportConfig 上游HTTP连接池配置（每个适配器独立）
+// 未设置的字段沿用Go默认值
+type TransportConfig struct {
+	MaxIdleConns          int           `mapstructure:"max_idle_conns"`          // 空闲连接总数上限
+	MaxIdleConnsPerHost   int           `mapstructure:"max_idle_conns_per_host"` // 每个主机的空闲连接上限（Go默认仅2个）
+	MaxConnsPerHost       int           `mapstructure:"max_conns_per_host"`      // 每个主机的连接总数上限（0表示不限）
+	IdleConnTimeout       time.Duration `mapstructure:"idle_conn_timeout"`       // 空闲连接保留时长
+	DialTimeout           time.Duration `mapstructure:"dial_timeout"`            // 建立TCP连接的超时
+	TLSHandshakeTimeout   time.Duration `mapstructure:"tls_handshake_timeout"`   // TLS握手超时
+	ResponseHeaderTimeout time.Duration `mapstructure:"response_header_timeout"` // 发送请求后等待响应头的超时（0表示不限）
+	DisableHTTP2          bool          `mapstructure:"disable_http2"`           // 关闭HTTP/2，只使用HTTP/1.1
+	Proxy                 string        `mapstructure:"proxy"`                   // 代理地址：http://、https:// 或 socks5://（为空时使用环境变量 HTTPS_PROXY 等）
+	CAFiles               []string      `mapstructure:"ca_files"`                // 追加信任的CA证书（PEM），用于自签名的私有部署
+}
+
+// ModelConfig 模型元数据（/v1/models 返回的扩展信息）
+type ModelConfig struct {
+	OwnedBy         string       `mapstructure:"owned_by"`          // 模型所属组织，如 zhipuai
+	ContextWindow   int          `mapstructure:"context_window"`    // 上下文窗口（token）
+	MaxOutputTokens int          `mapstructure:"max_output_tokens"` // 单次最大输出（token）
+	Pricing         ModelPricing `mapstructure:"pricing"`           // 价格
+	Capabilities    []string     `mapstructure:"capabilities"`      // 能力，如 chat, stream, tools, vision
+	Tokenizer       string       `mapstructure:"tokenizer"`         // 分词器（tokenizer.vocabularies 中的名称，为空使用默认分词器）
+	Truncation      Truncation   `mapstructure:"truncation"`        // 超出上下文窗口时的对话截断策略
+}
+
+// Truncation 对话截断策略（请求超出模型上下文窗口时裁剪 messages）
+// system消息总是保留；一轮对话从user消息开始，到下一条user消息之前结束
+type Truncation struct {
+	Strategy         string `mapstructure:"strategy"`           // none（默认）, drop_oldest, keep_first_last, summarize
+	KeepFirst        int    `mapstructure:"keep_first"`         // keep_first_last：始终保留的最早轮数
+	KeepLast         int    `mapstructure:"keep_last"`          // 始终保留的最近轮数（至少为1）
+	SummaryModel     string `mapstructure:"summary_model"`      // summarize：生成摘要使用的模型（建议使用便宜的模型）
+	SummaryMaxTokens int    `mapstructure:"summary_max_tokens"` // summarize：摘要的最大token数（默认512）
+}
+
+// ModelPricing 模型价格（每百万token）
+type ModelPricing struct {
+	Input    float64 `mapstructure:"input"`    // 输入价格
+	Output   float64 `mapstructure:"output"`   // 输出价格
+	Currency string  `mapstructure:"currency"` // 货币，如 CNY, USD
 }
 
 // RouterConfig 路由配置
@@ -43,3 +143,139 @@ type LoggingConfig struct {
 	MaxBackups int    `mapstructure:"max_backups"` // 最大备份数
 	MaxAge     int    `mapstructure:"max_age"`     // 保留天数
 }
+
+// MetricsConfig Prometheus指标配置
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // 是否暴露指标接口
+	Path    string `mapstructure:"path"`    // 指标接口路径，如 /metrics
+}
+
+// TracingConfig 链路追踪配置（OpenTelemetry）
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`      // 是否导出trace
+	Endpoint    string  `mapstructure:"endpoint"`     // OTLP/HTTP 接收地址，如 localhost:4318
+	Insecure    bool    `mapstructure:"insecure"`     // 是否使用明文HTTP
+	ServiceName string  `mapstructure:"service_name"` // 服务名称
+	SampleRatio float64 `mapstructure:"sample_ratio"` // 根span采样比例（0.0 - 1.0）
+}
+
+// AuditConfig 请求/响应审计日志配置
+type AuditConfig struct {
+	Enabled        bool              `mapstructure:"enabled"`         // 是否记录审计日志
+	Sink           string            `mapstructure:"sink"`            // file, rotating_file, webhook
+	FilePath       string            `mapstructure:"file_path"`       // JSONL文件路径（file, rotating_file）
+	MaxSize        int               `mapstructure:"max_size"`        // MB（rotating_file）
+	MaxBackups     int               `mapstructure:"max_backups"`     // 最大备份数（rotating_file）
+	MaxAge         int               `mapstructure:"max_age"`         // 保留天数（rotating_file）
+	WebhookURL     string            `mapstructure:"webhook_url"`     // 接收地址（webhook）
+	WebhookTimeout time.Duration     `mapstructure:"webhook_timeout"` // 单次请求超时（webhook）
+	WebhookHeaders map[string]string `mapstructure:"webhook_headers"` // 额外请求头（webhook）
+	RedactContent  bool              `mapstructure:"redact_content"`  // 是否脱敏消息内容
+	BufferSize     int               `mapstructure:"buffer_size"`     // 异步写入队列长度
+}
+
+// CacheConfig 响应缓存配置
+type CacheConfig struct {
+	Enabled           bool          `mapstructure:"enabled"`            // 是否启用响应缓存
+	Backend           string        `mapstructure:"backend"`            // memory, disk
+	TTL               time.Duration `mapstructure:"ttl"`                // 条目有效期（0表示不过期）
+	MaxEntries        int           `mapstructure:"max_entries"`        // 最大条目数，超出后按LRU淘汰
+	Dir               string        `mapstructure:"dir"`                // 缓存目录（disk）
+	DeterministicOnly bool          `mapstructure:"deterministic_only"` // 只缓存 temperature=0 的请求
+
+	Semantic SemanticCacheConfig `mapstructure:"semantic"` // 语义缓存
+}
+
+// SemanticCacheConfig 语义缓存配置
+type SemanticCacheConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`        // 是否启用语义缓存
+	Endpoint     string        `mapstructure:"endpoint"`       // OpenAI兼容的向量接口地址
+	APIKey       string        `mapstructure:"api_key"`        // 向量接口API密钥
+	Model        string        `mapstructure:"model"`          // 向量模型名称
+	Timeout      time.Duration `mapstructure:"timeout"`        // 向量化请求超时
+	Index        string        `mapstructure:"index"`          // 向量索引：brute_force
+	Threshold    float64       `mapstructure:"threshold"`      // 相似度阈值（余弦相似度，0.0 - 1.0）
+	MaxEntries   int           `mapstructure:"max_entries"`    // 最大向量数
+	ScopeByModel bool          `mapstructure:"scope_by_model"` // 是否按模型隔离
+	ScopeByKey   bool          `mapstructure:"scope_by_key"`   // 是否按调用方API Key隔离
+}
+
+// AuthConfig 网关API Key鉴权配置
+type AuthConfig struct {
+	Enabled bool           `mapstructure:"enabled"` // 是否要求调用方携带API Key
+	Keys    []APIKeyConfig `mapstructure:"keys"`    // 允许访问的API Key
+}
+
+// APIKeyConfig 单个调用方API Key
+type APIKeyConfig struct {
+	Name   string   `mapstructure:"name"`   // 调用方名称（用于日志）
+	Key    string   `mapstructure:"key"`    // Bearer token
+	Models []string `mapstructure:"models"` // 允许访问的模型（为空表示全部）
+}
+
+// GetModel 获取模型元数据
+// viper会将map的key转为小写，这里先按原名查找，再按小写查找
+func (c *Config) GetModel(id string) (ModelConfig, bool) {
+	if m, ok := c.Models[id]; ok {
+		return m, true
+	}
+	m, ok := c.Models[strings.ToLower(id)]
+	return m, ok
+}
+
+// MediaConfig 多模态内容配置（图片、音频）
+type MediaConfig struct {
+	MaxImageSize      int           `mapstructure:"max_image_size"`      // 单张图片最大大小（MB，解码后）
+	MaxAudioSize      int           `mapstructure:"max_audio_size"`      // 单段音频最大大小（MB，解码后）
+	MaxParts          int           `mapstructure:"max_parts"`           // 单个请求最多的图片/音频/文件片段数
+	FetchRemoteImages bool          `mapstructure:"fetch_remote_images"` // 是否下载远程图片并内联为data URL
+	FetchTimeout      time.Duration `mapstructure:"fetch_timeout"`       // 下载远程图片的超时时间
+}
+
+// StructuredConfig 结构化输出配置（response_format）
+type StructuredConfig struct {
+	MaxRetries int `mapstructure:"max_retries"` // 输出不符合格式时的最大重试次数（0表示不重试）
+}
+
+// HealthConfig 上游健康探测配置（/readyz 和路由使用探测结果）
+type HealthConfig struct {
+	Interval        time.Duration `mapstructure:"interval"`         // 探测间隔（0表示不探测，/readyz 只反映是否正在关闭）
+	Timeout         time.Duration `mapstructure:"timeout"`          // 单个适配器的探测超时
+	RejectUnhealthy bool          `mapstructure:"reject_unhealthy"` // 路由到探测失败的适配器时直接返回503，不再等待上游超时
+}
+
+// ResponsesConfig Responses API 配置（/v1/responses）
+type ResponsesConfig struct {
+	Dir string        `mapstructure:"dir"` // 响应存储目录（previous_response_id 据此重建对话）
+	TTL time.Duration `mapstructure:"ttl"` // 存储的响应保留时间（0表示永久保留）
+}
+
+// BatchConfig Batch API 配置（/v1/files、/v1/batches）
+type BatchConfig struct {
+	Dir               string                        `mapstructure:"dir"`                 // 文件和批处理任务的存储目录（重启后继续未完成的任务）
+	MaxFileSize       int                           `mapstructure:"max_file_size"`       // 上传文件最大大小（MB）
+	MaxRequests       int                           `mapstructure:"max_requests"`        // 单个批处理最多的请求数
+	Concurrency       int                           `mapstructure:"concurrency"`         // 每个适配器同时处理的批处理请求数（所有任务共享）
+	RequestsPerMinute int                           `mapstructure:"requests_per_minute"` // 每个适配器每分钟最多发出的批处理请求数（0表示不限制）
+	MaxRetries        int                           `mapstructure:"max_retries"`         // 限流、上游故障时的最大重试次数
+	RetryBackoff      time.Duration                 `mapstructure:"retry_backoff"`       // 第一次重试前的等待时间，之后每次翻倍
+	Adapters          map[string]BatchAdapterConfig `mapstructure:"adapters"`            // 按适配器覆盖并发数和速率
+}
+
+// BatchAdapterConfig 单个适配器的批处理限制（0表示使用 batch 中的默认值）
+type BatchAdapterConfig struct {
+	Concurrency       int `mapstructure:"concurrency"`         // 同时处理的请求数
+	RequestsPerMinute int `mapstructure:"requests_per_minute"` // 每分钟最多发出的请求数
+}
+
+// TokenizerConfig 本地分词器配置（token计数、上下文窗口校验）
+type TokenizerConfig struct {
+	Default      string                      `mapstructure:"default"`      // 模型未指定分词器时使用的词表（为空表示按字符估算）
+	Vocabularies map[string]VocabularyConfig `mapstructure:"vocabularies"` // 词表名称 -> 词表配置
+}
+
+// VocabularyConfig BPE词表配置
+type VocabularyConfig struct {
+	Path    string `mapstructure:"path"`    // tiktoken格式的词表文件（每行：base64编码的token 序号）
+	Pattern string `mapstructure:"pattern"` // 预分词规则：cl100k（默认）
+}
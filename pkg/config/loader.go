@@ -2,20 +2,42 @@ package config
 
 import (
 	"fmt"
-	"os"
+	"net/url"
+	"strings"
+	"sync/atomic"
 
 	"github.com/spf13/viper"
+	"go.uber.org/zap"
 )
 
-var (
-	// GlobalConfig 全局配置实例
-	GlobalConfig *Config
-)
+// globalConfig 全局配置实例
+// 使用atomic.Pointer保存：热加载时整体替换，读取方无需加锁
+var globalConfig atomic.Pointer[Config]
+
+// GlobalConfig 启动时加载的配置实例（只在 Load 时设置，热加载不会更新）
+//
+// Deprecated: 不反映热加载后的配置，请使用 GetConfig
+var GlobalConfig *Config
 
 // Load 加载配置文件
 // configPath: 配置文件路径，如 "configs/config.yaml"
 // 返回: Config实例和错误信息
 func Load(configPath string) (*Config, error) {
+	cfg, err := load(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 保存到全局变量
+	globalConfig.Store(cfg)
+	GlobalConfig = cfg
+
+	return cfg, nil
+}
+
+// load 读取、解析并验证配置文件，不修改全局配置
+// 供 Load 和热加载共用
+func load(configPath string) (*Config, error) {
 	v := viper.New()
 
 	// 1. 设置配置文件路径
@@ -40,12 +62,12 @@ func Load(configPath string) (*Config, error) {
 	if err := v.ReadInConfig(); err != nil {
 		// 如果配置文件不存在，只使用环境变量和默认值
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			fmt.Fprintf(os.Stderr, "Warning: Config file not found, using environment variables and defaults\n")
+			zap.L().Warn("Config file not found, using environment variables and defaults")
 		} else {
 			return nil, fmt.Errorf("failed to read config file: %w", err)
 		}
 	} else {
-		fmt.Printf("Using config file: %s\n", v.ConfigFileUsed())
+		zap.L().Info("Using config file", zap.String("path", v.ConfigFileUsed()))
 	}
 
 	// 5. 解析到Config结构体
@@ -59,9 +81,6 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
-	// 保存到全局变量
-	GlobalConfig = cfg
-
 	return cfg, nil
 }
 
@@ -71,8 +90,18 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.host", "0.0.0.0")
 	v.SetDefault("server.port", 8080)
 	v.SetDefault("server.mode", "release")
+	v.SetDefault("server.read_header_timeout", "10s")
 	v.SetDefault("server.read_timeout", "30s")
 	v.SetDefault("server.write_timeout", "30s")
+	v.SetDefault("server.idle_timeout", "120s")
+	v.SetDefault("server.stream_write_timeout", "30s")
+	v.SetDefault("server.max_stream_duration", "10m")
+	v.SetDefault("server.shutdown_delay", "0s")
+	v.SetDefault("server.shutdown_timeout", "30s")
+	v.SetDefault("server.http2", true)
+	v.SetDefault("server.tls.enabled", false)
+	v.SetDefault("server.tls.min_version", "1.2")
+	v.SetDefault("server.tls.client_auth", "none")
 
 	// Logging defaults
 	v.SetDefault("logging.level", "info")
@@ -85,6 +114,76 @@ func setDefaults(v *viper.Viper) {
 
 	// Router defaults
 	v.SetDefault("router.default_strategy", "simple")
+
+	// Metrics defaults
+	v.SetDefault("metrics.enabled", true)
+	v.SetDefault("metrics.path", "/metrics")
+
+	// Tracing defaults
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.endpoint", "localhost:4318")
+	v.SetDefault("tracing.insecure", true)
+	v.SetDefault("tracing.service_name", "prism")
+	v.SetDefault("tracing.sample_ratio", 1.0)
+
+	// Audit defaults
+	v.SetDefault("audit.enabled", false)
+	v.SetDefault("audit.sink", "rotating_file")
+	v.SetDefault("audit.file_path", "./logs/audit.jsonl")
+	v.SetDefault("audit.max_size", 100)
+	v.SetDefault("audit.max_backups", 10)
+	v.SetDefault("audit.max_age", 30)
+	v.SetDefault("audit.webhook_timeout", "5s")
+	v.SetDefault("audit.redact_content", true)
+	v.SetDefault("audit.buffer_size", 1024)
+
+	// Auth defaults
+	v.SetDefault("auth.enabled", false)
+
+	// Media defaults
+	v.SetDefault("media.max_image_size", 10)
+	v.SetDefault("media.max_audio_size", 25)
+	v.SetDefault("media.max_parts", 20)
+	v.SetDefault("media.fetch_remote_images", false)
+	v.SetDefault("media.fetch_timeout", "10s")
+
+	// Structured output defaults
+	v.SetDefault("structured_output.max_retries", 1)
+
+	// Health defaults
+	v.SetDefault("health.interval", "30s")
+	v.SetDefault("health.timeout", "5s")
+	v.SetDefault("health.reject_unhealthy", true)
+
+	// Responses defaults
+	v.SetDefault("responses.dir", "./data/responses")
+	v.SetDefault("responses.ttl", "720h")
+
+	// Batch defaults
+	v.SetDefault("batch.dir", "./data/batch")
+	v.SetDefault("batch.max_file_size", 200)
+	v.SetDefault("batch.max_requests", 50000)
+	v.SetDefault("batch.concurrency", 4)
+	v.SetDefault("batch.requests_per_minute", 0)
+	v.SetDefault("batch.max_retries", 3)
+	v.SetDefault("batch.retry_backoff", "2s")
+
+	// Cache defaults
+	v.SetDefault("cache.enabled", false)
+	v.SetDefault("cache.backend", "memory")
+	v.SetDefault("cache.ttl", "1h")
+	v.SetDefault("cache.max_entries", 10000)
+	v.SetDefault("cache.dir", "./data/cache")
+	v.SetDefault("cache.deterministic_only", true)
+	v.SetDefault("cache.semantic.enabled", false)
+	v.SetDefault("cache.semantic.endpoint", "https://open.bigmodel.cn/api/paas/v4/embeddings")
+	v.SetDefault("cache.semantic.model", "embedding-3")
+	v.SetDefault("cache.semantic.timeout", "10s")
+	v.SetDefault("cache.semantic.index", "brute_force")
+	v.SetDefault("cache.semantic.threshold", 0.95)
+	v.SetDefault("cache.semantic.max_entries", 10000)
+	v.SetDefault("cache.semantic.scope_by_model", true)
+	v.SetDefault("cache.semantic.scope_by_key", true)
 }
 
 // bindEnvVars 显式绑定环境变量
@@ -96,6 +195,14 @@ func bindEnvVars(v *viper.Viper) {
 	v.BindEnv("server.mode", "SERVER_MODE")
 	v.BindEnv("server.read_timeout", "SERVER_READ_TIMEOUT")
 	v.BindEnv("server.write_timeout", "SERVER_WRITE_TIMEOUT")
+	v.BindEnv("server.stream_write_timeout", "SERVER_STREAM_WRITE_TIMEOUT")
+	v.BindEnv("server.max_stream_duration", "SERVER_MAX_STREAM_DURATION")
+	v.BindEnv("server.shutdown_delay", "SERVER_SHUTDOWN_DELAY")
+	v.BindEnv("server.shutdown_timeout", "SERVER_SHUTDOWN_TIMEOUT")
+	v.BindEnv("server.unix_socket", "SERVER_UNIX_SOCKET")
+	v.BindEnv("server.tls.enabled", "SERVER_TLS_ENABLED")
+	v.BindEnv("server.tls.cert_file", "SERVER_TLS_CERT_FILE")
+	v.BindEnv("server.tls.key_file", "SERVER_TLS_KEY_FILE")
 
 	// Logging 配置绑定
 	v.BindEnv("logging.level", "LOG_LEVEL")
@@ -106,12 +213,57 @@ func bindEnvVars(v *viper.Viper) {
 	// Router 配置绑定
 	v.BindEnv("router.default_strategy", "ROUTER_STRATEGY")
 
+	// Metrics 配置绑定
+	v.BindEnv("metrics.enabled", "METRICS_ENABLED")
+	v.BindEnv("metrics.path", "METRICS_PATH")
+
+	// Tracing 配置绑定
+	v.BindEnv("tracing.enabled", "TRACING_ENABLED")
+	v.BindEnv("tracing.endpoint", "TRACING_ENDPOINT")
+	v.BindEnv("tracing.service_name", "OTEL_SERVICE_NAME")
+
+	// Audit 配置绑定
+	v.BindEnv("audit.enabled", "AUDIT_ENABLED")
+	v.BindEnv("audit.sink", "AUDIT_SINK")
+	v.BindEnv("audit.file_path", "AUDIT_FILE_PATH")
+	v.BindEnv("audit.webhook_url", "AUDIT_WEBHOOK_URL")
+	v.BindEnv("audit.redact_content", "AUDIT_REDACT_CONTENT")
+
+	// Auth 配置绑定（Key列表只能在配置文件中设置）
+	v.BindEnv("auth.enabled", "AUTH_ENABLED")
+
+	// Media 配置绑定
+	v.BindEnv("media.fetch_remote_images", "MEDIA_FETCH_REMOTE_IMAGES")
+
+	// Cache 配置绑定
+	v.BindEnv("cache.enabled", "CACHE_ENABLED")
+	v.BindEnv("cache.backend", "CACHE_BACKEND")
+	v.BindEnv("cache.ttl", "CACHE_TTL")
+	v.BindEnv("cache.dir", "CACHE_DIR")
+	v.BindEnv("cache.semantic.enabled", "CACHE_SEMANTIC_ENABLED")
+	v.BindEnv("cache.semantic.endpoint", "CACHE_SEMANTIC_ENDPOINT")
+	v.BindEnv("cache.semantic.api_key", "CACHE_SEMANTIC_API_KEY")
+
+	// Responses 配置绑定
+	v.BindEnv("responses.dir", "RESPONSES_DIR")
+	v.BindEnv("responses.ttl", "RESPONSES_TTL")
+
+	// Batch 配置绑定
+	v.BindEnv("batch.dir", "BATCH_DIR")
+	v.BindEnv("batch.concurrency", "BATCH_CONCURRENCY")
+	v.BindEnv("batch.requests_per_minute", "BATCH_REQUESTS_PER_MINUTE")
+
 	// Adapter 配置绑定（API密钥）
 	// GLM 适配器
 	v.BindEnv("adapters.glm.api_key", "GLM_API_KEY")
 	v.BindEnv("adapters.glm.base_url", "GLM_BASE_URL")
 	v.BindEnv("adapters.glm.timeout", "GLM_TIMEOUT")
 
+	// OpenAI兼容适配器
+	v.BindEnv("adapters.openai.api_key", "OPENAI_API_KEY")
+	v.BindEnv("adapters.openai.base_url", "OPENAI_BASE_URL")
+	v.BindEnv("adapters.openai.timeout", "OPENAI_TIMEOUT")
+
 	// 豆包适配器（预留）
 	v.BindEnv("adapters.doubao.api_key", "DOUBAO_API_KEY")
 	v.BindEnv("adapters.doubao.base_url", "DOUBAO_BASE_URL")
@@ -134,6 +286,19 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("invalid server mode: %s (must be 'debug' or 'release')", cfg.Server.Mode)
 	}
 
+	if cfg.Server.ReadHeaderTimeout < 0 || cfg.Server.ReadTimeout < 0 || cfg.Server.WriteTimeout < 0 ||
+		cfg.Server.IdleTimeout < 0 || cfg.Server.StreamWriteTimeout < 0 || cfg.Server.MaxStreamDuration < 0 {
+		return fmt.Errorf("invalid server timeouts: must not be negative")
+	}
+
+	if cfg.Server.ShutdownDelay < 0 || cfg.Server.ShutdownTimeout <= 0 {
+		return fmt.Errorf("invalid server shutdown settings: shutdown_delay must not be negative and shutdown_timeout must be positive")
+	}
+
+	if err := validateServerTLS(&cfg.Server); err != nil {
+		return err
+	}
+
 	// 验证适配器配置
 	if len(cfg.Adapters) == 0 {
 		return fmt.Errorf("no adapters configured")
@@ -149,6 +314,12 @@ func validate(cfg *Config) error {
 		if len(adapter.Models) == 0 {
 			return fmt.Errorf("adapter '%s' has no models configured", name)
 		}
+		if adapter.Timeout < 0 || adapter.StreamIdleTimeout < 0 {
+			return fmt.Errorf("adapter '%s' timeouts must not be negative", name)
+		}
+		if err := validateTransport(adapter.Transport); err != nil {
+			return fmt.Errorf("adapter '%s' transport: %w", name, err)
+		}
 	}
 
 	// 验证日志配置
@@ -157,10 +328,254 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("invalid logging level: %s", cfg.Logging.Level)
 	}
 
+	if cfg.Logging.Encoding != "json" && cfg.Logging.Encoding != "console" {
+		return fmt.Errorf("invalid logging encoding: %s (must be 'json' or 'console')", cfg.Logging.Encoding)
+	}
+
+	if cfg.Logging.Output != "stdout" && cfg.Logging.Output != "file" {
+		return fmt.Errorf("invalid logging output: %s (must be 'stdout' or 'file')", cfg.Logging.Output)
+	}
+
+	// 验证指标配置
+	if cfg.Metrics.Enabled && !strings.HasPrefix(cfg.Metrics.Path, "/") {
+		return fmt.Errorf("invalid metrics path: %s (must start with '/')", cfg.Metrics.Path)
+	}
+
+	// 验证链路追踪配置
+	if cfg.Tracing.Enabled {
+		if cfg.Tracing.Endpoint == "" {
+			return fmt.Errorf("tracing endpoint is required when tracing is enabled")
+		}
+		if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+			return fmt.Errorf("invalid tracing sample ratio: %v (must be between 0 and 1)", cfg.Tracing.SampleRatio)
+		}
+	}
+
+	// 验证审计配置
+	if cfg.Audit.Enabled {
+		switch cfg.Audit.Sink {
+		case "file", "rotating_file":
+			if cfg.Audit.FilePath == "" {
+				return fmt.Errorf("audit file_path is required for sink '%s'", cfg.Audit.Sink)
+			}
+		case "webhook":
+			if cfg.Audit.WebhookURL == "" {
+				return fmt.Errorf("audit webhook_url is required for sink 'webhook'")
+			}
+		default:
+			return fmt.Errorf("invalid audit sink: %s (must be 'file', 'rotating_file' or 'webhook')", cfg.Audit.Sink)
+		}
+	}
+
+	// 验证鉴权配置
+	if cfg.Auth.Enabled {
+		if len(cfg.Auth.Keys) == 0 {
+			return fmt.Errorf("at least one auth key is required when auth is enabled")
+		}
+		seen := make(map[string]bool, len(cfg.Auth.Keys))
+		for i, key := range cfg.Auth.Keys {
+			if key.Key == "" {
+				return fmt.Errorf("auth key #%d (%s) is empty", i, key.Name)
+			}
+			if seen[key.Key] {
+				return fmt.Errorf("auth key #%d (%s) is duplicated", i, key.Name)
+			}
+			seen[key.Key] = true
+		}
+	}
+
+	// 验证多模态内容配置
+	if cfg.Media.MaxImageSize < 0 || cfg.Media.MaxAudioSize < 0 || cfg.Media.MaxParts < 0 {
+		return fmt.Errorf("media size limits cannot be negative")
+	}
+
+	// 验证结构化输出配置
+	if cfg.Health.Interval < 0 || (cfg.Health.Interval > 0 && cfg.Health.Timeout <= 0) {
+		return fmt.Errorf("invalid health settings: interval must not be negative and timeout must be positive")
+	}
+
+	if cfg.Structured.MaxRetries < 0 {
+		return fmt.Errorf("invalid structured_output max_retries: %d", cfg.Structured.MaxRetries)
+	}
+
+	// 验证 Responses API 配置
+	if cfg.Responses.Dir == "" {
+		return fmt.Errorf("responses dir is required")
+	}
+	if cfg.Responses.TTL < 0 {
+		return fmt.Errorf("invalid responses ttl: %v", cfg.Responses.TTL)
+	}
+
+	// 验证 Batch API 配置
+	if err := validateBatch(cfg.Batch); err != nil {
+		return err
+	}
+
+	// 验证分词器配置（viper会将map的key转为小写，引用词表时忽略大小写）
+	for name, vocab := range cfg.Tokenizer.Vocabularies {
+		if vocab.Path == "" {
+			return fmt.Errorf("tokenizer vocabulary %s: path is required", name)
+		}
+		if vocab.Pattern != "" && vocab.Pattern != "cl100k" {
+			return fmt.Errorf("tokenizer vocabulary %s: invalid pattern %s (must be 'cl100k')", name, vocab.Pattern)
+		}
+	}
+	if name := cfg.Tokenizer.Default; name != "" {
+		if _, ok := cfg.Tokenizer.Vocabularies[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("tokenizer default %s is not defined in tokenizer.vocabularies", name)
+		}
+	}
+	for id, m := range cfg.Models {
+		if m.Tokenizer == "" {
+			continue
+		}
+		if _, ok := cfg.Tokenizer.Vocabularies[strings.ToLower(m.Tokenizer)]; !ok {
+			return fmt.Errorf("model %s: tokenizer %s is not defined in tokenizer.vocabularies", id, m.Tokenizer)
+		}
+	}
+
+	// 验证对话截断策略
+	for id, m := range cfg.Models {
+		t := m.Truncation
+		switch t.Strategy {
+		case "", "none", "drop_oldest", "keep_first_last":
+		case "summarize":
+			if t.SummaryModel == "" {
+				return fmt.Errorf("model %s: truncation summary_model is required for strategy 'summarize'", id)
+			}
+		default:
+			return fmt.Errorf("model %s: invalid truncation strategy %s (must be 'none', 'drop_oldest', 'keep_first_last' or 'summarize')", id, t.Strategy)
+		}
+		if t.KeepFirst < 0 || t.KeepLast < 0 || t.SummaryMaxTokens < 0 {
+			return fmt.Errorf("model %s: truncation limits cannot be negative", id)
+		}
+		if t.Strategy != "" && t.Strategy != "none" && m.ContextWindow <= 0 {
+			return fmt.Errorf("model %s: truncation requires context_window", id)
+		}
+	}
+
+	// 验证缓存配置
+	if cfg.Cache.Enabled {
+		switch cfg.Cache.Backend {
+		case "memory":
+		case "disk":
+			if cfg.Cache.Dir == "" {
+				return fmt.Errorf("cache dir is required for backend 'disk'")
+			}
+		default:
+			return fmt.Errorf("invalid cache backend: %s (must be 'memory' or 'disk')", cfg.Cache.Backend)
+		}
+		if cfg.Cache.TTL < 0 {
+			return fmt.Errorf("invalid cache ttl: %v", cfg.Cache.TTL)
+		}
+
+		if sem := cfg.Cache.Semantic; sem.Enabled {
+			if sem.Endpoint == "" || sem.Model == "" {
+				return fmt.Errorf("cache semantic endpoint and model are required when semantic cache is enabled")
+			}
+			if sem.Index != "brute_force" {
+				return fmt.Errorf("invalid cache semantic index: %s (must be 'brute_force')", sem.Index)
+			}
+			if sem.Threshold <= 0 || sem.Threshold > 1 {
+				return fmt.Errorf("invalid cache semantic threshold: %v (must be between 0 and 1)", sem.Threshold)
+			}
+		}
+	}
+
 	return nil
 }
 
 // GetConfig 获取全局配置实例
 func GetConfig() *Config {
-	return GlobalConfig
+	return globalConfig.Load()
+}
+
+// validateServerTLS 验证服务器TLS配置
+func validateServerTLS(server *ServerConfig) error {
+	tls := server.TLS
+	if !tls.Enabled {
+		if tls.HTTP3 {
+			return fmt.Errorf("server tls http3 requires tls to be enabled")
+		}
+		return nil
+	}
+
+	if tls.CertFile == "" || tls.KeyFile == "" {
+		return fmt.Errorf("server tls requires cert_file and key_file")
+	}
+
+	switch tls.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		return fmt.Errorf("invalid server tls min_version: %s (must be '1.2' or '1.3')", tls.MinVersion)
+	}
+
+	switch strings.ToLower(tls.ClientAuth) {
+	case "", "none":
+	case "optional", "require":
+		if len(tls.ClientCAFiles) == 0 {
+			return fmt.Errorf("server tls client_auth %s requires client_ca_files", tls.ClientAuth)
+		}
+	default:
+		return fmt.Errorf("invalid server tls client_auth: %s (must be 'none', 'optional' or 'require')", tls.ClientAuth)
+	}
+
+	if tls.HTTP3 && server.UnixSocket != "" {
+		return fmt.Errorf("server tls http3 cannot be used with unix_socket")
+	}
+	return nil
+}
+
+// validateTransport 验证上游连接池配置
+func validateTransport(t TransportConfig) error {
+	if t.MaxIdleConns < 0 || t.MaxIdleConnsPerHost < 0 || t.MaxConnsPerHost < 0 {
+		return fmt.Errorf("connection limits must not be negative")
+	}
+	if t.IdleConnTimeout < 0 || t.DialTimeout < 0 || t.TLSHandshakeTimeout < 0 || t.ResponseHeaderTimeout < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+
+	if t.Proxy != "" {
+		u, err := url.Parse(t.Proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("invalid proxy scheme: %q (must be http, https or socks5)", u.Scheme)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("proxy %q missing host", t.Proxy)
+		}
+	}
+
+	for _, file := range t.CAFiles {
+		if file == "" {
+			return fmt.Errorf("ca_files contains an empty path")
+		}
+	}
+	return nil
+}
+
+// validateBatch 校验批处理配置
+func validateBatch(cfg BatchConfig) error {
+	if cfg.Dir == "" {
+		return fmt.Errorf("batch dir is required")
+	}
+	if cfg.MaxFileSize <= 0 || cfg.MaxRequests <= 0 {
+		return fmt.Errorf("batch max_file_size and max_requests must be positive")
+	}
+	if cfg.Concurrency <= 0 {
+		return fmt.Errorf("invalid batch concurrency: %d (must be positive)", cfg.Concurrency)
+	}
+	if cfg.RequestsPerMinute < 0 || cfg.MaxRetries < 0 || cfg.RetryBackoff < 0 {
+		return fmt.Errorf("batch requests_per_minute, max_retries and retry_backoff cannot be negative")
+	}
+	for name, adapter := range cfg.Adapters {
+		if adapter.Concurrency < 0 || adapter.RequestsPerMinute < 0 {
+			return fmt.Errorf("batch adapter %s: concurrency and requests_per_minute cannot be negative", name)
+		}
+	}
+	return nil
 }
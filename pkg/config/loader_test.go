@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeConfig 在临时目录写入配置文件，返回文件路径
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const minimalConfig = `
+adapters:
+  glm:
+    api_key: "test-key"
+    base_url: "https://example.com/v1"
+    models: ["glm-4"]
+`
+
+func TestLoadUpdatesGlobalConfig(t *testing.T) {
+	cfg, err := Load(writeConfig(t, minimalConfig))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if GetConfig() != cfg {
+		t.Error("GetConfig does not return the loaded config")
+	}
+	if GlobalConfig != cfg {
+		t.Error("GlobalConfig does not point to the loaded config")
+	}
+}
+
+func TestReloadUpdatesGlobalConfig(t *testing.T) {
+	path := writeConfig(t, minimalConfig)
+	if _, err := Load(path); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(minimalConfig+"\nserver:\n  port: 9999\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	reload(path, nil)
+
+	if got := GetConfig().Server.Port; got != 9999 {
+		t.Errorf("GetConfig().Server.Port = %d, want 9999", got)
+	}
+	// GlobalConfig 只在 Load 时设置，热加载时不写入（避免与并发读取产生数据竞争）
+	if GlobalConfig == GetConfig() || GlobalConfig.Server.Port == 9999 {
+		t.Error("GlobalConfig written on reload")
+	}
+}
+
+func TestReloadKeepsConfigOnInvalidFile(t *testing.T) {
+	path := writeConfig(t, minimalConfig)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("server:\n  port: 8080\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	reload(path, nil)
+
+	if GetConfig() != cfg || GlobalConfig != cfg {
+		t.Error("invalid config replaced the current config")
+	}
+}
+
+func TestHealthDefaults(t *testing.T) {
+	cfg, err := Load(writeConfig(t, minimalConfig))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Health.Interval != 30*time.Second || cfg.Health.Timeout != 5*time.Second {
+		t.Errorf("Health = %+v, want 30s interval and 5s timeout", cfg.Health)
+	}
+	if !cfg.Health.RejectUnhealthy {
+		t.Error("Health.RejectUnhealthy defaults to false, want true")
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// reloadDebounce 文件变更事件的合并窗口
+// 编辑器保存文件时通常会连续触发多次写入/重命名事件，合并后只加载一次
+const reloadDebounce = 200 * time.Millisecond
+
+// ReloadFunc 新配置验证通过后的回调
+// 返回error表示新配置无法应用（如适配器创建失败），此时保留当前配置
+type ReloadFunc func(cfg *Config) error
+
+// Watch 监听配置文件变化并热加载
+// 参数：
+//   - ctx: 上下文（取消后停止监听）
+//   - configPath: 配置文件路径，如 "configs/config.yaml"
+//   - onReload: 新配置通过 validate 后的回调，负责替换运行中的组件
+//
+// 返回：
+//   - error: 创建文件监听器失败时返回错误
+//
+// 说明：
+//   - 监听的是配置文件所在目录而非文件本身，
+//     这样编辑器"写临时文件再重命名"的保存方式也能被捕获
+//   - 新配置读取、解析或验证失败时只记录日志，继续使用当前配置
+func Watch(ctx context.Context, configPath string, onReload ReloadFunc) error {
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return fmt.Errorf("resolve config path failed: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create config watcher failed: %w", err)
+	}
+
+	if err := watcher.Add(filepath.Dir(absPath)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("watch config dir failed: %w", err)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		// debounce 定时器：最后一次事件之后 reloadDebounce 才真正加载
+		var debounce *time.Timer
+		reloadCh := make(chan struct{}, 1)
+
+		for {
+			select {
+			case <-ctx.Done():
+				if debounce != nil {
+					debounce.Stop()
+				}
+				return
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != absPath {
+					continue
+				}
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+					continue
+				}
+
+				if debounce != nil {
+					debounce.Stop()
+				}
+				debounce = time.AfterFunc(reloadDebounce, func() {
+					select {
+					case reloadCh <- struct{}{}:
+					default:
+					}
+				})
+
+			case <-reloadCh:
+				reload(configPath, onReload)
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				zap.L().Warn("配置文件监听出错", zap.Error(err))
+			}
+		}
+	}()
+
+	return nil
+}
+
+// reload 重新加载配置文件并应用
+// 只有读取、验证和回调全部成功后才更新全局配置
+func reload(configPath string, onReload ReloadFunc) {
+	cfg, err := load(configPath)
+	if err != nil {
+		zap.L().Error("配置热加载被拒绝，继续使用当前配置",
+			zap.String("path", configPath),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if onReload != nil {
+		if err := onReload(cfg); err != nil {
+			zap.L().Error("配置热加载应用失败，继续使用当前配置",
+				zap.String("path", configPath),
+				zap.Error(err),
+			)
+			return
+		}
+	}
+
+	globalConfig.Store(cfg)
+	zap.L().Info("配置热加载成功", zap.String("path", configPath))
+}
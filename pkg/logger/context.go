@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// ctxKey context中存放请求级Logger的key
+type ctxKey struct{}
+
+// requestLogger 请求级Logger
+// 请求处理过程中各层（handler、adapter manager）会陆续补充字段，
+// 例如模型名称、实际使用的适配器、token用量，请求结束时统一输出
+type requestLogger struct {
+	base *zap.Logger
+
+	mu     sync.Mutex
+	fields []zap.Field
+}
+
+// NewContext 在context中创建请求级Logger
+// 参数：
+//   - ctx: 父context
+//   - l: 基础Logger（通常已带有request_id等字段）
+//
+// 返回：
+//   - context.Context: 携带请求级Logger的context
+func NewContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &requestLogger{base: l})
+}
+
+// FromContext 获取请求级Logger
+// 返回的Logger包含 AddFields 已补充的全部字段；
+// 如果context中没有请求级Logger，返回全局Logger
+//
+// 示例：
+//
+//	logger.FromContext(ctx).Warn("上游返回异常", zap.Error(err))
+func FromContext(ctx context.Context) *zap.Logger {
+	rl, ok := ctx.Value(ctxKey{}).(*requestLogger)
+	if !ok {
+		return zap.L()
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.base.With(rl.fields...)
+}
+
+// AddFields 为请求级Logger补充字段
+// 同名字段重复添加时保留最后一次的值
+// 如果context中没有请求级Logger则忽略
+func AddFields(ctx context.Context, fields ...zap.Field) {
+	rl, ok := ctx.Value(ctxKey{}).(*requestLogger)
+	if !ok {
+		return
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	for _, f := range fields {
+		replaced := false
+		for i := range rl.fields {
+			if rl.fields[i].Key == f.Key {
+				rl.fields[i] = f
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			rl.fields = append(rl.fields, f)
+		}
+	}
+}
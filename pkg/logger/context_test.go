@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFromContext(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	ctx := NewContext(context.Background(), zap.New(core).With(zap.String("request_id", "req-1")))
+
+	AddFields(ctx, zap.String("model", "glm-4"), zap.Int("prompt_tokens", 10))
+	AddFields(ctx, zap.Int("prompt_tokens", 12))
+	FromContext(ctx).Info("done")
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	want := map[string]any{"request_id": "req-1", "model": "glm-4", "prompt_tokens": int64(12)}
+	if len(fields) != len(want) {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s = %v, want %v", k, fields[k], v)
+		}
+	}
+}
+
+func TestFromContextWithoutLogger(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	restore := zap.ReplaceGlobals(zap.New(core))
+	defer restore()
+
+	ctx := context.Background()
+	AddFields(ctx, zap.String("model", "glm-4")) // 没有请求级Logger时忽略
+	FromContext(ctx).Info("global")
+
+	entries := logs.All()
+	if len(entries) != 1 || entries[0].Message != "global" {
+		t.Fatalf("entries = %v", entries)
+	}
+	if len(entries[0].Context) != 0 {
+		t.Errorf("context = %v, want none", entries[0].Context)
+	}
+}
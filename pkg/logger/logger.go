@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/AtSunset1/prism/pkg/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// level 全局日志级别
+// 使用AtomicLevel：配置热加载时可以直接调整级别，无需重建Logger
+var level = zap.NewAtomicLevelAt(zap.InfoLevel)
+
+// NewBootstrap 创建启动阶段使用的Logger
+// 配置文件加载之前还不知道日志配置，先用控制台格式输出到stderr
+func NewBootstrap() *zap.Logger {
+	encoderCfg := zap.NewDevelopmentEncoderConfig()
+	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	core := zapcore.NewCore(
+		zapcore.NewConsoleEncoder(encoderCfg),
+		zapcore.Lock(os.Stderr),
+		level,
+	)
+	return zap.New(core, zap.AddCaller())
+}
+
+// New 根据日志配置创建Logger
+// 参数：
+//   - cfg: 日志配置
+//
+// 返回：
+//   - *zap.Logger: Logger实例
+//   - error: 配置无效或日志文件无法创建时返回错误
+//
+// 示例：
+//
+//	l, err := logger.New(cfg.Logging)
+//	if err != nil {
+//	    panic(err)
+//	}
+//	zap.ReplaceGlobals(l)
+func New(cfg config.LoggingConfig) (*zap.Logger, error) {
+	lvl, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	encoder, err := newEncoder(cfg.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := newWriter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 全部校验通过后才调整全局级别，配置无效时保持原级别
+	SetLevel(lvl)
+	core := zapcore.NewCore(encoder, writer, level)
+	return zap.New(core, zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel)), nil
+}
+
+// ParseLevel 解析日志级别
+// 参数：
+//   - lvl: 日志级别（debug, info, warn, error）
+//
+// 返回：
+//   - zapcore.Level: 解析后的级别
+//   - error: 级别无效时返回错误
+func ParseLevel(lvl string) (zapcore.Level, error) {
+	var zapLevel zapcore.Level
+	if err := zapLevel.UnmarshalText([]byte(lvl)); err != nil {
+		return zapLevel, fmt.Errorf("invalid logging level: %s", lvl)
+	}
+	return zapLevel, nil
+}
+
+// SetLevel 调整全局日志级别（已创建的Logger立即生效）
+//
+// 示例：
+//
+//	lvl, err := logger.ParseLevel(cfg.Logging.Level)
+//	if err != nil {
+//	    return err
+//	}
+//	logger.SetLevel(lvl)
+func SetLevel(lvl zapcore.Level) {
+	level.SetLevel(lvl)
+}
+
+// newEncoder 根据编码方式创建Encoder
+//   - json：生产环境，便于日志系统采集
+//   - console：开发环境，便于人工阅读
+func newEncoder(encoding string) (zapcore.Encoder, error) {
+	switch encoding {
+	case "json", "":
+		encoderCfg := zap.NewProductionEncoderConfig()
+		encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		return zapcore.NewJSONEncoder(encoderCfg), nil
+	case "console":
+		encoderCfg := zap.NewDevelopmentEncoderConfig()
+		encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		return zapcore.NewConsoleEncoder(encoderCfg), nil
+	default:
+		return nil, fmt.Errorf("invalid logging encoding: %s", encoding)
+	}
+}
+
+// newWriter 根据输出方式创建WriteSyncer
+//   - stdout：标准输出
+//   - file：写入文件，按大小切割，按天数和备份数清理
+func newWriter(cfg config.LoggingConfig) (zapcore.WriteSyncer, error) {
+	switch cfg.Output {
+	case "stdout", "":
+		return zapcore.Lock(os.Stdout), nil
+	case "file":
+		if cfg.FilePath == "" {
+			return nil, fmt.Errorf("logging file_path is required when output is file")
+		}
+		if err := os.MkdirAll(filepath.Dir(cfg.FilePath), 0o755); err != nil {
+			return nil, fmt.Errorf("create log dir failed: %w", err)
+		}
+		return zapcore.AddSync(&lumberjack.Logger{
+			Filename:   cfg.FilePath,
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+			LocalTime:  true,
+		}), nil
+	default:
+		return nil, fmt.Errorf("invalid logging output: %s", cfg.Output)
+	}
+}
@@ -0,0 +1,160 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AtSunset1/prism/pkg/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// restoreLevel 测试结束后恢复全局日志级别
+func restoreLevel(t *testing.T) {
+	t.Helper()
+	old := level.Level()
+	t.Cleanup(func() { level.SetLevel(old) })
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    zapcore.Level
+		wantErr bool
+	}{
+		{in: "debug", want: zapcore.DebugLevel},
+		{in: "info", want: zapcore.InfoLevel},
+		{in: "warn", want: zapcore.WarnLevel},
+		{in: "error", want: zapcore.ErrorLevel},
+		{in: "WARN", want: zapcore.WarnLevel},
+		{in: "verbose", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	restoreLevel(t)
+
+	path := filepath.Join(t.TempDir(), "prism.log")
+	l, err := New(config.LoggingConfig{Level: "info", Encoding: "json", Output: "file", FilePath: path})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	l.Debug("hidden")
+	SetLevel(zapcore.DebugLevel)
+	l.Debug("shown")
+	SetLevel(zapcore.ErrorLevel)
+	l.Warn("hidden")
+	l.Sync()
+
+	var messages []string
+	for _, line := range readLines(t, path) {
+		messages = append(messages, line["msg"].(string))
+	}
+	if len(messages) != 1 || messages[0] != "shown" {
+		t.Errorf("messages = %v, want [shown]", messages)
+	}
+}
+
+func TestNew(t *testing.T) {
+	restoreLevel(t)
+
+	tests := []struct {
+		name    string
+		cfg     config.LoggingConfig
+		wantErr string
+	}{
+		{name: "defaults", cfg: config.LoggingConfig{Level: "info"}},
+		{name: "console", cfg: config.LoggingConfig{Level: "debug", Encoding: "console", Output: "stdout"}},
+		{name: "invalid level", cfg: config.LoggingConfig{Level: "verbose"}, wantErr: "invalid logging level"},
+		{name: "invalid encoding", cfg: config.LoggingConfig{Level: "info", Encoding: "xml"}, wantErr: "invalid logging encoding"},
+		{name: "invalid output", cfg: config.LoggingConfig{Level: "info", Output: "syslog"}, wantErr: "invalid logging output"},
+		{name: "file without path", cfg: config.LoggingConfig{Level: "info", Output: "file"}, wantErr: "file_path is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("New failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewInvalidKeepsLevel(t *testing.T) {
+	restoreLevel(t)
+	SetLevel(zapcore.WarnLevel)
+
+	if _, err := New(config.LoggingConfig{Level: "debug", Encoding: "xml"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := level.Level(); got != zapcore.WarnLevel {
+		t.Errorf("level = %v, want warn (unchanged)", got)
+	}
+}
+
+func TestNewFileOutput(t *testing.T) {
+	restoreLevel(t)
+
+	path := filepath.Join(t.TempDir(), "logs", "prism.log")
+	l, err := New(config.LoggingConfig{Level: "info", Encoding: "json", Output: "file", FilePath: path})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	l.Info("started", zap.String("addr", ":8080"))
+	l.Sync()
+
+	lines := readLines(t, path)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(lines))
+	}
+	line := lines[0]
+	if line["level"] != "info" || line["msg"] != "started" || line["addr"] != ":8080" {
+		t.Errorf("line = %v", line)
+	}
+	if _, ok := line["caller"]; !ok {
+		t.Error("caller missing")
+	}
+}
+
+// readLines 读取JSON格式的日志文件
+func readLines(t *testing.T, path string) []map[string]any {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open log file: %v", err)
+	}
+	defer f.Close()
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid log line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
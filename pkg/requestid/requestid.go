@@ -0,0 +1,59 @@
+// Package requestid 请求ID的生成、校验以及在context中的传递
+// 请求ID贯穿日志、审计、响应头和发往上游的请求，用于串联一次调用的全部记录
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Header 请求ID请求头/响应头
+const Header = "X-Request-ID"
+
+// maxLength 调用方传入的请求ID最大长度，超出时重新生成
+const maxLength = 128
+
+// ctxKey context中存放请求ID的key
+type ctxKey struct{}
+
+// New 生成请求ID（16字节随机数的十六进制）
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// Valid 判断调用方传入的请求ID是否可以直接使用
+// 只接受字母、数字和 - _ . : 组成的不超过128个字符的字符串，
+// 避免把任意内容原样写进日志和响应头
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext 在context中保存请求ID
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext 获取context中的请求ID
+// 不存在时返回空字符串
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
@@ -0,0 +1,52 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"abc-123_DEF.ghi:jkl", true},
+		{"0123456789abcdef0123456789abcdef", true},
+		{strings.Repeat("a", maxLength), true},
+		{"", false},
+		{strings.Repeat("a", maxLength+1), false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{"quote\"", false},
+		{"中文", false},
+	}
+	for _, tt := range tests {
+		if got := Valid(tt.id); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := New()
+		if len(id) != 32 || !Valid(id) {
+			t.Fatalf("New() = %q, want 32 hex characters", id)
+		}
+		if seen[id] {
+			t.Fatalf("New() returned duplicate id %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != "" {
+		t.Errorf("FromContext(empty) = %q", got)
+	}
+	if got := FromContext(NewContext(context.Background(), "req-1")); got != "req-1" {
+		t.Errorf("FromContext = %q, want req-1", got)
+	}
+}